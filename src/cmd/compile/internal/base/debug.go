@@ -32,6 +32,8 @@ type DebugFlags struct {
 	InlFuncsWithClosures          int    `help:"allow functions with closures to be inlined"`
 	Libfuzzer                     int    `help:"enable coverage instrumentation for libfuzzer"`
 	LocationLists                 int    `help:"print information about DWARF location list creation"`
+	LoopVar                       int    `help:"per-iteration loop variables\n0: per-iteration if GOEXPERIMENT=loopvar and the file's go version is at least go1.20\n1: per-iteration in all loops\n2: like 0, and report the loop variables whose behavior changes\n3: like 1, and report the loop variables whose behavior changes" concurrent:"ok"`
+	LoopVarHash                   string `help:"hash value for use in debugging changes to loop variable semantics; limits per-iteration variables to the loops it selects" concurrent:"ok"`
 	Nil                           int    `help:"print information about nil checks"`
 	NoOpenDefer                   int    `help:"disable open-coded defers"`
 	NoRefName                     int    `help:"do not include referenced symbol names in object file"`
@@ -51,6 +53,8 @@ type DebugFlags struct {
 	InlineHotCallSiteCDFThreshold string `help:"cummulative threshold percentage for determining call sites as hot candidates for inlining"`
 	InlineHotBudget               int    `help:"inline budget for hot functions"`
 	PGOInline                     int    `help:"debug profile-guided inlining"`
+	PGODevirtualize               int    `help:"enable profile-guided devirtualization; set to 2 to print rewritten calls" concurrent:"ok"`
+	PGOLayout                     int    `help:"enable profile-guided block layout and hot function grouping" concurrent:"ok"`
 
 	ConcurrentOk bool // true if only concurrentOk flags seen
 }
@@ -167,6 +167,8 @@ func ParseFlags() {
 
 	Debug.ConcurrentOk = true
 	Debug.InlFuncsWithClosures = 1
+	Debug.PGODevirtualize = 1
+	Debug.PGOLayout = 1
 	if buildcfg.Experiment.Unified {
 		Debug.Unified = 1
 	}
@@ -194,6 +196,10 @@ func ParseFlags() {
 		FmaHash = NewHashDebug("fmahash", Debug.Fmahash, nil)
 	}
 
+	if Debug.LoopVarHash != "" {
+		LoopVarHash = NewHashDebug("loopvarhash", Debug.LoopVarHash, nil)
+	}
+
 	if Flag.MSan && !platform.MSanSupported(buildcfg.GOOS, buildcfg.GOARCH) {
 		log.Fatalf("%s/%s does not support -msan", buildcfg.GOOS, buildcfg.GOARCH)
 	}
@@ -39,6 +39,7 @@ type HashDebug struct {
 // The default compiler-debugging HashDebug, for "-d=gossahash=..."
 var hashDebug *HashDebug
 var FmaHash *HashDebug
+var LoopVarHash *HashDebug
 
 // DebugHashMatch reports whether debug variable Gossahash
 //
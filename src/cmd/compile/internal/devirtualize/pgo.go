@@ -0,0 +1,242 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package devirtualize
+
+import (
+	"cmd/compile/internal/base"
+	"cmd/compile/internal/inline"
+	"cmd/compile/internal/ir"
+	"cmd/compile/internal/pgo"
+	"cmd/compile/internal/typecheck"
+	"cmd/compile/internal/types"
+	"fmt"
+	"strings"
+)
+
+// ProfileGuided performs call-site specific devirtualization of
+// interface calls in fn, using the profile p to select the concrete
+// type most frequently seen at each call site. Only hot call sites
+// dominated by a single concrete type are devirtualized; see
+// findHotConcreteCallee.
+//
+// A call
+//
+//	x.M(args)
+//
+// whose hottest target in the profile is (T).M is rewritten to
+//
+//	if t, ok := x.(T); ok {
+//		t.M(args)
+//	} else {
+//		x.M(args)
+//	}
+//
+// The direct call to t.M is then eligible for inlining.
+//
+// ProfileGuided must run before inlining. Calls within closures are
+// not devirtualized, as their profile entries belong to the closure
+// rather than to fn.
+func ProfileGuided(fn *ir.Func, p *pgo.Profile) {
+	ir.CurFunc = fn
+
+	name := ir.PkgFuncName(fn)
+
+	// Can't devirtualize go/defer calls. See comment in Func.
+	goDeferCall := make(map[*ir.CallExpr]bool)
+
+	var edit func(n ir.Node) ir.Node
+	edit = func(n ir.Node) ir.Node {
+		if n == nil {
+			return n
+		}
+		switch n := n.(type) {
+		case *ir.ClosureExpr:
+			return n
+		case *ir.GoDeferStmt:
+			if call, ok := n.Call.(*ir.CallExpr); ok {
+				goDeferCall[call] = true
+			}
+		}
+
+		ir.EditChildren(n, edit)
+
+		call, ok := n.(*ir.CallExpr)
+		if !ok || call.Op() != ir.OCALLINTER || goDeferCall[call] {
+			return n
+		}
+
+		callee, weight := findHotConcreteCallee(p, fn, name, call)
+		if callee == nil {
+			return n
+		}
+
+		typ := methodRecvType(call, callee)
+		if typ == nil {
+			return n
+		}
+
+		if base.Flag.LowerM != 0 {
+			base.WarnfAt(call.Pos(), "PGO devirtualizing %v to %v (weight %d)", call.X, typ, weight)
+		}
+		return rewriteCondCall(call, typ)
+	}
+
+	ir.EditChildren(fn, edit)
+}
+
+// minCalleeShare is the percentage of a call site's total edge weight
+// that its hottest concrete callee must exceed to be devirtualized.
+// Below that, the type check would fail too often to pay for itself.
+const minCalleeShare = 75
+
+// hotCallSiteThreshold is the edge weight percentage above which a
+// call site is hot, as computed by inline.HotCallSiteThreshold, or
+// negative if not yet computed.
+var hotCallSiteThreshold = -1.0
+
+// findHotConcreteCallee returns the hottest concrete method called from
+// the interface call in fn, along with its edge weight. It returns nil
+// if the profile recorded no such method, if the call site is not hot,
+// or if the hottest method does not account for more than
+// minCalleeShare percent of the weight of the matching edges.
+func findHotConcreteCallee(p *pgo.Profile, fn *ir.Func, name string, call *ir.CallExpr) (*ir.Func, int64) {
+	caller, ok := p.WeightedCG.IRNodes[name]
+	if !ok {
+		return nil, 0
+	}
+
+	offset := pgo.NodeLineOffset(call, fn)
+	suffix := "." + call.X.(*ir.SelectorExpr).Sel.Name
+
+	var hottest *pgo.IREdge
+	var total int64
+	for _, e := range p.WeightedCG.OutEdges[caller] {
+		if e.CallSiteOffset != offset || e.Weight == 0 {
+			continue
+		}
+		callee := e.Dst.AST
+		if callee.Type().Recv() == nil || !strings.HasSuffix(callee.Sym().Name, suffix) {
+			// Not a method with the called name; this edge
+			// belongs to some other call on the same line.
+			continue
+		}
+		total += e.Weight
+		// Break ties by name so the choice is deterministic.
+		if hottest == nil || e.Weight > hottest.Weight ||
+			e.Weight == hottest.Weight && ir.PkgFuncName(callee) < ir.PkgFuncName(hottest.Dst.AST) {
+			hottest = e
+		}
+	}
+	if hottest == nil {
+		return nil, 0
+	}
+	if pgo.WeightInPercentage(hottest.Weight, total) <= minCalleeShare {
+		return nil, 0
+	}
+	if hotCallSiteThreshold < 0 {
+		hotCallSiteThreshold = inline.HotCallSiteThreshold(p)
+	}
+	if pgo.WeightInPercentage(hottest.Weight, p.TotalEdgeWeight) <= hotCallSiteThreshold {
+		return nil, 0
+	}
+	return hottest.Dst.AST, hottest.Weight
+}
+
+// methodRecvType returns the receiver type of callee if it is a
+// suitable devirtualization target for call, or nil otherwise.
+func methodRecvType(call *ir.CallExpr, callee *ir.Func) *types.Type {
+	iface := call.X.(*ir.SelectorExpr).X.Type()
+	typ := callee.Type().Recv().Type
+
+	// See the comments in Call about shaped types. For the same
+	// reasons, we cannot devirtualize to or from them here.
+	if typ.HasShape() || iface.HasShape() {
+		return nil
+	}
+	if typ.IsInterface() || !typecheck.Implements(typ, iface) {
+		return nil
+	}
+	return typ
+}
+
+// rewriteCondCall rewrites call into a conditional direct call to the
+// method of typ, falling back to the original dynamic call. The result
+// is an OINLCALL that can replace call in any expression context.
+func rewriteCondCall(call *ir.CallExpr, typ *types.Type) ir.Node {
+	// We generate an OINLCALL of:
+	//
+	//	var recv Iface
+	//	var arg1 A1
+	//	var argN AN
+	//	var ret1 R1
+	//	var retN RN
+	//
+	//	recv, arg1, argN = recv expr, arg1 expr, argN expr
+	//
+	//	t, ok := recv.(Concrete)
+	//	if ok {
+	//		ret1, retN = t.Method(arg1, ... argN)
+	//	} else {
+	//		ret1, retN = recv.Method(arg1, ... argN)
+	//	}
+	//
+	// with result variables ret1, ... retN.
+	//
+	// This isn't really an inlined call of course, but InlinedCallExpr
+	// takes care of using the results in the original context.
+	pos := call.Pos()
+	sel := call.X.(*ir.SelectorExpr)
+	init := ir.TakeInit(call)
+
+	// Evaluate the receiver and arguments once, in their original order.
+	recv := typecheck.Temp(sel.X.Type())
+	init.Append(typecheck.Stmt(ir.NewAssignStmt(pos, recv, sel.X)))
+	sel.X = recv
+
+	args := make([]ir.Node, len(call.Args))
+	for i, arg := range call.Args {
+		tmp := typecheck.Temp(arg.Type())
+		init.Append(typecheck.Stmt(ir.NewAssignStmt(pos, tmp, arg)))
+		args[i] = tmp
+	}
+	call.Args = append([]ir.Node(nil), args...)
+
+	tmp := typecheck.Temp(typ)
+	ok := typecheck.Temp(types.Types[types.TBOOL])
+	assert := ir.NewTypeAssertExpr(pos, recv, typ)
+	init.Append(typecheck.Stmt(ir.NewAssignListStmt(pos, ir.OAS2, []ir.Node{tmp, ok}, []ir.Node{typecheck.Expr(assert)})))
+
+	method := typecheck.Callee(ir.NewSelectorExpr(pos, ir.OXDOT, tmp, sel.Sel))
+	direct := typecheck.Call(pos, method, args, call.IsDDD)
+
+	var retvars []ir.Node
+	for _, ret := range sel.Type().Results().FieldSlice() {
+		retvars = append(retvars, typecheck.Temp(ret.Type))
+	}
+
+	var then, els ir.Nodes
+	if len(retvars) == 0 {
+		then.Append(direct)
+		els.Append(call)
+	} else {
+		// Copy retvars so that the two assignments don't share a slice.
+		thenRet := append([]ir.Node(nil), retvars...)
+		then.Append(typecheck.Stmt(ir.NewAssignListStmt(pos, ir.OAS2, thenRet, []ir.Node{direct})))
+		elseRet := append([]ir.Node(nil), retvars...)
+		els.Append(typecheck.Stmt(ir.NewAssignListStmt(pos, ir.OAS2, elseRet, []ir.Node{call})))
+	}
+
+	cond := ir.NewIfStmt(pos, ok, then, els)
+	cond.SetInit(init)
+
+	res := ir.NewInlinedCallExpr(pos, []ir.Node{typecheck.Stmt(cond)}, retvars)
+	res.SetType(call.Type())
+	res.SetTypecheck(1)
+
+	if base.Debug.PGODevirtualize > 1 {
+		fmt.Printf("%v: PGO devirtualized call to %v.%v: %+v\n", ir.Line(call), typ, sel.Sel, res)
+	}
+	return res
+}
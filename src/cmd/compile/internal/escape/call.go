@@ -180,10 +180,21 @@ func (e *escape) callCommon(ks []hole, call ir.Node, init *ir.Nodes, wrapper *ir
 			argument(e.discardHole(), &call.Args[i])
 		}
 
-	case ir.OLEN, ir.OCAP, ir.OREAL, ir.OIMAG, ir.OCLOSE, ir.OUNSAFESTRINGDATA, ir.OUNSAFESLICEDATA:
+	case ir.OLEN, ir.OCAP, ir.OREAL, ir.OIMAG, ir.OCLEAR, ir.OCLOSE:
 		call := call.(*ir.UnaryExpr)
 		argument(e.discardHole(), &call.X)
 
+	case ir.OUNSAFESTRINGDATA, ir.OUNSAFESLICEDATA:
+		call := call.(*ir.UnaryExpr)
+		argument(ks[0], &call.X)
+
+	case ir.OMIN, ir.OMAX:
+		// Any of the arguments may be the result (e.g., for strings).
+		call := call.(*ir.CallExpr)
+		for i := range call.Args {
+			argument(ks[0], &call.Args[i])
+		}
+
 	case ir.OUNSAFEADD, ir.OUNSAFESLICE, ir.OUNSAFESTRING:
 		call := call.(*ir.BinaryExpr)
 		argument(ks[0], &call.X)
@@ -205,7 +216,9 @@ func (e *escape) callCommon(ks []hole, call ir.Node, init *ir.Nodes, wrapper *ir
 //	defer func() { f(x1, y1) }()
 func (e *escape) goDeferStmt(n *ir.GoDeferStmt) {
 	k := e.heapHole()
-	if n.Op() == ir.ODEFER && e.loopDepth == 1 {
+	// Defers in the body of a range-over-func loop (DeferAt != nil) are
+	// queued for the enclosing function, so they always escape.
+	if n.Op() == ir.ODEFER && e.loopDepth == 1 && n.DeferAt == nil {
 		// Top-level defer arguments don't escape to the heap,
 		// but they do need to last until they're invoked.
 		k = e.later(e.discardHole())
@@ -221,6 +234,10 @@ func (e *escape) goDeferStmt(n *ir.GoDeferStmt) {
 	init.Append(ir.TakeInit(call)...)
 	e.stmts(*init)
 
+	if n.DeferAt != nil {
+		e.discard(n.DeferAt)
+	}
+
 	// If the function is already a zero argument/result function call,
 	// just escape analyze it normally.
 	//
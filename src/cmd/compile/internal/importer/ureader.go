@@ -408,8 +408,14 @@ func (pr *pkgReader) objIdx(idx pkgbits.Index) (*types2.Package, string) {
 
 		case pkgbits.ObjAlias:
 			pos := r.pos()
+			var tparams []*types2.TypeParam
+			if r.p.Version().Has(pkgbits.AliasTypeParamNames) {
+				tparams = r.typeParamNames()
+			}
 			typ := r.typ()
-			return types2.NewTypeName(pos, objPkg, objName, typ)
+			tname := types2.NewTypeName(pos, objPkg, objName, nil)
+			types2.NewAlias(tname, typ).SetTypeParams(tparams)
+			return tname
 
 		case pkgbits.ObjConst:
 			pos := r.pos()
@@ -128,6 +128,18 @@ func pgoInlinePrologue(p *pgo.Profile) {
 	}
 }
 
+// HotCallSiteThreshold returns the edge weight, as a percentage of the
+// total edge weight in p, above which a call site is considered hot.
+// This is the same threshold pgoInlinePrologue uses to select hot
+// call sites for inlining.
+func HotCallSiteThreshold(p *pgo.Profile) float64 {
+	if s, err := strconv.ParseFloat(base.Debug.InlineHotCallSiteCDFThreshold, 64); err == nil {
+		inlineCDFHotCallSiteThresholdPercent = s
+	}
+	threshold, _ := computeThresholdFromCDF(p)
+	return threshold
+}
+
 // computeThresholdFromCDF computes an edge weight threshold based on the
 // CDF of edge weights from the profile. Returns the threshold, and the
 // list of edges that make up the given percentage of the CDF.
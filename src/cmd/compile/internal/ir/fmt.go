@@ -39,6 +39,7 @@ var OpNames = []string{
 	OCALL:             "function call", // not actual syntax
 	OCAP:              "cap",
 	OCASE:             "case",
+	OCLEAR:            "clear",
 	OCLOSE:            "close",
 	OCOMPLEX:          "complex",
 	OBITNOT:           "^",
@@ -62,6 +63,8 @@ var OpNames = []string{
 	OLSH:              "<<",
 	OLT:               "<",
 	OMAKE:             "make",
+	OMAX:              "max",
+	OMIN:              "min",
 	ONEG:              "-",
 	OMOD:              "%",
 	OMUL:              "*",
@@ -182,6 +185,7 @@ var OpPrec = []int{
 	OCALLMETH:         8,
 	OCALL:             8,
 	OCAP:              8,
+	OCLEAR:            8,
 	OCLOSE:            8,
 	OCOMPLIT:          8,
 	OCONVIFACE:        8,
@@ -196,6 +200,8 @@ var OpPrec = []int{
 	OMAKESLICE:        8,
 	OMAKESLICECOPY:    8,
 	OMAKE:             8,
+	OMAX:              8,
+	OMIN:              8,
 	OMAPLIT:           8,
 	ONAME:             8,
 	ONEW:              8,
@@ -767,6 +773,7 @@ func exprFmt(n Node, s fmt.State, prec int) {
 	case OREAL,
 		OIMAG,
 		OCAP,
+		OCLEAR,
 		OCLOSE,
 		OLEN,
 		ONEW,
@@ -780,6 +787,8 @@ func exprFmt(n Node, s fmt.State, prec int) {
 	case OAPPEND,
 		ODELETE,
 		OMAKE,
+		OMAX,
+		OMIN,
 		ORECOVER,
 		OPRINT,
 		OPRINTN:
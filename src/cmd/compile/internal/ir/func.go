@@ -271,6 +271,18 @@ func PkgFuncName(f *Func) string {
 	return pkg.Path + "." + s.Name
 }
 
+// LookupMethodFunc returns the *Func for the method named by fullName,
+// a symbol name of the form returned by PkgFuncName (e.g.,
+// "io.(*SectionReader).Read"). The method's receiver type is read from
+// export data if it has not been loaded yet.
+//
+// Methods of generic types are not supported, as profiles do not
+// record enough information to reconstruct their type arguments.
+var LookupMethodFunc = func(fullName string) (*Func, error) {
+	base.Fatalf("ir.LookupMethodFunc not overridden")
+	panic("unreachable")
+}
+
 var CurFunc *Func
 
 // WithFunc invokes do with CurFunc and base.Pos set to curfn and
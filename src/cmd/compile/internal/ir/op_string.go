@@ -42,132 +42,135 @@ func _() {
 	_ = x[OCALLMETH-31]
 	_ = x[OCALLINTER-32]
 	_ = x[OCAP-33]
-	_ = x[OCLOSE-34]
-	_ = x[OCLOSURE-35]
-	_ = x[OCOMPLIT-36]
-	_ = x[OMAPLIT-37]
-	_ = x[OSTRUCTLIT-38]
-	_ = x[OARRAYLIT-39]
-	_ = x[OSLICELIT-40]
-	_ = x[OPTRLIT-41]
-	_ = x[OCONV-42]
-	_ = x[OCONVIFACE-43]
-	_ = x[OCONVIDATA-44]
-	_ = x[OCONVNOP-45]
-	_ = x[OCOPY-46]
-	_ = x[ODCL-47]
-	_ = x[ODCLFUNC-48]
-	_ = x[ODCLCONST-49]
-	_ = x[ODCLTYPE-50]
-	_ = x[ODELETE-51]
-	_ = x[ODOT-52]
-	_ = x[ODOTPTR-53]
-	_ = x[ODOTMETH-54]
-	_ = x[ODOTINTER-55]
-	_ = x[OXDOT-56]
-	_ = x[ODOTTYPE-57]
-	_ = x[ODOTTYPE2-58]
-	_ = x[OEQ-59]
-	_ = x[ONE-60]
-	_ = x[OLT-61]
-	_ = x[OLE-62]
-	_ = x[OGE-63]
-	_ = x[OGT-64]
-	_ = x[ODEREF-65]
-	_ = x[OINDEX-66]
-	_ = x[OINDEXMAP-67]
-	_ = x[OKEY-68]
-	_ = x[OSTRUCTKEY-69]
-	_ = x[OLEN-70]
-	_ = x[OMAKE-71]
-	_ = x[OMAKECHAN-72]
-	_ = x[OMAKEMAP-73]
-	_ = x[OMAKESLICE-74]
-	_ = x[OMAKESLICECOPY-75]
-	_ = x[OMUL-76]
-	_ = x[ODIV-77]
-	_ = x[OMOD-78]
-	_ = x[OLSH-79]
-	_ = x[ORSH-80]
-	_ = x[OAND-81]
-	_ = x[OANDNOT-82]
-	_ = x[ONEW-83]
-	_ = x[ONOT-84]
-	_ = x[OBITNOT-85]
-	_ = x[OPLUS-86]
-	_ = x[ONEG-87]
-	_ = x[OOROR-88]
-	_ = x[OPANIC-89]
-	_ = x[OPRINT-90]
-	_ = x[OPRINTN-91]
-	_ = x[OPAREN-92]
-	_ = x[OSEND-93]
-	_ = x[OSLICE-94]
-	_ = x[OSLICEARR-95]
-	_ = x[OSLICESTR-96]
-	_ = x[OSLICE3-97]
-	_ = x[OSLICE3ARR-98]
-	_ = x[OSLICEHEADER-99]
-	_ = x[OSTRINGHEADER-100]
-	_ = x[ORECOVER-101]
-	_ = x[ORECOVERFP-102]
-	_ = x[ORECV-103]
-	_ = x[ORUNESTR-104]
-	_ = x[OSELRECV2-105]
-	_ = x[OREAL-106]
-	_ = x[OIMAG-107]
-	_ = x[OCOMPLEX-108]
-	_ = x[OALIGNOF-109]
-	_ = x[OOFFSETOF-110]
-	_ = x[OSIZEOF-111]
-	_ = x[OUNSAFEADD-112]
-	_ = x[OUNSAFESLICE-113]
-	_ = x[OUNSAFESLICEDATA-114]
-	_ = x[OUNSAFESTRING-115]
-	_ = x[OUNSAFESTRINGDATA-116]
-	_ = x[OMETHEXPR-117]
-	_ = x[OMETHVALUE-118]
-	_ = x[OBLOCK-119]
-	_ = x[OBREAK-120]
-	_ = x[OCASE-121]
-	_ = x[OCONTINUE-122]
-	_ = x[ODEFER-123]
-	_ = x[OFALL-124]
-	_ = x[OFOR-125]
-	_ = x[OGOTO-126]
-	_ = x[OIF-127]
-	_ = x[OLABEL-128]
-	_ = x[OGO-129]
-	_ = x[ORANGE-130]
-	_ = x[ORETURN-131]
-	_ = x[OSELECT-132]
-	_ = x[OSWITCH-133]
-	_ = x[OTYPESW-134]
-	_ = x[OFUNCINST-135]
-	_ = x[OINLCALL-136]
-	_ = x[OEFACE-137]
-	_ = x[OITAB-138]
-	_ = x[OIDATA-139]
-	_ = x[OSPTR-140]
-	_ = x[OCFUNC-141]
-	_ = x[OCHECKNIL-142]
-	_ = x[ORESULT-143]
-	_ = x[OINLMARK-144]
-	_ = x[OLINKSYMOFFSET-145]
-	_ = x[OJUMPTABLE-146]
-	_ = x[ODYNAMICDOTTYPE-147]
-	_ = x[ODYNAMICDOTTYPE2-148]
-	_ = x[ODYNAMICTYPE-149]
-	_ = x[OTAILCALL-150]
-	_ = x[OGETG-151]
-	_ = x[OGETCALLERPC-152]
-	_ = x[OGETCALLERSP-153]
-	_ = x[OEND-154]
+	_ = x[OCLEAR-34]
+	_ = x[OCLOSE-35]
+	_ = x[OCLOSURE-36]
+	_ = x[OCOMPLIT-37]
+	_ = x[OMAPLIT-38]
+	_ = x[OSTRUCTLIT-39]
+	_ = x[OARRAYLIT-40]
+	_ = x[OSLICELIT-41]
+	_ = x[OPTRLIT-42]
+	_ = x[OCONV-43]
+	_ = x[OCONVIFACE-44]
+	_ = x[OCONVIDATA-45]
+	_ = x[OCONVNOP-46]
+	_ = x[OCOPY-47]
+	_ = x[ODCL-48]
+	_ = x[ODCLFUNC-49]
+	_ = x[ODCLCONST-50]
+	_ = x[ODCLTYPE-51]
+	_ = x[ODELETE-52]
+	_ = x[ODOT-53]
+	_ = x[ODOTPTR-54]
+	_ = x[ODOTMETH-55]
+	_ = x[ODOTINTER-56]
+	_ = x[OXDOT-57]
+	_ = x[ODOTTYPE-58]
+	_ = x[ODOTTYPE2-59]
+	_ = x[OEQ-60]
+	_ = x[ONE-61]
+	_ = x[OLT-62]
+	_ = x[OLE-63]
+	_ = x[OGE-64]
+	_ = x[OGT-65]
+	_ = x[ODEREF-66]
+	_ = x[OINDEX-67]
+	_ = x[OINDEXMAP-68]
+	_ = x[OKEY-69]
+	_ = x[OSTRUCTKEY-70]
+	_ = x[OLEN-71]
+	_ = x[OMAKE-72]
+	_ = x[OMAKECHAN-73]
+	_ = x[OMAKEMAP-74]
+	_ = x[OMAKESLICE-75]
+	_ = x[OMAKESLICECOPY-76]
+	_ = x[OMAX-77]
+	_ = x[OMIN-78]
+	_ = x[OMUL-79]
+	_ = x[ODIV-80]
+	_ = x[OMOD-81]
+	_ = x[OLSH-82]
+	_ = x[ORSH-83]
+	_ = x[OAND-84]
+	_ = x[OANDNOT-85]
+	_ = x[ONEW-86]
+	_ = x[ONOT-87]
+	_ = x[OBITNOT-88]
+	_ = x[OPLUS-89]
+	_ = x[ONEG-90]
+	_ = x[OOROR-91]
+	_ = x[OPANIC-92]
+	_ = x[OPRINT-93]
+	_ = x[OPRINTN-94]
+	_ = x[OPAREN-95]
+	_ = x[OSEND-96]
+	_ = x[OSLICE-97]
+	_ = x[OSLICEARR-98]
+	_ = x[OSLICESTR-99]
+	_ = x[OSLICE3-100]
+	_ = x[OSLICE3ARR-101]
+	_ = x[OSLICEHEADER-102]
+	_ = x[OSTRINGHEADER-103]
+	_ = x[ORECOVER-104]
+	_ = x[ORECOVERFP-105]
+	_ = x[ORECV-106]
+	_ = x[ORUNESTR-107]
+	_ = x[OSELRECV2-108]
+	_ = x[OREAL-109]
+	_ = x[OIMAG-110]
+	_ = x[OCOMPLEX-111]
+	_ = x[OALIGNOF-112]
+	_ = x[OOFFSETOF-113]
+	_ = x[OSIZEOF-114]
+	_ = x[OUNSAFEADD-115]
+	_ = x[OUNSAFESLICE-116]
+	_ = x[OUNSAFESLICEDATA-117]
+	_ = x[OUNSAFESTRING-118]
+	_ = x[OUNSAFESTRINGDATA-119]
+	_ = x[OMETHEXPR-120]
+	_ = x[OMETHVALUE-121]
+	_ = x[OBLOCK-122]
+	_ = x[OBREAK-123]
+	_ = x[OCASE-124]
+	_ = x[OCONTINUE-125]
+	_ = x[ODEFER-126]
+	_ = x[OFALL-127]
+	_ = x[OFOR-128]
+	_ = x[OGOTO-129]
+	_ = x[OIF-130]
+	_ = x[OLABEL-131]
+	_ = x[OGO-132]
+	_ = x[ORANGE-133]
+	_ = x[ORETURN-134]
+	_ = x[OSELECT-135]
+	_ = x[OSWITCH-136]
+	_ = x[OTYPESW-137]
+	_ = x[OFUNCINST-138]
+	_ = x[OINLCALL-139]
+	_ = x[OEFACE-140]
+	_ = x[OITAB-141]
+	_ = x[OIDATA-142]
+	_ = x[OSPTR-143]
+	_ = x[OCFUNC-144]
+	_ = x[OCHECKNIL-145]
+	_ = x[ORESULT-146]
+	_ = x[OINLMARK-147]
+	_ = x[OLINKSYMOFFSET-148]
+	_ = x[OJUMPTABLE-149]
+	_ = x[ODYNAMICDOTTYPE-150]
+	_ = x[ODYNAMICDOTTYPE2-151]
+	_ = x[ODYNAMICTYPE-152]
+	_ = x[OTAILCALL-153]
+	_ = x[OGETG-154]
+	_ = x[OGETCALLERPC-155]
+	_ = x[OGETCALLERSP-156]
+	_ = x[OEND-157]
 }
 
-const _Op_name = "XXXNAMENONAMETYPELITERALNILADDSUBORXORADDSTRADDRANDANDAPPENDBYTES2STRBYTES2STRTMPRUNES2STRSTR2BYTESSTR2BYTESTMPSTR2RUNESSLICE2ARRSLICE2ARRPTRASAS2AS2DOTTYPEAS2FUNCAS2MAPRAS2RECVASOPCALLCALLFUNCCALLMETHCALLINTERCAPCLOSECLOSURECOMPLITMAPLITSTRUCTLITARRAYLITSLICELITPTRLITCONVCONVIFACECONVIDATACONVNOPCOPYDCLDCLFUNCDCLCONSTDCLTYPEDELETEDOTDOTPTRDOTMETHDOTINTERXDOTDOTTYPEDOTTYPE2EQNELTLEGEGTDEREFINDEXINDEXMAPKEYSTRUCTKEYLENMAKEMAKECHANMAKEMAPMAKESLICEMAKESLICECOPYMULDIVMODLSHRSHANDANDNOTNEWNOTBITNOTPLUSNEGORORPANICPRINTPRINTNPARENSENDSLICESLICEARRSLICESTRSLICE3SLICE3ARRSLICEHEADERSTRINGHEADERRECOVERRECOVERFPRECVRUNESTRSELRECV2REALIMAGCOMPLEXALIGNOFOFFSETOFSIZEOFUNSAFEADDUNSAFESLICEUNSAFESLICEDATAUNSAFESTRINGUNSAFESTRINGDATAMETHEXPRMETHVALUEBLOCKBREAKCASECONTINUEDEFERFALLFORGOTOIFLABELGORANGERETURNSELECTSWITCHTYPESWFUNCINSTINLCALLEFACEITABIDATASPTRCFUNCCHECKNILRESULTINLMARKLINKSYMOFFSETJUMPTABLEDYNAMICDOTTYPEDYNAMICDOTTYPE2DYNAMICTYPETAILCALLGETGGETCALLERPCGETCALLERSPEND"
+const _Op_name = "XXXNAMENONAMETYPELITERALNILADDSUBORXORADDSTRADDRANDANDAPPENDBYTES2STRBYTES2STRTMPRUNES2STRSTR2BYTESSTR2BYTESTMPSTR2RUNESSLICE2ARRSLICE2ARRPTRASAS2AS2DOTTYPEAS2FUNCAS2MAPRAS2RECVASOPCALLCALLFUNCCALLMETHCALLINTERCAPCLEARCLOSECLOSURECOMPLITMAPLITSTRUCTLITARRAYLITSLICELITPTRLITCONVCONVIFACECONVIDATACONVNOPCOPYDCLDCLFUNCDCLCONSTDCLTYPEDELETEDOTDOTPTRDOTMETHDOTINTERXDOTDOTTYPEDOTTYPE2EQNELTLEGEGTDEREFINDEXINDEXMAPKEYSTRUCTKEYLENMAKEMAKECHANMAKEMAPMAKESLICEMAKESLICECOPYMAXMINMULDIVMODLSHRSHANDANDNOTNEWNOTBITNOTPLUSNEGORORPANICPRINTPRINTNPARENSENDSLICESLICEARRSLICESTRSLICE3SLICE3ARRSLICEHEADERSTRINGHEADERRECOVERRECOVERFPRECVRUNESTRSELRECV2REALIMAGCOMPLEXALIGNOFOFFSETOFSIZEOFUNSAFEADDUNSAFESLICEUNSAFESLICEDATAUNSAFESTRINGUNSAFESTRINGDATAMETHEXPRMETHVALUEBLOCKBREAKCASECONTINUEDEFERFALLFORGOTOIFLABELGORANGERETURNSELECTSWITCHTYPESWFUNCINSTINLCALLEFACEITABIDATASPTRCFUNCCHECKNILRESULTINLMARKLINKSYMOFFSETJUMPTABLEDYNAMICDOTTYPEDYNAMICDOTTYPE2DYNAMICTYPETAILCALLGETGGETCALLERPCGETCALLERSPEND"
 
-var _Op_index = [...]uint16{0, 3, 7, 13, 17, 24, 27, 30, 33, 35, 38, 44, 48, 54, 60, 69, 81, 90, 99, 111, 120, 129, 141, 143, 146, 156, 163, 170, 177, 181, 185, 193, 201, 210, 213, 218, 225, 232, 238, 247, 255, 263, 269, 273, 282, 291, 298, 302, 305, 312, 320, 327, 333, 336, 342, 349, 357, 361, 368, 376, 378, 380, 382, 384, 386, 388, 393, 398, 406, 409, 418, 421, 425, 433, 440, 449, 462, 465, 468, 471, 474, 477, 480, 486, 489, 492, 498, 502, 505, 509, 514, 519, 525, 530, 534, 539, 547, 555, 561, 570, 581, 593, 600, 609, 613, 620, 628, 632, 636, 643, 650, 658, 664, 673, 684, 699, 711, 727, 735, 744, 749, 754, 758, 766, 771, 775, 778, 782, 784, 789, 791, 796, 802, 808, 814, 820, 828, 835, 840, 844, 849, 853, 858, 866, 872, 879, 892, 901, 915, 930, 941, 949, 953, 964, 975, 978}
+var _Op_index = [...]uint16{0, 3, 7, 13, 17, 24, 27, 30, 33, 35, 38, 44, 48, 54, 60, 69, 81, 90, 99, 111, 120, 129, 141, 143, 146, 156, 163, 170, 177, 181, 185, 193, 201, 210, 213, 218, 223, 230, 237, 243, 252, 260, 268, 274, 278, 287, 296, 303, 307, 310, 317, 325, 332, 338, 341, 347, 354, 362, 366, 373, 381, 383, 385, 387, 389, 391, 393, 398, 403, 411, 414, 423, 426, 430, 438, 445, 454, 467, 470, 473, 476, 479, 482, 485, 488, 491, 497, 500, 503, 509, 513, 516, 520, 525, 530, 536, 541, 545, 550, 558, 566, 572, 581, 592, 604, 611, 620, 624, 631, 639, 643, 647, 654, 661, 669, 675, 684, 695, 710, 722, 738, 746, 755, 760, 765, 769, 777, 782, 786, 789, 793, 795, 800, 802, 807, 813, 819, 825, 831, 839, 846, 851, 855, 860, 864, 869, 877, 883, 890, 903, 912, 926, 941, 952, 960, 964, 975, 986, 989}
 
 func (i Op) String() string {
 	if i >= Op(len(_Op_index)-1) {
@@ -207,11 +207,12 @@ func NewCommStmt(pos src.XPos, comm Node, body []Node) *CommClause {
 // A ForStmt is a non-range for loop: for Init; Cond; Post { Body }
 type ForStmt struct {
 	miniStmt
-	Label    *types.Sym
-	Cond     Node
-	Post     Node
-	Body     Nodes
-	HasBreak bool
+	Label        *types.Sym
+	Cond         Node
+	Post         Node
+	Body         Nodes
+	HasBreak     bool
+	DistinctVars bool // each iteration has its own copy of the loop variables
 }
 
 func NewForStmt(pos src.XPos, init Node, cond, post Node, body []Node) *ForStmt {
@@ -232,7 +233,8 @@ func NewForStmt(pos src.XPos, init Node, cond, post Node, body []Node) *ForStmt
 // in a different context (a separate goroutine or a later time).
 type GoDeferStmt struct {
 	miniStmt
-	Call Node
+	Call    Node
+	DeferAt Expr // for ODEFER in a range-over-func loop body: frame for runtime.deferprocat
 }
 
 func NewGoDeferStmt(pos src.XPos, op Op, call Node) *GoDeferStmt {
@@ -342,6 +344,8 @@ type RangeStmt struct {
 	HasBreak bool
 	Prealloc *Name
 
+	DistinctVars bool // each iteration has its own copy of the loop variables
+
 	// When desugaring the RangeStmt during walk, the assignments to Key
 	// and Value may require OCONVIFACE operations. If so, these fields
 	// will be copied to their respective ConvExpr fields.
@@ -0,0 +1,312 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package loopvar applies the proper per-iteration loop variable semantics
+// to loops whose variables are captured by closures or have their address taken.
+package loopvar
+
+import (
+	"cmd/compile/internal/base"
+	"cmd/compile/internal/ir"
+	"cmd/compile/internal/typecheck"
+	"cmd/compile/internal/types"
+	"cmd/internal/src"
+)
+
+// A VarAndLoop records a loop variable that was made per-iteration,
+// together with the loop that declares it.
+type VarAndLoop struct {
+	Name *ir.Name
+	Loop ir.Node // the *ir.ForStmt or *ir.RangeStmt
+}
+
+// ForCapture transforms the loops in fn (including the loops in its
+// closures) that are marked DistinctVars and whose loop variables are
+// captured by a closure or have their address taken, so that each
+// iteration of the loop has its own copy of those variables.
+// Loops whose variables do not leak this way behave the same under
+// either semantics and are left alone.
+//
+// A range loop
+//
+//	for k, v := range x { body }
+//
+// becomes
+//
+//	for k', v' := range x { k := k'; v := v'; body }
+//
+// and a 3-clause loop
+//
+//	for z := init; cond; post { body }
+//
+// becomes
+//
+//	first := true
+//	for z' := init; ; z' = z {
+//		z := z'
+//		if first { first = false } else { post }
+//		if !cond { break }
+//		body
+//	}
+//
+// so that the post statement applies to the variable of the next
+// iteration, as required by the per-iteration semantics.
+//
+// ForCapture returns the variables that were transformed.
+func ForCapture(fn *ir.Func) []VarAndLoop {
+	var transformed []VarAndLoop
+	forCapture(fn, &transformed)
+	return transformed
+}
+
+func forCapture(fn *ir.Func, transformed *[]VarAndLoop) {
+	// possiblyLeaked maps each loop variable currently in scope to
+	// whether it has been captured or had its address taken.
+	possiblyLeaked := make(map[*ir.Name]bool)
+
+	noteMayLeak := func(x ir.Node) {
+		if n, ok := x.(*ir.Name); ok {
+			n = n.Canonical()
+			if _, ok := possiblyLeaked[n]; ok {
+				possiblyLeaked[n] = true
+			}
+		}
+	}
+
+	// leaked reports which of the loop variables vars escaped into a
+	// closure or had their address taken, after removing them from
+	// possiblyLeaked.
+	leaked := func(loop ir.Node, vars []*ir.Name) []*ir.Name {
+		var out []*ir.Name
+		for _, v := range vars {
+			if possiblyLeaked[v] && matchHash(fn, v) {
+				out = append(out, v)
+			}
+			delete(possiblyLeaked, v)
+		}
+		for _, v := range out {
+			*transformed = append(*transformed, VarAndLoop{v, loop})
+		}
+		return out
+	}
+
+	var do func(n ir.Node) bool
+	do = func(n ir.Node) bool {
+		switch n := n.(type) {
+		case *ir.ClosureExpr:
+			for _, cv := range n.Func.ClosureVars {
+				noteMayLeak(cv)
+			}
+			forCapture(n.Func, transformed)
+			return false
+
+		case *ir.AddrExpr:
+			noteMayLeak(ir.OuterValue(n.X))
+
+		case *ir.RangeStmt:
+			if !n.DistinctVars || !n.Def {
+				break
+			}
+			vars := rangeVars(n)
+			for _, v := range vars {
+				possiblyLeaked[v] = false
+			}
+			ir.DoChildren(n, do)
+			if vars := leaked(n, vars); len(vars) > 0 {
+				rewriteRange(fn, n, vars)
+			}
+			return false
+
+		case *ir.ForStmt:
+			if !n.DistinctVars {
+				break
+			}
+			vars := forVars(n)
+			if len(vars) == 0 {
+				break
+			}
+			for _, v := range vars {
+				possiblyLeaked[v] = false
+			}
+			ir.DoChildren(n, do)
+			// A 3-clause loop is rewritten as a whole, so if any
+			// of its variables leaked, all of them are made
+			// per-iteration.
+			if len(leaked(n, vars)) > 0 {
+				rewriteFor(fn, n, vars)
+			}
+			return false
+		}
+		ir.DoChildren(n, do)
+		return false
+	}
+	ir.DoChildren(fn, do)
+}
+
+// matchHash reports whether loop variable v should get per-iteration
+// semantics under -d=loopvarhash.
+func matchHash(fn *ir.Func, v *ir.Name) bool {
+	if base.LoopVarHash == nil {
+		return true
+	}
+	return base.LoopVarHash.DebugHashMatchParam(ir.PkgFuncName(fn), uint64(base.Ctxt.InnermostPos(v.Pos()).Line()))
+}
+
+// rangeVars returns the variables declared by the range loop n.
+func rangeVars(n *ir.RangeStmt) []*ir.Name {
+	var vars []*ir.Name
+	for _, x := range []ir.Node{n.Key, n.Value} {
+		if v, ok := x.(*ir.Name); ok && v.Defn == n {
+			vars = append(vars, v)
+		}
+	}
+	return vars
+}
+
+// forVars returns the variables declared by the init statement of the
+// 3-clause loop n.
+func forVars(n *ir.ForStmt) []*ir.Name {
+	var vars []*ir.Name
+	for _, init := range n.Init() {
+		for _, x := range init.Init() {
+			if x.Op() == ir.ODCL {
+				vars = append(vars, x.(*ir.Decl).X)
+			}
+		}
+	}
+	return vars
+}
+
+// rewriteRange makes the variables vars of the range loop n per-iteration.
+func rewriteRange(fn *ir.Func, n *ir.RangeStmt, vars []*ir.Name) {
+	var prefix ir.Nodes
+	for _, v := range vars {
+		tmp := typecheck.TempAt(v.Pos(), fn, v.Type())
+		if n.Key == v {
+			n.Key = tmp
+		} else {
+			n.Value = tmp
+		}
+		removeDecl(n.PtrInit(), v)
+		prefix.Append(declare(fn, v, tmp))
+	}
+	n.Body.Prepend(prefix...)
+}
+
+// rewriteFor makes the variables vars of the 3-clause loop n per-iteration.
+func rewriteFor(fn *ir.Func, n *ir.ForStmt, vars []*ir.Name) {
+	pos := n.Pos()
+	tmps := make(map[*ir.Name]*ir.Name)
+	var lhs, rhs []ir.Node
+	var prefix ir.Nodes
+	for _, v := range vars {
+		tmp := typecheck.TempAt(v.Pos(), fn, v.Type())
+		tmps[v] = tmp
+		lhs = append(lhs, tmp)
+		rhs = append(rhs, v)
+		prefix.Append(declare(fn, v, tmp))
+	}
+
+	// Make the init statement assign to the temporaries instead.
+	replace := func(x ir.Node) ir.Node {
+		if v, ok := x.(*ir.Name); ok && tmps[v] != nil {
+			return tmps[v]
+		}
+		return x
+	}
+	for _, init := range n.Init() {
+		switch init := init.(type) {
+		case *ir.AssignStmt:
+			init.X = replace(init.X)
+			init.Def = false
+		case *ir.AssignListStmt:
+			for i, x := range init.Lhs {
+				init.Lhs[i] = replace(x)
+			}
+			init.Def = false
+		}
+		for _, v := range vars {
+			removeDecl(init.(ir.InitNode).PtrInit(), v)
+		}
+	}
+
+	if n.Post != nil {
+		first := typecheck.TempAt(pos, fn, types.Types[types.TBOOL])
+		n.PtrInit().Append(assign(fn, pos, first, ir.NewBool(true)))
+		prefix.Append(stmt(fn, ir.NewIfStmt(pos, first,
+			[]ir.Node{assign(fn, pos, first, ir.NewBool(false))},
+			[]ir.Node{n.Post})))
+	}
+	if n.Cond != nil {
+		not := expr(fn, ir.NewUnaryExpr(pos, ir.ONOT, n.Cond))
+		prefix.Append(stmt(fn, ir.NewIfStmt(pos, not,
+			[]ir.Node{ir.NewBranchStmt(pos, ir.OBREAK, nil)}, nil)))
+		n.Cond = nil
+		n.HasBreak = true
+	}
+
+	var post ir.Node
+	if len(lhs) == 1 {
+		post = ir.NewAssignStmt(pos, lhs[0], rhs[0])
+	} else {
+		post = ir.NewAssignListStmt(pos, ir.OAS2, lhs, rhs)
+	}
+	n.Post = stmt(fn, post)
+	n.Body.Prepend(prefix...)
+}
+
+// declare returns the statement "v := tmp", which declares the
+// per-iteration copy v of a loop variable.
+func declare(fn *ir.Func, v, tmp *ir.Name) ir.Node {
+	as := ir.NewAssignStmt(v.Pos(), v, tmp)
+	as.Def = true
+	as.PtrInit().Append(stmt(fn, ir.NewDecl(v.Pos(), ir.ODCL, v)))
+	v.Defn = as
+	return stmt(fn, as)
+}
+
+// removeDecl removes the declaration of v from init.
+func removeDecl(init *ir.Nodes, v *ir.Name) {
+	out := (*init)[:0]
+	for _, x := range *init {
+		if x.Op() == ir.ODCL && x.(*ir.Decl).X == v {
+			continue
+		}
+		out = append(out, x)
+	}
+	*init = out
+}
+
+func assign(fn *ir.Func, pos src.XPos, x, y ir.Node) ir.Node {
+	return stmt(fn, ir.NewAssignStmt(pos, x, y))
+}
+
+func stmt(fn *ir.Func, n ir.Node) ir.Node {
+	defer func(curfn *ir.Func) { ir.CurFunc = curfn }(ir.CurFunc)
+	ir.CurFunc = fn
+	return typecheck.Stmt(n)
+}
+
+func expr(fn *ir.Func, n ir.Node) ir.Node {
+	defer func(curfn *ir.Func) { ir.CurFunc = curfn }(ir.CurFunc)
+	ir.CurFunc = fn
+	return typecheck.Expr(n)
+}
+
+// LogTransformations reports, under -d=loopvar=2, the loop variables
+// transformed by ForCapture. It must be called after escape analysis
+// so that it can say where each per-iteration variable is allocated.
+func LogTransformations(transformed []VarAndLoop) {
+	if base.Debug.LoopVar < 2 {
+		return
+	}
+	for _, t := range transformed {
+		n := t.Name
+		if n.Esc() == ir.EscHeap {
+			base.WarnfAt(n.Pos(), "loop variable %v now per-iteration, heap-allocated", n)
+		} else {
+			base.WarnfAt(n.Pos(), "loop variable %v now per-iteration, stack-allocated", n)
+		}
+	}
+}
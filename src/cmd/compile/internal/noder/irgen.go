@@ -58,6 +58,7 @@ func checkFiles(noders []*noder) (posMap, *types2.Package, *types2.Info) {
 		Importer:               &importer,
 		Sizes:                  &gcSizes{},
 		AltComparableSemantics: base.Flag.AltComparable, // experiment - remove eventually
+		EnableAlias:            base.Debug.Unified != 0, // irgen does not support Alias types
 	}
 	info := &types2.Info{
 		StoreTypesInSyntax: true,
@@ -117,6 +118,8 @@ func check2(noders []*noder) {
 		posMap: m,
 		objs:   make(map[types2.Object]*ir.Name),
 		typs:   make(map[types2.Type]*types.Type),
+
+		fileVersions: makeFileVersions(noders),
 	}
 	g.generate(noders)
 }
@@ -191,6 +194,9 @@ type irgen struct {
 	typs   map[types2.Type]*types.Type
 	marker dwarfgen.ScopeMarker
 
+	// fileVersions holds the go versions of the package's files.
+	fileVersions fileVersions
+
 	// laterFuncs records tasks that need to run after all declarations
 	// are processed.
 	laterFuncs []func()
@@ -7,6 +7,7 @@ package noder
 import (
 	"errors"
 	"fmt"
+	"go/build/constraint"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -115,6 +116,10 @@ type noder struct {
 	err            chan syntax.Error
 	importedUnsafe bool
 	importedEmbed  bool
+
+	// goVersion is the minimum Go version required by the file's
+	// //go:build constraint, such as "go1.20", or "" if none.
+	goVersion string
 }
 
 // linkname records a //go:linkname directive.
@@ -346,6 +351,9 @@ func (p *noder) pragma(pos syntax.Pos, blankLine bool, text string, old syntax.P
 		if flag == 0 && !allowedStdPragmas[verb] && base.Flag.Std {
 			p.error(syntax.Error{Pos: pos, Msg: fmt.Sprintf("//%s is not allowed in the standard library", verb)})
 		}
+		if flag == ir.GoBuildPragma {
+			p.goVersion = buildGoVersion(text)
+		}
 		pragma.Flag |= flag
 		pragma.Pos = append(pragma.Pos, pragmaPos{flag, pos})
 	}
@@ -353,6 +361,61 @@ func (p *noder) pragma(pos syntax.Pos, blankLine bool, text string, old syntax.P
 	return pragma
 }
 
+// buildGoVersion returns the minimum Go version, such as "go1.20",
+// required by the //go:build constraint text, or "" if the constraint
+// can be satisfied by any version.
+func buildGoVersion(text string) string {
+	x, err := constraint.Parse("//" + text)
+	if err != nil {
+		return ""
+	}
+	if minor := minGoVersion(x, true); minor >= 0 {
+		return fmt.Sprintf("go1.%d", minor)
+	}
+	return ""
+}
+
+// minGoVersion returns the minimum Go 1 minor version required for x
+// to evaluate to want, or -1 if x can do so with any version.
+func minGoVersion(x constraint.Expr, want bool) int {
+	switch x := x.(type) {
+	case *constraint.AndExpr:
+		if want {
+			return maxInt(minGoVersion(x.X, want), minGoVersion(x.Y, want))
+		}
+		return minInt(minGoVersion(x.X, want), minGoVersion(x.Y, want))
+	case *constraint.OrExpr:
+		if want {
+			return minInt(minGoVersion(x.X, want), minGoVersion(x.Y, want))
+		}
+		return maxInt(minGoVersion(x.X, want), minGoVersion(x.Y, want))
+	case *constraint.NotExpr:
+		return minGoVersion(x.X, !want)
+	case *constraint.TagExpr:
+		// A tag can be false with any version.
+		if want && strings.HasPrefix(x.Tag, "go1.") {
+			if minor, err := strconv.Atoi(x.Tag[len("go1."):]); err == nil && minor >= 0 {
+				return minor
+			}
+		}
+	}
+	return -1
+}
+
+func minInt(x, y int) int {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+func maxInt(x, y int) int {
+	if x > y {
+		return x
+	}
+	return y
+}
+
 // isCgoGeneratedFile reports whether pos is in a file
 // generated by cgo, which is to say a file with name
 // beginning with "_cgo_". Such files are allowed to
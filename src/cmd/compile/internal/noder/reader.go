@@ -702,7 +702,7 @@ func (pr *pkgReader) objIdx(idx pkgbits.Index, implicits, explicits []*types.Typ
 		panic("unexpected object")
 
 	case pkgbits.ObjAlias:
-		name := do(ir.OTYPE, false)
+		name := do(ir.OTYPE, r.p.Version().Has(pkgbits.AliasTypeParamNames))
 		setType(name, r.typ())
 		name.SetAlias(true)
 		return name
@@ -1734,7 +1734,14 @@ func (r *reader) stmt1(tag codeStmt, out *ir.Nodes) ir.Node {
 		pos := r.pos()
 		op := r.op()
 		call := r.expr()
-		return ir.NewGoDeferStmt(pos, op, call)
+		stmt := ir.NewGoDeferStmt(pos, op, call)
+		if op == ir.ODEFER {
+			x := r.optExpr()
+			if x != nil {
+				stmt.DeferAt = x.(ir.Expr)
+			}
+		}
+		return stmt
 
 	case stmtExpr:
 		return r.expr()
@@ -1848,6 +1855,7 @@ func (r *reader) forStmt(label *types.Sym) ir.Node {
 			rang.ValueTypeWord, rang.ValueSrcRType = r.convRTTI(pos)
 		}
 
+		rang.DistinctVars = r.Bool()
 		rang.Body = r.blockStmt()
 		r.closeAnotherScope()
 
@@ -1858,11 +1866,13 @@ func (r *reader) forStmt(label *types.Sym) ir.Node {
 	init := r.stmt()
 	cond := r.optExpr()
 	post := r.stmt()
+	distinctVars := r.Bool()
 	body := r.blockStmt()
 	r.closeAnotherScope()
 
 	stmt := ir.NewForStmt(pos, init, cond, post, body)
 	stmt.Label = label
+	stmt.DistinctVars = distinctVars
 	return stmt
 }
 
@@ -2091,6 +2101,15 @@ func (r *reader) expr() (res ir.Node) {
 	case exprLocal:
 		return typecheck.Expr(r.useLocal())
 
+	case exprRuntimeBuiltin:
+		name := r.String()
+		if name == "deferrangefunc" {
+			// The defer record that deferrangefunc takes over must be
+			// on the defer chain, not open-coded.
+			r.curfn.SetOpenCodedDeferDisallowed(true)
+		}
+		return typecheck.Expr(typecheck.LookupRuntime(name))
+
 	case exprGlobal:
 		// Callee instead of Expr allows builtins
 		// TODO(mdempsky): Handle builtins directly in exprCall, like method calls?
@@ -974,7 +974,7 @@ func (subst *subster) node(n ir.Node) ir.Node {
 				_, isStructKeyExpr := m.(*ir.StructKeyExpr)
 				_, isKeyExpr := m.(*ir.KeyExpr)
 				if !isCallExpr && !isStructKeyExpr && !isKeyExpr && x.Op() != ir.OPANIC &&
-					x.Op() != ir.OCLOSE {
+					x.Op() != ir.OCLEAR && x.Op() != ir.OCLOSE {
 					base.FatalfAt(m.Pos(), "Nil type for %v", x)
 				}
 			} else if x.Op() != ir.OCLOSURE {
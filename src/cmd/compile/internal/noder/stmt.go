@@ -5,11 +5,14 @@
 package noder
 
 import (
+	"internal/buildcfg"
+
 	"cmd/compile/internal/base"
 	"cmd/compile/internal/ir"
 	"cmd/compile/internal/syntax"
 	"cmd/compile/internal/typecheck"
 	"cmd/compile/internal/types"
+	"cmd/compile/internal/types2"
 	"cmd/internal/src"
 )
 
@@ -244,10 +247,15 @@ func unpackTwo(list []ir.Node) (fst, snd ir.Node) {
 
 func (g *irgen) forStmt(stmt *syntax.ForStmt) ir.Node {
 	if r, ok := stmt.Init.(*syntax.RangeClause); ok {
+		if _, ok := types2.CoreType(r.X.GetTypeInfo().Type).(*types2.Signature); ok {
+			base.ErrorfAt(g.pos(r), "range over function requires GOEXPERIMENT=unified")
+			return ir.NewBlockStmt(g.pos(stmt), nil)
+		}
 		names, lhs := g.assignList(r.Lhs, r.Def)
 		key, value := unpackTwo(lhs)
 		n := ir.NewRangeStmt(g.pos(r), key, value, g.expr(r.X), g.blockStmt(stmt.Body))
 		n.Def = initDefn(n, names)
+		n.DistinctVars = distinctVars(g.fileVersions.at(stmt.Pos()))
 		if key != nil {
 			transformCheckAssign(n, key)
 		}
@@ -257,7 +265,53 @@ func (g *irgen) forStmt(stmt *syntax.ForStmt) ir.Node {
 		return n
 	}
 
-	return ir.NewForStmt(g.pos(stmt), g.stmt(stmt.Init), g.expr(stmt.Cond), g.stmt(stmt.Post), g.blockStmt(stmt.Body))
+	n := ir.NewForStmt(g.pos(stmt), g.stmt(stmt.Init), g.expr(stmt.Cond), g.stmt(stmt.Post), g.blockStmt(stmt.Body))
+	n.DistinctVars = distinctVars(g.fileVersions.at(stmt.Pos()))
+	return n
+}
+
+// distinctVars reports whether each iteration of a "for" loop in a
+// file whose go version is fileVersion has its own copy of the loop
+// variables. This is the case when the loopvar experiment is enabled
+// and the file's go version is at least go1.20, or in all loops with
+// -d=loopvar=1 or 3. With -d=loopvarhash, package loopvar later
+// narrows the loops that change down to those selected by the hash.
+func distinctVars(fileVersion string) bool {
+	if base.Debug.LoopVar == 1 || base.Debug.LoopVar == 3 {
+		return true
+	}
+	return buildcfg.Experiment.LoopVar && types.FileAllowsGoVersion(fileVersion, 1, 20)
+}
+
+// fileVersions maps the position bases of a package's files to the go
+// versions set by their //go:build constraints. Files without such a
+// constraint use the -lang version and have no entry.
+type fileVersions map[*syntax.PosBase]string
+
+func makeFileVersions(noders []*noder) fileVersions {
+	fv := make(fileVersions)
+	for _, p := range noders {
+		if p.goVersion != "" {
+			fv[fileBase(p.file.Pos())] = p.goVersion
+		}
+	}
+	return fv
+}
+
+// at returns the go version of the file containing pos, or "" if the
+// -lang version applies.
+func (fv fileVersions) at(pos syntax.Pos) string {
+	return fv[fileBase(pos)]
+}
+
+// fileBase returns the position base of the file containing pos,
+// looking through any //line directives.
+func fileBase(pos syntax.Pos) *syntax.PosBase {
+	b := pos.Base()
+	for b != nil && !b.IsFileBase() {
+		b = b.Pos().Base()
+	}
+	return b
 }
 
 func (g *irgen) selectStmt(stmt *syntax.SelectStmt) ir.Node {
@@ -879,7 +879,7 @@ func transformBuiltin(n *ir.CallExpr) ir.Node {
 	op := fun.BuiltinOp
 
 	switch op {
-	case ir.OAPPEND, ir.ODELETE, ir.OMAKE, ir.OPRINT, ir.OPRINTN, ir.ORECOVER:
+	case ir.OAPPEND, ir.ODELETE, ir.OMAKE, ir.OMAX, ir.OMIN, ir.OPRINT, ir.OPRINTN, ir.ORECOVER:
 		n.SetOp(op)
 		n.X = nil
 		switch op {
@@ -889,6 +889,9 @@ func transformBuiltin(n *ir.CallExpr) ir.Node {
 			return transformDelete(n)
 		case ir.OMAKE:
 			return transformMake(n)
+		case ir.OMAX, ir.OMIN:
+			transformArgs(n)
+			return n
 		case ir.OPRINT, ir.OPRINTN:
 			return transformPrint(n)
 		case ir.ORECOVER:
@@ -896,7 +899,7 @@ func transformBuiltin(n *ir.CallExpr) ir.Node {
 			return n
 		}
 
-	case ir.OCAP, ir.OCLOSE, ir.OIMAG, ir.OLEN, ir.OPANIC, ir.OREAL:
+	case ir.OCAP, ir.OCLEAR, ir.OCLOSE, ir.OIMAG, ir.OLEN, ir.OPANIC, ir.OREAL:
 		transformArgs(n)
 		fallthrough
 
@@ -913,7 +916,7 @@ func transformBuiltin(n *ir.CallExpr) ir.Node {
 		case ir.OALIGNOF, ir.OOFFSETOF, ir.OSIZEOF:
 			// This corresponds to the EvalConst() call near end of typecheck().
 			return typecheck.EvalConst(u1)
-		case ir.OCLOSE, ir.ONEW, ir.OUNSAFESTRINGDATA, ir.OUNSAFESLICEDATA:
+		case ir.OCLEAR, ir.OCLOSE, ir.ONEW, ir.OUNSAFESTRINGDATA, ir.OUNSAFESLICEDATA:
 			// nothing more to do
 			return u1
 		}
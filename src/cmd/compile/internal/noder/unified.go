@@ -16,6 +16,8 @@ import (
 	"cmd/compile/internal/base"
 	"cmd/compile/internal/inline"
 	"cmd/compile/internal/ir"
+	"cmd/compile/internal/rangefunc"
+	"cmd/compile/internal/syntax"
 	"cmd/compile/internal/typecheck"
 	"cmd/compile/internal/types"
 	"cmd/compile/internal/types2"
@@ -71,6 +73,7 @@ var localPkgReader *pkgReader
 func unified(noders []*noder) {
 	inline.InlineCall = unifiedInlineCall
 	typecheck.HaveInlineBody = unifiedHaveInlineBody
+	ir.LookupMethodFunc = lookupMethodFunc
 
 	data := writePkgStub(noders)
 
@@ -165,13 +168,81 @@ func readBodies(target *ir.Package) {
 	todoBodies = nil
 }
 
+// lookupMethodFunc implements ir.LookupMethodFunc. It splits fullName
+// into its package path, receiver type name, and method name, and
+// reads the receiver type from export data if necessary.
+func lookupMethodFunc(fullName string) (*ir.Func, error) {
+	if strings.Contains(fullName, "[") {
+		return nil, fmt.Errorf("%s: generic methods are not supported", fullName)
+	}
+
+	// The package path ends at the first dot after the last slash.
+	dot := strings.LastIndex(fullName, "/") + 1
+	if i := strings.Index(fullName[dot:], "."); i >= 0 {
+		dot += i
+	} else {
+		return nil, fmt.Errorf("%s: missing package path", fullName)
+	}
+	pkgPath, methName := fullName[:dot], fullName[dot+1:]
+
+	// methName is now "T.M" or "(*T).M".
+	var typName string
+	if strings.HasPrefix(methName, "(*") {
+		i := strings.Index(methName, ").")
+		if i < 0 {
+			return nil, fmt.Errorf("%s: malformed method name", fullName)
+		}
+		typName = methName[len("(*"):i]
+	} else {
+		i := strings.Index(methName, ".")
+		if i < 0 {
+			return nil, fmt.Errorf("%s: not a method", fullName)
+		}
+		typName = methName[:i]
+	}
+
+	pkg := types.LookupPkg(pkgPath)
+	if pkg == nil {
+		return nil, fmt.Errorf("%s: package %q not imported", fullName, pkgPath)
+	}
+	sym := pkg.Lookup(typName)
+
+	name, _ := sym.Def.(*ir.Name)
+	if name == nil {
+		pri, ok := objReader[sym]
+		if !ok {
+			return nil, fmt.Errorf("%s: type %v not found in export data", fullName, sym)
+		}
+		name = pri.pr.objIdx(pri.idx, nil, nil, false).(*ir.Name)
+	}
+	if name.Op() != ir.OTYPE {
+		return nil, fmt.Errorf("%s: %v is not a type", fullName, sym)
+	}
+
+	for _, m := range name.Type().Methods().Slice() {
+		if fn := m.Nname.(*ir.Name).Func; fn != nil && fn.Sym().Name == methName {
+			return fn, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: method not found", fullName)
+}
+
 // writePkgStub type checks the given parsed source files,
 // writes an export data package stub representing them,
 // and returns the result.
 func writePkgStub(noders []*noder) string {
 	m, pkg, info := checkFiles(noders)
 
+	// Rewrite range-over-func loops into calls of the iterator
+	// functions, so the writer and later phases never see them.
+	files := make([]*syntax.File, len(noders))
+	for i, p := range noders {
+		files[i] = p.file
+	}
+	rangefunc.Rewrite(pkg, info, files)
+
 	pw := newPkgWriter(m, pkg, info)
+	pw.fileVersions = makeFileVersions(noders)
 
 	pw.collectDecls(noders)
 
@@ -77,6 +77,9 @@ type pkgWriter struct {
 	// if specified by a //go:linkname directive.
 	linknames map[types2.Object]string
 
+	// fileVersions holds the go versions of the package's files.
+	fileVersions fileVersions
+
 	// cgoPragmas accumulates any //go:cgo_* pragmas that need to be
 	// passed through to cmd/link.
 	cgoPragmas [][]string
@@ -469,6 +472,10 @@ func (w *writer) typInfo(info typeInfo) {
 // typIdx also reports whether typ is a derived type; that is, whether
 // its identity depends on type parameters.
 func (pw *pkgWriter) typIdx(typ types2.Type, dict *writerDict) typeInfo {
+	// Alias types are not represented in the export data. References
+	// to them are written as references to the aliased type instead.
+	typ = types2.Unalias(typ)
+
 	if idx, ok := pw.typsIdx[typ]; ok {
 		return typeInfo{idx: idx, derived: false}
 	}
@@ -790,7 +797,15 @@ func (w *writer) doObj(wext *writer, obj types2.Object) pkgbits.CodeObj {
 
 		if obj.IsAlias() {
 			w.pos(obj)
-			w.typ(obj.Type())
+			rhs := obj.Type()
+			var tparams *types2.TypeParamList
+			if alias, ok := rhs.(*types2.Alias); ok {
+				assert(alias.TypeArgs() == nil)
+				tparams = alias.TypeParams()
+				rhs = alias.Rhs()
+			}
+			w.typeParamNames(tparams)
+			w.typ(rhs)
 			return pkgbits.ObjAlias
 		}
 
@@ -1226,6 +1241,9 @@ func (w *writer) stmt1(stmt syntax.Stmt) {
 		w.pos(stmt)
 		w.op(callOps[stmt.Tok])
 		w.expr(stmt.Call)
+		if stmt.Tok == syntax.Defer {
+			w.optExpr(stmt.DeferAt)
+		}
 
 	case *syntax.DeclStmt:
 		for _, decl := range stmt.DeclList {
@@ -1420,6 +1438,7 @@ func (w *writer) forStmt(stmt *syntax.ForStmt) {
 		w.stmt(stmt.Post)
 	}
 
+	w.Bool(distinctVars(w.p.fileVersions.at(stmt.Pos())))
 	w.blockStmt(stmt.Body)
 	w.closeAnotherScope()
 }
@@ -1615,6 +1634,15 @@ func (w *writer) expr(expr syntax.Expr) {
 			w.p.fatalf(expr, "unexpected type expression %v", syntax.String(expr))
 		}
 
+		if tv.IsRuntimeHelper() {
+			if pkg := obj.Pkg(); pkg != nil && pkg.Name() == "runtime" {
+				w.Code(exprRuntimeBuiltin)
+				w.String(obj.Name())
+				return
+			}
+			w.p.fatalf(expr, "unexpected runtime helper %v", syntax.String(expr))
+		}
+
 		if tv.Value != nil {
 			w.Code(exprConst)
 			w.pos(expr)
@@ -1996,7 +2024,7 @@ func (w *writer) methodExpr(expr *syntax.SelectorExpr, recv types2.Type, sel *ty
 	}
 
 	if !isInterface(recv) {
-		if named, ok := deref2(recv).(*types2.Named); ok {
+		if named, ok := types2.Unalias(deref2(recv)).(*types2.Named); ok {
 			obj, targs := splitNamed(named)
 			info := w.p.objInstIdx(obj, targs, w.dict)
 
@@ -2652,9 +2680,9 @@ func isNil(p *pkgWriter, expr syntax.Expr) bool {
 
 // recvBase returns the base type for the given receiver parameter.
 func recvBase(recv *types2.Var) *types2.Named {
-	typ := recv.Type()
+	typ := types2.Unalias(recv.Type())
 	if ptr, ok := typ.(*types2.Pointer); ok {
-		typ = ptr.Elem()
+		typ = types2.Unalias(ptr.Elem())
 	}
 	return typ.(*types2.Named)
 }
@@ -2695,8 +2723,13 @@ func objTypeParams(obj types2.Object) *types2.TypeParamList {
 		}
 		return sig.TypeParams()
 	case *types2.TypeName:
-		if !obj.IsAlias() {
-			return obj.Type().(*types2.Named).TypeParams()
+		switch typ := obj.Type().(type) {
+		case *types2.Named:
+			if !obj.IsAlias() {
+				return typ.TypeParams()
+			}
+		case *types2.Alias:
+			return typ.TypeParams()
 		}
 	}
 	return nil
@@ -2723,6 +2756,6 @@ func asPragmaFlag(p syntax.Pragma) ir.PragmaFlag {
 
 // isPtrTo reports whether from is the type *to.
 func isPtrTo(from, to types2.Type) bool {
-	ptr, ok := from.(*types2.Pointer)
+	ptr, ok := types2.Unalias(from).(*types2.Pointer)
 	return ok && types2.Identical(ptr.Elem(), to)
 }
@@ -51,6 +51,8 @@ import (
 	"internal/profile"
 	"log"
 	"os"
+	"sort"
+	"strings"
 )
 
 // IRGraph is the key datastrcture that is built from profile. It is
@@ -124,6 +126,33 @@ type Profile struct {
 	// WeightedCG represents the IRGraph built from profile, which we will
 	// update as part of inlining.
 	WeightedCG *IRGraph
+
+	// callSites maps a caller and call site offset to the names of all
+	// callees observed at that call site in the profile. It is used to
+	// resolve the targets of indirect (interface) calls.
+	callSites map[callSiteKey][]string
+
+	// lineWeights maps a function name to the cumulative weight of
+	// each of its lines, keyed by line offset from the function start
+	// line. It is used for basic block layout.
+	lineWeights map[string]map[int]int64
+
+	// hotFuncs is the set of functions, by name, that account for most
+	// of the profile's samples. Samples in inlined code are attributed
+	// to the function they were inlined into, as that is where the
+	// instructions are.
+	hotFuncs map[string]bool
+}
+
+// hotFuncCDFThreshold is the percentage of the total sample weight
+// covered by the functions in Profile.hotFuncs.
+const hotFuncCDFThreshold = 95
+
+// callSiteKey identifies a call site in the profile by caller name and
+// line offset.
+type callSiteKey struct {
+	CallerName     string
+	CallSiteOffset int // Line offset from function start line.
 }
 
 // New generates a profile-graph from the profile.
@@ -146,7 +175,9 @@ func New(profileFile string) *Profile {
 	})
 
 	p := &Profile{
-		NodeMap: make(map[NodeMapKey]*Weights),
+		NodeMap:     make(map[NodeMapKey]*Weights),
+		callSites:   make(map[callSiteKey][]string),
+		lineWeights: make(map[string]map[int]int64),
 		WeightedCG: &IRGraph{
 			IRNodes: make(map[string]*IRNode),
 		},
@@ -155,6 +186,9 @@ func New(profileFile string) *Profile {
 	// Build the node map and totals from the profile graph.
 	p.processprofileGraph(g)
 
+	// Find the functions where most of the time is spent.
+	p.computeHotFuncs(profile)
+
 	// Create package-level call graph with weights from profile and IR.
 	p.initializeIRGraph()
 
@@ -185,6 +219,15 @@ func (p *Profile) processprofileGraph(g *Graph) {
 
 		p.TotalNodeWeight += n.FlatValue()
 		canonicalName := n.Info.Name
+
+		if n.CumValue() != 0 {
+			lines := p.lineWeights[canonicalName]
+			if lines == nil {
+				lines = make(map[int]int64)
+				p.lineWeights[canonicalName] = lines
+			}
+			lines[n.Info.Lineno-n.Info.StartLine] += n.CumValue()
+		}
 		// Create the key to the nodeMapKey.
 		nodeinfo := NodeMapKey{
 			CallerName:     canonicalName,
@@ -202,6 +245,9 @@ func (p *Profile) processprofileGraph(g *Graph) {
 				weights.NCum = nCum[canonicalName]
 				weights.EWeight = e.WeightValue()
 				p.NodeMap[nodeinfo] = weights
+
+				site := callSiteKey{nodeinfo.CallerName, nodeinfo.CallSiteOffset}
+				p.callSites[site] = append(p.callSites[site], nodeinfo.CalleeName)
 			}
 		}
 	}
@@ -214,6 +260,63 @@ func (p *Profile) processprofileGraph(g *Graph) {
 	}
 }
 
+// computeHotFuncs initializes hotFuncs from the samples in profile.
+func (p *Profile) computeHotFuncs(profile *profile.Profile) {
+	weights := make(map[string]int64)
+	var total int64
+	for _, s := range profile.Sample {
+		if len(s.Location) == 0 || len(s.Location[0].Line) == 0 {
+			continue
+		}
+		// The last line of the leaf location is the outermost of
+		// the inlined frames, i.e., the function containing the
+		// sampled instruction.
+		lines := s.Location[0].Line
+		fn := lines[len(lines)-1].Function
+		if fn == nil {
+			continue
+		}
+		weights[fn.Name] += s.Value[1]
+		total += s.Value[1]
+	}
+
+	names := make([]string, 0, len(weights))
+	for name := range weights {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		wi, wj := weights[names[i]], weights[names[j]]
+		if wi != wj {
+			return wi > wj
+		}
+		return names[i] < names[j]
+	})
+
+	p.hotFuncs = make(map[string]bool)
+	var cum int64
+	for _, name := range names {
+		if WeightInPercentage(cum, total) >= hotFuncCDFThreshold || weights[name] == 0 {
+			break
+		}
+		p.hotFuncs[name] = true
+		cum += weights[name]
+	}
+}
+
+// IsHotFunc reports whether the function with the given name accounts
+// for a significant share of the samples in the profile.
+func (p *Profile) IsHotFunc(name string) bool {
+	return p.hotFuncs[name]
+}
+
+// LineWeights returns the cumulative weight of the lines of the
+// function with the given name, keyed by line offset from the function
+// start line (see NodeLineOffset). Lines without samples are absent. It
+// returns nil if the function does not appear in the profile.
+func (p *Profile) LineWeights(name string) map[int]int64 {
+	return p.lineWeights[name]
+}
+
 // initializeIRGraph builds the IRGraph by visting all the ir.Func in decl list
 // of a package.
 func (p *Profile) initializeIRGraph() {
@@ -325,6 +428,35 @@ func (p *Profile) addIREdge(caller *IRNode, callername string, call ir.Node, cal
 	}
 }
 
+// addIndirectEdges adds edges between caller and the methods that the
+// profile records as targets of the indirect call. Targets whose
+// definition cannot be found are ignored.
+func (p *Profile) addIndirectEdges(caller *IRNode, callername string, call *ir.CallExpr) {
+	site := callSiteKey{
+		CallerName:     callername,
+		CallSiteOffset: NodeLineOffset(call, caller.AST),
+	}
+	suffix := "." + call.X.(*ir.SelectorExpr).Sel.Name
+	for _, calleename := range p.callSites[site] {
+		if !strings.HasSuffix(calleename, suffix) {
+			// Some other call on the same line.
+			continue
+		}
+		var callee *ir.Func
+		if n, ok := p.WeightedCG.IRNodes[calleename]; ok {
+			callee = n.AST
+		} else {
+			fn, err := ir.LookupMethodFunc(calleename)
+			if err != nil {
+				// Not a method, or not one visible to this package.
+				continue
+			}
+			callee = fn
+		}
+		p.addIREdge(caller, callername, call, callee)
+	}
+}
+
 // createIRGraphEdge traverses the nodes in the body of ir.Func and add edges between callernode which points to the ir.Func and the nodes in the body.
 func (p *Profile) createIRGraphEdge(fn *ir.Func, callernode *IRNode, name string) {
 	var doNode func(ir.Node) bool
@@ -344,6 +476,12 @@ func (p *Profile) createIRGraphEdge(fn *ir.Func, callernode *IRNode, name string
 			// Find the callee method from the call site and add the edge.
 			callee := ir.MethodExprName(call.X).Func
 			p.addIREdge(callernode, name, n, callee)
+		case ir.OCALLINTER:
+			// The callee is not statically known. Add edges to the
+			// concrete methods the profile recorded at this call site.
+			p.addIndirectEdges(callernode, name, n.(*ir.CallExpr))
+			// The receiver and arguments may contain further calls.
+			ir.DoChildren(n, doNode)
 		}
 		return false
 	}
@@ -0,0 +1,773 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package rangefunc rewrites range-over-func loops into code that calls
+the iterator function directly, so that later phases of the compiler
+never see them.
+
+A loop
+
+	for k, v := range f {
+		body
+	}
+
+where f has type func(yield func(K, V) bool) becomes, roughly,
+
+	{
+		var #exit1 bool
+		f(func(k K, v V) bool {
+			if #exit1 {
+				runtime.panicrangeexit()
+			}
+			body
+			return true
+		})
+		#exit1 = true
+	}
+
+with these changes in body:
+
+A continue targeting the loop becomes "return true".
+A break targeting the loop becomes "#exit1 = true; return false".
+
+The #exitN flag makes a call of the yield function after the loop has
+exited, or after it has returned false, panic.
+
+Control flow that leaves the loop body by other means is recorded in a
+variable #next shared by all loops of a nest of range-over-func loops,
+and completed after the call of the iterator function. A return from
+the enclosing function saves its results in variables #r1, #r2, ...
+and sets #next to -1 (or -2 for a bare return); a break, continue or
+goto targeting a statement outside the loop body sets #next to a
+positive code identifying the branch. In both cases the body then
+exits with "#exitN = true; return false". After the call, the branch
+is repeated in its original form, which, if the loop is itself nested
+in the body of another range-over-func loop, is rewritten in turn.
+For example,
+
+	F: for x := range f {
+		for y := range g {
+			if y == 0 {
+				continue F
+			}
+			if y < 0 {
+				return y
+			}
+		}
+	}
+
+becomes
+
+	{
+		var #next int
+		var #r1 int
+		var #exit1 bool
+		f(func(x int) bool {
+			if #exit1 {
+				runtime.panicrangeexit()
+			}
+			{
+				var #exit2 bool
+				g(func(y int) bool {
+					if #exit2 {
+						runtime.panicrangeexit()
+					}
+					if y == 0 {
+						#next = 1
+						#exit2 = true
+						return false
+					}
+					if y < 0 {
+						#r1 = y
+						#next = -1
+						#exit2 = true
+						return false
+					}
+					return true
+				})
+				#exit2 = true
+				if #next == 1 {
+					#next = 0
+					return true
+				}
+				if #next == -1 {
+					#exit1 = true
+					return false
+				}
+			}
+			return true
+		})
+		#exit1 = true
+		if #next == -1 {
+			return #r1
+		}
+	}
+
+A defer statement in the loop body must run when the enclosing
+function returns, not when the body's function literal does. For a
+nest of loops containing such defer statements the enclosing function
+first executes
+
+	defer func() {}()
+	#defers := runtime.deferrangefunc()
+
+which turns the new defer record into a placeholder for the defers of
+the loop bodies, and each defer statement in the bodies is marked with
+#defers, so that the compiler queues it with runtime.deferprocat
+instead of runtime.deferproc.
+
+The rewritten syntax is annotated with the type information the
+compiler backend expects, as if it had been type-checked.
+*/
+package rangefunc
+
+import (
+	"cmd/compile/internal/syntax"
+	"cmd/compile/internal/types2"
+	"fmt"
+	"go/constant"
+)
+
+// Rewrite rewrites all the range-over-func loops in the given files
+// of package pkg, using and updating the type information in info.
+func Rewrite(pkg *types2.Package, info *types2.Info, files []*syntax.File) {
+	for _, file := range files {
+		syntax.Inspect(file, func(n syntax.Node) bool {
+			switch n := n.(type) {
+			case *syntax.FuncDecl:
+				if n.Body != nil {
+					sig, _ := info.Defs[n.Name].Type().(*types2.Signature)
+					rewriteFunc(pkg, info, sig, n.Body)
+				}
+				return false
+			case *syntax.FuncLit:
+				sig, _ := n.GetTypeInfo().Type.(*types2.Signature)
+				rewriteFunc(pkg, info, sig, n.Body)
+				return false
+			}
+			return true
+		})
+	}
+}
+
+// A rewriter implements rewriting the range-over-func loops in a
+// single function body.
+type rewriter struct {
+	pkg  *types2.Package
+	info *types2.Info
+	sig  *types2.Signature // signature of the function being rewritten
+
+	// loopOf maps each for, switch, select and labeled statement in
+	// the function to the innermost range-over-func loop whose body
+	// contains it, or nil.
+	loopOf map[syntax.Stmt]*syntax.ForStmt
+
+	// forStack holds the range-over-func loops being rewritten,
+	// outermost first.
+	forStack []*forLoop
+
+	// Variables shared by the current nest of range-over-func loops.
+	// They are created as needed and declared before the outermost loop.
+	next    *types2.Var   // #next
+	retVars []*types2.Var // #r1, #r2, ...
+	defers  *types2.Var   // #defers
+
+	nextCode int // last code allocated for a branch out of a loop body
+	nexit    int // number of #exitN variables allocated
+	nparam   int // number of #pN variables allocated
+
+	runtimePkg  *types2.Package
+	runtimeObjs map[string]*types2.Func
+}
+
+// A forLoop is a range-over-func loop being rewritten.
+type forLoop struct {
+	nfor *syntax.ForStmt
+	exit *types2.Var // #exitN
+
+	// pending lists the branches out of the loop body that need to
+	// be completed after the call of the iterator function, in the
+	// order in which they were encountered.
+	pending []pendingBranch
+}
+
+// A pendingBranch is a branch out of a range-over-func loop body.
+type pendingBranch struct {
+	code   int                // value of #next while it is pending
+	branch *syntax.BranchStmt // nil for returns
+}
+
+// Branch codes for returns from the enclosing function.
+const (
+	returnValues = -1 // return #r1, #r2, ...
+	returnBare   = -2 // return
+)
+
+func rewriteFunc(pkg *types2.Package, info *types2.Info, sig *types2.Signature, body *syntax.BlockStmt) {
+	r := &rewriter{
+		pkg:    pkg,
+		info:   info,
+		sig:    sig,
+		loopOf: make(map[syntax.Stmt]*syntax.ForStmt),
+	}
+	if r.analyze(body) {
+		r.stmtList(body.List)
+	}
+}
+
+// analyze records the innermost range-over-func loop containing each
+// statement of body in r.loopOf and rewrites any nested function
+// literals. It reports whether body contains range-over-func loops.
+func (r *rewriter) analyze(body *syntax.BlockStmt) bool {
+	found := false
+	var stack []syntax.Node
+	var loops []*syntax.ForStmt
+	syntax.Inspect(body, func(n syntax.Node) bool {
+		if n == nil {
+			if fs, ok := stack[len(stack)-1].(*syntax.ForStmt); ok && len(loops) > 0 && loops[len(loops)-1] == fs {
+				loops = loops[:len(loops)-1]
+			}
+			stack = stack[:len(stack)-1]
+			return true
+		}
+
+		var loop *syntax.ForStmt
+		if len(loops) > 0 {
+			loop = loops[len(loops)-1]
+		}
+
+		switch n := n.(type) {
+		case *syntax.FuncLit:
+			sig, _ := n.GetTypeInfo().Type.(*types2.Signature)
+			rewriteFunc(r.pkg, r.info, sig, n.Body)
+			return false
+		case *syntax.ForStmt:
+			r.loopOf[n] = loop
+			if isRangeFunc(n) {
+				found = true
+				loops = append(loops, n)
+			}
+		case *syntax.SwitchStmt:
+			r.loopOf[n] = loop
+		case *syntax.SelectStmt:
+			r.loopOf[n] = loop
+		case *syntax.LabeledStmt:
+			r.loopOf[n] = loop
+		}
+		stack = append(stack, n)
+		return true
+	})
+	return found
+}
+
+// isRangeFunc reports whether fs is a range-over-func loop.
+func isRangeFunc(fs *syntax.ForStmt) bool {
+	rclause, ok := fs.Init.(*syntax.RangeClause)
+	if !ok {
+		return false
+	}
+	_, ok = types2.CoreType(rclause.X.GetTypeInfo().Type).(*types2.Signature)
+	return ok
+}
+
+func (r *rewriter) stmtList(list []syntax.Stmt) {
+	for i, s := range list {
+		list[i] = r.stmt(s)
+	}
+}
+
+// stmt rewrites the statement s and returns its replacement.
+func (r *rewriter) stmt(s syntax.Stmt) syntax.Stmt {
+	switch s := s.(type) {
+	case *syntax.BlockStmt:
+		r.stmtList(s.List)
+	case *syntax.LabeledStmt:
+		s.Stmt = r.stmt(s.Stmt)
+	case *syntax.IfStmt:
+		r.stmtList(s.Then.List)
+		if s.Else != nil {
+			s.Else = r.stmt(s.Else)
+		}
+	case *syntax.ForStmt:
+		if isRangeFunc(s) {
+			return r.rangeFunc(s)
+		}
+		r.stmtList(s.Body.List)
+	case *syntax.SwitchStmt:
+		for _, clause := range s.Body {
+			r.stmtList(clause.Body)
+		}
+	case *syntax.SelectStmt:
+		for _, clause := range s.Body {
+			r.stmtList(clause.Body)
+		}
+	case *syntax.BranchStmt:
+		if len(r.forStack) > 0 {
+			return r.branch(s, 0)
+		}
+	case *syntax.ReturnStmt:
+		if len(r.forStack) > 0 {
+			return r.returnStmt(s)
+		}
+	case *syntax.CallStmt:
+		if s.Tok == syntax.Defer && len(r.forStack) > 0 {
+			s.DeferAt = r.useVar(s.Pos(), r.defersVar(s.Pos()))
+		}
+	}
+	return s
+}
+
+// rangeFunc rewrites the range-over-func loop fs and returns its replacement.
+func (r *rewriter) rangeFunc(fs *syntax.ForStmt) syntax.Stmt {
+	pos := fs.Pos()
+	rclause := fs.Init.(*syntax.RangeClause)
+
+	r.nexit++
+	loop := &forLoop{
+		nfor: fs,
+		exit: types2.NewVar(pos, r.pkg, fmt.Sprintf("#exit%d", r.nexit), types2.Typ[types2.Bool]),
+	}
+
+	// Rewrite the body first, so that nested loops are rewritten and
+	// all the branches out of this loop body are known.
+	r.forStack = append(r.forStack, loop)
+	r.stmtList(fs.Body.List)
+	r.forStack = r.forStack[:len(r.forStack)-1]
+
+	yield := r.yieldFunc(loop, rclause)
+
+	// f(func(...) bool { ... })
+	call := &syntax.CallExpr{Fun: rclause.X, ArgList: []syntax.Expr{yield}}
+	call.SetPos(pos)
+	setVoid(call)
+
+	block := &syntax.BlockStmt{Rbrace: fs.Body.Rbrace}
+	block.SetPos(pos)
+	block.List = append(block.List,
+		r.declVar(pos, loop.exit, nil),
+		r.exprStmt(pos, call),
+		r.assign(pos, r.useVar(pos, loop.exit), r.boolLit(pos, true)),
+	)
+
+	// Complete the branches out of the loop body.
+	for _, p := range loop.pending {
+		var then []syntax.Stmt
+		switch {
+		case p.branch != nil:
+			then = r.completeBranch(pos, p)
+		case len(r.forStack) > 0:
+			then = r.exitLoop(pos, p)
+		case p.code == returnValues:
+			ret := &syntax.ReturnStmt{Results: r.retResults(pos)}
+			ret.SetPos(pos)
+			then = []syntax.Stmt{ret}
+		default:
+			ret := &syntax.ReturnStmt{}
+			ret.SetPos(pos)
+			then = []syntax.Stmt{ret}
+		}
+		block.List = append(block.List, r.ifNext(pos, p.code, then))
+	}
+
+	if len(r.forStack) == 0 {
+		// This is the outermost loop of a nest; declare the variables
+		// shared by the loops of the nest.
+		var decls []syntax.Stmt
+		if r.next != nil {
+			decls = append(decls, r.declVar(pos, r.next, nil))
+		}
+		for _, v := range r.retVars {
+			decls = append(decls, r.declVar(pos, v, nil))
+		}
+		if r.defers != nil {
+			decls = append(decls, r.deferAnchor(pos), r.declVar(pos, r.defers, r.callRuntime(pos, "deferrangefunc")))
+		}
+		block.List = append(decls, block.List...)
+		r.next, r.retVars, r.defers = nil, nil, nil
+	}
+
+	return block
+}
+
+// yieldFunc returns the function literal passed to the iterator
+// function of loop, containing the rewritten loop body.
+func (r *rewriter) yieldFunc(loop *forLoop, rclause *syntax.RangeClause) *syntax.FuncLit {
+	fs := loop.nfor
+	pos := fs.Pos()
+
+	ftyp := types2.CoreType(rclause.X.GetTypeInfo().Type).(*types2.Signature)
+	ytyp := types2.CoreType(ftyp.Params().At(0).Type()).(*types2.Signature)
+
+	var lhs []syntax.Expr
+	if rclause.Lhs != nil {
+		if list, ok := rclause.Lhs.(*syntax.ListExpr); ok {
+			lhs = list.ElemList
+		} else {
+			lhs = []syntax.Expr{rclause.Lhs}
+		}
+	}
+
+	var body []syntax.Stmt
+
+	// if #exitN { runtime.panicrangeexit() }
+	body = append(body, r.ifThen(pos, r.useVar(pos, loop.exit), r.exprStmt(pos, r.callRuntime(pos, "panicrangeexit"))))
+
+	params := make([]*types2.Var, ytyp.Params().Len())
+	var assignLhs, assignRhs []syntax.Expr
+	for i := range params {
+		typ := ytyp.Params().At(i).Type()
+		if i < len(lhs) {
+			if rclause.Def {
+				// The iteration variables become the parameters.
+				if name, ok := lhs[i].(*syntax.Name); ok && name.Value != "_" {
+					if v, ok := r.info.Defs[name].(*types2.Var); ok {
+						params[i] = v
+						continue
+					}
+				}
+			} else {
+				// The parameters are assigned to the iteration variables.
+				r.nparam++
+				params[i] = types2.NewVar(pos, r.pkg, fmt.Sprintf("#p%d", r.nparam), typ)
+				assignLhs = append(assignLhs, lhs[i])
+				assignRhs = append(assignRhs, r.useVar(pos, params[i]))
+				continue
+			}
+		}
+		params[i] = types2.NewParam(pos, r.pkg, "", typ)
+	}
+	if len(assignLhs) > 0 {
+		body = append(body, r.assign(pos, listExpr(pos, assignLhs), listExpr(pos, assignRhs)))
+	}
+
+	body = append(body, fs.Body.List...)
+
+	// return true
+	ret := &syntax.ReturnStmt{Results: r.boolLit(fs.Body.Rbrace, true)}
+	ret.SetPos(fs.Body.Rbrace)
+	body = append(body, ret)
+
+	results := []*types2.Var{types2.NewParam(pos, r.pkg, "", types2.Typ[types2.Bool])}
+	sig := types2.NewSignatureType(nil, nil, nil, types2.NewTuple(params...), types2.NewTuple(results...), false)
+
+	block := &syntax.BlockStmt{List: body, Rbrace: fs.Body.Rbrace}
+	block.SetPos(fs.Body.Pos())
+
+	ftype := &syntax.FuncType{}
+	ftype.SetPos(pos)
+	lit := &syntax.FuncLit{Type: ftype, Body: block}
+	lit.SetPos(pos)
+	setValue(lit, sig)
+	return lit
+}
+
+// branch rewrites the branch statement s, which appears in the body of
+// the innermost range-over-func loop being rewritten, and returns its
+// replacement. If s leaves the loop body and code is not 0, code is
+// used to identify it.
+func (r *rewriter) branch(s *syntax.BranchStmt, code int) syntax.Stmt {
+	loop := r.forStack[len(r.forStack)-1]
+	pos := s.Pos()
+
+	if (s.Tok == syntax.Break || s.Tok == syntax.Continue) && s.Target == loop.nfor {
+		if s.Tok == syntax.Continue {
+			// return true
+			ret := &syntax.ReturnStmt{Results: r.boolLit(pos, true)}
+			ret.SetPos(pos)
+			return ret
+		}
+		// #exitN = true; return false
+		return r.block(pos, r.exitBody(pos, loop)...)
+	}
+	if !r.leaves(s, loop) {
+		return s
+	}
+
+	// The branch leaves the loop body: record it and exit.
+	if code == 0 {
+		r.nextCode++
+		code = r.nextCode
+	}
+	loop.addPending(pendingBranch{code: code, branch: s})
+	list := []syntax.Stmt{r.assign(pos, r.useVar(pos, r.nextVar(pos)), r.intLit(pos, code))}
+	return r.block(pos, append(list, r.exitBody(pos, loop)...)...)
+}
+
+// returnStmt rewrites the return statement s, which appears in the
+// body of the innermost range-over-func loop being rewritten, and
+// returns its replacement.
+func (r *rewriter) returnStmt(s *syntax.ReturnStmt) syntax.Stmt {
+	loop := r.forStack[len(r.forStack)-1]
+	pos := s.Pos()
+
+	var list []syntax.Stmt
+	code := returnBare
+	if s.Results != nil {
+		// #r1, #r2, ... = results
+		code = returnValues
+		list = append(list, r.assign(pos, r.retResults(pos), s.Results))
+	}
+	loop.addPending(pendingBranch{code: code})
+	list = append(list, r.assign(pos, r.useVar(pos, r.nextVar(pos)), r.intLit(pos, code)))
+	return r.block(pos, append(list, r.exitBody(pos, loop)...)...)
+}
+
+// completeBranch returns the statements that complete the pending
+// branch p after the call of the iterator function, where the
+// innermost range-over-func loop being rewritten, if any, is the one
+// containing the loop that p leaves.
+func (r *rewriter) completeBranch(pos syntax.Pos, p pendingBranch) []syntax.Stmt {
+	s := &syntax.BranchStmt{Tok: p.branch.Tok, Label: p.branch.Label, Target: p.branch.Target}
+	s.SetPos(p.branch.Pos())
+	if len(r.forStack) > 0 && r.leaves(s, r.forStack[len(r.forStack)-1]) {
+		// Still leaving the enclosing loop body; #next is already set.
+		return r.branch(s, p.code).(*syntax.BlockStmt).List
+	}
+	var then syntax.Stmt = s
+	if len(r.forStack) > 0 {
+		then = r.branch(s, p.code)
+	}
+	return []syntax.Stmt{r.assign(pos, r.useVar(pos, r.nextVar(pos)), r.intLit(pos, 0)), then}
+}
+
+// leaves reports whether the branch statement s, which appears in the
+// body of loop, leaves the body by means other than breaking or
+// continuing loop itself.
+func (r *rewriter) leaves(s *syntax.BranchStmt, loop *forLoop) bool {
+	switch s.Tok {
+	case syntax.Break, syntax.Continue:
+		if s.Target == loop.nfor {
+			return false
+		}
+	case syntax.Goto:
+	default:
+		return false
+	}
+	return r.loopOf[s.Target] != loop.nfor
+}
+
+// exitLoop returns the statements that propagate the pending return p
+// out of the body of the innermost range-over-func loop being rewritten.
+func (r *rewriter) exitLoop(pos syntax.Pos, p pendingBranch) []syntax.Stmt {
+	loop := r.forStack[len(r.forStack)-1]
+	loop.addPending(p)
+	return r.exitBody(pos, loop)
+}
+
+// exitBody returns "#exitN = true; return false" for loop.
+func (r *rewriter) exitBody(pos syntax.Pos, loop *forLoop) []syntax.Stmt {
+	ret := &syntax.ReturnStmt{Results: r.boolLit(pos, false)}
+	ret.SetPos(pos)
+	return []syntax.Stmt{r.assign(pos, r.useVar(pos, loop.exit), r.boolLit(pos, true)), ret}
+}
+
+// addPending records p as pending for loop, unless it already is.
+func (loop *forLoop) addPending(p pendingBranch) {
+	for _, q := range loop.pending {
+		if q.code == p.code {
+			return
+		}
+	}
+	loop.pending = append(loop.pending, p)
+}
+
+// nextVar returns the #next variable of the current loop nest.
+func (r *rewriter) nextVar(pos syntax.Pos) *types2.Var {
+	if r.next == nil {
+		r.next = types2.NewVar(pos, r.pkg, "#next", types2.Typ[types2.Int])
+	}
+	return r.next
+}
+
+// defersVar returns the #defers variable of the current loop nest.
+func (r *rewriter) defersVar(pos syntax.Pos) *types2.Var {
+	if r.defers == nil {
+		r.defers = types2.NewVar(pos, r.pkg, "#defers", r.runtimeFunc("deferrangefunc").Type().(*types2.Signature).Results().At(0).Type())
+	}
+	return r.defers
+}
+
+// retResults returns a list of references to the #rN variables of the
+// current loop nest, which hold the results of the enclosing function.
+func (r *rewriter) retResults(pos syntax.Pos) syntax.Expr {
+	results := r.sig.Results()
+	if r.retVars == nil {
+		for i := 0; i < results.Len(); i++ {
+			r.retVars = append(r.retVars, types2.NewVar(pos, r.pkg, fmt.Sprintf("#r%d", i+1), results.At(i).Type()))
+		}
+	}
+	list := make([]syntax.Expr, len(r.retVars))
+	for i, v := range r.retVars {
+		list[i] = r.useVar(pos, v)
+	}
+	return listExpr(pos, list)
+}
+
+// deferAnchor returns the statement "defer func() {}()", whose defer
+// record is turned into the placeholder for the defers of the loop
+// bodies by runtime.deferrangefunc.
+func (r *rewriter) deferAnchor(pos syntax.Pos) syntax.Stmt {
+	body := &syntax.BlockStmt{Rbrace: pos}
+	body.SetPos(pos)
+	ftype := &syntax.FuncType{}
+	ftype.SetPos(pos)
+	lit := &syntax.FuncLit{Type: ftype, Body: body}
+	lit.SetPos(pos)
+	setValue(lit, types2.NewSignatureType(nil, nil, nil, nil, nil, false))
+
+	call := &syntax.CallExpr{Fun: lit}
+	call.SetPos(pos)
+	setVoid(call)
+
+	s := &syntax.CallStmt{Tok: syntax.Defer, Call: call}
+	s.SetPos(pos)
+	return s
+}
+
+// runtimeFunc returns the object for the runtime helper function name.
+func (r *rewriter) runtimeFunc(name string) *types2.Func {
+	if obj := r.runtimeObjs[name]; obj != nil {
+		return obj
+	}
+	if r.runtimePkg == nil {
+		r.runtimePkg = types2.NewPackage("runtime", "runtime")
+		r.runtimeObjs = make(map[string]*types2.Func)
+	}
+	var results *types2.Tuple
+	switch name {
+	case "deferrangefunc":
+		results = types2.NewTuple(types2.NewParam(syntax.Pos{}, r.runtimePkg, "", types2.Universe.Lookup("any").Type()))
+	case "panicrangeexit":
+	default:
+		panic("unknown runtime helper " + name)
+	}
+	obj := types2.NewFunc(syntax.Pos{}, r.runtimePkg, name, types2.NewSignatureType(nil, nil, nil, nil, results, false))
+	r.runtimeObjs[name] = obj
+	return obj
+}
+
+// callRuntime returns a call of the runtime helper function name.
+func (r *rewriter) callRuntime(pos syntax.Pos, name string) *syntax.CallExpr {
+	obj := r.runtimeFunc(name)
+	sig := obj.Type().(*types2.Signature)
+
+	fn := syntax.NewName(pos, name)
+	r.info.Uses[fn] = obj
+	tv := syntax.TypeAndValue{Type: sig}
+	tv.SetIsValue()
+	tv.SetIsRuntimeHelper()
+	fn.SetTypeInfo(tv)
+
+	call := &syntax.CallExpr{Fun: fn}
+	call.SetPos(pos)
+	if sig.Results().Len() == 0 {
+		setVoid(call)
+	} else {
+		setValue(call, sig.Results().At(0).Type())
+	}
+	return call
+}
+
+// useVar returns a reference to the variable v.
+func (r *rewriter) useVar(pos syntax.Pos, v *types2.Var) *syntax.Name {
+	n := syntax.NewName(pos, v.Name())
+	r.info.Uses[n] = v
+	tv := syntax.TypeAndValue{Type: v.Type()}
+	tv.SetIsValue()
+	tv.SetAddressable()
+	tv.SetAssignable()
+	n.SetTypeInfo(tv)
+	return n
+}
+
+// declVar returns the declaration "var v = init", or "var v" if init is nil.
+func (r *rewriter) declVar(pos syntax.Pos, v *types2.Var, init syntax.Expr) syntax.Stmt {
+	n := syntax.NewName(pos, v.Name())
+	r.info.Defs[n] = v
+	decl := &syntax.VarDecl{NameList: []*syntax.Name{n}, Values: init}
+	decl.SetPos(pos)
+	s := &syntax.DeclStmt{DeclList: []syntax.Decl{decl}}
+	s.SetPos(pos)
+	return s
+}
+
+// assign returns the assignment "lhs = rhs".
+func (r *rewriter) assign(pos syntax.Pos, lhs, rhs syntax.Expr) syntax.Stmt {
+	s := &syntax.AssignStmt{Lhs: lhs, Rhs: rhs}
+	s.SetPos(pos)
+	return s
+}
+
+// exprStmt returns the expression statement x.
+func (r *rewriter) exprStmt(pos syntax.Pos, x syntax.Expr) syntax.Stmt {
+	s := &syntax.ExprStmt{X: x}
+	s.SetPos(pos)
+	return s
+}
+
+// block returns a block containing list.
+func (r *rewriter) block(pos syntax.Pos, list ...syntax.Stmt) *syntax.BlockStmt {
+	b := &syntax.BlockStmt{List: list, Rbrace: pos}
+	b.SetPos(pos)
+	return b
+}
+
+// ifThen returns "if cond { then... }".
+func (r *rewriter) ifThen(pos syntax.Pos, cond syntax.Expr, then ...syntax.Stmt) syntax.Stmt {
+	s := &syntax.IfStmt{Cond: cond, Then: r.block(pos, then...)}
+	s.SetPos(pos)
+	return s
+}
+
+// ifNext returns "if #next == code { then... }".
+func (r *rewriter) ifNext(pos syntax.Pos, code int, then []syntax.Stmt) syntax.Stmt {
+	cond := &syntax.Operation{Op: syntax.Eql, X: r.useVar(pos, r.nextVar(pos)), Y: r.intLit(pos, code)}
+	cond.SetPos(pos)
+	setValue(cond, types2.Typ[types2.Bool])
+	return r.ifThen(pos, cond, then...)
+}
+
+// boolLit returns the constant true or false.
+func (r *rewriter) boolLit(pos syntax.Pos, b bool) syntax.Expr {
+	n := syntax.NewName(pos, fmt.Sprint(b))
+	tv := syntax.TypeAndValue{Type: types2.Typ[types2.Bool], Value: constant.MakeBool(b)}
+	tv.SetIsValue()
+	n.SetTypeInfo(tv)
+	return n
+}
+
+// intLit returns the int constant c.
+func (r *rewriter) intLit(pos syntax.Pos, c int) syntax.Expr {
+	lit := &syntax.BasicLit{Value: fmt.Sprint(c), Kind: syntax.IntLit}
+	lit.SetPos(pos)
+	tv := syntax.TypeAndValue{Type: types2.Typ[types2.Int], Value: constant.MakeInt64(int64(c))}
+	tv.SetIsValue()
+	lit.SetTypeInfo(tv)
+	return lit
+}
+
+// listExpr returns list as a single expression.
+func listExpr(pos syntax.Pos, list []syntax.Expr) syntax.Expr {
+	if len(list) == 1 {
+		return list[0]
+	}
+	x := &syntax.ListExpr{ElemList: list}
+	x.SetPos(pos)
+	return x
+}
+
+// setValue records that x is a value of type typ.
+func setValue(x syntax.Expr, typ types2.Type) {
+	tv := syntax.TypeAndValue{Type: typ}
+	tv.SetIsValue()
+	x.SetTypeInfo(tv)
+}
+
+// setVoid records that x is a call without results.
+func setVoid(x syntax.Expr) {
+	tv := syntax.TypeAndValue{Type: (*types2.Tuple)(nil)}
+	tv.SetIsVoid()
+	x.SetTypeInfo(tv)
+}
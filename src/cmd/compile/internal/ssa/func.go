@@ -61,6 +61,12 @@ type Func struct {
 	// AuxCall describing parameters and results for this function.
 	OwnAux *AuxCall
 
+	// PGOLineWeight, if non-nil, returns the profile weight of the
+	// source line of pos, which is zero if the line was never sampled.
+	// It is set when compiling with a PGO profile that has samples for
+	// this function, and guides block layout.
+	PGOLineWeight func(pos src.XPos) int64
+
 	// WBLoads is a list of Blocks that branch on the write
 	// barrier flag. Safe-points are disabled from the OpLoad that
 	// reads the write-barrier flag until the control flow rejoins
@@ -72,6 +72,17 @@ func layoutOrder(f *Func) []*Block {
 		}
 	}
 
+	// With a profile, blocks that never executed are moved out of line
+	// along with the exit blocks.
+	weights := blockWeights(f)
+	if weights != nil {
+		for _, b := range f.Blocks {
+			if weights[b.ID] == 0 && b != f.Entry {
+				exit.add(b.ID)
+			}
+		}
+	}
+
 	// Initialize indegree of each block
 	for _, b := range f.Blocks {
 		if exit.contains(b.ID) {
@@ -122,6 +133,24 @@ blockloop:
 		// Pick the next block to schedule
 		// Pick among the successor blocks that have not been scheduled yet.
 
+		// Use the hotter successor if the profile tells them apart.
+		if weights != nil && len(b.Succs) == 2 {
+			s0, s1 := b.Succs[0].b, b.Succs[1].b
+			w0, w1 := weights[s0.ID], weights[s1.ID]
+			var hot *Block
+			if w0 >= 0 && w1 >= 0 {
+				if w0 > w1 {
+					hot = s0
+				} else if w1 > w0 {
+					hot = s1
+				}
+			}
+			if hot != nil && !scheduled[hot.ID] && !exit.contains(hot.ID) {
+				bid = hot.ID
+				continue
+			}
+		}
+
 		// Use likely direction if we have it.
 		var likely *Block
 		switch b.Likely {
@@ -183,3 +212,36 @@ blockloop:
 	return order
 	//f.Blocks = order
 }
+
+// blockWeights returns the profile weight of each block of f, indexed
+// by block ID, or nil if f has no profile samples. The weight of a block
+// is the largest weight of the lines of its values and control; it is
+// -1 if none of them has a position covered by the profile.
+func blockWeights(f *Func) []int64 {
+	if f.PGOLineWeight == nil {
+		return nil
+	}
+	weights := make([]int64, f.NumBlocks())
+	sampled := false
+	for _, b := range f.Blocks {
+		w := int64(-1)
+		if b.Pos.IsKnown() {
+			w = f.PGOLineWeight(b.Pos)
+		}
+		for _, v := range b.Values {
+			if v.Pos.IsKnown() {
+				if vw := f.PGOLineWeight(v.Pos); vw > w {
+					w = vw
+				}
+			}
+		}
+		weights[b.ID] = w
+		if w > 0 {
+			sampled = true
+		}
+	}
+	if !sampled {
+		return nil
+	}
+	return weights
+}
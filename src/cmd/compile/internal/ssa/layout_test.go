@@ -0,0 +1,67 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssa
+
+import (
+	"cmd/compile/internal/types"
+	"cmd/internal/src"
+	"testing"
+)
+
+func TestLayoutPGO(t *testing.T) {
+	c := testConfig(t)
+	fun := c.Fun("entry",
+		Bloc("entry",
+			Valu("mem", OpInitMem, types.TypeMem, 0, nil),
+			Valu("cond", OpConstBool, c.config.Types.Bool, 1, nil),
+			If("cond", "cold", "hot")),
+		Bloc("cold",
+			Valu("x", OpConst64, c.config.Types.Int64, 1, nil),
+			Goto("join")),
+		Bloc("hot",
+			Valu("y", OpConst64, c.config.Types.Int64, 2, nil),
+			Goto("join")),
+		Bloc("join",
+			Valu("z", OpConst64, c.config.Types.Int64, 3, nil),
+			If("cond", "join", "exit")),
+		Bloc("exit",
+			Exit("mem")))
+
+	// The static hint favors the cold block; the profile says otherwise.
+	fun.blocks["entry"].Likely = BranchLikely
+
+	var tab src.PosTable
+	base := src.NewFileBase("a.go", "a.go")
+	lines := map[string]uint{"cond": 1, "x": 2, "y": 3, "z": 4}
+	for name, line := range lines {
+		fun.values[name].Pos = tab.XPos(src.MakePos(base, line, 1))
+	}
+	weights := map[uint]int64{1: 100, 3: 90, 4: 100}
+	fun.f.PGOLineWeight = func(pos src.XPos) int64 {
+		return weights[pos.Line()]
+	}
+
+	CheckFunc(fun.f)
+	layout(fun.f)
+	CheckFunc(fun.f)
+
+	index := make(map[*Block]int)
+	for i, b := range fun.f.Blocks {
+		index[b] = i
+	}
+	if got := index[fun.blocks["hot"]]; got != 1 {
+		t.Errorf("hot block at position %d, want 1", got)
+	}
+	if index[fun.blocks["cold"]] < index[fun.blocks["join"]] {
+		t.Errorf("cold block laid out before join block")
+	}
+
+	// Without a profile, the static hint wins.
+	fun.f.PGOLineWeight = nil
+	layout(fun.f)
+	if fun.f.Blocks[1] != fun.blocks["cold"] {
+		t.Errorf("got %v after entry without profile, want %v", fun.f.Blocks[1], fun.blocks["cold"])
+	}
+}
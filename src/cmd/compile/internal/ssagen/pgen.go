@@ -190,6 +190,10 @@ func Compile(fn *ir.Func, worker int) {
 		largeStackFramesMu.Unlock()
 		return
 	}
+	if PGOProfile != nil && base.Debug.PGOLayout > 0 && PGOProfile.IsHotFunc(ir.PkgFuncName(fn)) {
+		// Let the linker place fn next to the other hot functions.
+		fn.LSym.Set(obj.AttrHot, true)
+	}
 	pp := objw.NewProgs(fn, worker)
 	defer pp.Free()
 	genssa(f, pp)
@@ -570,7 +570,9 @@ func AnySideEffects(n ir.Node) bool {
 			ir.ORUNESTR,
 			ir.OREAL,
 			ir.OIMAG,
-			ir.OCOMPLEX:
+			ir.OCOMPLEX,
+			ir.OMAX,
+			ir.OMIN:
 			return false
 
 		// Only possible side effect is division by zero.
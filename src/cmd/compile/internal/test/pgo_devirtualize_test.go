@@ -0,0 +1,141 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package test
+
+import (
+	"bufio"
+	"fmt"
+	"internal/testenv"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// testPGODevirtualize tests that specific PGO devirtualize rewrites are performed.
+func testPGODevirtualize(t *testing.T, dir string) {
+	testenv.MustHaveGoRun(t)
+	t.Parallel()
+
+	const pkg = "example.com/pgo/devirtualize"
+
+	// Add a go.mod so we have a consistent symbol names in this temp dir.
+	goMod := fmt.Sprintf(`module %s
+go 1.19
+`, pkg)
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0644); err != nil {
+		t.Fatalf("error writing go.mod: %v", err)
+	}
+
+	// Build the test with the profile. The profile is small enough that
+	// the default hot call site CDF threshold leaves Exercise's a.Add
+	// call site just below the cutoff, so include more of the CDF.
+	pprof := filepath.Join(dir, "devirt.pprof")
+	gcflag := fmt.Sprintf("-gcflags=-m -d=inlinehotcallsitecdfthreshold=99 -pgoprofile=%s", pprof)
+	out := filepath.Join(dir, "test.exe")
+	cmd := testenv.CleanCmdEnv(exec.Command(testenv.GoToolPath(t), "test", "-c", "-o", out, gcflag, "."))
+	cmd.Dir = dir
+
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("error creating pipe: %v", err)
+	}
+	defer pr.Close()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+
+	err = cmd.Start()
+	pw.Close()
+	if err != nil {
+		t.Fatalf("error starting go test: %v", err)
+	}
+
+	type devirtualization struct {
+		pos    string
+		callee string
+	}
+
+	want := []devirtualization{
+		{
+			pos:    "./devirt.go:56:31",
+			callee: "Add",
+		},
+		{
+			pos:    "./devirt.go:56:21",
+			callee: "mult.Mult",
+		},
+		// ExerciseSplit's a.Add call is hot, but split evenly
+		// between Add and Sub, so it must not be devirtualized.
+	}
+
+	got := make(map[devirtualization]struct{})
+
+	devirtualizedLine := regexp.MustCompile(`(.*): PGO devirtualizing .* to ([^ ]*)`)
+
+	scanner := bufio.NewScanner(pr)
+	for scanner.Scan() {
+		line := scanner.Text()
+		t.Logf("child: %s", line)
+
+		m := devirtualizedLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		d := devirtualization{
+			pos:    m[1],
+			callee: m[2],
+		}
+		got[d] = struct{}{}
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("error running go test: %v", err)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("error reading go test output: %v", err)
+	}
+
+	if len(got) != len(want) {
+		t.Errorf("mismatched devirtualization count; got %v want %v", got, want)
+	}
+	for _, w := range want {
+		if _, ok := got[w]; ok {
+			continue
+		}
+		t.Errorf("devirtualization %v missing; got %v", w, got)
+	}
+
+	// Run the benchmark, which exercises both the devirtualized path
+	// and the fallback to dynamic dispatch.
+	cmd = testenv.CleanCmdEnv(exec.Command(out, "-test.run=^$", "-test.bench=.", "-test.benchtime=100x"))
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("error running test binary: %v\n%s", err, output)
+	}
+}
+
+// TestPGODevirtualize tests that specific functions are devirtualized when PGO
+// is applied to the exact source that was profiled.
+func TestPGODevirtualize(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("error getting wd: %v", err)
+	}
+	srcDir := filepath.Join(wd, "testdata", "pgo", "devirtualize")
+
+	// Copy the module to a scratch location so we can add a go.mod.
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "mult"), 0755); err != nil {
+		t.Fatalf("error creating dir: %v", err)
+	}
+	for _, file := range []string{"devirt.go", "devirt_test.go", "devirt.pprof", filepath.Join("mult", "mult.go")} {
+		if err := copyFile(filepath.Join(dir, file), filepath.Join(srcDir, file)); err != nil {
+			t.Fatalf("error copying %s: %v", file, err)
+		}
+	}
+
+	testPGODevirtualize(t, dir)
+}
@@ -0,0 +1,114 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package test
+
+import (
+	"fmt"
+	"internal/testenv"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestPGOHotFunctions tests that the functions where the profile
+// spends most of its time are marked hot for the linker, and that a
+// binary laid out using the profile runs.
+func TestPGOHotFunctions(t *testing.T) {
+	testenv.MustHaveGoRun(t)
+	t.Parallel()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("error getting wd: %v", err)
+	}
+	srcDir := filepath.Join(wd, "testdata", "pgo", "devirtualize")
+
+	// Copy the module to a scratch location so we can add a go.mod.
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "mult"), 0755); err != nil {
+		t.Fatalf("error creating dir: %v", err)
+	}
+	for _, file := range []string{"devirt.go", "devirt_test.go", "devirt.pprof", filepath.Join("mult", "mult.go")} {
+		if err := copyFile(filepath.Join(dir, file), filepath.Join(srcDir, file)); err != nil {
+			t.Fatalf("error copying %s: %v", file, err)
+		}
+	}
+	goMod := "module example.com/pgo/devirtualize\ngo 1.19\n"
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0644); err != nil {
+		t.Fatalf("error writing go.mod: %v", err)
+	}
+
+	pprof := filepath.Join(dir, "devirt.pprof")
+	gcflag := fmt.Sprintf("-gcflags=example.com/...=-S -pgoprofile=%s", pprof)
+	out := filepath.Join(dir, "test.exe")
+	cmd := testenv.CleanCmdEnv(exec.Command(testenv.GoToolPath(t), "test", "-c", "-o", out, gcflag, "."))
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("error running go test: %v\n%s", err, output)
+	}
+
+	// From the profile, these functions account for 95% of the samples.
+	// They are listed in link order.
+	hot := []string{
+		"example.com/pgo/devirtualize/mult.Mult.Multiply",
+		"example.com/pgo/devirtualize.Add.Add",
+		"example.com/pgo/devirtualize.Sub.Add",
+	}
+	notHot := []string{
+		"example.com/pgo/devirtualize.Exercise",
+		"example.com/pgo/devirtualize.BenchmarkDevirt",
+		"example.com/pgo/devirtualize/mult.NegMult.Multiply",
+	}
+	for _, name := range hot {
+		re := regexp.MustCompile(`(?m)^` + regexp.QuoteMeta(name) + ` STEXT .*\bhot\b`)
+		if !re.Match(output) {
+			t.Errorf("%s not marked hot", name)
+		}
+	}
+	for _, name := range notHot {
+		re := regexp.MustCompile(`(?m)^` + regexp.QuoteMeta(name) + ` STEXT .*\bhot\b`)
+		if re.Match(output) {
+			t.Errorf("%s unexpectedly marked hot", name)
+		}
+	}
+
+	// The linker should have placed the hot functions next to each other.
+	cmd = testenv.CleanCmdEnv(exec.Command(testenv.GoToolPath(t), "tool", "nm", "-n", out))
+	nm, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("error running nm: %v\n%s", err, nm)
+	}
+	var text []string
+	for _, line := range strings.Split(string(nm), "\n") {
+		if f := strings.Fields(line); len(f) == 3 && (f[1] == "T" || f[1] == "t") {
+			text = append(text, f[2])
+		}
+	}
+	first := -1
+	for i, name := range text {
+		if name == hot[0] {
+			first = i
+			break
+		}
+	}
+	if first < 0 || first+len(hot) > len(text) {
+		t.Fatalf("%s not found in binary", hot[0])
+	}
+	for i, name := range hot {
+		if text[first+i] != name {
+			t.Errorf("got %s at text position %d, want hot function %s", text[first+i], first+i, name)
+		}
+	}
+
+	cmd = testenv.CleanCmdEnv(exec.Command(out, "-test.run=^$", "-test.bench=.", "-test.benchtime=100x"))
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("error running test binary: %v\n%s", err, output)
+	}
+}
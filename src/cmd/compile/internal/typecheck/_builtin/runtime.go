@@ -22,10 +22,12 @@ func panicmakeslicelen()
 func panicmakeslicecap()
 func throwinit()
 func panicwrap()
+func panicrangeexit()
 
 func gopanic(interface{})
 func gorecover(*int32) interface{}
 func goschedguarded()
+func deferrangefunc() interface{}
 
 // Note: these declarations are just for wasm port.
 // Other ports call assembly stubs instead.
@@ -238,6 +240,14 @@ func uint32tofloat64(uint32) float64
 
 func complex128div(num complex128, den complex128) (quo complex128)
 
+// min/max of floats and strings
+func fmin32(x, y float32) float32
+func fmin64(x, y float64) float64
+func fmax32(x, y float32) float32
+func fmax64(x, y float64) float64
+func strmin(x, y string) string
+func strmax(x, y string) string
+
 func getcallerpc() uintptr
 func getcallersp() uintptr
 
@@ -743,6 +743,7 @@ func callOrChan(n ir.Node) bool {
 		ir.OCALLINTER,
 		ir.OCALLMETH,
 		ir.OCAP,
+		ir.OCLEAR,
 		ir.OCLOSE,
 		ir.OCOMPLEX,
 		ir.OCOPY,
@@ -750,6 +751,8 @@ func callOrChan(n ir.Node) bool {
 		ir.OIMAG,
 		ir.OLEN,
 		ir.OMAKE,
+		ir.OMAX,
+		ir.OMIN,
 		ir.ONEW,
 		ir.OPANIC,
 		ir.OPRINT,
@@ -1489,8 +1489,8 @@ func (r *importReader) node() ir.Node {
 		n.SetImplicit(r.bool())
 		return n
 
-	case ir.OCOPY, ir.OCOMPLEX, ir.OREAL, ir.OIMAG, ir.OAPPEND, ir.OCAP, ir.OCLOSE, ir.ODELETE, ir.OLEN, ir.OMAKE,
-		ir.ONEW, ir.OPANIC, ir.ORECOVER, ir.OPRINT, ir.OPRINTN,
+	case ir.OCOPY, ir.OCOMPLEX, ir.OREAL, ir.OIMAG, ir.OAPPEND, ir.OCAP, ir.OCLEAR, ir.OCLOSE, ir.ODELETE, ir.OLEN, ir.OMAKE,
+		ir.OMAX, ir.OMIN, ir.ONEW, ir.OPANIC, ir.ORECOVER, ir.OPRINT, ir.OPRINTN,
 		ir.OUNSAFEADD, ir.OUNSAFESLICE, ir.OUNSAFESLICEDATA, ir.OUNSAFESTRING, ir.OUNSAFESTRINGDATA:
 		pos := r.pos()
 		switch op {
@@ -1500,20 +1500,20 @@ func (r *importReader) node() ir.Node {
 			n.SetInit(init)
 			n.SetType(r.typ())
 			return n
-		case ir.OREAL, ir.OIMAG, ir.OCAP, ir.OCLOSE, ir.OLEN, ir.ONEW, ir.OPANIC, ir.OUNSAFESTRINGDATA, ir.OUNSAFESLICEDATA:
+		case ir.OREAL, ir.OIMAG, ir.OCAP, ir.OCLEAR, ir.OCLOSE, ir.OLEN, ir.ONEW, ir.OPANIC, ir.OUNSAFESTRINGDATA, ir.OUNSAFESLICEDATA:
 			n := ir.NewUnaryExpr(pos, op, r.expr())
 			if op != ir.OPANIC {
 				n.SetType(r.typ())
 			}
 			return n
-		case ir.OAPPEND, ir.ODELETE, ir.ORECOVER, ir.OPRINT, ir.OPRINTN:
+		case ir.OAPPEND, ir.ODELETE, ir.OMAX, ir.OMIN, ir.ORECOVER, ir.OPRINT, ir.OPRINTN:
 			init := r.stmtList()
 			n := ir.NewCallExpr(pos, op, nil, r.exprList())
 			n.SetInit(init)
 			if op == ir.OAPPEND {
 				n.IsDDD = r.bool()
 			}
-			if op == ir.OAPPEND || op == ir.ORECOVER {
+			if op == ir.OAPPEND || op == ir.OMAX || op == ir.OMIN || op == ir.ORECOVER {
 				n.SetType(r.typ())
 			}
 			return n
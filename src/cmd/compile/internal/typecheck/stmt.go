@@ -273,6 +273,7 @@ func tcGoDefer(n *ir.GoDeferStmt) {
 	case ir.OCALLINTER,
 		ir.OCALLMETH,
 		ir.OCALLFUNC,
+		ir.OCLEAR,
 		ir.OCLOSE,
 		ir.OCOPY,
 		ir.ODELETE,
@@ -291,6 +292,8 @@ func tcGoDefer(n *ir.GoDeferStmt) {
 		ir.OMAKESLICE,
 		ir.OMAKECHAN,
 		ir.OMAKEMAP,
+		ir.OMAX,
+		ir.OMIN,
 		ir.ONEW,
 		ir.OREAL,
 		ir.OLITERAL: // conversion or unsafe.Alignof, Offsetof, Sizeof
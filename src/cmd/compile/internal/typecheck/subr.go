@@ -721,11 +721,20 @@ func ifacelookdot(s *types.Sym, t *types.Type, ignorecase bool) (m *types.Field,
 	return m, followptr
 }
 
+// Implements reports whether t implements the interface iface. t can be
+// an interface, a type parameter, or a concrete type. Unlike implements,
+// it never reports errors.
+func Implements(t, iface *types.Type) bool {
+	var missing *types.Field
+	var ptr int
+	return implements(t, iface, &missing, nil, &ptr)
+}
+
 // implements reports whether t implements the interface iface. t can be
 // an interface, a type parameter, or a concrete type. If implements returns
 // false, it stores a method of iface that is not implemented in *m. If the
 // method name matches but the type is wrong, it additionally stores the type
-// of the method (on t) in *samename.
+// of the method (on t) in *samename, unless samename is nil.
 func implements(t, iface *types.Type, m, samename **types.Field, ptr *int) bool {
 	t0 := t
 	if t == nil {
@@ -753,14 +762,14 @@ func implements(t, iface *types.Type, m, samename **types.Field, ptr *int) bool
 			}
 			if i == len(tms) {
 				*m = im
-				*samename = nil
+				setSamename(samename, nil)
 				*ptr = 0
 				return false
 			}
 			tm := tms[i]
 			if !types.Identical(tm.Type, im.Type) {
 				*m = im
-				*samename = tm
+				setSamename(samename, tm)
 				*ptr = 0
 				return false
 			}
@@ -782,14 +791,16 @@ func implements(t, iface *types.Type, m, samename **types.Field, ptr *int) bool
 		}
 		if i == len(tms) {
 			*m = im
-			*samename, _ = ifacelookdot(im.Sym, t, true)
+			if samename != nil {
+				*samename, _ = ifacelookdot(im.Sym, t, true)
+			}
 			*ptr = 0
 			return false
 		}
 		tm := tms[i]
 		if tm.Nointerface() || !types.Identical(tm.Type, im.Type) {
 			*m = im
-			*samename = tm
+			setSamename(samename, tm)
 			*ptr = 0
 			return false
 		}
@@ -804,7 +815,7 @@ func implements(t, iface *types.Type, m, samename **types.Field, ptr *int) bool
 			}
 
 			*m = im
-			*samename = nil
+			setSamename(samename, nil)
 			*ptr = 1
 			return false
 		}
@@ -813,6 +824,13 @@ func implements(t, iface *types.Type, m, samename **types.Field, ptr *int) bool
 	return true
 }
 
+// setSamename stores f in *samename if samename is non-nil.
+func setSamename(samename **types.Field, f *types.Field) {
+	if samename != nil {
+		*samename = f
+	}
+}
+
 func isptrto(t *types.Type, et types.Kind) bool {
 	if t == nil {
 		return false
@@ -33,6 +33,20 @@ func AllowsGoVersion(major, minor int) bool {
 	return langWant.major > major || (langWant.major == major && langWant.minor >= minor)
 }
 
+// FileAllowsGoVersion is like AllowsGoVersion, but for code in a file
+// whose go version, set by its //go:build constraint, is fileVersion.
+// If fileVersion is empty, the -lang flag applies.
+func FileAllowsGoVersion(fileVersion string, major, minor int) bool {
+	if fileVersion == "" {
+		return AllowsGoVersion(major, minor)
+	}
+	want, err := parseLang(fileVersion)
+	if err != nil {
+		base.Fatalf("invalid file go version %q: %v", fileVersion, err)
+	}
+	return want.major > major || (want.major == major && want.minor >= minor)
+}
+
 // ParseLangFlag verifies that the -lang flag holds a valid value, and
 // exits if not. It initializes data used by langSupported.
 func ParseLangFlag() {
@@ -0,0 +1,122 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types2
+
+import "cmd/compile/internal/syntax"
+
+// An Alias represents an alias type.
+//
+// Alias types are created by alias declarations such as:
+//
+//	type A = int
+//
+// The type on the right-hand side of the declaration can be accessed
+// using [Alias.Rhs]. This type may itself be an alias.
+// Call [Unalias] to obtain the first non-alias type in a chain of
+// alias type declarations.
+//
+// Like a defined ([Named]) type, an alias type has a name.
+// Use the [Alias.Obj] method to access its [TypeName] object.
+//
+// An alias type may have type parameters:
+//
+//	type Set[T comparable] = map[T]struct{}
+//
+// Instantiating a generic alias type yields an alias type
+// whose [Alias.TypeArgs] are set.
+//
+// Alias types are only created if Config.EnableAlias is set.
+// Otherwise, the type name of an alias declaration denotes
+// the aliased type directly.
+type Alias struct {
+	obj     *TypeName      // corresponding declared alias object
+	orig    *Alias         // original, uninstantiated alias
+	tparams *TypeParamList // type parameters, or nil
+	targs   *TypeList      // type arguments, or nil
+	fromRHS Type           // RHS of type alias declaration; may be an alias
+	actual  Type           // actual (aliased) type; never an alias
+}
+
+// NewAlias creates a new Alias type with the given type name and rhs.
+// rhs must not be nil.
+func NewAlias(obj *TypeName, rhs Type) *Alias {
+	return (*Checker)(nil).newAlias(obj, rhs)
+}
+
+// Obj returns the type name for the declaration defining the alias type a.
+// For instantiated types, this is same as the type name of the origin type.
+func (a *Alias) Obj() *TypeName { return a.orig.obj }
+
+func (a *Alias) Underlying() Type { return a.actual.Underlying() }
+func (a *Alias) String() string   { return TypeString(a, nil) }
+
+// Rhs returns the type R on the right-hand side of an alias
+// declaration "type A = R", which may be another alias.
+func (a *Alias) Rhs() Type { return a.fromRHS }
+
+// Origin returns the generic Alias type of which a is an instance.
+// If a is not an instance of a generic alias, Origin returns a.
+func (a *Alias) Origin() *Alias { return a.orig }
+
+// TypeParams returns the type parameters of the alias type a, or nil.
+// A generic Alias and its instances have the same type parameters.
+func (a *Alias) TypeParams() *TypeParamList { return a.tparams }
+
+// SetTypeParams sets the type parameters of the alias type a.
+// The alias a must not have type arguments.
+func (a *Alias) SetTypeParams(tparams []*TypeParam) {
+	assert(a.targs == nil)
+	a.tparams = bindTParams(tparams)
+}
+
+// TypeArgs returns the type arguments used to instantiate the Alias type.
+// If a is not an instance of a generic alias, the result is nil.
+func (a *Alias) TypeArgs() *TypeList { return a.targs }
+
+// Unalias returns t if it is not an alias type;
+// otherwise it follows t's alias chain until it
+// reaches a non-alias type which is then returned.
+// Consequently, the result is never an alias type.
+func Unalias(t Type) Type {
+	if a0, _ := t.(*Alias); a0 != nil {
+		return a0.actual
+	}
+	return t
+}
+
+// asNamed returns t as *Named if that is t's
+// actual type. It returns nil otherwise.
+func asNamed(t Type) *Named {
+	n, _ := Unalias(t).(*Named)
+	return n
+}
+
+// newAlias creates a new Alias type with the given type name and rhs.
+// rhs must not be nil. Since the right-hand side of an alias declaration
+// is fully type-checked before the alias is created, the actual type
+// can be determined right away.
+func (check *Checker) newAlias(obj *TypeName, rhs Type) *Alias {
+	assert(rhs != nil)
+	a := &Alias{obj: obj, fromRHS: rhs, actual: Unalias(rhs)}
+	a.orig = a
+	if obj.typ == nil {
+		obj.typ = a
+	}
+	return a
+}
+
+// newAliasInstance creates a new alias instance for the given origin and type
+// arguments, recording pos as the position of its synthetic object (for error
+// reporting).
+func (check *Checker) newAliasInstance(pos syntax.Pos, orig *Alias, targs []Type, expanding *Named, ctxt *Context) *Alias {
+	assert(len(targs) > 0)
+	obj := NewTypeName(pos, orig.obj.pkg, orig.obj.name, nil)
+	rhs := check.subst(pos, orig.fromRHS, makeSubstMap(orig.TypeParams().list(), targs), expanding, ctxt)
+	res := check.newAlias(obj, rhs)
+	res.orig = orig
+	res.tparams = orig.tparams
+	res.targs = newTypeList(targs)
+	return res
+}
@@ -171,6 +171,12 @@ type Config struct {
 	// If AltComparableSemantics is set, ordinary (non-type parameter)
 	// interfaces satisfy the comparable constraint.
 	AltComparableSemantics bool
+
+	// If EnableAlias is set, alias declarations produce an Alias type.
+	// Otherwise the alias information is only in the type name,
+	// which points directly to the actual (aliased) type.
+	// Generic alias declarations require EnableAlias.
+	EnableAlias bool
 }
 
 func srcimporter_setUsesCgo(conf *Config) {
@@ -443,7 +449,7 @@ func (conf *Config) Check(path string, files []*syntax.File, info *Info) (*Packa
 func AssertableTo(V *Interface, T Type) bool {
 	// Checker.newAssertableTo suppresses errors for invalid types, so we need special
 	// handling here.
-	if T.Underlying() == Typ[Invalid] {
+	if !isValid(T.Underlying()) {
 		return false
 	}
 	return (*Checker)(nil).newAssertableTo(V, T)
@@ -481,7 +487,7 @@ func Implements(V Type, T *Interface) bool {
 	}
 	// Checker.implements suppresses errors for invalid types, so we need special
 	// handling here.
-	if V.Underlying() == Typ[Invalid] {
+	if !isValid(V.Underlying()) {
 		return false
 	}
 	return (*Checker)(nil).implements(V, T, false, nil)
@@ -131,9 +131,11 @@ func testFiles(t *testing.T, filenames []string, colDelta uint, manual bool) {
 	flags.StringVar(&conf.GoVersion, "lang", "", "")
 	flags.BoolVar(&conf.FakeImportC, "fakeImportC", false, "")
 	flags.BoolVar(&conf.AltComparableSemantics, "altComparableSemantics", false, "")
+	gotypesalias := flags.String("gotypesalias", "", "")
 	if err := parseFlags(filenames[0], nil, flags); err != nil {
 		t.Fatal(err)
 	}
+	conf.EnableAlias = *gotypesalias == "1"
 
 	files, errlist := parseFiles(t, filenames, 0)
 
@@ -391,7 +391,7 @@ func (check *Checker) constDecl(obj *Const, typ, init syntax.Expr, inherited boo
 		if !isConstType(t) {
 			// don't report an error if the type is an invalid C (defined) type
 			// (issue #22090)
-			if under(t) != Typ[Invalid] {
+			if isValid(under(t)) {
 				check.errorf(typ, InvalidConstType, "invalid constant type %s", t)
 			}
 			obj.typ = Typ[Invalid]
@@ -424,11 +424,11 @@ func (check *Checker) varDecl(obj *Var, lhs []*Var, typ, init syntax.Expr) {
 	// mark variables as used to avoid follow-on errors.
 	// Matches compiler behavior.
 	defer func() {
-		if obj.typ == Typ[Invalid] {
+		if !isValid(obj.typ) {
 			obj.used = true
 		}
 		for _, lhs := range lhs {
-			if lhs.typ == Typ[Invalid] {
+			if !isValid(lhs.typ) {
 				lhs.used = true
 			}
 		}
@@ -493,7 +493,7 @@ func (check *Checker) varDecl(obj *Var, lhs []*Var, typ, init syntax.Expr) {
 
 // isImportedConstraint reports whether typ is an imported type constraint.
 func (check *Checker) isImportedConstraint(typ Type) bool {
-	named, _ := typ.(*Named)
+	named := asNamed(typ)
 	if named == nil || named.obj.pkg == check.pkg || named.obj.pkg == nil {
 		return false
 	}
@@ -517,10 +517,14 @@ func (check *Checker) typeDecl(obj *TypeName, tdecl *syntax.TypeDecl, def *Named
 
 	alias := tdecl.Alias
 	if alias && tdecl.TParamList != nil {
-		// The parser will ensure this but we may still get an invalid AST.
-		// Complain and continue as regular type definition.
-		check.error(tdecl, BadDecl, "generic type cannot be alias")
-		alias = false
+		if !check.conf.EnableAlias {
+			// Without Alias types, generic aliases cannot be represented.
+			// Complain and continue as regular type definition.
+			check.error(tdecl, UnsupportedFeature, "generic type alias requires GODEBUG=gotypesalias=1")
+			alias = false
+		} else if !check.allowVersion(check.pkg, 1, 20) {
+			check.versionErrorf(tdecl, "go1.20", "generic type alias")
+		}
 	}
 
 	// alias declaration
@@ -529,9 +533,35 @@ func (check *Checker) typeDecl(obj *TypeName, tdecl *syntax.TypeDecl, def *Named
 			check.versionErrorf(tdecl, "go1.9", "type aliases")
 		}
 
+		// The alias is broken (and references to it are reported as
+		// invalid) until its right-hand side has been type-checked.
 		check.brokenAlias(obj)
+
+		if !check.conf.EnableAlias {
+			rhs = check.typ(tdecl.Type)
+			check.validAlias(obj, rhs)
+			return
+		}
+
+		var tparams *TypeParamList
+		if tdecl.TParamList != nil {
+			check.openScope(tdecl, "type parameters")
+			defer check.closeScope()
+			check.collectTypeParams(&tparams, tdecl.TParamList)
+		}
+
 		rhs = check.typ(tdecl.Type)
-		check.validAlias(obj, rhs)
+
+		// spec: "In an alias declaration the given type cannot be a type
+		// parameter declared in the same declaration."
+		if isTypeParam(rhs) {
+			check.error(tdecl.Type, MisplacedTypeParam, "cannot use type parameter declared in alias declaration as RHS")
+			rhs = Typ[Invalid]
+		}
+
+		a := check.newAlias(obj, rhs)
+		a.tparams = tparams
+		check.validAlias(obj, a)
 		return
 	}
 
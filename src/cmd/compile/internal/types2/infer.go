@@ -256,7 +256,7 @@ func (check *Checker) infer(pos syntax.Pos, tparams []*TypeParam, targs []Type,
 					errorf("type", par.typ, targ, arg)
 					return nil
 				}
-			} else if _, ok := par.typ.(*TypeParam); ok {
+			} else if isTypeParam(par.typ) {
 				// Since default types are all basic (i.e., non-composite) types, an
 				// untyped argument will never match a composite parameter type; the
 				// only parameter type it can possibly match against is a *TypeParam.
@@ -290,7 +290,7 @@ func (check *Checker) infer(pos syntax.Pos, tparams []*TypeParam, targs []Type,
 	// Some generic parameters with untyped arguments may have been given
 	// a type by now, we can ignore them.
 	for _, i := range indices {
-		tpar := params.At(i).typ.(*TypeParam) // is type parameter by construction of indices
+		tpar := Unalias(params.At(i).typ).(*TypeParam) // is type parameter by construction of indices
 		// Only consider untyped arguments for which the corresponding type
 		// parameter doesn't have an inferred type yet.
 		if targs[tpar.index] == nil {
@@ -383,6 +383,9 @@ func (w *tpWalker) isParameterized(typ Type) (res bool) {
 	case nil, *Basic: // TODO(gri) should nil be handled here?
 		break
 
+	case *Alias:
+		return w.isParameterized(Unalias(t))
+
 	case *Array:
 		return w.isParameterized(t.elem)
 
@@ -722,6 +725,9 @@ func (w *cycleFinder) typ(typ Type) {
 	case *Basic:
 		// nothing to do
 
+	case *Alias:
+		w.typ(Unalias(t))
+
 	case *Array:
 		w.typ(t.elem)
 
@@ -212,6 +212,9 @@ func (w *monoGraph) assign(pkg *Package, pos syntax.Pos, tpar *TypeParam, targ T
 		default:
 			panic("unexpected type")
 
+		case *Alias:
+			do(Unalias(typ))
+
 		case *TypeParam:
 			assert(typ.Obj().Pkg() == pkg)
 			flow(w.typeParamVertex(typ), typ)
@@ -453,7 +453,7 @@ func (t *Named) AddMethod(m *Func) {
 	}
 }
 
-func (t *Named) Underlying() Type { return t.resolve().underlying }
+func (t *Named) Underlying() Type { return Unalias(t.resolve().underlying) }
 func (t *Named) String() string   { return TypeString(t, nil) }
 
 // ----------------------------------------------------------------------------
@@ -552,7 +552,7 @@ loop:
 
 func (n *Named) setUnderlying(typ Type) {
 	if n != nil {
-		n.underlying = typ
+		n.underlying = Unalias(typ)
 	}
 }
 
@@ -272,6 +272,19 @@ func (w *typeWriter) typ(typ Type) {
 			w.byte(')')
 		}
 
+	case *Alias:
+		// An alias is identical to its actual type. If hashing,
+		// write the actual type so that identical types hash alike.
+		if w.ctxt != nil {
+			w.typ(Unalias(t))
+			break
+		}
+		w.typeName(t.obj)
+		if list := t.targs.list(); len(list) != 0 {
+			// instantiated type
+			w.typeList(list)
+		}
+
 	case *Named:
 		// If hashing, write a unique prefix for t to represent its identity, since
 		// named type identity is pointer identity.
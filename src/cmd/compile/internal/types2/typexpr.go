@@ -79,7 +79,7 @@ func (check *Checker) ident(x *operand, e *syntax.Name, def *Named, wantType boo
 
 	case *Const:
 		check.addDeclDep(obj)
-		if typ == Typ[Invalid] {
+		if !isValid(typ) {
 			return
 		}
 		if obj == universeIota {
@@ -109,7 +109,7 @@ func (check *Checker) ident(x *operand, e *syntax.Name, def *Named, wantType boo
 			obj.used = true
 		}
 		check.addDeclDep(obj)
-		if typ == Typ[Invalid] {
+		if !isValid(typ) {
 			return
 		}
 		x.mode = variable
@@ -194,7 +194,7 @@ func (check *Checker) definedType(e syntax.Expr, def *Named) Type {
 func (check *Checker) genericType(e syntax.Expr, cause *string) Type {
 	typ := check.typInternal(e, nil)
 	assert(isTyped(typ))
-	if typ != Typ[Invalid] && !isGeneric(typ) {
+	if isValid(typ) && !isGeneric(typ) {
 		if cause != nil {
 			*cause = check.sprintf("%s is not a generic type", typ)
 		}
@@ -326,7 +326,7 @@ func (check *Checker) typInternal(e0 syntax.Expr, def *Named) (T Type) {
 			// useful - even a valid dereferenciation will lead to an invalid
 			// type again, and in some cases we get unexpected follow-on errors
 			// (e.g., see #49005). Return an invalid type instead.
-			if typ.base == Typ[Invalid] {
+			if !isValid(typ.base) {
 				return Typ[Invalid]
 			}
 			return typ
@@ -419,15 +419,10 @@ func (check *Checker) instantiatedType(x syntax.Expr, xlist []syntax.Expr, def *
 	if cause != "" {
 		check.errorf(x, NotAGenericType, invalidOp+"%s%s (%s)", x, xlist, cause)
 	}
-	if gtyp == Typ[Invalid] {
+	if !isValid(gtyp) {
 		return gtyp // error already reported
 	}
 
-	orig, _ := gtyp.(*Named)
-	if orig == nil {
-		panic(fmt.Sprintf("%v: cannot instantiate %v", x.Pos(), gtyp))
-	}
-
 	// evaluate arguments
 	targs := check.typeList(xlist)
 	if targs == nil {
@@ -435,6 +430,15 @@ func (check *Checker) instantiatedType(x syntax.Expr, xlist []syntax.Expr, def *
 		return Typ[Invalid]
 	}
 
+	if orig, _ := gtyp.(*Alias); orig != nil && orig.TypeParams().Len() > 0 {
+		return check.aliasInstance(x, xlist, orig, targs, def)
+	}
+
+	orig := asNamed(gtyp)
+	if orig == nil {
+		panic(fmt.Sprintf("%v: cannot instantiate %v", x.Pos(), gtyp))
+	}
+
 	// create the instance
 	inst := check.instance(x.Pos(), orig, targs, nil, check.context()).(*Named)
 	def.setUnderlying(inst)
@@ -468,6 +472,38 @@ func (check *Checker) instantiatedType(x syntax.Expr, xlist []syntax.Expr, def *
 	return inst
 }
 
+// aliasInstance instantiates the generic alias type orig with the type
+// arguments targs, given by the type expressions xlist.
+func (check *Checker) aliasInstance(x syntax.Expr, xlist []syntax.Expr, orig *Alias, targs []Type, def *Named) Type {
+	// Unlike the type parameters of a Named type, the type parameters
+	// of an alias are set up before the alias can be referenced, so
+	// the type arguments can be validated right away.
+	tparams := orig.TypeParams().list()
+	if !check.validateTArgLen(x.Pos(), len(tparams), len(targs)) {
+		def.setUnderlying(Typ[Invalid])
+		return Typ[Invalid]
+	}
+
+	inst := check.instance(x.Pos(), orig, targs, nil, check.context())
+	def.setUnderlying(inst)
+
+	check.later(func() {
+		check.recordInstance(x, targs, inst)
+		if i, err := check.verify(x.Pos(), tparams, targs, check.context()); err != nil {
+			// best position for error reporting
+			pos := x.Pos()
+			if i < len(xlist) {
+				pos = syntax.StartPos(xlist[i])
+			}
+			check.softErrorf(pos, InvalidTypeArg, "%s", err)
+		} else {
+			check.mono.recordInstance(check.pkg, x.Pos(), tparams, targs, xlist)
+		}
+	}).describef(x, "verify instance %s", inst)
+
+	return inst
+}
+
 // arrayLength type-checks the array length expression e
 // and returns the constant length >= 0, or a value < 0
 // to indicate an error (and thus an unknown length).
@@ -519,7 +555,7 @@ func (check *Checker) typeList(list []syntax.Expr) []Type {
 	res := make([]Type, len(list)) // res != nil even if len(list) == 0
 	for i, x := range list {
 		t := check.varType(x)
-		if t == Typ[Invalid] {
+		if !isValid(t) {
 			res = nil
 		}
 		if res != nil {
@@ -186,7 +186,7 @@ func (u *unifier) join(i, j int) bool {
 // If typ is a type parameter of d, index returns the type parameter index.
 // Otherwise, the result is < 0.
 func (d *tparamsList) index(typ Type) int {
-	if tpar, ok := typ.(*TypeParam); ok {
+	if tpar, ok := Unalias(typ).(*TypeParam); ok {
 		return tparamIndex(d.tparams, tpar)
 	}
 	return -1
@@ -285,6 +285,9 @@ func (u *unifier) nifyEq(x, y Type, p *ifacePair) bool {
 // code the corresponding changes should be made here.
 // Must not be called directly from outside the unifier.
 func (u *unifier) nify(x, y Type, p *ifacePair) (result bool) {
+	x = Unalias(x)
+	y = Unalias(y)
+
 	if traceInference {
 		u.tracef("%s ≡ %s", x, y)
 	}
@@ -747,6 +747,15 @@ func (o *orderState) stmt(n ir.Node) {
 		o.out = append(o.out, n)
 		o.popTemp(t)
 
+	case ir.OCLEAR:
+		// The argument is referenced more than once when
+		// clearing a slice, so make sure it is cheap.
+		n := n.(*ir.UnaryExpr)
+		t := o.markTemp()
+		n.X = o.cheapExpr(o.expr(n.X, nil))
+		o.out = append(o.out, n)
+		o.popTemp(t)
+
 	case ir.OCOPY:
 		n := n.(*ir.BinaryExpr)
 		t := o.markTemp()
@@ -768,6 +777,9 @@ func (o *orderState) stmt(n ir.Node) {
 		t := o.markTemp()
 		o.init(n.Call)
 		o.call(n.Call)
+		if n.DeferAt != nil {
+			n.DeferAt = o.cheapExpr(o.expr(n.DeferAt, nil)).(ir.Expr)
+		}
 		o.out = append(o.out, n)
 		o.popTemp(t)
 
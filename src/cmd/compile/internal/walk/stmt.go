@@ -39,6 +39,7 @@ func walkStmt(n ir.Node) ir.Node {
 		ir.OAS2RECV,
 		ir.OAS2FUNC,
 		ir.OAS2MAPR,
+		ir.OCLEAR,
 		ir.OCLOSE,
 		ir.OCOPY,
 		ir.OCALLINTER,
@@ -210,6 +211,9 @@ func walkGoDefer(n *ir.GoDeferStmt) ir.Node {
 
 	call := n.Call.(*ir.CallExpr)
 	call.X = walkExpr(call.X, &init)
+	if n.DeferAt != nil {
+		n.DeferAt = walkExpr(n.DeferAt, &init).(ir.Expr)
+	}
 
 	if len(init) > 0 {
 		init.Append(n)
@@ -29,6 +29,7 @@ const (
 	FuncID_asmcgocall
 	FuncID_asyncPreempt
 	FuncID_cgocallback
+	FuncID_corostart
 	FuncID_debugCallV2
 	FuncID_gcBgMarkWorker
 	FuncID_goexit
@@ -53,6 +54,7 @@ var funcIDs = map[string]FuncID{
 	"asmcgocall":         FuncID_asmcgocall,
 	"asyncPreempt":       FuncID_asyncPreempt,
 	"cgocallback":        FuncID_cgocallback,
+	"corostart":          FuncID_corostart,
 	"debugCallV2":        FuncID_debugCallV2,
 	"gcBgMarkWorker":     FuncID_gcBgMarkWorker,
 	"rt0_go":             FuncID_rt0_go,
@@ -1182,6 +1182,16 @@ func (l *Loader) IsDict(i Sym) bool {
 	return r.Sym(li).IsDict()
 }
 
+// IsHot reports whether this is a text symbol that profile-guided
+// optimization identified as hot.
+func (l *Loader) IsHot(i Sym) bool {
+	if l.IsExternal(i) {
+		return false
+	}
+	r, li := l.toLocal(i)
+	return r.Sym(li).Hot()
+}
+
 // Return whether this is a trampoline of a deferreturn call.
 func (l *Loader) IsDeferReturnTramp(i Sym) bool {
 	return l.deferReturnTramp[i]
@@ -2480,15 +2490,26 @@ func (l *Loader) AssignTextSymbolOrder(libs []*sym.Library, intlibs []bool, exts
 	}
 
 	// Now assemble global textp, and assign text symbols to units.
-	for _, doInternal := range [2]bool{true, false} {
+	// Functions marked hot by profile-guided optimization are laid
+	// out together, ahead of the other functions of non-runtime
+	// packages, so that they share cache lines and pages.
+	passes := [...]struct{ doInternal, hotOnly bool }{
+		{doInternal: true},
+		{doInternal: false, hotOnly: true},
+		{doInternal: false},
+	}
+	for _, pass := range passes {
 		for idx, lib := range libs {
-			if intlibs[idx] != doInternal {
+			if intlibs[idx] != pass.doInternal {
 				continue
 			}
 			lists := [2][]sym.LoaderSym{lib.Textp, lib.DupTextSyms}
 			for i, list := range lists {
 				for _, s := range list {
 					sym := Sym(s)
+					if pass.hotOnly && !l.IsHot(sym) {
+						continue
+					}
 					if !assignedToUnit.Has(sym) {
 						textp = append(textp, sym)
 						unit := l.SymUnit(sym)
@@ -2507,8 +2528,10 @@ func (l *Loader) AssignTextSymbolOrder(libs []*sym.Library, intlibs []bool, exts
 					}
 				}
 			}
-			lib.Textp = nil
-			lib.DupTextSyms = nil
+			if !pass.hotOnly {
+				lib.Textp = nil
+				lib.DupTextSyms = nil
+			}
 		}
 	}
 
@@ -10,6 +10,7 @@ import (
 	"debug/macho"
 	"internal/buildcfg"
 	"internal/platform"
+	"internal/profile"
 	"internal/testenv"
 	"os"
 	"os/exec"
@@ -1151,3 +1152,106 @@ func TestUnlinkableObj(t *testing.T) {
 		t.Errorf("link failed: %v. output:\n%s", err, out)
 	}
 }
+
+const testHotTextSrc = `
+package main
+
+//go:noinline
+func cold1() int { return 1 }
+
+//go:noinline
+func hot1(x int) int { return x * 3 }
+
+//go:noinline
+func cold2() int { return 2 }
+
+//go:noinline
+func hot2(x int) int { return x + 5 }
+
+//go:noinline
+func cold3() int { return 3 }
+
+func main() {
+	println(cold1() + hot1(1) + cold2() + hot2(2) + cold3())
+}
+`
+
+// TestHotText checks that functions marked hot by profile-guided
+// optimization are laid out next to each other.
+func TestHotText(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+	t.Parallel()
+
+	tmpdir := t.TempDir()
+	src := filepath.Join(tmpdir, "main.go")
+	if err := os.WriteFile(src, []byte(testHotTextSrc), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	// Write a CPU profile in which all the time is spent in hot1
+	// and hot2.
+	hot := []string{"main.hot1", "main.hot2"}
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{
+			{Type: "samples", Unit: "count"},
+			{Type: "cpu", Unit: "nanoseconds"},
+		},
+		PeriodType: &profile.ValueType{Type: "cpu", Unit: "nanoseconds"},
+		Period:     1,
+	}
+	for i, name := range hot {
+		line := int64(strings.Index(testHotTextSrc, "func "+strings.TrimPrefix(name, "main.")+"("))
+		line = int64(strings.Count(testHotTextSrc[:line], "\n")) + 1
+		fn := &profile.Function{ID: uint64(i + 1), Name: name, SystemName: name, Filename: src, StartLine: line}
+		loc := &profile.Location{ID: uint64(i + 1), Line: []profile.Line{{Function: fn, Line: line}}}
+		p.Function = append(p.Function, fn)
+		p.Location = append(p.Location, loc)
+		p.Sample = append(p.Sample, &profile.Sample{Location: []*profile.Location{loc}, Value: []int64{100, 100e7}})
+	}
+	pprof := filepath.Join(tmpdir, "default.pgo")
+	f, err := os.Create(pprof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Write(f); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	exe := filepath.Join(tmpdir, "main.exe")
+	cmd := exec.Command(testenv.GoToolPath(t), "build", "-gcflags=-pgoprofile="+pprof, "-o", exe, src)
+	cmd.Dir = tmpdir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("build failed: %v\n%s", err, out)
+	}
+
+	cmd = exec.Command(testenv.GoToolPath(t), "tool", "nm", "-n", exe)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("nm failed: %v\n%s", err, out)
+	}
+	pos := make(map[string]int)
+	n := 0
+	for _, line := range strings.Split(string(out), "\n") {
+		f := strings.Fields(line)
+		if len(f) == 3 && (f[1] == "T" || f[1] == "t") {
+			pos[f[2]] = n
+			n++
+		}
+	}
+	for _, name := range append([]string{"main.cold1", "main.cold2", "main.cold3"}, hot...) {
+		if _, ok := pos[name]; !ok {
+			t.Fatalf("%s not found in binary:\n%s", name, out)
+		}
+	}
+	if pos["main.hot2"] != pos["main.hot1"]+1 {
+		t.Errorf("hot functions not adjacent: main.hot1 at %d, main.hot2 at %d", pos["main.hot1"], pos["main.hot2"])
+	}
+	for _, name := range []string{"main.cold1", "main.cold2", "main.cold3"} {
+		if pos[name] < pos["main.hot1"] {
+			t.Errorf("%s at %d placed before hot function main.hot1 at %d", name, pos[name], pos["main.hot1"])
+		}
+	}
+}
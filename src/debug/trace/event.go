@@ -0,0 +1,452 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trace
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Time is a timestamp in nanoseconds since the start of the trace.
+// Only differences between timestamps are meaningful.
+type Time int64
+
+// Sub returns the duration t - t0.
+func (t Time) Sub(t0 Time) time.Duration {
+	return time.Duration(t - t0)
+}
+
+// GoID is the runtime-internal ID of a goroutine.
+type GoID int64
+
+// NoGoroutine indicates that an event is not associated with a goroutine.
+const NoGoroutine GoID = -1
+
+// ProcID is the runtime-internal ID of a P, a resource that is needed
+// to execute Go code. See the documentation of GOMAXPROCS in package
+// runtime.
+type ProcID int64
+
+// NoProc indicates that an event is not associated with a P.
+const NoProc ProcID = -1
+
+// TaskID is the ID of a task, as created by runtime/trace.NewTask.
+type TaskID uint64
+
+// BackgroundTask is the ID of the task that all other tasks belong
+// to, and that regions and logs outside of any task are attributed to.
+const BackgroundTask TaskID = 0
+
+// EventKind indicates what an Event describes.
+type EventKind uint16
+
+const (
+	EventBad EventKind = iota
+
+	// EventSync marks the start of a part of the trace that can be
+	// understood without the parts before it. The first event in any
+	// trace is an EventSync.
+	EventSync
+
+	// EventMetric is an event that reports the value of a metric
+	// at a point in time.
+	EventMetric
+
+	// EventStackSample is a sample of a goroutine's stack taken by
+	// the CPU profiler.
+	EventStackSample
+
+	// EventRangeBegin and EventRangeEnd delimit a period of time
+	// during which some runtime activity, such as a garbage
+	// collection phase, takes place.
+	EventRangeBegin
+	EventRangeEnd
+
+	// EventTaskBegin and EventTaskEnd mark the start and end of a
+	// user task (runtime/trace.NewTask and Task.End).
+	EventTaskBegin
+	EventTaskEnd
+
+	// EventRegionBegin and EventRegionEnd mark the start and end of
+	// a user region (runtime/trace.StartRegion and Region.End).
+	EventRegionBegin
+	EventRegionEnd
+
+	// EventLog is a user log message (runtime/trace.Log).
+	EventLog
+
+	// EventStateTransition reports a change in the state of a
+	// goroutine or a P.
+	EventStateTransition
+)
+
+var eventKindStrings = [...]string{
+	EventBad:             "Bad",
+	EventSync:            "Sync",
+	EventMetric:          "Metric",
+	EventStackSample:     "StackSample",
+	EventRangeBegin:      "RangeBegin",
+	EventRangeEnd:        "RangeEnd",
+	EventTaskBegin:       "TaskBegin",
+	EventTaskEnd:         "TaskEnd",
+	EventRegionBegin:     "RegionBegin",
+	EventRegionEnd:       "RegionEnd",
+	EventLog:             "Log",
+	EventStateTransition: "StateTransition",
+}
+
+func (k EventKind) String() string {
+	if int(k) < len(eventKindStrings) {
+		return eventKindStrings[k]
+	}
+	return "Bad"
+}
+
+// Event is a single event in an execution trace.
+type Event struct {
+	kind  EventKind
+	time  Time
+	g     GoID
+	p     ProcID
+	stack Stack
+	data  any // kind-specific payload
+}
+
+// Kind returns the kind of the event.
+func (e Event) Kind() EventKind {
+	return e.kind
+}
+
+// Time returns the time at which the event happened.
+func (e Event) Time() Time {
+	return e.time
+}
+
+// Goroutine returns the goroutine that was running when the event
+// happened, or NoGoroutine if there was none.
+func (e Event) Goroutine() GoID {
+	return e.g
+}
+
+// Proc returns the P on which the event happened, or NoProc if it did
+// not happen on a P.
+func (e Event) Proc() ProcID {
+	return e.p
+}
+
+// Stack returns the stack of the goroutine at the time of the event,
+// or NoStack if none was recorded.
+func (e Event) Stack() Stack {
+	return e.stack
+}
+
+// Metric returns details about an EventMetric event.
+// It panics if the event is of a different kind.
+func (e Event) Metric() Metric {
+	e.mustBe("Metric", EventMetric)
+	return e.data.(Metric)
+}
+
+// Range returns details about an EventRangeBegin or EventRangeEnd
+// event. It panics if the event is of a different kind.
+func (e Event) Range() Range {
+	e.mustBe("Range", EventRangeBegin, EventRangeEnd)
+	return e.data.(Range)
+}
+
+// Task returns details about an EventTaskBegin or EventTaskEnd event.
+// It panics if the event is of a different kind.
+func (e Event) Task() Task {
+	e.mustBe("Task", EventTaskBegin, EventTaskEnd)
+	return e.data.(Task)
+}
+
+// Region returns details about an EventRegionBegin or EventRegionEnd
+// event. It panics if the event is of a different kind.
+func (e Event) Region() Region {
+	e.mustBe("Region", EventRegionBegin, EventRegionEnd)
+	return e.data.(Region)
+}
+
+// Log returns details about an EventLog event.
+// It panics if the event is of a different kind.
+func (e Event) Log() Log {
+	e.mustBe("Log", EventLog)
+	return e.data.(Log)
+}
+
+// StateTransition returns details about an EventStateTransition event.
+// It panics if the event is of a different kind.
+func (e Event) StateTransition() StateTransition {
+	e.mustBe("StateTransition", EventStateTransition)
+	return e.data.(StateTransition)
+}
+
+func (e Event) mustBe(method string, kinds ...EventKind) {
+	for _, k := range kinds {
+		if e.kind == k {
+			return
+		}
+	}
+	panic(fmt.Sprintf("trace: %s called on event of kind %v", method, e.kind))
+}
+
+// String returns a description of the event for debugging.
+func (e Event) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "P=%v G=%v %v Time=%d", e.p, e.g, e.kind, e.time)
+	switch e.kind {
+	case EventMetric:
+		m := e.Metric()
+		fmt.Fprintf(&b, " Name=%q Value=%d", m.Name, m.Value)
+	case EventRangeBegin, EventRangeEnd:
+		r := e.Range()
+		fmt.Fprintf(&b, " Name=%q Scope=%v", r.Name, r.Scope)
+	case EventTaskBegin, EventTaskEnd:
+		t := e.Task()
+		fmt.Fprintf(&b, " ID=%d Parent=%d Type=%q", t.ID, t.Parent, t.Type)
+	case EventRegionBegin, EventRegionEnd:
+		r := e.Region()
+		fmt.Fprintf(&b, " Task=%d Type=%q", r.Task, r.Type)
+	case EventLog:
+		l := e.Log()
+		fmt.Fprintf(&b, " Task=%d Category=%q Message=%q", l.Task, l.Category, l.Message)
+	case EventStateTransition:
+		s := e.StateTransition()
+		fmt.Fprintf(&b, " Resource=%v", s.Resource)
+		switch s.Resource.Kind {
+		case ResourceGoroutine:
+			from, to := s.Goroutine()
+			fmt.Fprintf(&b, " %v->%v", from, to)
+		case ResourceProc:
+			from, to := s.Proc()
+			fmt.Fprintf(&b, " %v->%v", from, to)
+		}
+		if s.Reason != "" {
+			fmt.Fprintf(&b, " Reason=%q", s.Reason)
+		}
+	}
+	return b.String()
+}
+
+// Metric is a point-in-time reading of a runtime metric. The names
+// follow those of the runtime/metrics package, for example
+// "/sched/gomaxprocs:threads".
+type Metric struct {
+	Name  string
+	Value uint64
+}
+
+// Range describes a period of runtime activity, such as
+// "GC concurrent mark phase" or "GC mark assist".
+type Range struct {
+	// Name identifies the activity.
+	Name string
+
+	// Scope is the resource the activity is attributed to. Its Kind
+	// is ResourceNone for activities that concern the whole program.
+	Scope ResourceID
+}
+
+// Task describes a user task.
+type Task struct {
+	ID     TaskID
+	Parent TaskID
+	Type   string // the task type passed to runtime/trace.NewTask
+}
+
+// Region describes a user region.
+type Region struct {
+	Task TaskID
+	Type string // the region type passed to runtime/trace.StartRegion
+}
+
+// Log is a user log message.
+type Log struct {
+	Task     TaskID
+	Category string
+	Message  string
+}
+
+// Stack is a stack trace.
+type Stack struct {
+	frames []StackFrame
+}
+
+// NoStack is the Stack of events that have no stack trace.
+var NoStack = Stack{}
+
+// StackFrame is a single frame of a stack trace.
+type StackFrame struct {
+	PC   uint64
+	Func string
+	File string
+	Line uint64
+}
+
+// Frames returns the frames of the stack, innermost first.
+// The caller must not modify the result.
+func (s Stack) Frames() []StackFrame {
+	return s.frames
+}
+
+// ResourceKind is the kind of a resource in the trace.
+type ResourceKind uint8
+
+const (
+	ResourceNone ResourceKind = iota
+	ResourceGoroutine
+	ResourceProc
+)
+
+func (r ResourceKind) String() string {
+	switch r {
+	case ResourceGoroutine:
+		return "Goroutine"
+	case ResourceProc:
+		return "Proc"
+	}
+	return "None"
+}
+
+// ResourceID identifies a goroutine or a P.
+type ResourceID struct {
+	Kind ResourceKind
+	id   int64
+}
+
+// GoroutineResource returns the ResourceID of goroutine id.
+func GoroutineResource(id GoID) ResourceID {
+	return ResourceID{Kind: ResourceGoroutine, id: int64(id)}
+}
+
+// ProcResource returns the ResourceID of P id.
+func ProcResource(id ProcID) ResourceID {
+	return ResourceID{Kind: ResourceProc, id: int64(id)}
+}
+
+// Goroutine returns the goroutine that r identifies.
+// It panics if r is not a goroutine.
+func (r ResourceID) Goroutine() GoID {
+	if r.Kind != ResourceGoroutine {
+		panic(fmt.Sprintf("trace: attempted to get GoID from %v resource", r.Kind))
+	}
+	return GoID(r.id)
+}
+
+// Proc returns the P that r identifies.
+// It panics if r is not a P.
+func (r ResourceID) Proc() ProcID {
+	if r.Kind != ResourceProc {
+		panic(fmt.Sprintf("trace: attempted to get ProcID from %v resource", r.Kind))
+	}
+	return ProcID(r.id)
+}
+
+func (r ResourceID) String() string {
+	if r.Kind == ResourceNone {
+		return "None"
+	}
+	return fmt.Sprintf("%v(%d)", r.Kind, r.id)
+}
+
+// StateTransition describes a change in the state of a goroutine or
+// a P.
+type StateTransition struct {
+	// Resource is the goroutine or P whose state changed.
+	Resource ResourceID
+
+	// Reason is a short description of why the goroutine stopped
+	// running or blocked, such as "chan receive" or "preempted".
+	// It may be empty.
+	Reason string
+
+	// Stack is the stack of the goroutine whose state changed, if
+	// it differs from that of the event. For goroutines that are
+	// created or first seen, it is the goroutine's start function.
+	Stack Stack
+
+	from, to uint8
+}
+
+// Goroutine returns the states the goroutine moved from and to.
+// It panics if the transition is not that of a goroutine.
+func (s StateTransition) Goroutine() (from, to GoState) {
+	if s.Resource.Kind != ResourceGoroutine {
+		panic("trace: Goroutine called on non-goroutine state transition")
+	}
+	return GoState(s.from), GoState(s.to)
+}
+
+// Proc returns the states the P moved from and to.
+// It panics if the transition is not that of a P.
+func (s StateTransition) Proc() (from, to ProcState) {
+	if s.Resource.Kind != ResourceProc {
+		panic("trace: Proc called on non-proc state transition")
+	}
+	return ProcState(s.from), ProcState(s.to)
+}
+
+// GoState is the state of a goroutine.
+type GoState uint8
+
+const (
+	GoUndetermined GoState = iota // no information about the goroutine's state yet
+	GoNotExist                    // the goroutine does not exist
+	GoRunnable                    // the goroutine is ready to run
+	GoRunning                     // the goroutine is running
+	GoWaiting                     // the goroutine is blocked
+	GoSyscall                     // the goroutine is in a system call that blocked
+)
+
+var goStateStrings = [...]string{
+	GoUndetermined: "Undetermined",
+	GoNotExist:     "NotExist",
+	GoRunnable:     "Runnable",
+	GoRunning:      "Running",
+	GoWaiting:      "Waiting",
+	GoSyscall:      "Syscall",
+}
+
+func (s GoState) String() string {
+	if int(s) < len(goStateStrings) {
+		return goStateStrings[s]
+	}
+	return "Bad"
+}
+
+// Executing reports whether the goroutine is executing on a thread in
+// this state, which is the case for GoRunning and GoSyscall.
+func (s GoState) Executing() bool {
+	return s == GoRunning || s == GoSyscall
+}
+
+// ProcState is the state of a P.
+type ProcState uint8
+
+const (
+	ProcUndetermined ProcState = iota // no information about the P's state yet
+	ProcRunning                       // the P is running goroutines
+	ProcIdle                          // the P is idle
+)
+
+var procStateStrings = [...]string{
+	ProcUndetermined: "Undetermined",
+	ProcRunning:      "Running",
+	ProcIdle:         "Idle",
+}
+
+func (s ProcState) String() string {
+	if int(s) < len(procStateStrings) {
+		return procStateStrings[s]
+	}
+	return "Bad"
+}
+
+// Executing reports whether the P is running goroutines in this state.
+func (s ProcState) Executing() bool {
+	return s == ProcRunning
+}
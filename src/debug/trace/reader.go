@@ -0,0 +1,309 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package trace reads execution traces written by the runtime/trace
+// package.
+//
+// An execution trace is a sequence of generations, each of which can
+// be understood without the ones before it. A Reader parses one
+// generation at a time, so it can read traces of any length in bounded
+// memory, and reports the trace as a stream of typed events: state
+// transitions of goroutines and Ps, runtime activity ranges such as
+// garbage collection phases, user tasks, regions and logs, metrics,
+// and CPU profile samples.
+//
+// Only traces written by Go 1.20 and later are supported.
+package trace
+
+import (
+	"fmt"
+	itrace "internal/trace"
+	"io"
+)
+
+// Reader reads the events of an execution trace.
+type Reader struct {
+	r *itrace.Reader
+
+	events []*itrace.Event  // events of the current generation
+	stacks map[uint64]Stack // converted stacks of the current generation
+	frames map[uint64][]*itrace.Frame
+	sync   bool // whether the next event is an EventSync
+
+	// State carried across generations.
+	procs    map[ProcID]bool   // Ps that have appeared in the trace
+	tasks    map[TaskID]string // types of the tasks that have not ended
+	stwName  string            // name of the open stop-the-world range
+	lastTime Time
+}
+
+// NewReader reads the trace header from r and returns a Reader for the
+// events of the trace. It returns an error if the trace was written by
+// a version of Go that is not supported.
+func NewReader(r io.Reader) (*Reader, error) {
+	ir, err := itrace.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	if v := ir.Version(); v < 1020 {
+		return nil, fmt.Errorf("trace: unsupported trace version go%d.%d; traces written before go1.20 are not supported", v/1000, v%1000)
+	}
+	return &Reader{
+		r:     ir,
+		procs: make(map[ProcID]bool),
+		tasks: make(map[TaskID]string),
+	}, nil
+}
+
+// ReadEvent returns the next event of the trace. Events are returned
+// in timestamp order. ReadEvent returns io.EOF at the end of the trace.
+//
+// Each generation of the trace starts with an EventSync event. At that
+// point, goroutines that have not been seen before are described by
+// state transitions from GoUndetermined.
+func (r *Reader) ReadEvent() (Event, error) {
+	for {
+		if len(r.events) == 0 {
+			events, frames, err := r.r.Next()
+			if err != nil {
+				return Event{}, err
+			}
+			r.events = events
+			r.frames = frames
+			r.stacks = make(map[uint64]Stack)
+			r.sync = true
+		}
+		if r.sync {
+			r.sync = false
+			if len(r.events) > 0 {
+				r.lastTime = Time(r.events[0].Ts)
+			}
+			return Event{kind: EventSync, time: r.lastTime, g: NoGoroutine, p: NoProc}, nil
+		}
+		ev := r.events[0]
+		r.events[0] = nil
+		r.events = r.events[1:]
+		if e, ok := r.translate(ev); ok {
+			r.lastTime = e.time
+			return e, nil
+		}
+	}
+}
+
+// translate converts ev into an Event. It reports false for events
+// that have no counterpart in this package.
+func (r *Reader) translate(ev *itrace.Event) (Event, bool) {
+	e := Event{
+		time:  Time(ev.Ts),
+		g:     goID(ev.G),
+		p:     procID(ev.P),
+		stack: r.stack(ev.StkID),
+	}
+	switch ev.Type {
+	case itrace.EvGomaxprocs:
+		e.kind = EventMetric
+		e.data = Metric{Name: "/sched/gomaxprocs:threads", Value: ev.Args[0]}
+	case itrace.EvHeapAlloc:
+		e.kind = EventMetric
+		e.data = Metric{Name: "/memory/classes/heap/objects:bytes", Value: ev.Args[0]}
+	case itrace.EvHeapGoal:
+		e.kind = EventMetric
+		e.data = Metric{Name: "/gc/heap/goal:bytes", Value: ev.Args[0]}
+
+	case itrace.EvCPUSample:
+		e.kind = EventStackSample
+
+	case itrace.EvGCStart:
+		e.kind = EventRangeBegin
+		e.data = Range{Name: "GC concurrent mark phase"}
+	case itrace.EvGCDone:
+		e.kind = EventRangeEnd
+		e.data = Range{Name: "GC concurrent mark phase"}
+	case itrace.EvGCSTWStart:
+		e.kind = EventRangeBegin
+		r.stwName = "stop-the-world (" + ev.SArgs[0] + ")"
+		e.data = Range{Name: r.stwName}
+	case itrace.EvGCSTWDone:
+		e.kind = EventRangeEnd
+		e.data = Range{Name: r.stwName}
+	case itrace.EvGCSweepStart:
+		e.kind = EventRangeBegin
+		e.data = Range{Name: "GC incremental sweep", Scope: r.procScope(e.p)}
+	case itrace.EvGCSweepDone:
+		e.kind = EventRangeEnd
+		e.data = Range{Name: "GC incremental sweep", Scope: r.procScope(e.p)}
+	case itrace.EvGCMarkAssistStart:
+		e.kind = EventRangeBegin
+		e.data = Range{Name: "GC mark assist", Scope: GoroutineResource(e.g)}
+	case itrace.EvGCMarkAssistDone:
+		e.kind = EventRangeEnd
+		e.data = Range{Name: "GC mark assist", Scope: GoroutineResource(e.g)}
+
+	case itrace.EvUserTaskCreate:
+		e.kind = EventTaskBegin
+		t := Task{ID: TaskID(ev.Args[0]), Parent: TaskID(ev.Args[1]), Type: ev.SArgs[0]}
+		r.tasks[t.ID] = t.Type
+		e.data = t
+	case itrace.EvUserTaskEnd:
+		e.kind = EventTaskEnd
+		t := Task{ID: TaskID(ev.Args[0])}
+		t.Type = r.tasks[t.ID]
+		delete(r.tasks, t.ID)
+		e.data = t
+	case itrace.EvUserRegion:
+		e.kind = EventRegionBegin
+		if ev.Args[1] != 0 {
+			e.kind = EventRegionEnd
+		}
+		e.data = Region{Task: TaskID(ev.Args[0]), Type: ev.SArgs[0]}
+	case itrace.EvUserLog:
+		e.kind = EventLog
+		e.data = Log{Task: TaskID(ev.Args[0]), Category: ev.SArgs[0], Message: ev.SArgs[1]}
+
+	case itrace.EvProcStart:
+		from := ProcIdle
+		if !r.procs[e.p] {
+			from = ProcUndetermined
+			r.procs[e.p] = true
+		}
+		return procTransition(e, from, ProcRunning), true
+	case itrace.EvProcStop:
+		r.procs[e.p] = true
+		return procTransition(e, ProcRunning, ProcIdle), true
+
+	case itrace.EvGoStatus:
+		// The goroutine is described by the generation rather than by
+		// whatever happened to be running.
+		g := goID(ev.Args[0])
+		to := GoRunnable
+		switch ev.Args[1] {
+		case itrace.GoStatusWaiting:
+			to = GoWaiting
+		case itrace.GoStatusSyscall:
+			to = GoSyscall
+		}
+		e.g = NoGoroutine
+		e.stack = NoStack
+		e = goTransition(e, g, GoUndetermined, to, "")
+		e.data = withStack(e.data, r.stack(ev.StkID))
+	case itrace.EvGoCreate:
+		e = goTransition(e, goID(ev.Args[0]), GoNotExist, GoRunnable, "")
+		e.data = withStack(e.data, r.stack(ev.Args[1]))
+	case itrace.EvGoStart, itrace.EvGoStartLabel:
+		var reason string
+		if ev.Type == itrace.EvGoStartLabel {
+			reason = ev.SArgs[0]
+		}
+		e = goTransition(e, e.g, GoRunnable, GoRunning, reason)
+	case itrace.EvGoEnd:
+		e = goTransition(e, e.g, GoRunning, GoNotExist, "")
+	case itrace.EvGoSched:
+		e = goTransition(e, e.g, GoRunning, GoRunnable, "yield")
+	case itrace.EvGoPreempt:
+		e = goTransition(e, e.g, GoRunning, GoRunnable, "preempted")
+	case itrace.EvGoStop, itrace.EvGoSleep, itrace.EvGoBlock,
+		itrace.EvGoBlockSend, itrace.EvGoBlockRecv, itrace.EvGoBlockSelect,
+		itrace.EvGoBlockSync, itrace.EvGoBlockCond, itrace.EvGoBlockNet,
+		itrace.EvGoBlockGC:
+		e = goTransition(e, e.g, GoRunning, GoWaiting, blockReasons[ev.Type])
+	case itrace.EvGoUnblock:
+		e = goTransition(e, goID(ev.Args[0]), GoWaiting, GoRunnable, "")
+	case itrace.EvGoSysBlock:
+		e = goTransition(e, e.g, GoRunning, GoSyscall, "")
+	case itrace.EvGoSysExit:
+		e = goTransition(e, e.g, GoSyscall, GoRunnable, "")
+
+	default:
+		// EvGoSysCall marks a system call that did not block, which
+		// does not change the goroutine's state. The remaining event
+		// types describe the trace rather than the program.
+		return Event{}, false
+	}
+	return e, true
+}
+
+var blockReasons = map[byte]string{
+	itrace.EvGoStop:        "forever",
+	itrace.EvGoSleep:       "sleep",
+	itrace.EvGoBlockSend:   "chan send",
+	itrace.EvGoBlockRecv:   "chan receive",
+	itrace.EvGoBlockSelect: "select",
+	itrace.EvGoBlockSync:   "sync",
+	itrace.EvGoBlockCond:   "sync.(*Cond).Wait",
+	itrace.EvGoBlockNet:    "network",
+	itrace.EvGoBlockGC:     "GC mark assist wait",
+}
+
+func goTransition(e Event, g GoID, from, to GoState, reason string) Event {
+	e.kind = EventStateTransition
+	e.data = StateTransition{
+		Resource: GoroutineResource(g),
+		Reason:   reason,
+		from:     uint8(from),
+		to:       uint8(to),
+	}
+	return e
+}
+
+func procTransition(e Event, from, to ProcState) Event {
+	e.kind = EventStateTransition
+	e.data = StateTransition{
+		Resource: ProcResource(e.p),
+		from:     uint8(from),
+		to:       uint8(to),
+	}
+	return e
+}
+
+func withStack(data any, stk Stack) StateTransition {
+	s := data.(StateTransition)
+	s.Stack = stk
+	return s
+}
+
+func (r *Reader) procScope(p ProcID) ResourceID {
+	if p == NoProc {
+		return ResourceID{}
+	}
+	return ProcResource(p)
+}
+
+// stack returns the Stack with the given ID in the current generation.
+func (r *Reader) stack(id uint64) Stack {
+	if id == 0 {
+		return NoStack
+	}
+	if stk, ok := r.stacks[id]; ok {
+		return stk
+	}
+	var stk Stack
+	for _, f := range r.frames[id] {
+		stk.frames = append(stk.frames, StackFrame{
+			PC:   f.PC,
+			Func: f.Fn,
+			File: f.File,
+			Line: uint64(f.Line),
+		})
+	}
+	r.stacks[id] = stk
+	return stk
+}
+
+// goID converts a goroutine ID of package internal/trace, where 0
+// means no goroutine.
+func goID(g uint64) GoID {
+	if g == 0 {
+		return NoGoroutine
+	}
+	return GoID(g)
+}
+
+// procID converts a P ID of package internal/trace, where negative
+// IDs and the fake Ps used for display mean no P.
+func procID(p int) ProcID {
+	if p < 0 || p >= itrace.FakeP {
+		return NoProc
+	}
+	return ProcID(p)
+}
@@ -0,0 +1,177 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trace_test
+
+import (
+	"bytes"
+	"context"
+	. "debug/trace"
+	"io"
+	"runtime"
+	rtrace "runtime/trace"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func traceProgram(t *testing.T, f func()) []byte {
+	if rtrace.IsEnabled() {
+		t.Skip("skipping because -test.trace is set")
+	}
+	var buf bytes.Buffer
+	if err := rtrace.Start(&buf); err != nil {
+		t.Fatalf("failed to start tracing: %v", err)
+	}
+	f()
+	rtrace.Stop()
+	return buf.Bytes()
+}
+
+func TestReader(t *testing.T) {
+	data := traceProgram(t, func() {
+		ctx, task := rtrace.NewTask(context.Background(), "task")
+		rtrace.WithRegion(ctx, "region", func() {
+			rtrace.Log(ctx, "category", "message")
+		})
+		var wg sync.WaitGroup
+		c := make(chan int)
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-c
+			}()
+		}
+		time.Sleep(time.Millisecond)
+		close(c)
+		wg.Wait()
+		runtime.GC()
+		task.End()
+	})
+
+	r, err := NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		syncs, tasks, regions, logs, gcs int
+		last                             Time
+		gstates                          = make(map[GoID]GoState)
+		pstates                          = make(map[ProcID]ProcState)
+	)
+	for i := 0; ; i++ {
+		ev, err := r.ReadEvent()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 && ev.Kind() != EventSync {
+			t.Fatalf("first event is %v, want Sync", ev)
+		}
+		if ev.Time() < last {
+			t.Errorf("event %v is out of order: previous event at %d", ev, last)
+		}
+		last = ev.Time()
+
+		switch ev.Kind() {
+		case EventSync:
+			syncs++
+		case EventTaskBegin:
+			if task := ev.Task(); task.Type == "task" {
+				tasks++
+			}
+		case EventTaskEnd:
+			if task := ev.Task(); task.Type == "task" {
+				tasks++
+			}
+		case EventRegionBegin, EventRegionEnd:
+			if ev.Region().Type == "region" {
+				regions++
+			}
+		case EventLog:
+			l := ev.Log()
+			if l.Category != "category" || l.Message != "message" {
+				t.Errorf("unexpected log %v", ev)
+			}
+			logs++
+		case EventRangeBegin:
+			if ev.Range().Name == "GC concurrent mark phase" {
+				gcs++
+			}
+		case EventStateTransition:
+			st := ev.StateTransition()
+			switch st.Resource.Kind {
+			case ResourceGoroutine:
+				g := st.Resource.Goroutine()
+				from, to := st.Goroutine()
+				if cur, ok := gstates[g]; ok && cur != from {
+					t.Errorf("%v: goroutine %d was %v", ev, g, cur)
+				} else if !ok && from != GoUndetermined && from != GoNotExist {
+					t.Errorf("%v: first transition of goroutine %d is from %v", ev, g, from)
+				}
+				gstates[g] = to
+			case ResourceProc:
+				p := st.Resource.Proc()
+				from, to := st.Proc()
+				if cur, ok := pstates[p]; ok && cur != from {
+					t.Errorf("%v: P %d was %v", ev, p, cur)
+				}
+				pstates[p] = to
+			}
+		}
+	}
+	if syncs == 0 {
+		t.Errorf("no Sync events")
+	}
+	if tasks != 2 {
+		t.Errorf("got %d task begin and end events, want 2", tasks)
+	}
+	if regions != 2 {
+		t.Errorf("got %d region begin and end events, want 2", regions)
+	}
+	if logs != 1 {
+		t.Errorf("got %d log events, want 1", logs)
+	}
+	if gcs == 0 {
+		t.Errorf("no GC events")
+	}
+	var exited int
+	for _, s := range gstates {
+		if s == GoNotExist {
+			exited++
+		}
+	}
+	if exited < 4 {
+		t.Errorf("%d goroutines exited, want at least 4", exited)
+	}
+}
+
+func TestReaderUnsupportedVersion(t *testing.T) {
+	_, err := NewReader(strings.NewReader("go 1.19 trace\x00\x00\x00"))
+	if err == nil {
+		t.Fatal("NewReader succeeded on a go1.19 trace")
+	}
+}
+
+func TestEventWrongKind(t *testing.T) {
+	data := traceProgram(t, func() {})
+	r, err := NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev, err := r.ReadEvent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Log on %v event did not panic", ev.Kind())
+		}
+	}()
+	ev.Log()
+}
@@ -5,6 +5,8 @@
 // Represents JSON data structure using native Go types: booleans, floats,
 // strings, arrays, and maps.
 
+//go:build !goexperiment.jsonv2
+
 package json
 
 import (
@@ -44,7 +46,9 @@ import (
 // keys to the keys used by Marshal (either the struct field name or its tag),
 // preferring an exact match but also accepting a case-insensitive match. By
 // default, object keys which don't have a corresponding struct field are
-// ignored (see Decoder.DisallowUnknownFields for an alternative).
+// ignored (see Decoder.DisallowUnknownFields for an alternative),
+// unless the struct has a map field with the "inline" option
+// (see Marshal), in which case they are stored in that map.
 //
 // To unmarshal JSON into an interface value,
 // Unmarshal stores one of these in the interface value:
@@ -688,7 +692,8 @@ func (d *decodeState) object(v reflect.Value) error {
 
 		// Figure out field corresponding to key.
 		var subv reflect.Value
-		destring := false // whether the value is wrapped in a string to be decoded first
+		var inlinedMap reflect.Value // map holding unknown members, if subv is destined for it
+		destring := false            // whether the value is wrapped in a string to be decoded first
 
 		if v.Kind() == reflect.Map {
 			elemType := t.Elem()
@@ -699,51 +704,25 @@ func (d *decodeState) object(v reflect.Value) error {
 			}
 			subv = mapElem
 		} else {
-			var f *field
-			if i, ok := fields.nameIndex[string(key)]; ok {
-				// Found an exact name match.
-				f = &fields.list[i]
-			} else {
-				// Fall back to the expensive case-insensitive
-				// linear search.
-				for i := range fields.list {
-					ff := &fields.list[i]
-					if ff.equalFold(ff.nameBytes, key) {
-						f = ff
-						break
-					}
-				}
-			}
-			if f != nil {
-				subv = v
-				destring = f.quoted
-				for _, i := range f.index {
-					if subv.Kind() == reflect.Pointer {
-						if subv.IsNil() {
-							// If a struct embeds a pointer to an unexported type,
-							// it is not possible to set a newly allocated value
-							// since the field is unexported.
-							//
-							// See https://golang.org/issue/21357
-							if !subv.CanSet() {
-								d.saveError(fmt.Errorf("json: cannot set embedded pointer to unexported struct: %v", subv.Type().Elem()))
-								// Invalidate subv to ensure d.value(subv) skips over
-								// the JSON value without assigning it to subv.
-								subv = reflect.Value{}
-								destring = false
-								break
-							}
-							subv.Set(reflect.New(subv.Type().Elem()))
-						}
-						subv = subv.Elem()
-					}
-					subv = subv.Field(i)
-				}
+			if f := fields.lookup(key); f != nil {
+				// If subv is invalid, d.value(subv) skips over
+				// the JSON value without assigning it to subv.
+				subv = d.fieldByIndex(v, f.index)
+				destring = f.quoted && subv.IsValid()
 				if d.errorContext == nil {
 					d.errorContext = new(errorContext)
 				}
 				d.errorContext.FieldStack = append(d.errorContext.FieldStack, f.name)
 				d.errorContext.Struct = t
+			} else if fields.inlined != nil {
+				// Store the unknown member in the inlined map.
+				inlinedMap = d.fieldByIndex(v, fields.inlined.index)
+				if inlinedMap.IsValid() {
+					if inlinedMap.IsNil() {
+						inlinedMap.Set(reflect.MakeMap(inlinedMap.Type()))
+					}
+					subv = reflect.New(inlinedMap.Type().Elem()).Elem()
+				}
 			} else if d.disallowUnknownFields {
 				d.saveError(fmt.Errorf("json: unknown field %q", key))
 			}
@@ -777,6 +756,10 @@ func (d *decodeState) object(v reflect.Value) error {
 			}
 		}
 
+		if inlinedMap.IsValid() {
+			inlinedMap.SetMapIndex(reflect.ValueOf(string(key)).Convert(inlinedMap.Type().Key()), subv)
+		}
+
 		// Write value back to map;
 		// if using struct, subv points into struct already.
 		if v.Kind() == reflect.Map {
@@ -1080,6 +1063,32 @@ func (d *decodeState) arrayInterface() []any {
 	return v
 }
 
+// fieldByIndex returns the nested field of the struct v given by index,
+// allocating nil embedded pointers along the way.
+// If a nil embedded pointer cannot be set, it saves an error
+// and returns the zero Value.
+func (d *decodeState) fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for _, i := range index {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				// If a struct embeds a pointer to an unexported type,
+				// it is not possible to set a newly allocated value
+				// since the field is unexported.
+				//
+				// See https://golang.org/issue/21357
+				if !v.CanSet() {
+					d.saveError(fmt.Errorf("json: cannot set embedded pointer to unexported struct: %v", v.Type().Elem()))
+					return reflect.Value{}
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v
+}
+
 // objectInterface is like object but returns map[string]interface{}.
 func (d *decodeState) objectInterface() map[string]any {
 	m := make(map[string]any)
@@ -2,6 +2,8 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
+//go:build !goexperiment.jsonv2
+
 package json
 
 import (
@@ -2351,6 +2353,86 @@ func TestUnmarshalEmbeddedUnexported(t *testing.T) {
 	}
 }
 
+func TestUnmarshalInline(t *testing.T) {
+	type (
+		inlineUnexported struct {
+			Extras map[string]RawMessage `json:",inline"`
+		}
+		S1 struct {
+			*inlineUnexported
+			R int
+		}
+		S2 struct {
+			Inline
+			Extras map[string]int `json:",inline"`
+		}
+		S3 struct {
+			M1 map[string]RawMessage `json:",inline"`
+			M2 map[string]RawMessage `json:",inline"`
+		}
+	)
+
+	tests := []struct {
+		in  string
+		ptr any
+		out any
+		err error
+	}{{
+		in:  `{"A":1,"B":2,"named":{"k":1},"C":[true], "D" : null}`,
+		ptr: new(Inline),
+		out: &Inline{
+			A:      1,
+			Inner:  InlineInner{2},
+			Extras: map[string]RawMessage{"C": RawMessage(`[true]`), "D": RawMessage(`null`)},
+			Named:  map[string]RawMessage{"k": RawMessage(`1`)},
+		},
+	}, {
+		// Existing entries are preserved.
+		in:  `{"C":2}`,
+		ptr: &Inline{Extras: map[string]RawMessage{"E": RawMessage(`3`)}},
+		out: &Inline{Extras: map[string]RawMessage{"C": RawMessage(`2`), "E": RawMessage(`3`)}},
+	}, {
+		// Nil embedded pointers are allocated.
+		in:  `{"C":3,"x":true}`,
+		ptr: new(InlineEmbed),
+		out: &InlineEmbed{&Inline{Extras: map[string]RawMessage{"x": RawMessage(`true`)}}, 3},
+	}, {
+		// Error since we cannot set S1.inlineUnexported, but still able to set S1.R.
+		in:  `{"R":2,"Q":1}`,
+		ptr: new(S1),
+		out: &S1{R: 2},
+		err: fmt.Errorf("json: cannot set embedded pointer to unexported struct: json.inlineUnexported"),
+	}, {
+		// The least nested inlined map is used.
+		in:  `{"A":1,"C":2}`,
+		ptr: new(S2),
+		out: &S2{Inline: Inline{A: 1}, Extras: map[string]int{"C": 2}},
+	}, {
+		// Multiple inlined maps at the same level are ignored.
+		in:  `{"C":1}`,
+		ptr: new(S3),
+		out: new(S3),
+	}}
+
+	for i, tt := range tests {
+		err := Unmarshal([]byte(tt.in), tt.ptr)
+		if !equalError(err, tt.err) {
+			t.Errorf("#%d: %v, want %v", i, err, tt.err)
+		}
+		if !reflect.DeepEqual(tt.ptr, tt.out) {
+			t.Errorf("#%d: mismatch\ngot:  %#+v\nwant: %#+v", i, tt.ptr, tt.out)
+		}
+	}
+
+	// Unknown members held by an inlined map are not rejected.
+	dec := NewDecoder(strings.NewReader(`{"A":1,"C":2}`))
+	dec.DisallowUnknownFields()
+	var v Inline
+	if err := dec.Decode(&v); err != nil {
+		t.Errorf("Decode error: %v", err)
+	}
+}
+
 func TestUnmarshalErrorAfterMultipleJSON(t *testing.T) {
 	tests := []struct {
 		in  string
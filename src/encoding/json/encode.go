@@ -2,6 +2,8 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
+//go:build !goexperiment.jsonv2
+
 // Package json implements encoding and decoding of JSON as defined in
 // RFC 7159. The mapping between JSON and Go values is described
 // in the documentation for the Marshal and Unmarshal functions.
@@ -72,6 +74,17 @@ import (
 // false, 0, a nil pointer, a nil interface value, and any empty array,
 // slice, map, or string.
 //
+// The "omitzero" option specifies that the field should be omitted
+// from the encoding if the field has a zero value, according to rules:
+//
+// 1) If the field type has an "IsZero() bool" method, that will be used to
+// determine whether the value is zero.
+//
+// 2) Otherwise, the value is zero if it is the zero value for its type.
+//
+// If both "omitempty" and "omitzero" are specified, the field will be omitted
+// if the value is either empty or zero (or both).
+//
 // As a special case, if the field tag is "-", the field is always omitted.
 // Note that a field with name "-" can still be generated using the tag "-,".
 //
@@ -96,6 +109,13 @@ import (
 //	// Field appears in JSON as key "-".
 //	Field int `json:"-,"`
 //
+//	// Field is omitted from the object if its IsZero method reports true.
+//	Field time.Time `json:",omitzero"`
+//
+//	// Members of the JSON object without a corresponding field
+//	// are held in Field.
+//	Field map[string]json.RawMessage `json:",inline"`
+//
 // The "string" option signals that a field is stored as JSON inside a
 // JSON-encoded string. It applies only to fields of string, floating point,
 // integer, or boolean types. This extra level of encoding is sometimes used
@@ -107,6 +127,19 @@ import (
 // only Unicode letters, digits, and ASCII punctuation except quotation
 // marks, backslash, and comma.
 //
+// The "inline" option specifies that the exported fields of a field of
+// struct type are marshaled as if they were fields in the outer struct,
+// in the same way as an anonymous struct field without a JSON name.
+// If the field instead has a map type with string keys, such as
+// map[string]RawMessage, it holds the members of the JSON object
+// that do not correspond to any other field: Unmarshal stores such members
+// in the map, and Marshal encodes the map entries, sorted by key,
+// after all other fields. Marshal skips map entries whose keys match
+// the name of another field of the struct, ignoring case as Unmarshal
+// does, since Unmarshal would store such a member in that field.
+// At most one such map is used, with the same precedence rules
+// as for fields of the same name described below.
+//
 // Anonymous struct fields are usually marshaled as if their inner exported fields
 // were fields in the outer struct, subject to the usual Go visibility rules amended
 // as described in the next paragraph.
@@ -725,6 +758,28 @@ type structEncoder struct {
 type structFields struct {
 	list      []field
 	nameIndex map[string]int
+
+	// inlined is the map field with the "inline" option, if any,
+	// which holds the object members without a corresponding field.
+	inlined *field
+}
+
+// lookup returns the field that Unmarshal stores an object member
+// with the given key in, or nil if there is none.
+func (fs *structFields) lookup(key []byte) *field {
+	if i, ok := fs.nameIndex[string(key)]; ok {
+		// Found an exact name match.
+		return &fs.list[i]
+	}
+	// Fall back to the expensive case-insensitive
+	// linear search.
+	for i := range fs.list {
+		f := &fs.list[i]
+		if f.equalFold(f.nameBytes, key) {
+			return f
+		}
+	}
+	return nil
 }
 
 func (se structEncoder) encode(e *encodeState, v reflect.Value, opts encOpts) {
@@ -745,7 +800,8 @@ FieldLoop:
 			fv = fv.Field(i)
 		}
 
-		if f.omitEmpty && isEmptyValue(fv) {
+		if (f.omitEmpty && isEmptyValue(fv)) ||
+			(f.omitZero && (f.isZero == nil && fv.IsZero() || (f.isZero != nil && f.isZero(fv)))) {
 			continue
 		}
 		e.WriteByte(next)
@@ -758,6 +814,9 @@ FieldLoop:
 		opts.quoted = f.quoted
 		f.encoder(e, fv, opts)
 	}
+	if f := se.fields.inlined; f != nil {
+		next = se.encodeInlined(e, v, f, next, opts)
+	}
 	if next == '{' {
 		e.WriteString("{}")
 	} else {
@@ -765,6 +824,47 @@ FieldLoop:
 	}
 }
 
+// encodeInlined encodes the entries of the inlined map field f of v
+// as members of the enclosing object, sorted by key.
+// It reports the next byte to be written before a member.
+func (se structEncoder) encodeInlined(e *encodeState, v reflect.Value, f *field, next byte, opts encOpts) byte {
+	mv := v
+	for _, i := range f.index {
+		if mv.Kind() == reflect.Pointer {
+			if mv.IsNil() {
+				return next
+			}
+			mv = mv.Elem()
+		}
+		mv = mv.Field(i)
+	}
+	if mv.Len() == 0 {
+		return next
+	}
+
+	keys := make([]string, 0, mv.Len())
+	for mi := mv.MapRange(); mi.Next(); {
+		keys = append(keys, mi.Key().String())
+	}
+	sort.Strings(keys)
+
+	opts.quoted = false
+	kv := reflect.New(mv.Type().Key()).Elem()
+	for _, k := range keys {
+		if se.fields.lookup([]byte(k)) != nil {
+			// Unmarshal would store this member in a field.
+			continue
+		}
+		e.WriteByte(next)
+		next = ','
+		e.string(k, opts.escapeHTML)
+		e.WriteByte(':')
+		kv.SetString(k)
+		f.encoder(e, mv.MapIndex(kv), opts)
+	}
+	return next
+}
+
 func newStructEncoder(t reflect.Type) encoderFunc {
 	se := structEncoder{fields: cachedTypeFields(t)}
 	return se.encode
@@ -1182,6 +1282,8 @@ type field struct {
 	index     []int
 	typ       reflect.Type
 	omitEmpty bool
+	omitZero  bool
+	isZero    func(reflect.Value) bool
 	quoted    bool
 
 	encoder encoderFunc
@@ -1223,6 +1325,9 @@ func typeFields(t reflect.Type) structFields {
 	// Fields found.
 	var fields []field
 
+	// Map fields with the "inline" option found.
+	var inlined []field
+
 	// Buffer to run HTMLEscape on field names.
 	var nameEscBuf bytes.Buffer
 
@@ -1272,6 +1377,20 @@ func typeFields(t reflect.Type) structFields {
 					ft = ft.Elem()
 				}
 
+				// An inlined struct is treated like an anonymous struct field,
+				// while an inlined map holds otherwise unknown members.
+				inline := opts.Contains("inline")
+				if inline && sf.Type.Kind() == reflect.Map && sf.Type.Key().Kind() == reflect.String {
+					inlined = append(inlined, field{index: index, typ: sf.Type})
+					if count[f.typ] > 1 {
+						// As below, record a duplicate so that
+						// the multiple instances annihilate.
+						inlined = append(inlined, inlined[len(inlined)-1])
+					}
+					continue
+				}
+				inlineStruct := inline && ft.Kind() == reflect.Struct
+
 				// Only strings, floats, integers, and booleans can be quoted.
 				quoted := false
 				if opts.Contains("string") {
@@ -1286,7 +1405,7 @@ func typeFields(t reflect.Type) structFields {
 				}
 
 				// Record found field and index sequence.
-				if name != "" || !sf.Anonymous || ft.Kind() != reflect.Struct {
+				if !inlineStruct && (name != "" || !sf.Anonymous || ft.Kind() != reflect.Struct) {
 					tagged := name != ""
 					if name == "" {
 						name = sf.Name
@@ -1297,8 +1416,12 @@ func typeFields(t reflect.Type) structFields {
 						index:     index,
 						typ:       ft,
 						omitEmpty: opts.Contains("omitempty"),
+						omitZero:  opts.Contains("omitzero"),
 						quoted:    quoted,
 					}
+					if field.omitZero {
+						field.isZero = isZeroFunc(sf.Type)
+					}
 					field.nameBytes = []byte(field.name)
 					field.equalFold = foldFunc(field.nameBytes)
 
@@ -1386,7 +1509,57 @@ func typeFields(t reflect.Type) structFields {
 	for i, field := range fields {
 		nameIndex[field.name] = i
 	}
-	return structFields{fields, nameIndex}
+
+	// The inlined map fields were found in order of depth.
+	// As with fields of the same name, only a single least nested one is used.
+	sf := structFields{list: fields, nameIndex: nameIndex}
+	if len(inlined) == 1 || len(inlined) > 1 && len(inlined[0].index) < len(inlined[1].index) {
+		f := inlined[0]
+		f.encoder = typeEncoder(f.typ.Elem())
+		sf.inlined = &f
+	}
+	return sf
+}
+
+type isZeroer interface {
+	IsZero() bool
+}
+
+var isZeroerType = reflect.TypeOf((*isZeroer)(nil)).Elem()
+
+// isZeroFunc returns a function that reports whether a value of type t
+// is zero according to its IsZero method, or nil if t has no such method.
+func isZeroFunc(t reflect.Type) func(reflect.Value) bool {
+	switch {
+	case t.Kind() == reflect.Interface && t.Implements(isZeroerType):
+		return func(v reflect.Value) bool {
+			// Avoid panics calling IsZero on a nil interface or
+			// non-nil interface with nil pointer.
+			return v.IsNil() ||
+				(v.Elem().Kind() == reflect.Pointer && v.Elem().IsNil()) ||
+				v.Interface().(isZeroer).IsZero()
+		}
+	case t.Kind() == reflect.Pointer && t.Implements(isZeroerType):
+		return func(v reflect.Value) bool {
+			// Avoid panics calling IsZero on a nil pointer.
+			return v.IsNil() || v.Interface().(isZeroer).IsZero()
+		}
+	case t.Implements(isZeroerType):
+		return func(v reflect.Value) bool {
+			return v.Interface().(isZeroer).IsZero()
+		}
+	case reflect.PointerTo(t).Implements(isZeroerType):
+		return func(v reflect.Value) bool {
+			if !v.CanAddr() {
+				// Temporarily box v so we can take the address.
+				v2 := reflect.New(v.Type()).Elem()
+				v2.Set(v)
+				v = v2
+			}
+			return v.Addr().Interface().(isZeroer).IsZero()
+		}
+	}
+	return nil
 }
 
 // dominantField looks through the fields, all of which are known to
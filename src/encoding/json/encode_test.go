@@ -2,6 +2,8 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
+//go:build !goexperiment.jsonv2
+
 package json
 
 import (
@@ -15,6 +17,7 @@ import (
 	"runtime/debug"
 	"strconv"
 	"testing"
+	"time"
 	"unicode"
 )
 
@@ -72,6 +75,164 @@ func TestOmitEmpty(t *testing.T) {
 	}
 }
 
+type OptionalsZero struct {
+	Sr string `json:"sr"`
+	So string `json:"so,omitzero"`
+
+	Io int `json:"io,omitzero"`
+
+	Slr []string `json:"slr"`
+	Slo []string `json:"slo,omitzero"`
+
+	Mr map[string]any `json:"mr"`
+	Mo map[string]any `json:",omitzero"`
+
+	Str struct{} `json:"str"`
+	Sto struct{} `json:"sto,omitzero"`
+
+	Time      time.Time     `json:"time,omitzero"`
+	Nzt       nonZeroStruct `json:"nzt,omitzero"`
+	NzPtr     *nonZeroPtr   `json:"nzptr,omitzero"`
+	NzIface   isZeroer      `json:"nziface,omitzero"`
+	NzAddr    zeroAddr      `json:"nzaddr,omitzero"`
+	ZeroEmpty []int         `json:"zeroempty,omitzero,omitempty"`
+}
+
+// nonZeroStruct is never zero according to its IsZero method.
+type nonZeroStruct struct{}
+
+func (nonZeroStruct) IsZero() bool { return false }
+
+type nonZeroPtr struct{ N int }
+
+func (p *nonZeroPtr) IsZero() bool { return p.N == 0 }
+
+// zeroAddr has an IsZero method with a pointer receiver.
+type zeroAddr struct{ N int }
+
+func (p *zeroAddr) IsZero() bool { return p.N < 0 }
+
+func TestOmitZero(t *testing.T) {
+	const want = `{
+ "sr": "",
+ "slr": null,
+ "mr": {},
+ "Mo": {},
+ "str": {},
+ "nzt": {},
+ "nzaddr": {
+  "N": 0
+ }
+}`
+	var o OptionalsZero
+	o.Mr = map[string]any{}
+	o.Mo = map[string]any{} // an empty map is not the zero value
+	o.Time = time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
+	o.NzPtr = &nonZeroPtr{}
+	o.NzIface = (*nonZeroPtr)(nil)
+	o.ZeroEmpty = []int{} // empty but not zero
+
+	got, err := MarshalIndent(&o, "", " ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(got); got != want {
+		t.Errorf(" got: %s\nwant: %s\n", got, want)
+	}
+
+	// Values that are not addressable must still use IsZero methods
+	// declared on the pointer receiver.
+	o2 := OptionalsZero{NzAddr: zeroAddr{-1}, Mr: map[string]any{}}
+	got, err = Marshal(o2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"sr":"","slr":null,"mr":{},"str":{},"nzt":{}}`; string(got) != want {
+		t.Errorf(" got: %s\nwant: %s\n", got, want)
+	}
+}
+
+type InlineInner struct {
+	B int
+}
+
+type Inline struct {
+	A      int
+	Inner  InlineInner           `json:",inline"`
+	Extras map[string]RawMessage `json:",inline"`
+	Named  map[string]RawMessage `json:"named"`
+}
+
+type InlineEmbed struct {
+	*Inline
+	C int
+}
+
+func TestMarshalInline(t *testing.T) {
+	tests := []struct {
+		name string
+		in   any
+		want string
+	}{{
+		name: "Struct",
+		in:   Inline{A: 1, Inner: InlineInner{2}},
+		want: `{"A":1,"B":2,"named":null}`,
+	}, {
+		name: "Map",
+		in:   Inline{Extras: map[string]RawMessage{"z": RawMessage(`[1]`), "<y>": RawMessage(`null`)}},
+		want: `{"A":0,"B":0,"named":null,"\u003cy\u003e":null,"z":[1]}`,
+	}, {
+		name: "Embedded",
+		in:   InlineEmbed{&Inline{Extras: map[string]RawMessage{"x": RawMessage(`true`)}}, 3},
+		want: `{"A":0,"B":0,"named":null,"C":3,"x":true}`,
+	}, {
+		name: "EmbeddedNil",
+		in:   InlineEmbed{C: 3},
+		want: `{"C":3}`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(tt.in)
+			if err != nil {
+				t.Fatalf("Marshal error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal:\n\tgot:  %s\n\twant: %s", got, tt.want)
+			}
+		})
+	}
+}
+
+type InlineCollision struct {
+	A    int                   `json:"a"`
+	Rest map[string]RawMessage `json:",inline"`
+}
+
+func TestMarshalInlineCollision(t *testing.T) {
+	in := InlineCollision{A: 1, Rest: map[string]RawMessage{
+		"a": RawMessage(`2`),
+		"A": RawMessage(`3`),
+		"b": RawMessage(`4`),
+	}}
+	got, err := Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	const want = `{"a":1,"b":4}`
+	if string(got) != want {
+		t.Errorf("Marshal:\n\tgot:  %s\n\twant: %s", got, want)
+	}
+
+	var out InlineCollision
+	if err := Unmarshal(got, &out); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	wantOut := InlineCollision{A: 1, Rest: map[string]RawMessage{"b": RawMessage(`4`)}}
+	if !reflect.DeepEqual(out, wantOut) {
+		t.Errorf("Unmarshal:\n\tgot:  %+v\n\twant: %+v", out, wantOut)
+	}
+}
+
 type StringTag struct {
 	BoolStr    bool    `json:",string"`
 	IntStr     int64   `json:",string"`
@@ -0,0 +1,193 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.jsonv2
+
+package jsonwire
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"testing"
+
+	"encoding/json/internal/jsonflags"
+)
+
+func TestAppendQuote(t *testing.T) {
+	tests := []struct {
+		in          string
+		flags       jsonflags.Bools
+		want        string
+		wantErr     error
+		wantErrUTF8 error
+	}{
+		{"", 0, `""`, nil, nil},
+		{"hello", 0, `"hello"`, nil, nil},
+		{"\x00", 0, `"\u0000"`, nil, nil},
+		{"\x1f", 0, `"\u001f"`, nil, nil},
+		{"\b\f\n\r\t", 0, `"\b\f\n\r\t"`, nil, nil},
+		{"\"\\", 0, `"\"\\"`, nil, nil},
+		{"<>&", 0, `"<>&"`, nil, nil},
+		{"<>&", jsonflags.EscapeForHTML | 1, `"\u003c\u003e\u0026"`, nil, nil},
+		{"\u2028\u2029", 0, "\"\u2028\u2029\"", nil, nil},
+		{"\u2028\u2029", jsonflags.EscapeForJS | 1, `"\u2028\u2029"`, nil, nil},
+		{"x\xffy", 0, "\"x\ufffdy\"", nil, ErrInvalidUTF8},
+		{"x\xffy", jsonflags.AllowInvalidUTF8 | 1, "\"x\ufffdy\"", nil, nil},
+		{"\U0001f600", 0, "\"\U0001f600\"", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			var flags jsonflags.Flags
+			flags.Set(tt.flags | 1)
+
+			got, gotErr := AppendQuote(nil, tt.in, &flags)
+			if string(got) != tt.want || gotErr != tt.wantErrUTF8 {
+				t.Errorf("AppendQuote(nil, %q, ...) = (%s, %v), want (%s, %v)", tt.in, got, gotErr, tt.want, tt.wantErrUTF8)
+			}
+		})
+	}
+}
+
+func TestNeedEscape(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"", false},
+		{"hello", false},
+		{"\x00", true},
+		{"\"", true},
+		{"\\", true},
+		{"<", true},
+		{"\u2028", true},
+		{"\xff", true},
+		{"\U0001f600", false},
+	}
+	for _, tt := range tests {
+		if got := NeedEscape(tt.in); got != tt.want {
+			t.Errorf("NeedEscape(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestAppendNumber(t *testing.T) {
+	tests := []struct {
+		in     float64
+		want32 string
+		want64 string
+	}{
+		{math.E, "2.7182817", "2.718281828459045"},
+		{math.Pi, "3.1415927", "3.141592653589793"},
+		{math.SmallestNonzeroFloat32, "1e-45", "1.401298464324817e-45"},
+		{math.SmallestNonzeroFloat64, "0", "5e-324"},
+		{math.MaxFloat32, "3.4028235e+38", "3.4028234663852886e+38"},
+		{math.MaxFloat64, "", "1.7976931348623157e+308"},
+		{0.1111111111111111, "0.11111111", "0.1111111111111111"},
+		{0.2222222222222222, "0.22222222", "0.2222222222222222"},
+		{0.3333333333333333, "0.33333334", "0.3333333333333333"},
+		{0.4444444444444444, "0.44444445", "0.4444444444444444"},
+		{0.5555555555555555, "0.5555556", "0.5555555555555555"},
+		{0.6666666666666666, "0.6666667", "0.6666666666666666"},
+		{0.7777777777777777, "0.7777778", "0.7777777777777777"},
+		{0.8888888888888888, "0.8888889", "0.8888888888888888"},
+		{0.9999999999999999, "1", "0.9999999999999999"},
+
+		// The following entries are from RFC 8785, appendix B
+		// which are designed to ensure repeatable formatting of 64-bit floats.
+		{math.Float64frombits(0x0000000000000000), "0", "0"},
+		{math.Float64frombits(0x8000000000000000), "-0", "-0"}, // differs from RFC 8785
+		{math.Float64frombits(0x0000000000000001), "0", "5e-324"},
+		{math.Float64frombits(0x8000000000000001), "-0", "-5e-324"},
+		{math.Float64frombits(0x7fefffffffffffff), "", "1.7976931348623157e+308"},
+		{math.Float64frombits(0xffefffffffffffff), "", "-1.7976931348623157e+308"},
+		{math.Float64frombits(0x4340000000000000), "9007199000000000", "9007199254740992"},
+		{math.Float64frombits(0xc340000000000000), "-9007199000000000", "-9007199254740992"},
+		{math.Float64frombits(0x4430000000000000), "295147900000000000000", "295147905179352830000"},
+		{math.Float64frombits(0x44b52d02c7e14af5), "1e+23", "9.999999999999997e+22"},
+		{math.Float64frombits(0x44b52d02c7e14af6), "1e+23", "1e+23"},
+		{math.Float64frombits(0x44b52d02c7e14af7), "1e+23", "1.0000000000000001e+23"},
+		{math.Float64frombits(0x444b1ae4d6e2ef4e), "1e+21", "999999999999999700000"},
+		{math.Float64frombits(0x444b1ae4d6e2ef4f), "1e+21", "999999999999999900000"},
+		{math.Float64frombits(0x444b1ae4d6e2ef50), "1e+21", "1e+21"},
+		{math.Float64frombits(0x3eb0c6f7a0b5ed8c), "0.000001", "9.999999999999997e-7"},
+		{math.Float64frombits(0x3eb0c6f7a0b5ed8d), "0.000001", "0.000001"},
+		{math.Float64frombits(0x41b3de4355555553), "333333340", "333333333.3333332"},
+		{math.Float64frombits(0x41b3de4355555554), "333333340", "333333333.33333325"},
+		{math.Float64frombits(0x41b3de4355555555), "333333340", "333333333.3333333"},
+		{math.Float64frombits(0x41b3de4355555556), "333333340", "333333333.3333334"},
+		{math.Float64frombits(0x41b3de4355555557), "333333340", "333333333.33333343"},
+		{math.Float64frombits(0xbecbf647612f3696), "-0.0000033333333", "-0.0000033333333333333333"},
+		{math.Float64frombits(0x43143ff3c1cb0959), "1424953900000000", "1424953923781206.2"},
+
+		// The following are select entries from RFC 8785, appendix B,
+		// but modified for equivalent 32-bit behavior.
+		{float64(math.Float32frombits(0x65a96815)), "9.999999e+22", ""},
+		{float64(math.Float32frombits(0x65a96816)), "1e+23", ""},
+		{float64(math.Float32frombits(0x65a96817)), "1.0000001e+23", ""},
+		{float64(math.Float32frombits(0x6258d725)), "999999900000000000000", ""},
+		{float64(math.Float32frombits(0x6258d726)), "999999950000000000000", ""},
+		{float64(math.Float32frombits(0x6258d727)), "1e+21", ""},
+		{float64(math.Float32frombits(0x6258d728)), "1.0000001e+21", ""},
+		{float64(math.Float32frombits(0x358637bc)), "9.999999e-7", ""},
+		{float64(math.Float32frombits(0x358637bd)), "0.000001", ""},
+		{float64(math.Float32frombits(0x358637be)), "0.0000010000001", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			if got32 := string(AppendFloat(nil, tt.in, 32)); got32 != tt.want32 && tt.want32 != "" {
+				t.Errorf("AppendFloat(nil, %v, 32) = %v, want %v", tt.in, got32, tt.want32)
+			}
+			if got64 := string(AppendFloat(nil, tt.in, 64)); got64 != tt.want64 && tt.want64 != "" {
+				t.Errorf("AppendFloat(nil, %v, 64) = %v, want %v", tt.in, got64, tt.want64)
+			}
+		})
+	}
+}
+
+// TestCanonicalNumberConsistency verifies that ReformatNumber produces
+// the same output as AppendFloat for a pseudo-random sample of numbers.
+func TestCanonicalNumberConsistency(t *testing.T) {
+	for i := 0; i < 10000; i++ {
+		f := math.Float64frombits(uint64(i) * 0x9e3779b97f4a7c15)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			continue
+		}
+		var flags jsonflags.Flags
+		flags.Set(jsonflags.CanonicalizeNumbers | 1)
+		want := AppendFloat(nil, f, 64)
+		if f == 0 {
+			want = []byte("0")
+		}
+		got, _, err := ReformatNumber(nil, []byte(strconv.FormatFloat(f, 'g', -1, 64)), &flags)
+		if err != nil || !bytes.Equal(got, want) {
+			t.Fatalf("ReformatNumber(%v) = (%s, %v), want %s", f, got, err, want)
+		}
+	}
+}
+
+func TestReformatString(t *testing.T) {
+	tests := []struct {
+		in    string
+		flags jsonflags.Bools
+		want  string
+	}{
+		{`"hello"`, 0, `"hello"`},
+		{`"hello"`, 0, `"hello"`},
+		{`"hello"`, jsonflags.PreserveRawStrings | 1, `"hello"`},
+		{`"<h>"`, jsonflags.PreserveRawStrings | jsonflags.EscapeForHTML | 1, `"\u003ch\u003e"`},
+		{"\"\u2028\"", jsonflags.EscapeForJS | 1, `"\u2028"`},
+		{`"\/"`, 0, `"/"`},
+	}
+	for _, tt := range tests {
+		var flags jsonflags.Flags
+		flags.Set(tt.flags | 1)
+		got, n, err := ReformatString(nil, []byte(tt.in), &flags)
+		if string(got) != tt.want || n != len(tt.in) || err != nil {
+			t.Errorf("ReformatString(%s) = (%s, %d, %v), want (%s, %d, nil)", tt.in, got, n, err, tt.want, len(tt.in))
+		}
+	}
+}
@@ -0,0 +1,796 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.jsonv2
+
+package jsontext
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// tokOrVal is either a Token or a Value.
+type tokOrVal interface{ Kind() Kind }
+
+type coderTestdataEntry struct {
+	name             string
+	in               string
+	outCompacted     string
+	outIndented      string // outCompacted if empty; uses "  " for indent prefix and "\t" for indent
+	outCanonicalized string // outCompacted if empty
+	tokens           []Token
+	pointers         []Pointer
+}
+
+var coderTestdata = []coderTestdataEntry{{
+	name:         "Null",
+	in:           ` null `,
+	outCompacted: `null`,
+	tokens:       []Token{Null},
+	pointers:     []Pointer{""},
+}, {
+	name:         "False",
+	in:           ` false `,
+	outCompacted: `false`,
+	tokens:       []Token{False},
+}, {
+	name:         "True",
+	in:           ` true `,
+	outCompacted: `true`,
+	tokens:       []Token{True},
+}, {
+	name:         "EmptyString",
+	in:           ` "" `,
+	outCompacted: `""`,
+	tokens:       []Token{String("")},
+}, {
+	name:         "SimpleString",
+	in:           ` "hello" `,
+	outCompacted: `"hello"`,
+	tokens:       []Token{String("hello")},
+}, {
+	name:         "ComplicatedString",
+	in:           ` "Hello, 世界 🌟★☆✩🌠 \"\\\/\b\f\n\r\t \u0041\ud83d\ude02" `,
+	outCompacted: `"Hello, 世界 🌟★☆✩🌠 \"\\/\b\f\n\r\t A😂"`,
+	tokens:       []Token{String("Hello, 世界 🌟★☆✩🌠 \"\\/\b\f\n\r\t A😂")},
+}, {
+	name:             "ZeroNumber",
+	in:               ` 0 `,
+	outCompacted:     `0`,
+	tokens:           []Token{Uint(0)},
+	outCanonicalized: `0`,
+}, {
+	name:             "SimpleNumber",
+	in:               ` 123456789 `,
+	outCompacted:     `123456789`,
+	tokens:           []Token{Uint(123456789)},
+	outCanonicalized: `123456789`,
+}, {
+	name:             "NegativeNumber",
+	in:               ` -123456789 `,
+	outCompacted:     `-123456789`,
+	tokens:           []Token{Int(-123456789)},
+	outCanonicalized: `-123456789`,
+}, {
+	name:             "FractionalNumber",
+	in:               " 0.123456789 ",
+	outCompacted:     `0.123456789`,
+	tokens:           []Token{Float(0.123456789)},
+	outCanonicalized: `0.123456789`,
+}, {
+	name:             "ExponentNumber",
+	in:               " 0e12456789 ",
+	outCompacted:     `0e12456789`,
+	tokens:           []Token{rawToken(`0e12456789`)},
+	outCanonicalized: `0`,
+}, {
+	name:             "ExponentNumberP",
+	in:               " 0e+12456789 ",
+	outCompacted:     `0e+12456789`,
+	tokens:           []Token{rawToken(`0e+12456789`)},
+	outCanonicalized: `0`,
+}, {
+	name:             "ExponentNumberN",
+	in:               " 0e-12456789 ",
+	outCompacted:     `0e-12456789`,
+	tokens:           []Token{rawToken(`0e-12456789`)},
+	outCanonicalized: `0`,
+}, {
+	name:             "ComplicatedNumber",
+	in:               ` -123456789.987654321E+0123456789 `,
+	outCompacted:     `-123456789.987654321E+0123456789`,
+	tokens:           []Token{rawToken(`-123456789.987654321E+0123456789`)},
+	outCanonicalized: `-1.7976931348623157e+308`,
+}, {
+	name: "Numbers",
+	in: ` [
+		0, -0, 0.0, -0.0, 1.001, 1e-1000, 1e+1000,
+		-9223372036854775809, -9223372036854775808, -9223372036854775807,
+		9223372036854775806, 9223372036854775807, 9223372036854775808,
+		18446744073709551614, 18446744073709551615, 18446744073709551616
+	] `,
+	outCompacted: `[0,-0,0.0,-0.0,1.001,1e-1000,1e+1000,-9223372036854775809,-9223372036854775808,-9223372036854775807,9223372036854775806,9223372036854775807,9223372036854775808,18446744073709551614,18446744073709551615,18446744073709551616]`,
+	outIndented: ` [
+	    0,
+	    -0,
+	    0.0,
+	    -0.0,
+	    1.001,
+	    1e-1000,
+	    1e+1000,
+	    -9223372036854775809,
+	    -9223372036854775808,
+	    -9223372036854775807,
+	    9223372036854775806,
+	    9223372036854775807,
+	    9223372036854775808,
+	    18446744073709551614,
+	    18446744073709551615,
+	    18446744073709551616
+	]`,
+	outCanonicalized: `[0,0,0,0,1.001,0,1.7976931348623157e+308,-9223372036854776000,-9223372036854776000,-9223372036854776000,9223372036854776000,9223372036854776000,9223372036854776000,18446744073709552000,18446744073709552000,18446744073709552000]`,
+	tokens: []Token{
+		BeginArray,
+		Float(0), Float(math.Copysign(0, -1)), rawToken(`0.0`), rawToken(`-0.0`), rawToken(`1.001`), rawToken(`1e-1000`), rawToken(`1e+1000`),
+		Float(-9223372036854775809), Int(minInt64), Int(minInt64 + 1),
+		Int(maxInt64 - 1), Int(maxInt64), Uint(maxInt64 + 1),
+		Uint(maxUint64 - 1), Uint(maxUint64), Float(18446744073709551616),
+		EndArray,
+	},
+	pointers: []Pointer{
+		"", "/0", "/1", "/2", "/3", "/4", "/5", "/6", "/7", "/8", "/9", "/10", "/11", "/12", "/13", "/14", "/15", "",
+	},
+}, {
+	name:         "ObjectN0",
+	in:           ` { } `,
+	outCompacted: `{}`,
+	tokens:       []Token{BeginObject, EndObject},
+	pointers:     []Pointer{"", ""},
+}, {
+	name:         "ObjectN1",
+	in:           ` { "0" : 0 } `,
+	outCompacted: `{"0":0}`,
+	outIndented: ` {
+	    "0": 0
+	}`,
+	tokens:   []Token{BeginObject, String("0"), Uint(0), EndObject},
+	pointers: []Pointer{"", "/0", "/0", ""},
+}, {
+	name:         "ObjectN2",
+	in:           ` { "0" : 0 , "1" : 1 } `,
+	outCompacted: `{"0":0,"1":1}`,
+	outIndented: ` {
+	    "0": 0,
+	    "1": 1
+	}`,
+	tokens:   []Token{BeginObject, String("0"), Uint(0), String("1"), Uint(1), EndObject},
+	pointers: []Pointer{"", "/0", "/0", "/1", "/1", ""},
+}, {
+	name:         "ObjectNested",
+	in:           ` { "0" : { "1" : { "2" : { "3" : { "4" : {  } } } } } } `,
+	outCompacted: `{"0":{"1":{"2":{"3":{"4":{}}}}}}`,
+	outIndented: ` {
+	    "0": {
+	        "1": {
+	            "2": {
+	                "3": {
+	                    "4": {}
+	                }
+	            }
+	        }
+	    }
+	}`,
+	tokens: []Token{
+		BeginObject,
+		String("0"),
+		BeginObject,
+		String("1"),
+		BeginObject,
+		String("2"),
+		BeginObject,
+		String("3"),
+		BeginObject,
+		String("4"),
+		BeginObject,
+		EndObject,
+		EndObject,
+		EndObject,
+		EndObject,
+		EndObject,
+		EndObject,
+	},
+	pointers: []Pointer{
+		"",
+		"/0", "/0",
+		"/0/1", "/0/1",
+		"/0/1/2", "/0/1/2",
+		"/0/1/2/3", "/0/1/2/3",
+		"/0/1/2/3/4", "/0/1/2/3/4",
+		"/0/1/2/3/4",
+		"/0/1/2/3",
+		"/0/1/2",
+		"/0/1",
+		"/0",
+		"",
+	},
+}, {
+	name: "ObjectSuperNested",
+	in: `{"": {
+		"44444": {
+			"6666666":  "ccccccc",
+			"77777777": "bb",
+			"555555":   "aaaa"
+		},
+		"0": {
+			"3333": "bbb",
+			"11": "",
+			"222": "aaaaa"
+		}
+	}}`,
+	outCompacted: `{"":{"44444":{"6666666":"ccccccc","77777777":"bb","555555":"aaaa"},"0":{"3333":"bbb","11":"","222":"aaaaa"}}}`,
+	outIndented: `{
+	    "": {
+	        "44444": {
+	            "6666666": "ccccccc",
+	            "77777777": "bb",
+	            "555555": "aaaa"
+	        },
+	        "0": {
+	            "3333": "bbb",
+	            "11": "",
+	            "222": "aaaaa"
+	        }
+	    }
+	}`,
+	outCanonicalized: `{"":{"0":{"11":"","222":"aaaaa","3333":"bbb"},"44444":{"555555":"aaaa","6666666":"ccccccc","77777777":"bb"}}}`,
+	tokens: []Token{
+		BeginObject,
+		String(""),
+		BeginObject,
+		String("44444"),
+		BeginObject,
+		String("6666666"), String("ccccccc"),
+		String("77777777"), String("bb"),
+		String("555555"), String("aaaa"),
+		EndObject,
+		String("0"),
+		BeginObject,
+		String("3333"), String("bbb"),
+		String("11"), String(""),
+		String("222"), String("aaaaa"),
+		EndObject,
+		EndObject,
+		EndObject,
+	},
+	pointers: []Pointer{
+		"",
+		"/", "/",
+		"//44444", "//44444",
+		"//44444/6666666", "//44444/6666666",
+		"//44444/77777777", "//44444/77777777",
+		"//44444/555555", "//44444/555555",
+		"//44444",
+		"//0", "//0",
+		"//0/3333", "//0/3333",
+		"//0/11", "//0/11",
+		"//0/222", "//0/222",
+		"//0",
+		"/",
+		"",
+	},
+}, {
+	name:         "ArrayN0",
+	in:           ` [ ] `,
+	outCompacted: `[]`,
+	tokens:       []Token{BeginArray, EndArray},
+	pointers:     []Pointer{"", ""},
+}, {
+	name:         "ArrayN1",
+	in:           ` [ 0 ] `,
+	outCompacted: `[0]`,
+	outIndented: ` [
+	    0
+	]`,
+	tokens:   []Token{BeginArray, Uint(0), EndArray},
+	pointers: []Pointer{"", "/0", ""},
+}, {
+	name:         "ArrayN2",
+	in:           ` [ 0 , 1 ] `,
+	outCompacted: `[0,1]`,
+	outIndented: ` [
+	    0,
+	    1
+	]`,
+	tokens: []Token{BeginArray, Uint(0), Uint(1), EndArray},
+}, {
+	name:         "ArrayNested",
+	in:           ` [ [ [ [ [ ] ] ] ] ] `,
+	outCompacted: `[[[[[]]]]]`,
+	outIndented: ` [
+	    [
+	        [
+	            [
+	                []
+	            ]
+	        ]
+	    ]
+	]`,
+	tokens: []Token{
+		BeginArray,
+		BeginArray,
+		BeginArray,
+		BeginArray,
+		BeginArray,
+		EndArray,
+		EndArray,
+		EndArray,
+		EndArray,
+		EndArray,
+	},
+	pointers: []Pointer{
+		"",
+		"/0",
+		"/0/0",
+		"/0/0/0",
+		"/0/0/0/0",
+		"/0/0/0/0",
+		"/0/0/0",
+		"/0/0",
+		"/0",
+		"",
+	},
+}, {
+	name: "Everything",
+	in: ` {
+		"literals" : [ null , false , true ],
+		"string" : "Hello, 世界" ,
+		"number" : 3.14159 ,
+		"arrayN0" : [ ] ,
+		"arrayN1" : [ 0 ] ,
+		"arrayN2" : [ 0 , 1 ] ,
+		"objectN0" : { } ,
+		"objectN1" : { "0" : 0 } ,
+		"objectN2" : { "0" : 0 , "1" : 1 }
+	} `,
+	outCompacted: `{"literals":[null,false,true],"string":"Hello, 世界","number":3.14159,"arrayN0":[],"arrayN1":[0],"arrayN2":[0,1],"objectN0":{},"objectN1":{"0":0},"objectN2":{"0":0,"1":1}}`,
+	outIndented: ` {
+	    "literals": [
+	        null,
+	        false,
+	        true
+	    ],
+	    "string": "Hello, 世界",
+	    "number": 3.14159,
+	    "arrayN0": [],
+	    "arrayN1": [
+	        0
+	    ],
+	    "arrayN2": [
+	        0,
+	        1
+	    ],
+	    "objectN0": {},
+	    "objectN1": {
+	        "0": 0
+	    },
+	    "objectN2": {
+	        "0": 0,
+	        "1": 1
+	    }
+	}`,
+	outCanonicalized: `{"arrayN0":[],"arrayN1":[0],"arrayN2":[0,1],"literals":[null,false,true],"number":3.14159,"objectN0":{},"objectN1":{"0":0},"objectN2":{"0":0,"1":1},"string":"Hello, 世界"}`,
+	tokens: []Token{
+		BeginObject,
+		String("literals"), BeginArray, Null, False, True, EndArray,
+		String("string"), String("Hello, 世界"),
+		String("number"), Float(3.14159),
+		String("arrayN0"), BeginArray, EndArray,
+		String("arrayN1"), BeginArray, Uint(0), EndArray,
+		String("arrayN2"), BeginArray, Uint(0), Uint(1), EndArray,
+		String("objectN0"), BeginObject, EndObject,
+		String("objectN1"), BeginObject, String("0"), Uint(0), EndObject,
+		String("objectN2"), BeginObject, String("0"), Uint(0), String("1"), Uint(1), EndObject,
+		EndObject,
+	},
+	pointers: []Pointer{
+		"",
+		"/literals", "/literals",
+		"/literals/0",
+		"/literals/1",
+		"/literals/2",
+		"/literals",
+		"/string", "/string",
+		"/number", "/number",
+		"/arrayN0", "/arrayN0", "/arrayN0",
+		"/arrayN1", "/arrayN1",
+		"/arrayN1/0",
+		"/arrayN1",
+		"/arrayN2", "/arrayN2",
+		"/arrayN2/0",
+		"/arrayN2/1",
+		"/arrayN2",
+		"/objectN0", "/objectN0", "/objectN0",
+		"/objectN1", "/objectN1",
+		"/objectN1/0", "/objectN1/0",
+		"/objectN1",
+		"/objectN2", "/objectN2",
+		"/objectN2/0", "/objectN2/0",
+		"/objectN2/1", "/objectN2/1",
+		"/objectN2",
+		"",
+	},
+}}
+
+// TestCoderInterleaved tests that we can interleave calls that operate on
+// tokens and raw values. The only error condition is trying to operate on a
+// raw value when the next token is an end of object or array.
+func TestCoderInterleaved(t *testing.T) {
+	for _, td := range coderTestdata {
+		// In TokenFirst and ValueFirst, alternate between tokens and values.
+		// In TokenDelims, only use tokens for object and array delimiters.
+		for _, modeName := range []string{"TokenFirst", "ValueFirst", "TokenDelims"} {
+			t.Run(td.name+"/"+modeName, func(t *testing.T) {
+				testCoderInterleaved(t, modeName, td)
+			})
+		}
+	}
+}
+func testCoderInterleaved(t *testing.T, modeName string, td coderTestdataEntry) {
+	src := strings.NewReader(td.in)
+	dst := new(bytes.Buffer)
+	dec := NewDecoder(src)
+	enc := NewEncoder(dst)
+	tickTock := modeName == "TokenFirst"
+	for {
+		if modeName == "TokenDelims" {
+			switch dec.PeekKind() {
+			case '{', '}', '[', ']':
+				tickTock = true // as token
+			default:
+				tickTock = false // as value
+			}
+		}
+		if tickTock {
+			tok, err := dec.ReadToken()
+			if err != nil {
+				if err == io.EOF {
+					break
+				}
+				t.Fatalf("Decoder.ReadToken error: %v", err)
+			}
+			if err := enc.WriteToken(tok); err != nil {
+				t.Fatalf("Encoder.WriteToken error: %v", err)
+			}
+		} else {
+			val, err := dec.ReadValue()
+			if err != nil {
+				// It is a syntactic error to call ReadValue
+				// at the end of an object or array.
+				// Retry as a ReadToken call.
+				expectError := dec.PeekKind() == '}' || dec.PeekKind() == ']'
+				if expectError {
+					if !errors.As(err, new(*SyntacticError)) {
+						t.Fatalf("Decoder.ReadToken error is %T, want %T", err, new(SyntacticError))
+					}
+					tickTock = !tickTock
+					continue
+				}
+
+				if err == io.EOF {
+					break
+				}
+				t.Fatalf("Decoder.ReadValue error: %v", err)
+			}
+			if err := enc.WriteValue(val); err != nil {
+				t.Fatalf("Encoder.WriteValue error: %v", err)
+			}
+		}
+		tickTock = !tickTock
+	}
+
+	got := dst.String()
+	want := td.outCompacted + "\n"
+	if got != want {
+		t.Fatalf("output mismatch:\ngot  %v\nwant %v", got, want)
+	}
+}
+
+// TestCoderFormat tests the whitespace and escaping options of the encoder.
+func TestCoderFormat(t *testing.T) {
+	for _, td := range coderTestdata {
+		t.Run(td.name, func(t *testing.T) {
+			check := func(name, want string, opts ...Options) {
+				t.Helper()
+				got, err := AppendFormat(nil, []byte(td.in), opts...)
+				if err != nil {
+					t.Fatalf("%s: AppendFormat error: %v", name, err)
+				}
+				if string(got) != want {
+					t.Errorf("%s: output mismatch:\ngot  %s\nwant %s", name, got, want)
+				}
+			}
+			check("Compacted", td.outCompacted)
+
+			if td.outIndented != "" {
+				want := strings.TrimPrefix(td.outIndented, " ")
+				want = strings.ReplaceAll(want, "\n\t", "\n")
+				want = strings.ReplaceAll(want, "    ", "\t")
+				check("Indented", want, Multiline(true))
+			}
+
+			want := td.outCanonicalized
+			if want == "" {
+				want = td.outCompacted
+			}
+			v := Value(td.in)
+			if err := v.Canonicalize(); err != nil {
+				t.Fatalf("Value.Canonicalize error: %v", err)
+			}
+			if string(v) != want {
+				t.Errorf("Canonicalized: output mismatch:\ngot  %s\nwant %s", v, want)
+			}
+		})
+	}
+}
+
+// TestCoderStackPointer tests that the stack pointer reported by the encoder
+// and decoder match after each token.
+func TestCoderStackPointer(t *testing.T) {
+	for _, td := range coderTestdata {
+		if len(td.pointers) == 0 {
+			continue
+		}
+		t.Run(td.name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(td.in))
+			enc := NewEncoder(new(bytes.Buffer))
+			for i := 0; ; i++ {
+				tok, err := dec.ReadToken()
+				if err != nil {
+					if err == io.EOF {
+						break
+					}
+					t.Fatalf("Decoder.ReadToken error: %v", err)
+				}
+				if got := dec.StackPointer(); got != td.pointers[i] {
+					t.Fatalf("%d: Decoder.StackPointer = %q, want %q", i, got, td.pointers[i])
+				}
+				if err := enc.WriteToken(tok); err != nil {
+					t.Fatalf("Encoder.WriteToken error: %v", err)
+				}
+				if got := enc.StackPointer(); got != td.pointers[i] {
+					t.Fatalf("%d: Encoder.StackPointer = %q, want %q", i, got, td.pointers[i])
+				}
+			}
+		})
+	}
+}
+
+// TestCoderTokens tests that the decoder produces the expected tokens and
+// that the encoder can reproduce the compacted output from those tokens.
+func TestCoderTokens(t *testing.T) {
+	for _, td := range coderTestdata {
+		t.Run(td.name, func(t *testing.T) {
+			// Use a one-byte reader to exercise the buffer refill logic.
+			dec := NewDecoder(iotest.OneByteReader(strings.NewReader(td.in)))
+			var gotToks []Token
+			for {
+				tok, err := dec.ReadToken()
+				if err != nil {
+					if err == io.EOF {
+						break
+					}
+					t.Fatalf("Decoder.ReadToken error: %v", err)
+				}
+				gotToks = append(gotToks, tok.Clone())
+			}
+			if len(gotToks) != len(td.tokens) {
+				t.Fatalf("got %d tokens, want %d", len(gotToks), len(td.tokens))
+			}
+			for i, got := range gotToks {
+				want := td.tokens[i]
+				if got.Kind() != want.Kind() {
+					t.Fatalf("%d: Token.Kind = %v, want %v", i, got.Kind(), want.Kind())
+				}
+				switch got.Kind() {
+				case '"':
+					if got.String() != want.String() {
+						t.Errorf("%d: Token.String = %q, want %q", i, got.String(), want.String())
+					}
+				case '0':
+					if g, w := got.Float(), want.Float(); g != w && !(math.IsNaN(g) && math.IsNaN(w)) {
+						t.Errorf("%d: Token.Float = %v, want %v", i, g, w)
+					}
+				}
+			}
+
+			// Encode the tokens, which should produce the compacted output.
+			dst := new(bytes.Buffer)
+			enc := NewEncoder(dst)
+			for _, tok := range td.tokens {
+				if err := enc.WriteToken(tok); err != nil {
+					t.Fatalf("Encoder.WriteToken error: %v", err)
+				}
+			}
+			want := td.outCompacted
+			if td.name == "Numbers" {
+				want = "" // exact floats are formatted differently from the raw input
+			}
+			if got := strings.TrimSuffix(dst.String(), "\n"); want != "" && got != want {
+				t.Errorf("output mismatch:\ngot  %s\nwant %s", got, want)
+			}
+		})
+	}
+}
+
+func TestCoderMaxDepth(t *testing.T) {
+	trimArray := func(b []byte) []byte { return b[len(`[`) : len(b)-len(`]`)] }
+	maxArrays := []byte(strings.Repeat(`[`, maxNestingDepth+1) + strings.Repeat(`]`, maxNestingDepth+1))
+	trimObject := func(b []byte) []byte { return b[len(`{"":`) : len(b)-len(`}`)] }
+	maxObjects := []byte(strings.Repeat(`{"":`, maxNestingDepth+1) + `""` + strings.Repeat(`}`, maxNestingDepth+1))
+
+	t.Run("Decoder", func(t *testing.T) {
+		var dec Decoder
+		checkReadToken := func(t *testing.T, wantKind Kind, wantErr error) {
+			t.Helper()
+			if tok, err := dec.ReadToken(); tok.Kind() != wantKind || !equalError(err, wantErr) {
+				t.Fatalf("Decoder.ReadToken = (%q, %v), want (%q, %v)", byte(tok.Kind()), err, byte(wantKind), wantErr)
+			}
+		}
+		checkReadValue := func(t *testing.T, wantLen int, wantErr error) {
+			t.Helper()
+			if val, err := dec.ReadValue(); len(val) != wantLen || !equalError(err, wantErr) {
+				t.Fatalf("Decoder.ReadValue = (%d, %v), want (%d, %v)", len(val), err, wantLen, wantErr)
+			}
+		}
+
+		t.Run("ArraysValid/SingleValue", func(t *testing.T) {
+			dec.s.reset(trimArray(maxArrays), nil)
+			checkReadValue(t, maxNestingDepth*len(`[]`), nil)
+		})
+		t.Run("ArraysValid/TokenThenValue", func(t *testing.T) {
+			dec.s.reset(trimArray(maxArrays), nil)
+			checkReadToken(t, '[', nil)
+			checkReadValue(t, (maxNestingDepth-1)*len(`[]`), nil)
+			checkReadToken(t, ']', nil)
+		})
+		t.Run("ArraysValid/AllTokens", func(t *testing.T) {
+			dec.s.reset(trimArray(maxArrays), nil)
+			for i := 0; i < maxNestingDepth; i++ {
+				checkReadToken(t, '[', nil)
+			}
+			for i := 0; i < maxNestingDepth; i++ {
+				checkReadToken(t, ']', nil)
+			}
+		})
+
+		wantErr := &SyntacticError{
+			ByteOffset:  maxNestingDepth,
+			JSONPointer: Pointer(strings.Repeat("/0", maxNestingDepth)),
+			Err:         errMaxDepth,
+		}
+		t.Run("ArraysInvalid/SingleValue", func(t *testing.T) {
+			dec.s.reset(maxArrays, nil)
+			checkReadValue(t, 0, wantErr)
+		})
+		t.Run("ArraysInvalid/TokenThenValue", func(t *testing.T) {
+			dec.s.reset(maxArrays, nil)
+			checkReadToken(t, '[', nil)
+			checkReadValue(t, 0, wantErr)
+		})
+		t.Run("ArraysInvalid/AllTokens", func(t *testing.T) {
+			dec.s.reset(maxArrays, nil)
+			for i := 0; i < maxNestingDepth; i++ {
+				checkReadToken(t, '[', nil)
+			}
+			checkReadValue(t, 0, wantErr)
+		})
+
+		t.Run("ObjectsValid/SingleValue", func(t *testing.T) {
+			dec.s.reset(trimObject(maxObjects), nil)
+			checkReadValue(t, maxNestingDepth*len(`{"":}`)+len(`""`), nil)
+		})
+		t.Run("ObjectsValid/AllTokens", func(t *testing.T) {
+			dec.s.reset(trimObject(maxObjects), nil)
+			for i := 0; i < maxNestingDepth; i++ {
+				checkReadToken(t, '{', nil)
+				checkReadToken(t, '"', nil)
+			}
+			checkReadToken(t, '"', nil)
+			for i := 0; i < maxNestingDepth; i++ {
+				checkReadToken(t, '}', nil)
+			}
+		})
+
+		wantErr = &SyntacticError{
+			ByteOffset:  maxNestingDepth * int64(len(`{"":`)),
+			JSONPointer: Pointer(strings.Repeat("/", maxNestingDepth)),
+			Err:         errMaxDepth,
+		}
+		t.Run("ObjectsInvalid/SingleValue", func(t *testing.T) {
+			dec.s.reset(maxObjects, nil)
+			checkReadValue(t, 0, wantErr)
+		})
+		t.Run("ObjectsInvalid/AllTokens", func(t *testing.T) {
+			dec.s.reset(maxObjects, nil)
+			for i := 0; i < maxNestingDepth; i++ {
+				checkReadToken(t, '{', nil)
+				checkReadToken(t, '"', nil)
+			}
+			checkReadToken(t, 0, wantErr)
+		})
+	})
+
+	t.Run("Encoder", func(t *testing.T) {
+		var enc Encoder
+		checkWriteToken := func(t *testing.T, tok Token, wantErr error) {
+			t.Helper()
+			if err := enc.WriteToken(tok); !equalError(err, wantErr) {
+				t.Fatalf("Encoder.WriteToken = %v, want %v", err, wantErr)
+			}
+		}
+		checkWriteValue := func(t *testing.T, val Value, wantErr error) {
+			t.Helper()
+			if err := enc.WriteValue(val); !equalError(err, wantErr) {
+				t.Fatalf("Encoder.WriteValue = %v, want %v", err, wantErr)
+			}
+		}
+
+		wantErr := &SyntacticError{
+			ByteOffset:  maxNestingDepth,
+			JSONPointer: Pointer(strings.Repeat("/0", maxNestingDepth)),
+			Err:         errMaxDepth,
+		}
+		t.Run("Arrays/SingleValue", func(t *testing.T) {
+			enc.s.reset(enc.s.Buf[:0], nil)
+			checkWriteValue(t, maxArrays, wantErr)
+			checkWriteValue(t, trimArray(maxArrays), nil)
+		})
+		t.Run("Arrays/AllTokens", func(t *testing.T) {
+			enc.s.reset(enc.s.Buf[:0], nil)
+			for i := 0; i < maxNestingDepth; i++ {
+				checkWriteToken(t, BeginArray, nil)
+			}
+			checkWriteToken(t, BeginArray, wantErr)
+			for i := 0; i < maxNestingDepth; i++ {
+				checkWriteToken(t, EndArray, nil)
+			}
+		})
+
+		wantErr = &SyntacticError{
+			ByteOffset:  maxNestingDepth * int64(len(`{"":`)),
+			JSONPointer: Pointer(strings.Repeat("/", maxNestingDepth)),
+			Err:         errMaxDepth,
+		}
+		t.Run("Objects/SingleValue", func(t *testing.T) {
+			enc.s.reset(enc.s.Buf[:0], nil)
+			checkWriteValue(t, maxObjects, wantErr)
+			checkWriteValue(t, trimObject(maxObjects), nil)
+		})
+		t.Run("Objects/AllTokens", func(t *testing.T) {
+			enc.s.reset(enc.s.Buf[:0], nil)
+			for i := 0; i < maxNestingDepth; i++ {
+				checkWriteToken(t, BeginObject, nil)
+				checkWriteToken(t, String(""), nil)
+			}
+			checkWriteToken(t, BeginObject, wantErr)
+			checkWriteToken(t, String(""), nil)
+			for i := 0; i < maxNestingDepth; i++ {
+				checkWriteToken(t, EndObject, nil)
+			}
+		})
+	})
+}
+
+// equalError reports whether two errors are equal,
+// comparing SyntacticError by value.
+func equalError(x, y error) bool {
+	return reflect.DeepEqual(x, y)
+}
@@ -0,0 +1,98 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.jsonv2
+
+package jsontext
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPointerTokens(t *testing.T) {
+	// TODO(https://go.dev/issue/61899): Use slices.Collect.
+	collect := func(p Pointer) (out []string) {
+		for tok := range p.Tokens() {
+			out = append(out, tok)
+		}
+		return out
+	}
+
+	tests := []struct {
+		in   Pointer
+		want []string
+	}{
+		{in: "", want: nil},
+		{in: "a", want: []string{"a"}}, // invalid JSON Pointer, but handled
+		{in: "/a", want: []string{"a"}},
+		{in: "//", want: []string{"", ""}},
+		{in: "/a/b/c", want: []string{"a", "b", "c"}},
+		{in: "/a/~0/~1/~01", want: []string{"a", "~", "/", "~1"}},
+		{in: "/\xff", want: []string{"\xff"}}, // invalid UTF-8, but handled
+	}
+	for _, tt := range tests {
+		if got := collect(tt.in); !slices.Equal(got, tt.want) {
+			t.Errorf("Pointer(%q).Tokens = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPointerMethods(t *testing.T) {
+	tests := []struct {
+		in        Pointer
+		valid     bool
+		parent    Pointer
+		lastToken string
+	}{
+		{in: "", valid: true, parent: "", lastToken: ""},
+		{in: "/", valid: true, parent: "", lastToken: ""},
+		{in: "/a", valid: true, parent: "", lastToken: "a"},
+		{in: "/a/b", valid: true, parent: "/a", lastToken: "b"},
+		{in: "/a/~1", valid: true, parent: "/a", lastToken: "/"},
+		{in: "a", valid: false},
+		{in: "/~", valid: false},
+		{in: "/~2", valid: false},
+		{in: "/\xff", valid: false},
+	}
+	for _, tt := range tests {
+		if got := tt.in.IsValid(); got != tt.valid {
+			t.Errorf("Pointer(%q).IsValid = %v, want %v", tt.in, got, tt.valid)
+		}
+		if !tt.valid {
+			continue
+		}
+		if got := tt.in.Parent(); got != tt.parent {
+			t.Errorf("Pointer(%q).Parent = %q, want %q", tt.in, got, tt.parent)
+		}
+		if got := tt.in.LastToken(); got != tt.lastToken {
+			t.Errorf("Pointer(%q).LastToken = %q, want %q", tt.in, got, tt.lastToken)
+		}
+		if tt.in != "" {
+			if got := tt.parent.AppendToken(tt.lastToken); got != tt.in {
+				t.Errorf("Pointer(%q).AppendToken(%q) = %q, want %q", tt.parent, tt.lastToken, got, tt.in)
+			}
+		}
+	}
+}
+
+func TestPointerContains(t *testing.T) {
+	tests := []struct {
+		parent, child Pointer
+		want          bool
+	}{
+		{"", "", true},
+		{"", "/a", true},
+		{"/a", "/a", true},
+		{"/a", "/a/b", true},
+		{"/a", "/ab", false},
+		{"/a/b", "/a", false},
+		{"/a/b", "/a/c", false},
+	}
+	for _, tt := range tests {
+		if got := tt.parent.Contains(tt.child); got != tt.want {
+			t.Errorf("Pointer(%q).Contains(%q) = %v, want %v", tt.parent, tt.child, got, tt.want)
+		}
+	}
+}
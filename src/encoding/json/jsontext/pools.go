@@ -0,0 +1,93 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.jsonv2
+
+package jsontext
+
+import (
+	"io"
+	"sync"
+)
+
+// TODO(https://go.dev/issue/47657): Use sync.PoolOf.
+
+var (
+	// This owns the internal buffer since there is no io.Writer to output to.
+	// Since the buffer can get arbitrarily large in normal usage,
+	// buffers beyond a certain size are not recycled.
+	bufferedEncoderPool = &sync.Pool{New: func() any { return new(Encoder) }}
+
+	// This owns the internal buffer, but it is only used to temporarily store
+	// buffered JSON before flushing it to the underlying io.Writer.
+	// In a sufficiently efficient streaming mode, we do not expect the buffer
+	// to grow arbitrarily large. Thus, we avoid recycling large buffers.
+	streamingEncoderPool = &sync.Pool{New: func() any { return new(Encoder) }}
+
+	// This does not own the internal buffer since
+	// it is provided by the caller.
+	bufferedDecoderPool = &sync.Pool{New: func() any { return new(Decoder) }}
+
+	// This owns the internal buffer, but it is only used to temporarily store
+	// buffered JSON fetched from the underlying io.Reader.
+	// In a sufficiently efficient streaming mode, we do not expect the buffer
+	// to grow arbitrarily large. Thus, we avoid recycling large buffers.
+	streamingDecoderPool = &sync.Pool{New: func() any { return new(Decoder) }}
+)
+
+// maxPooledBufferSize is the largest internal buffer that is recycled.
+const maxPooledBufferSize = 64 << 10
+
+func getBufferedEncoder(opts ...Options) *Encoder {
+	e := bufferedEncoderPool.Get().(*Encoder)
+	if e.s.Buf == nil {
+		e.s.Buf = make([]byte, 0, 1024)
+	}
+	e.s.reset(e.s.Buf[:0], nil, opts...)
+	return e
+}
+func putBufferedEncoder(e *Encoder) {
+	if cap(e.s.Buf) > maxPooledBufferSize {
+		e.s.Buf = nil // avoid pinning arbitrarily large amounts of memory
+	}
+	e.s.SeenPointers = nil
+	bufferedEncoderPool.Put(e)
+}
+
+func getStreamingEncoder(w io.Writer, opts ...Options) *Encoder {
+	e := streamingEncoderPool.Get().(*Encoder)
+	e.s.reset(e.s.Buf[:0], w, opts...)
+	return e
+}
+func putStreamingEncoder(e *Encoder) {
+	if cap(e.s.Buf) > maxPooledBufferSize {
+		e.s.Buf = nil // avoid pinning arbitrarily large amounts of memory
+	}
+	e.s.wr = nil
+	e.s.SeenPointers = nil
+	streamingEncoderPool.Put(e)
+}
+
+func getBufferedDecoder(b []byte, opts ...Options) *Decoder {
+	d := bufferedDecoderPool.Get().(*Decoder)
+	d.s.reset(b, nil, opts...)
+	return d
+}
+func putBufferedDecoder(d *Decoder) {
+	d.s.buf = nil // avoid pinning the caller's buffer
+	bufferedDecoderPool.Put(d)
+}
+
+func getStreamingDecoder(r io.Reader, opts ...Options) *Decoder {
+	d := streamingDecoderPool.Get().(*Decoder)
+	d.s.reset(d.s.buf[:0], r, opts...)
+	return d
+}
+func putStreamingDecoder(d *Decoder) {
+	if cap(d.s.buf) > maxPooledBufferSize {
+		d.s.buf = nil // avoid pinning arbitrarily large amounts of memory
+	}
+	d.s.rd = nil
+	streamingDecoderPool.Put(d)
+}
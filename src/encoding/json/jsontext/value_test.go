@@ -0,0 +1,148 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.jsonv2
+
+package jsontext
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"encoding/json/internal/jsonwire"
+)
+
+func TestValueMethods(t *testing.T) {
+	tests := []struct {
+		in            Value
+		wantKind      Kind
+		wantValid     bool
+		wantCompacted Value
+		wantIndented  Value
+	}{
+		{in: nil, wantKind: 0, wantValid: false},
+		{in: Value(" null "), wantKind: 'n', wantValid: true, wantCompacted: Value("null"), wantIndented: Value("null")},
+		{in: Value(`"A"`), wantKind: '"', wantValid: true, wantCompacted: Value(`"A"`), wantIndented: Value(`"A"`)},
+		// Compact and Indent permit duplicate names and invalid UTF-8,
+		// but such values are not valid.
+		{in: Value(`{"a":1,"a":2}`), wantKind: '{', wantValid: false, wantCompacted: Value(`{"a":1,"a":2}`), wantIndented: Value("{\n\t\"a\": 1,\n\t\"a\": 2\n}")},
+		{in: Value(" [ 1 , 2 ] "), wantKind: '[', wantValid: true, wantCompacted: Value("[1,2]"), wantIndented: Value("[\n\t1,\n\t2\n]")},
+		{in: Value(`[1,]`), wantKind: '[', wantValid: false},
+		{in: Value(`[1 2]`), wantKind: '[', wantValid: false},
+	}
+	for _, tt := range tests {
+		if got := tt.in.Kind(); got != tt.wantKind {
+			t.Errorf("Value(%q).Kind = %v, want %v", tt.in, got, tt.wantKind)
+		}
+		if got := tt.in.IsValid(); got != tt.wantValid {
+			t.Errorf("Value(%q).IsValid = %v, want %v", tt.in, got, tt.wantValid)
+		}
+		if tt.wantCompacted == nil {
+			v := tt.in.Clone()
+			if err := v.Compact(); err == nil {
+				t.Errorf("Value(%q).Compact error is nil, want non-nil", tt.in)
+			}
+			continue
+		}
+
+		v := tt.in.Clone()
+		if err := v.Compact(); err != nil || string(v) != string(tt.wantCompacted) {
+			t.Errorf("Value(%q).Compact = (%q, %v), want (%q, nil)", tt.in, v, err, tt.wantCompacted)
+		}
+		v = tt.in.Clone()
+		if err := v.Indent(); err != nil || string(v) != string(tt.wantIndented) {
+			t.Errorf("Value(%q).Indent = (%q, %v), want (%q, nil)", tt.in, v, err, tt.wantIndented)
+		}
+	}
+}
+
+func TestDecoderErrors(t *testing.T) {
+	tests := []struct {
+		in      string
+		opts    []Options
+		wantErr error
+	}{{
+		in:      ``,
+		wantErr: io.EOF,
+	}, {
+		in:      `[1,2`,
+		wantErr: &SyntacticError{ByteOffset: 4, JSONPointer: "", Err: io.ErrUnexpectedEOF},
+	}, {
+		in:      `{"a":1,"b"`,
+		wantErr: &SyntacticError{ByteOffset: 10, JSONPointer: "/b", Err: io.ErrUnexpectedEOF},
+	}, {
+		in:      `{"a":1,"a":2}`,
+		wantErr: &SyntacticError{ByteOffset: 7, JSONPointer: "/a", Err: ErrDuplicateName},
+	}, {
+		in:   `{"a":1,"a":2}`,
+		opts: []Options{AllowDuplicateNames(true)},
+	}, {
+		in:      `{1:2}`,
+		wantErr: &SyntacticError{ByteOffset: 1, JSONPointer: "", Err: jsonwire.NewInvalidCharacterError("1", "at start of string (expecting '\"')")},
+	}, {
+		in:      `[1 2]`,
+		wantErr: &SyntacticError{ByteOffset: 3, JSONPointer: "", Err: jsonwire.NewInvalidCharacterError("2", "after array element (expecting ',' or ']')")},
+	}, {
+		in:      "\"\xff\"",
+		wantErr: &SyntacticError{ByteOffset: 1, JSONPointer: "", Err: jsonwire.ErrInvalidUTF8},
+	}, {
+		in:   "\"\xff\"",
+		opts: []Options{AllowInvalidUTF8(true)},
+	}}
+	for _, tt := range tests {
+		dec := NewDecoder(strings.NewReader(tt.in), tt.opts...)
+		_, err := dec.ReadValue()
+		if err == nil {
+			err = dec.s.CheckEOF()
+		}
+		if !equalError(err, tt.wantErr) {
+			t.Errorf("ReadValue(%q) error:\ngot  %v\nwant %v", tt.in, err, tt.wantErr)
+		}
+		if err != nil && tt.wantErr != io.EOF && !errors.As(err, new(*SyntacticError)) {
+			t.Errorf("ReadValue(%q) error is %T, want *SyntacticError", tt.in, err)
+		}
+	}
+}
+
+func TestEncoderErrors(t *testing.T) {
+	var sb strings.Builder
+	enc := NewEncoder(&sb)
+	for _, tok := range []Token{BeginObject, String("a"), Null} {
+		if err := enc.WriteToken(tok); err != nil {
+			t.Fatalf("WriteToken(%v) error: %v", tok, err)
+		}
+	}
+	err := enc.WriteToken(String("a"))
+	want := &SyntacticError{ByteOffset: 10, JSONPointer: "/a", Err: ErrDuplicateName}
+	if !equalError(err, want) {
+		t.Errorf("WriteToken error:\ngot  %v\nwant %v", err, want)
+	}
+	if got, want := err.Error(), `jsontext: duplicate object member name "a"`; got != want {
+		t.Errorf("error text = %q, want %q", got, want)
+	}
+
+	if err := enc.WriteToken(EndArray); err == nil {
+		t.Errorf("WriteToken(EndArray) error is nil, want non-nil")
+	}
+	if err := enc.WriteToken(EndObject); err != nil {
+		t.Fatalf("WriteToken(EndObject) error: %v", err)
+	}
+	if got, want := sb.String(), `{"a":null}`+"\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestDecoderNonStringName(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{1:2}`))
+	if _, err := dec.ReadToken(); err != nil {
+		t.Fatalf("ReadToken error: %v", err)
+	}
+	_, err := dec.ReadToken()
+	want := &SyntacticError{ByteOffset: 1, JSONPointer: "", Err: ErrNonStringName}
+	if !equalError(err, want) {
+		t.Errorf("ReadToken error:\ngot  %v\nwant %v", err, want)
+	}
+}
@@ -0,0 +1,485 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.jsonv2
+
+package json
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"encoding/json/jsontext"
+)
+
+type (
+	structScalars struct {
+		Bool    bool
+		String  string
+		Int     int64
+		Uint    uint64
+		Float   float64
+		private int
+	}
+	structTagged struct {
+		Renamed  string `json:"renamed"`
+		Ignored  string `json:"-"`
+		OmitZero int    `json:",omitzero"`
+		OmitEmp  []int  `json:",omitempty"`
+		Number   int    `json:",string"`
+		Nocase   string `json:"nocase,case:ignore"`
+	}
+	structFormat struct {
+		Bytes    []byte         `json:",format:hex"`
+		Float    float64        `json:",format:nonfinite"`
+		Map      map[string]int `json:",format:emitnull"`
+		Slice    []int          `json:",format:emitnull"`
+		Duration time.Duration  `json:",format:nano"`
+		Time     time.Time      `json:",format:unix"`
+	}
+	structIsZero struct {
+		Value valueIsZero `json:",omitzero"`
+	}
+	valueIsZero struct{ N int }
+	structEmbed struct {
+		structScalars
+		Extra string
+	}
+	structCycle struct {
+		Next *structCycle
+	}
+	structInlined struct {
+		A       int
+		Inner   structInlinedInner        `json:",inline"`
+		Unknown map[string]jsontext.Value `json:",inline"`
+	}
+	structInlinedInner struct {
+		B int
+	}
+)
+
+func (v valueIsZero) IsZero() bool { return v.N < 0 }
+
+type marshalerText string
+
+func (s marshalerText) MarshalText() ([]byte, error) { return []byte("text:" + s), nil }
+
+type marshalerJSON struct{ V int }
+
+func (m marshalerJSON) MarshalJSON() ([]byte, error) { return []byte(strconv.Itoa(m.V)), nil }
+func (m *marshalerJSON) UnmarshalJSON(b []byte) error {
+	v, err := strconv.Atoi(string(b))
+	m.V = v
+	return err
+}
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Options
+		in   any
+		want string
+	}{{
+		name: "Nil",
+		in:   nil,
+		want: `null`,
+	}, {
+		name: "Scalars",
+		in:   structScalars{true, "hello", -1, 2, 3.5, 0},
+		want: `{"Bool":true,"String":"hello","Int":-1,"Uint":2,"Float":3.5}`,
+	}, {
+		name: "Tagged",
+		in:   structTagged{Renamed: "a", Ignored: "b", Number: 5},
+		want: `{"renamed":"a","Number":"5","nocase":""}`,
+	}, {
+		name: "Tagged/OmitZero",
+		in:   structTagged{OmitZero: 1, OmitEmp: []int{}},
+		want: `{"renamed":"","OmitZero":1,"Number":"0","nocase":""}`,
+	}, {
+		name: "IsZero",
+		in:   []structIsZero{{valueIsZero{0}}, {valueIsZero{-1}}},
+		want: `[{"Value":{"N":0}},{}]`,
+	}, {
+		name: "Embedded",
+		in:   structEmbed{structScalars{String: "x"}, "y"},
+		want: `{"Bool":false,"String":"x","Int":0,"Uint":0,"Float":0,"Extra":"y"}`,
+	}, {
+		name: "NilSliceAndMap",
+		in: struct {
+			S []int
+			M map[string]int
+		}{},
+		want: `{"S":[],"M":{}}`,
+	}, {
+		name: "NilSliceAndMap/AsNull",
+		opts: []Options{FormatNilSliceAsNull(true), FormatNilMapAsNull(true)},
+		in: struct {
+			S []int
+			M map[string]int
+		}{},
+		want: `{"S":null,"M":null}`,
+	}, {
+		name: "Deterministic",
+		opts: []Options{Deterministic(true)},
+		in:   map[string]int{"c": 3, "a": 1, "b": 2},
+		want: `{"a":1,"b":2,"c":3}`,
+	}, {
+		name: "Deterministic/IntKeys",
+		opts: []Options{Deterministic(true)},
+		in:   map[int]bool{10: true, 2: false, 1: true},
+		want: `{"1":true,"10":true,"2":false}`,
+	}, {
+		name: "StringifyNumbers",
+		opts: []Options{StringifyNumbers(true)},
+		in:   []any{1, uint8(2), 3.5, "four", true},
+		want: `["1","2","3.5","four",true]`,
+	}, {
+		name: "Formats",
+		in: structFormat{
+			Bytes:    []byte{0xde, 0xad},
+			Float:    math.Inf(-1),
+			Duration: time.Second,
+			Time:     time.Unix(1234, 0).UTC(),
+		},
+		want: `{"Bytes":"dead","Float":"-Infinity","Map":null,"Slice":null,"Duration":1000000000,"Time":1234}`,
+	}, {
+		name: "Bytes",
+		in:   []byte("hello"),
+		want: `"aGVsbG8="`,
+	}, {
+		name: "Duration",
+		in:   90 * time.Minute,
+		want: `"1h30m0s"`,
+	}, {
+		name: "Time",
+		in:   time.Date(2000, 1, 2, 3, 4, 5, 600, time.UTC),
+		want: `"2000-01-02T03:04:05.0000006Z"`,
+	}, {
+		name: "MarshalText",
+		in:   map[marshalerText]marshalerText{"k": "v"},
+		want: `{"text:k":"text:v"}`,
+	}, {
+		name: "MarshalJSON",
+		in:   []marshalerJSON{{1}, {2}},
+		want: `[1,2]`,
+	}, {
+		name: "MarshalFunc",
+		opts: []Options{WithMarshalers(MarshalFunc(func(v int) ([]byte, error) {
+			return []byte(`"int"`), nil
+		}))},
+		in:   []any{1, "1"},
+		want: `["int","1"]`,
+	}, {
+		name: "MarshalToFunc/Skip",
+		opts: []Options{WithMarshalers(MarshalToFunc(func(enc *jsontext.Encoder, v string) error {
+			if v == "skip" {
+				return SkipFunc
+			}
+			return enc.WriteToken(jsontext.String(strings.ToUpper(v)))
+		}))},
+		in:   []string{"skip", "other"},
+		want: `["skip","OTHER"]`,
+	}, {
+		name: "JoinMarshalers",
+		opts: []Options{WithMarshalers(JoinMarshalers(
+			MarshalFunc(func(bool) ([]byte, error) { return []byte(`0`), nil }),
+			MarshalFunc(func(bool) ([]byte, error) { return []byte(`1`), nil }),
+		))},
+		in:   true,
+		want: `0`,
+	}, {
+		name: "Inline",
+		opts: []Options{Deterministic(true)},
+		in: structInlined{A: 1, Inner: structInlinedInner{2}, Unknown: map[string]jsontext.Value{
+			"z": jsontext.Value(`[]`),
+			"y": jsontext.Value(`"y"`),
+		}},
+		want: `{"A":1,"B":2,"y":"y","z":[]}`,
+	}, {
+		name: "Inline/AllowDuplicateNames",
+		opts: []Options{Deterministic(true), jsontext.AllowDuplicateNames(true), MatchCaseInsensitiveNames(true)},
+		in: structInlined{A: 1, Unknown: map[string]jsontext.Value{
+			"A": jsontext.Value(`2`),
+			"b": jsontext.Value(`3`),
+			"c": jsontext.Value(`4`),
+		}},
+		want: `{"A":1,"B":0,"c":4}`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(tt.in, tt.opts...)
+			if err != nil {
+				t.Fatalf("Marshal error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal:\n\tgot:  %s\n\twant: %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	cycle := new(structCycle)
+	cycle.Next = cycle
+	tests := []struct {
+		name    string
+		in      any
+		wantErr string
+	}{
+		{"UnsupportedType", make(chan int), "json: cannot marshal from Go chan int"},
+		{"NaN", math.NaN(), "json: cannot marshal from Go float64: unsupported value: NaN"},
+		{"InvalidUTF8", "\xff", "invalid UTF-8"},
+		{"Cycle", cycle, "encountered a cycle"},
+		{"Inline/DuplicateName", structInlined{Unknown: map[string]jsontext.Value{"A": jsontext.Value(`2`)}}, "duplicate object member name"},
+		{"Inline/InvalidType", struct {
+			X int `json:",inline"`
+		}{}, "must be a Go struct, Go map of string key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Marshal(tt.in)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Marshal error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []Options
+		in    string
+		inVal any
+		want  any
+	}{{
+		name:  "Any",
+		in:    `{"a":[true,"s",1.5,null]}`,
+		inVal: new(any),
+		want:  addr(any(map[string]any{"a": []any{true, "s", 1.5, nil}})),
+	}, {
+		name:  "Scalars",
+		in:    `{"Bool":true,"String":"hello","Int":-1,"Uint":2,"Float":3.5,"private":1}`,
+		inVal: new(structScalars),
+		want:  &structScalars{true, "hello", -1, 2, 3.5, 0},
+	}, {
+		name:  "Tagged",
+		in:    `{"renamed":"a","Ignored":"b","Number":"5","NoCase":"c"}`,
+		inVal: new(structTagged),
+		want:  &structTagged{Renamed: "a", Number: 5, Nocase: "c"},
+	}, {
+		name:  "CaseSensitive",
+		in:    `{"STRING":"x"}`,
+		inVal: new(structScalars),
+		want:  new(structScalars),
+	}, {
+		name:  "MatchCaseInsensitiveNames",
+		opts:  []Options{MatchCaseInsensitiveNames(true)},
+		in:    `{"STRING":"x"}`,
+		inVal: new(structScalars),
+		want:  &structScalars{String: "x"},
+	}, {
+		name:  "Merge",
+		in:    `{"String":"y"}`,
+		inVal: &structEmbed{structScalars{Int: 1}, "z"},
+		want:  &structEmbed{structScalars{String: "y", Int: 1}, "z"},
+	}, {
+		name:  "Map",
+		in:    `{"1":"a","2":"b"}`,
+		inVal: new(map[int]string),
+		want:  &map[int]string{1: "a", 2: "b"},
+	}, {
+		name:  "Array",
+		in:    `[1,2,3]`,
+		inVal: new([3]int),
+		want:  &[3]int{1, 2, 3},
+	}, {
+		name:  "Slice/Empty",
+		in:    `[]`,
+		inVal: new([]int),
+		want:  &[]int{},
+	}, {
+		name:  "Formats",
+		in:    `{"Bytes":"dead","Float":"NaN","Duration":1000,"Time":1.5}`,
+		inVal: new(structFormat),
+		want: &structFormat{
+			Bytes:    []byte{0xde, 0xad},
+			Float:    math.NaN(),
+			Duration: time.Microsecond,
+			Time:     time.Unix(1, 5e8),
+		},
+	}, {
+		name:  "Duration",
+		in:    `"1h30m"`,
+		inVal: new(time.Duration),
+		want:  addr(90 * time.Minute),
+	}, {
+		name:  "UnmarshalJSON",
+		in:    `[1,2]`,
+		inVal: new([]marshalerJSON),
+		want:  &[]marshalerJSON{{1}, {2}},
+	}, {
+		name: "UnmarshalFunc",
+		opts: []Options{WithUnmarshalers(UnmarshalFunc(func(b []byte, v *string) error {
+			*v = strings.ToUpper(string(b))
+			return nil
+		}))},
+		in:    `["a",1]`,
+		inVal: new([]string),
+		want:  &[]string{`"A"`, `1`},
+	}, {
+		name:  "Inline",
+		in:    `{"A":1,"B":2,"C":{"k":null},"D":3}`,
+		inVal: new(structInlined),
+		want: &structInlined{A: 1, Inner: structInlinedInner{2}, Unknown: map[string]jsontext.Value{
+			"C": jsontext.Value(`{"k":null}`),
+			"D": jsontext.Value(`3`),
+		}},
+	}, {
+		name:  "Inline/RejectUnknownMembers",
+		opts:  []Options{RejectUnknownMembers(true)},
+		in:    `{"C":true}`,
+		inVal: new(structInlined),
+		want:  &structInlined{Unknown: map[string]jsontext.Value{"C": jsontext.Value(`true`)}},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Unmarshal([]byte(tt.in), tt.inVal, tt.opts...); err != nil {
+				t.Fatalf("Unmarshal error: %v", err)
+			}
+			if !equalValues(tt.inVal, tt.want) {
+				t.Errorf("Unmarshal:\n\tgot:  %#v\n\twant: %#v", tt.inVal, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Options
+		in      string
+		inVal   any
+		wantErr error
+	}{{
+		name:    "DuplicateName",
+		in:      `{"String":"a","String":"b"}`,
+		inVal:   new(structScalars),
+		wantErr: jsontext.ErrDuplicateName,
+	}, {
+		name:    "DuplicateMapKey",
+		in:      `{"0":1,"-0":2}`,
+		inVal:   new(map[int]int),
+		wantErr: jsontext.ErrDuplicateName,
+	}, {
+		name:    "RejectUnknownMembers",
+		opts:    []Options{RejectUnknownMembers(true)},
+		in:      `{"Unknown":1}`,
+		inVal:   new(structScalars),
+		wantErr: ErrUnknownName,
+	}, {
+		name:    "IntOverflow",
+		in:      `300`,
+		inVal:   new(int8),
+		wantErr: strconv.ErrRange,
+	}, {
+		name:    "ArrayOverflow",
+		in:      `[1,2,3]`,
+		inVal:   new([2]int),
+		wantErr: errArrayOverflow,
+	}, {
+		name:    "Inline/DuplicateName",
+		in:      `{"C":1,"C":2}`,
+		inVal:   new(structInlined),
+		wantErr: jsontext.ErrDuplicateName,
+	}, {
+		name:    "TypeMismatch",
+		in:      `"x"`,
+		inVal:   new(int),
+		wantErr: nil,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Unmarshal([]byte(tt.in), tt.inVal, tt.opts...)
+			if err == nil {
+				t.Fatal("Unmarshal error is nil, want non-nil")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Unmarshal error = %v, want %v", err, tt.wantErr)
+			}
+			var serr *SemanticError
+			if tt.wantErr == nil && !errors.As(err, &serr) {
+				t.Errorf("Unmarshal error = %T, want *SemanticError", err)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	in := map[string]any{
+		"string": " <>&",
+		"number": 1e100,
+		"array":  []any{false, nil, map[string]any{}},
+	}
+	var buf bytes.Buffer
+	if err := MarshalWrite(&buf, in, Deterministic(true), jsontext.Multiline(true)); err != nil {
+		t.Fatalf("MarshalWrite error: %v", err)
+	}
+	var out any
+	if err := UnmarshalRead(&buf, &out); err != nil {
+		t.Fatalf("UnmarshalRead error: %v", err)
+	}
+	if !reflect.DeepEqual(out, any(in)) {
+		t.Errorf("round-trip mismatch:\n\tgot:  %v\n\twant: %v", out, in)
+	}
+}
+
+func addr[T any](v T) *T { return &v }
+
+// equalValues is like reflect.DeepEqual, but treats NaN as equal to itself
+// and compares time.Time values with Equal.
+func equalValues(x, y any) bool {
+	return reflect.DeepEqual(normalize(reflect.ValueOf(x)), normalize(reflect.ValueOf(y)))
+}
+
+func normalize(v reflect.Value) any {
+	switch {
+	case !v.IsValid():
+		return nil
+	case v.Type() == timeTimeType:
+		return v.Interface().(time.Time).UnixNano()
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return normalize(v.Elem())
+	case reflect.Float32, reflect.Float64:
+		if math.IsNaN(v.Float()) {
+			return "NaN"
+		}
+	case reflect.Struct:
+		var fields []any
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				fields = append(fields, normalize(v.Field(i)))
+			}
+		}
+		return fields
+	}
+	if v.CanInterface() {
+		return v.Interface()
+	}
+	return nil
+}
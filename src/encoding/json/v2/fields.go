@@ -0,0 +1,528 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.jsonv2
+
+package json
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"encoding/json/internal/jsonflags"
+	"encoding/json/internal/jsonwire"
+)
+
+type isZeroer interface {
+	IsZero() bool
+}
+
+var isZeroerType = reflect.TypeOf((*isZeroer)(nil)).Elem()
+
+type structFields struct {
+	flattened       []structField // listed in depth-first ordering
+	byActualName    map[string]*structField
+	byFoldedName    map[string][]*structField
+	inlinedFallback *structField
+}
+
+// lookup returns the field that an object member with the given name
+// is unmarshaled into under the given flags, or nil if there is none.
+func (fs *structFields) lookup(name []byte, flags *jsonflags.Flags) *structField {
+	if f := fs.byActualName[string(name)]; f != nil {
+		return f
+	}
+	for _, f := range fs.lookupByFoldedName(name) {
+		if f.matchFoldedName(flags) {
+			return f
+		}
+	}
+	return nil
+}
+
+// lookupByFoldedName looks up name by a case-insensitive match.
+func (fs *structFields) lookupByFoldedName(name []byte) []*structField {
+	return fs.byFoldedName[string(foldName(name))]
+}
+
+type structField struct {
+	id      int   // unique numeric ID in breadth-first ordering
+	index   []int // index into a struct according to reflect.Type.FieldByIndex
+	typ     reflect.Type
+	fncs    *arshaler
+	isZero  func(addressableValue) bool
+	isEmpty func(addressableValue) bool
+
+	// stringLegacy reports whether the "string" tag option applies
+	// under legacy semantics, where it only affects fields
+	// of a boolean, numeric, or string type (or a pointer to such types).
+	stringLegacy bool
+
+	fieldOptions
+}
+
+// matchFoldedName reports whether the field may match a JSON object name
+// that only differs from the field name by case.
+func (f *structField) matchFoldedName(flags *jsonflags.Flags) bool {
+	return f.casing == caseIgnore || (flags.Get(jsonflags.MatchCaseInsensitiveNames) && f.casing != caseStrict)
+}
+
+const (
+	caseIgnore = 1
+	caseStrict = 2
+)
+
+type fieldOptions struct {
+	name           string
+	hasName        bool
+	nameNeedEscape bool
+	casing         int8 // either 0, caseIgnore, or caseStrict
+	omitzero       bool
+	omitempty      bool
+	string         bool
+	inline         bool
+	format         string
+}
+
+// makeStructFields receives a Go struct type and
+// returns the list of all JSON representable fields.
+// The errors reported are to be reported only under v2 semantics
+// since v1 silently ignores malformed struct tags.
+func makeStructFields(root reflect.Type) (fs structFields, serr *SemanticError) {
+	orErrorf := func(serr *SemanticError, t reflect.Type, f string, a ...any) *SemanticError {
+		if serr != nil {
+			return serr
+		}
+		return &SemanticError{GoType: t, Err: fmt.Errorf(f, a...)}
+	}
+
+	// Setup a queue for a breadth-first search.
+	// Types seen at a shallower depth are not visited again to avoid cycles,
+	// but the same type embedded multiple times at the same depth is visited
+	// for each occurrence so that the resulting fields annihilate each other.
+	var queueIndex int
+	type queueEntry struct {
+		typ   reflect.Type
+		index []int
+	}
+	queue := []queueEntry{{root, nil}}
+	seenDepth := map[reflect.Type]int{root: 0}
+
+	// Perform a breadth-first search over all reachable fields.
+	// This ensures that len(f.index) will be monotonically increasing.
+	var allFields, inlinedFallbacks []structField
+	for queueIndex < len(queue) {
+		qe := queue[queueIndex]
+		queueIndex++
+
+		t := qe.typ
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			options, ignored, err := parseFieldOptions(sf)
+			if err != nil {
+				serr = orErrorf(serr, t, "%v", err)
+			}
+			if ignored {
+				continue
+			}
+
+			f := structField{
+				// Allocate a new slice (len=N+1) to hold both
+				// the parent index (len=N) and the current index (len=1).
+				// Do this to avoid clobbering the memory of the parent index.
+				index:        append(append(make([]int, 0, len(qe.index)+1), qe.index...), i),
+				typ:          sf.Type,
+				fieldOptions: options,
+			}
+
+			// Embedded structs without an explicit JSON name are inlined,
+			// as are fields with the "inline" option.
+			if f.inline || (sf.Anonymous && !f.hasName) {
+				tf := sf.Type
+				if tf.Kind() == reflect.Pointer && tf.Name() == "" {
+					tf = tf.Elem()
+				}
+				if tf.Kind() == reflect.Struct {
+					if d, ok := seenDepth[tf]; !ok || d == len(f.index) {
+						seenDepth[tf] = len(f.index)
+						queue = append(queue, queueEntry{tf, f.index})
+					}
+					continue
+				}
+				if f.inline {
+					// An inlined Go map holds all unknown members.
+					if tf == sf.Type && tf.Kind() == reflect.Map && tf.Key().Kind() == reflect.String {
+						f.fncs = lookupArshaler(tf.Elem())
+						inlinedFallbacks = append(inlinedFallbacks, f)
+					} else {
+						serr = orErrorf(serr, t, "inlined Go struct field %s of type %s must be a Go struct, Go map of string key, or pointer to a Go struct", sf.Name, sf.Type)
+					}
+					continue
+				}
+				if !sf.IsExported() {
+					continue // embedded non-struct types must be exported
+				}
+			}
+
+			f.id = len(allFields)
+			f.fncs = lookupArshaler(sf.Type)
+			f.nameNeedEscape = jsonwire.NeedEscape(f.name)
+
+			// Derive the function for checking whether the field is zero.
+			switch {
+			case (sf.Type.Kind() == reflect.Interface || sf.Type.Kind() == reflect.Pointer) && sf.Type.Implements(isZeroerType):
+				f.isZero = func(va addressableValue) bool {
+					// Avoid panics calling IsZero on a nil interface or
+					// non-nil interface with nil pointer.
+					return va.IsNil() || (va.Elem().Kind() == reflect.Pointer && va.Elem().IsNil()) || va.Interface().(isZeroer).IsZero()
+				}
+			case sf.Type.Implements(isZeroerType):
+				f.isZero = func(va addressableValue) bool { return va.Interface().(isZeroer).IsZero() }
+			case reflect.PointerTo(sf.Type).Implements(isZeroerType):
+				f.isZero = func(va addressableValue) bool { return va.Addr().Interface().(isZeroer).IsZero() }
+			}
+
+			// Derive the function for checking whether the field is empty,
+			// which is a fast path for the "omitempty" option
+			// for types that cannot have a custom representation.
+			if !f.fncs.nonDefault {
+				switch sf.Type.Kind() {
+				case reflect.String, reflect.Map, reflect.Array, reflect.Slice:
+					f.isEmpty = func(va addressableValue) bool { return va.Len() == 0 }
+				case reflect.Pointer, reflect.Interface:
+					f.isEmpty = func(va addressableValue) bool { return va.IsNil() }
+				}
+			}
+
+			// The "string" option under legacy semantics only applies
+			// to fields of a boolean, numeric, or string type.
+			tf := sf.Type
+			if tf.Kind() == reflect.Pointer && tf.Name() == "" {
+				tf = tf.Elem()
+			}
+			switch tf.Kind() {
+			case reflect.Bool,
+				reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+				reflect.Float32, reflect.Float64, reflect.String:
+				f.stringLegacy = true
+			}
+
+			allFields = append(allFields, f)
+		}
+	}
+
+	// Sort the fields by name, then by depth, then by the presence of an
+	// explicit JSON name, and lastly by index order so that the dominant field
+	// of each name (if any) is listed first.
+	slices.SortFunc(allFields, func(x, y structField) int {
+		switch {
+		case x.name != y.name:
+			return strings.Compare(x.name, y.name)
+		case len(x.index) != len(y.index):
+			return cmp.Compare(len(x.index), len(y.index))
+		case x.hasName && !y.hasName:
+			return -1
+		case !x.hasName && y.hasName:
+			return +1
+		default:
+			return slices.Compare(x.index, y.index)
+		}
+	})
+
+	// Delete all fields that are hidden by the Go rules for embedded fields,
+	// except that fields with JSON names are promoted.
+	// Ambiguous fields (i.e., multiple fields at the same depth with the
+	// same name and all or none of them explicitly named) are dropped.
+	var flattened []structField
+	for i := 0; i < len(allFields); {
+		j := i + 1
+		for j < len(allFields) && allFields[j].name == allFields[i].name {
+			j++
+		}
+		if g := allFields[i:j]; len(g) == 1 || len(g[0].index) < len(g[1].index) || g[0].hasName && !g[1].hasName {
+			flattened = append(flattened, g[0])
+		}
+		i = j
+	}
+
+	// Sort the fields according to a depth-first ordering.
+	slices.SortFunc(flattened, func(x, y structField) int {
+		return slices.Compare(x.index, y.index)
+	})
+
+	// The inlined fallback at the shallowest depth is used.
+	// Multiple fallbacks at the same depth are ambiguous and rejected.
+	switch {
+	case len(inlinedFallbacks) == 1 || len(inlinedFallbacks) > 1 && len(inlinedFallbacks[0].index) < len(inlinedFallbacks[1].index):
+		fs.inlinedFallback = &inlinedFallbacks[0]
+	case len(inlinedFallbacks) > 1:
+		serr = orErrorf(serr, root, "inlined Go struct fields %s and %s are ambiguous fallbacks",
+			root.FieldByIndex(inlinedFallbacks[0].index).Name, root.FieldByIndex(inlinedFallbacks[1].index).Name)
+	}
+
+	fs.flattened = flattened
+	fs.byActualName = make(map[string]*structField, len(fs.flattened))
+	fs.byFoldedName = make(map[string][]*structField, len(fs.flattened))
+	for i, f := range fs.flattened {
+		foldedName := string(foldName([]byte(f.name)))
+		fs.byActualName[f.name] = &fs.flattened[i]
+		fs.byFoldedName[foldedName] = append(fs.byFoldedName[foldedName], &fs.flattened[i])
+	}
+	return fs, serr
+}
+
+// parseFieldOptions parses the `json` tag in a Go struct field as
+// a structured set of options configuring parameters such as
+// the JSON member name and other features.
+func parseFieldOptions(sf reflect.StructField) (out fieldOptions, ignored bool, err error) {
+	tag, hasTag := sf.Tag.Lookup("json")
+
+	// Check whether this field is explicitly ignored.
+	if tag == "-" {
+		return fieldOptions{}, true, nil
+	}
+
+	// Check whether this field is unexported and not embedded,
+	// which Go reflection cannot mutate for the sake of serialization.
+	if !sf.IsExported() && !sf.Anonymous {
+		// Tag options specified on an unexported field suggests user error.
+		if hasTag {
+			err = fmt.Errorf("unexported Go struct field %s cannot have non-ignored `json:%q` tag", sf.Name, tag)
+		}
+		return fieldOptions{}, true, err
+	}
+
+	// Determine the JSON member name for this Go field. A user-specified name
+	// may be provided as either an identifier or a single-quoted string.
+	// The single-quoted string allows arbitrary characters in the name.
+	// See https://go.dev/issue/2718 and https://go.dev/issue/3546.
+	out.name = sf.Name // always starts with an uppercase character
+	if len(tag) > 0 && !strings.HasPrefix(tag, ",") {
+		// For better compatibility with v1, accept almost any unescaped name.
+		n := len(tag) - len(strings.TrimLeftFunc(tag, func(r rune) bool {
+			return !strings.ContainsRune(",\\'\"`", r) // reserve comma, backslash, and quotes
+		}))
+		name := tag[:n]
+
+		// If the next character is not a comma, then the name is either
+		// malformed (if n > 0) or a single-quoted name.
+		// In either case, call consumeTagOption to handle it further.
+		var err2 error
+		if !strings.HasPrefix(tag[n:], ",") && len(name) != len(tag) {
+			name, n, err2 = consumeTagOption(tag)
+			if err2 != nil && err == nil {
+				err = fmt.Errorf("Go struct field %s has malformed `json` tag: %v", sf.Name, err2)
+			}
+		}
+		if !utf8.ValidString(name) {
+			if err == nil {
+				err = fmt.Errorf("Go struct field %s has JSON object name %q with invalid UTF-8", sf.Name, name)
+			}
+			name = string([]rune(name)) // replace invalid UTF-8 with utf8.RuneError
+		}
+		if err2 == nil {
+			out.hasName = true
+			out.name = name
+		}
+		tag = tag[n:]
+	}
+
+	// Handle any additional tag options (if any).
+	var wasFormat bool
+	seenOpts := make(map[string]bool)
+	for len(tag) > 0 {
+		// Consume comma delimiter.
+		if tag[0] != ',' {
+			if err == nil {
+				err = fmt.Errorf("Go struct field %s has malformed `json` tag: invalid character %q before next option (expecting ',')", sf.Name, tag[0])
+			}
+			break
+		}
+		tag = tag[len(","):]
+		if len(tag) == 0 {
+			break // v1 permits a trailing comma
+		}
+
+		// Consume and process the tag option.
+		opt, n, err2 := consumeTagOption(tag)
+		if err2 != nil && err == nil {
+			err = fmt.Errorf("Go struct field %s has malformed `json` tag: %v", sf.Name, err2)
+		}
+		rawOpt := tag[:n]
+		tag = tag[n:]
+		switch {
+		case wasFormat:
+			if err == nil {
+				err = fmt.Errorf("Go struct field %s has `format` tag option that was not specified last", sf.Name)
+			}
+		case strings.HasPrefix(rawOpt, "'") && strings.TrimFunc(opt, isLetterOrDigit) == "":
+			if err == nil {
+				err = fmt.Errorf("Go struct field %s has unnecessarily quoted appearance of `%s` tag option; specify `%s` instead", sf.Name, rawOpt, opt)
+			}
+		}
+		switch opt {
+		case "case":
+			if !strings.HasPrefix(tag, ":") {
+				if err == nil {
+					err = fmt.Errorf("Go struct field %s is missing value for `case` tag option; specify `case:ignore` or `case:strict` instead", sf.Name)
+				}
+				break
+			}
+			tag = tag[len(":"):]
+			opt, n, err2 := consumeTagOption(tag)
+			if err2 != nil {
+				if err == nil {
+					err = fmt.Errorf("Go struct field %s has malformed value for `case` tag option: %v", sf.Name, err2)
+				}
+				break
+			}
+			tag = tag[n:]
+			switch opt {
+			case "ignore":
+				out.casing |= caseIgnore
+			case "strict":
+				out.casing |= caseStrict
+			default:
+				if err == nil {
+					err = fmt.Errorf("Go struct field %s has unknown `case:%s` tag value", sf.Name, opt)
+				}
+			}
+		case "omitzero":
+			out.omitzero = true
+		case "omitempty":
+			out.omitempty = true
+		case "string":
+			out.string = true
+		case "inline":
+			out.inline = true
+		case "format":
+			if !strings.HasPrefix(tag, ":") {
+				if err == nil {
+					err = fmt.Errorf("Go struct field %s is missing value for `format` tag option", sf.Name)
+				}
+				break
+			}
+			tag = tag[len(":"):]
+			opt, n, err2 := consumeTagOption(tag)
+			if err2 != nil {
+				if err == nil {
+					err = fmt.Errorf("Go struct field %s has malformed value for `format` tag option: %v", sf.Name, err2)
+				}
+				break
+			}
+			tag = tag[n:]
+			out.format = opt
+			wasFormat = true
+		default:
+			// Reject keys that resemble one of the supported options.
+			// This catches invalid mutants such as "omitEmpty" or "omit_empty".
+			normOpt := strings.ReplaceAll(strings.ToLower(opt), "_", "")
+			switch normOpt {
+			case "case", "omitzero", "omitempty", "string", "inline", "format":
+				if err == nil {
+					err = fmt.Errorf("Go struct field %s has invalid appearance of `%s` tag option; specify `%s` instead", sf.Name, opt, normOpt)
+				}
+			}
+
+			// NOTE: Everything else is ignored. This does not mean it is
+			// forward compatible to insert arbitrary tag options since
+			// a future version of this package may understand that tag.
+		}
+
+		// Reject duplicates.
+		switch {
+		case out.casing == caseIgnore|caseStrict:
+			if err == nil {
+				err = fmt.Errorf("Go struct field %s cannot have both `case:ignore` and `case:strict` tag options", sf.Name)
+			}
+		case seenOpts[opt]:
+			if err == nil {
+				err = fmt.Errorf("Go struct field %s has duplicate appearance of `%s` tag option", sf.Name, rawOpt)
+			}
+		}
+		seenOpts[opt] = true
+	}
+
+	// An inlined field is promoted into its parent,
+	// so it has no JSON object name of its own.
+	if out.inline && out.hasName && err == nil {
+		err = fmt.Errorf("Go struct field %s cannot have both `inline` and an explicit JSON name", sf.Name)
+	}
+	return out, false, err
+}
+
+// consumeTagOption consumes the next option,
+// which is either a Go identifier or a single-quoted string.
+// If the next option is invalid, it returns all of in until the next comma,
+// and reports an error.
+func consumeTagOption(in string) (string, int, error) {
+	// For legacy compatibility with v1, assume options are comma-separated.
+	i := strings.IndexByte(in, ',')
+	if i < 0 {
+		i = len(in)
+	}
+
+	switch r, _ := utf8.DecodeRuneInString(in); {
+	// Option as a Go identifier.
+	case r == '_' || unicode.IsLetter(r):
+		n := len(in) - len(strings.TrimLeftFunc(in, isLetterOrDigit))
+		return in[:n], n, nil
+	// Option as a single-quoted string.
+	case r == '\'':
+		// The grammar is nearly identical to a double-quoted Go string literal,
+		// but uses single quotes as the terminators. The reason for a custom
+		// grammar is because both backtick and double quotes cannot be used
+		// verbatim in a struct tag.
+		//
+		// Convert a single-quoted string to a double-quote string and rely on
+		// strconv.Unquote to handle the rest.
+		var inEscape bool
+		b := []byte{'"'}
+		n := len(`'`)
+		for len(in) > n {
+			r, rn := utf8.DecodeRuneInString(in[n:])
+			switch {
+			case inEscape:
+				if r == '\'' {
+					b = b[:len(b)-1] // remove escape character: `\'` => `'`
+				}
+				inEscape = false
+			case r == '\\':
+				inEscape = true
+			case r == '"':
+				b = append(b, '\\') // insert escape character: `"` => `\"`
+			case r == '\'':
+				b = append(b, '"')
+				n += len(`'`)
+				out, err := strconv.Unquote(string(b))
+				if err != nil {
+					return in[:i], i, fmt.Errorf("invalid single-quoted string: %s", in[:n])
+				}
+				return out, n, nil
+			}
+			b = append(b, in[n:][:rn]...)
+			n += rn
+		}
+		if n > 10 {
+			n = 10 // limit the amount of context printed in the error
+		}
+		return in[:i], i, fmt.Errorf("single-quoted string not terminated: %s...", in[:n])
+	case len(in) == 0:
+		return in[:i], i, io.ErrUnexpectedEOF
+	default:
+		return in[:i], i, fmt.Errorf("invalid character %q at start of option (expecting Unicode letter or single quote)", r)
+	}
+}
+
+func isLetterOrDigit(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsNumber(r)
+}
@@ -0,0 +1,67 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.jsonv2
+
+package json
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestFoldRune(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	var foldSet []rune
+	for r := rune(0); r <= unicode.MaxRune; r++ {
+		// Derive all runes that are all part of the same fold set.
+		foldSet = foldSet[:0]
+		for r0 := r; r != r0 || len(foldSet) == 0; r = unicode.SimpleFold(r) {
+			foldSet = append(foldSet, r)
+		}
+
+		// Normalized form of each rune in a foldset must be the same and
+		// also be within the set itself.
+		var withinSet bool
+		rr0 := foldRune(foldSet[0])
+		for _, r := range foldSet {
+			withinSet = withinSet || rr0 == r
+			rr := foldRune(r)
+			if rr0 != rr {
+				t.Errorf("foldRune(%q) = %q, want %q", r, rr, rr0)
+			}
+		}
+		if !withinSet {
+			t.Errorf("foldRune(%q) = %q not in fold set %q", foldSet[0], rr0, string(foldSet))
+		}
+	}
+}
+
+func TestFoldName(t *testing.T) {
+	tests := []struct {
+		x, y string
+		want bool
+	}{
+		{"", "", true},
+		{"abc", "ABC", true},
+		{"abc", "abd", false},
+		{"k", "\u212a", true}, // Kelvin sign
+		{"s", "\u017f", true}, // Latin small letter long s
+		{"a_b", "ab", false},
+		{"\xff", "\xfe", true}, // invalid UTF-8 folds to the replacement character
+	}
+	for _, tt := range tests {
+		got := string(foldName([]byte(tt.x))) == string(foldName([]byte(tt.y)))
+		if got != tt.want {
+			t.Errorf("foldName(%q) == foldName(%q) = %v, want %v", tt.x, tt.y, got, tt.want)
+		}
+		if tt.want != strings.EqualFold(tt.x, tt.y) && tt.x != "\xff" {
+			t.Errorf("strings.EqualFold(%q, %q) disagrees", tt.x, tt.y)
+		}
+	}
+}
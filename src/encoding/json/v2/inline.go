@@ -0,0 +1,97 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.jsonv2
+
+package json
+
+import (
+	"reflect"
+	"slices"
+
+	"encoding/json/internal"
+	"encoding/json/internal/jsonflags"
+	"encoding/json/internal/jsonopts"
+	"encoding/json/jsontext"
+)
+
+// marshalInlinedFallbackAll marshals all the members in the inlined fallback
+// of fields. If duplicate names are allowed, members that would be
+// unmarshaled into another field are skipped; otherwise they are left
+// for the encoder to reject as duplicates.
+func marshalInlinedFallbackAll(enc *jsontext.Encoder, va addressableValue, mo *jsonopts.Struct, fields *structFields) error {
+	f := fields.inlinedFallback
+	v := va.fieldByIndex(f.index, false)
+	if !v.IsValid() || v.Len() == 0 {
+		return nil // implies a nil inlined field or an empty map
+	}
+
+	marshalVal := f.fncs.marshal
+	if mo.Marshalers != nil {
+		marshalVal, _ = mo.Marshalers.(*Marshalers).lookup(marshalVal, f.typ.Elem())
+	}
+	mk := newAddressableValue(f.typ.Key())
+	mv := newAddressableValue(f.typ.Elem())
+	marshalMember := func(name string) error {
+		if mo.Flags.Get(jsonflags.AllowDuplicateNames) && fields.lookup([]byte(name), &mo.Flags) != nil {
+			return nil // unmarshaling would store it in the field
+		}
+		if err := enc.WriteToken(jsontext.String(name)); err != nil {
+			return err
+		}
+		return marshalVal(enc, mv, mo)
+	}
+
+	if !mo.Flags.Get(jsonflags.Deterministic) || v.Len() <= 1 {
+		for iter := v.MapRange(); iter.Next(); {
+			mk.SetIterKey(iter)
+			mv.SetIterValue(iter)
+			if err := marshalMember(mk.String()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	names := getStrings(v.Len())
+	for i, iter := 0, v.MapRange(); iter.Next(); i++ {
+		mk.SetIterKey(iter)
+		(*names)[i] = mk.String()
+	}
+	slices.Sort(*names)
+	for _, name := range *names {
+		mk.SetString(name)
+		mv.Set(v.MapIndex(mk.Value))
+		if err := marshalMember(name); err != nil {
+			return err
+		}
+	}
+	putStrings(names)
+	return nil
+}
+
+// unmarshalInlinedFallbackNext unmarshals only the next member in an inlined fallback,
+// where name is the unquoted name of the member that was just read.
+func unmarshalInlinedFallbackNext(dec *jsontext.Decoder, va addressableValue, uo *jsonopts.Struct, f *structField, name []byte) error {
+	v := va.fieldByIndex(f.index, true)
+	if !v.IsValid() {
+		err := newUnmarshalErrorBefore(dec, va.Type(), internal.ErrNilField)
+		if err2 := dec.SkipValue(); err2 != nil {
+			return err2
+		}
+		return err
+	}
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(f.typ))
+	}
+
+	unmarshalVal := f.fncs.unmarshal
+	if uo.Unmarshalers != nil {
+		unmarshalVal, _ = uo.Unmarshalers.(*Unmarshalers).lookup(unmarshalVal, f.typ.Elem())
+	}
+	mk := reflect.ValueOf(string(name)).Convert(f.typ.Key())
+	mv := newAddressableValue(f.typ.Elem())
+	err := unmarshalVal(dec, mv, uo)
+	v.SetMapIndex(mk, mv.Value)
+	return err
+}
@@ -0,0 +1,278 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.jsonv2
+
+package json
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type compatInner struct {
+	N int `json:"n"`
+}
+
+type compatOuter struct {
+	Name  string
+	Inner compatInner
+	Bytes []byte `json:",omitempty"`
+	Count int    `json:",string"`
+}
+
+func TestCompatMarshal(t *testing.T) {
+	tests := []struct {
+		in   any
+		want string
+	}{
+		{compatOuter{Name: "x", Count: 3}, `{"Name":"x","Inner":{"n":0},"Count":"3"}`},
+		{map[string]int{"b": 2, "a": 1}, `{"a":1,"b":2}`},
+		{[]int(nil), `null`},
+		{map[string]int(nil), `null`},
+		{[3]byte{1, 2, 3}, `[1,2,3]`},
+		{[]byte("hi"), `"aGk="`},
+		{"<&>", `"\u003c\u0026\u003e"`},
+		{Number("12.5"), `12.5`},
+		{RawMessage(`{"a":1}`), `{"a":1}`},
+	}
+	for _, tt := range tests {
+		got, err := Marshal(tt.in)
+		if err != nil {
+			t.Errorf("Marshal(%#v) error: %v", tt.in, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("Marshal(%#v) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCompatMarshalErrors(t *testing.T) {
+	if _, err := Marshal(make(chan int)); !errors.As(err, new(*UnsupportedTypeError)) {
+		t.Errorf("Marshal(chan) error = %T, want *UnsupportedTypeError", err)
+	}
+	if _, err := Marshal(math.NaN()); !errors.As(err, new(*UnsupportedValueError)) {
+		t.Errorf("Marshal(NaN) error = %T, want *UnsupportedValueError", err)
+	}
+	type cyclic struct{ P *cyclic }
+	v := new(cyclic)
+	v.P = v
+	if _, err := Marshal(v); !errors.As(err, new(*UnsupportedValueError)) {
+		t.Errorf("Marshal(cycle) error = %T, want *UnsupportedValueError", err)
+	}
+}
+
+func TestCompatUnmarshal(t *testing.T) {
+	var got compatOuter
+	in := `{"name":"x","INNER":{"N":5},"Count":"7","Unknown":true}`
+	if err := Unmarshal([]byte(in), &got); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	want := compatOuter{Name: "x", Inner: compatInner{N: 5}, Count: 7}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unmarshal = %+v, want %+v", got, want)
+	}
+
+	var a [2]int
+	if err := Unmarshal([]byte(`[1,2,3]`), &a); err != nil || a != [2]int{1, 2} {
+		t.Errorf("Unmarshal into array = %v, %v, want [1 2], nil", a, err)
+	}
+
+	var dup map[string]int
+	if err := Unmarshal([]byte(`{"a":1,"a":2}`), &dup); err != nil || dup["a"] != 2 {
+		t.Errorf("Unmarshal duplicate names = %v, %v, want map[a:2], nil", dup, err)
+	}
+}
+
+type compatZero struct{ N int }
+
+func (z *compatZero) IsZero() bool { return z.N < 0 }
+
+func TestCompatOmitZero(t *testing.T) {
+	type S struct {
+		Time  time.Time      `json:",omitzero"`
+		Map   map[string]int `json:",omitzero"`
+		Slice []int          `json:",omitzero"`
+		Zero  compatZero     `json:",omitzero"`
+	}
+	tests := []struct {
+		in   S
+		want string
+	}{
+		{S{}, `{"Zero":{"N":0}}`},
+		{S{Map: map[string]int{}, Zero: compatZero{-1}}, `{"Map":{}}`},
+		{S{Time: time.Unix(0, 0).UTC(), Slice: []int{}, Zero: compatZero{-1}}, `{"Time":"1970-01-01T00:00:00Z","Slice":[]}`},
+	}
+	for _, tt := range tests {
+		got, err := Marshal(tt.in)
+		if err != nil {
+			t.Errorf("Marshal(%+v) error: %v", tt.in, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("Marshal(%+v) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+type compatInline struct {
+	A      int
+	Inner  compatInner           `json:",inline"`
+	Extras map[string]RawMessage `json:",inline"`
+}
+
+func TestCompatInline(t *testing.T) {
+	in := compatInline{A: 1, Inner: compatInner{2}, Extras: map[string]RawMessage{
+		"z":   RawMessage(`[1]`),
+		"<y>": RawMessage(`null`),
+	}}
+	got, err := Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	const want = `{"A":1,"n":2,"\u003cy\u003e":null,"z":[1]}`
+	if string(got) != want {
+		t.Errorf("Marshal = %s, want %s", got, want)
+	}
+
+	var out compatInline
+	dec := NewDecoder(strings.NewReader(`{"A":1,"n":2,"C":[true],"D" : null}`))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&out); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	wantOut := compatInline{A: 1, Inner: compatInner{2}, Extras: map[string]RawMessage{
+		"C": RawMessage(`[true]`),
+		"D": RawMessage(`null`),
+	}}
+	if !reflect.DeepEqual(out, wantOut) {
+		t.Errorf("Decode = %+v, want %+v", out, wantOut)
+	}
+}
+
+type compatInlineCollision struct {
+	A    int                   `json:"a"`
+	Rest map[string]RawMessage `json:",inline"`
+}
+
+func TestCompatInlineCollision(t *testing.T) {
+	in := compatInlineCollision{A: 1, Rest: map[string]RawMessage{
+		"a": RawMessage(`2`),
+		"A": RawMessage(`3`),
+		"b": RawMessage(`4`),
+	}}
+	got, err := Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	const want = `{"a":1,"b":4}`
+	if string(got) != want {
+		t.Errorf("Marshal = %s, want %s", got, want)
+	}
+
+	var out compatInlineCollision
+	if err := Unmarshal(got, &out); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	wantOut := compatInlineCollision{A: 1, Rest: map[string]RawMessage{"b": RawMessage(`4`)}}
+	if !reflect.DeepEqual(out, wantOut) {
+		t.Errorf("Unmarshal = %+v, want %+v", out, wantOut)
+	}
+}
+
+func TestCompatUnmarshalErrors(t *testing.T) {
+	var v compatOuter
+	err := Unmarshal([]byte(`{"Inner":{"n":"bad"},"Name":"ok"}`), &v)
+	var terr *UnmarshalTypeError
+	if !errors.As(err, &terr) {
+		t.Fatalf("Unmarshal error = %T, want *UnmarshalTypeError", err)
+	}
+	if terr.Value != "string" || terr.Type != reflect.TypeOf(0) || terr.Field != "Inner.n" || terr.Struct != "compatInner" {
+		t.Errorf("UnmarshalTypeError = %+v", terr)
+	}
+	if v.Name != "ok" {
+		t.Errorf("Unmarshal stopped at the first type error: Name = %q", v.Name)
+	}
+
+	if err := Unmarshal([]byte(`{}`), v); !errors.As(err, new(*InvalidUnmarshalError)) {
+		t.Errorf("Unmarshal(non-pointer) error = %T, want *InvalidUnmarshalError", err)
+	}
+	if err := Unmarshal([]byte(`{`), &v); !errors.As(err, new(*SyntaxError)) {
+		t.Errorf("Unmarshal(truncated) error = %T, want *SyntaxError", err)
+	}
+}
+
+func TestCompatDecoder(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{"a":[1,"s",null,true]} {"b":2}`))
+	dec.UseNumber()
+	var got []Token
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Token error: %v", err)
+		}
+		got = append(got, tok)
+	}
+	want := []Token{Delim('{'), "a", Delim('['), Number("1"), "s", nil, true, Delim(']'), Delim('}'),
+		Delim('{'), "b", Number("2"), Delim('}')}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Token sequence:\n\tgot  %v\n\twant %v", got, want)
+	}
+
+	dec = NewDecoder(strings.NewReader(`{"Name":"x","Extra":1}`))
+	dec.DisallowUnknownFields()
+	var v compatOuter
+	if err := dec.Decode(&v); err == nil || !strings.Contains(err.Error(), `unknown field "Extra"`) {
+		t.Errorf("Decode error = %v, want unknown field error", err)
+	}
+
+	dec = NewDecoder(strings.NewReader(`[1,`))
+	var s []int
+	if err := dec.Decode(&s); err != io.ErrUnexpectedEOF {
+		t.Errorf("Decode error = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestCompatEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent(">", "--")
+	if err := enc.Encode(map[string]string{"k": "<v>"}); err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+	want := "{\n>--\"k\": \"<v>\"\n>}\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Encode = %q, want %q", got, want)
+	}
+}
+
+func TestCompatIndent(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Indent(&buf, []byte(" [1,{\"a\":2}]\n"), "#", "\t"); err != nil {
+		t.Fatalf("Indent error: %v", err)
+	}
+	want := "[\n#\t1,\n#\t{\n#\t\t\"a\": 2\n#\t}\n#]\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Indent = %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	if err := Compact(&buf, []byte("{ \"a\" : [ 1 , 2 ] }")); err != nil || buf.String() != `{"a":[1,2]}` {
+		t.Errorf("Compact = %q, %v", buf.String(), err)
+	}
+	if err := Compact(&buf, []byte("{")); !errors.As(err, new(*SyntaxError)) {
+		t.Errorf("Compact(truncated) error = %T, want *SyntaxError", err)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.jsonv2
+
+package json
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// The v2 implementation folds names with its own foldName function
+// (see v2/fold_test.go), so these tests check case-insensitive matching
+// of object names to struct fields through Unmarshal instead.
+
+var foldTests = []struct {
+	s, t string
+	want bool
+}{
+	{"a", "a", true},
+	{"a", "", false},
+	{"a", "A", true},
+	{"AB", "ab", true},
+	{"AB", "ac", false},
+	{"sbkKc", "ſbKKc", true},
+	{"SbKkc", "ſbKKc", true},
+	{"SbKkc", "ſbKK", false},
+	{"e", "é", false},
+	{"s", "S", true},
+
+	{"abc", "abc", true},
+	{"abc", "ABC", true},
+	{"abc", "ABCD", false},
+	{"abc", "xxx", false},
+
+	{"a_B", "A_b", true},
+	{"aa@", "aa`", false}, // verify 0x40 and 0x60 aren't case-equivalent
+}
+
+// foldMatch reports whether Unmarshal stores the JSON object member
+// with the given name into the struct field with the given JSON name.
+func foldMatch(t *testing.T, field, name string) bool {
+	t.Helper()
+	typ := reflect.StructOf([]reflect.StructField{{
+		Name: "F",
+		Type: reflect.TypeOf(false),
+		Tag:  reflect.StructTag(`json:"` + field + `"`),
+	}})
+	b, err := Marshal(map[string]bool{name: true})
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	v := reflect.New(typ)
+	if err := Unmarshal(b, v.Interface()); err != nil {
+		t.Fatalf("Unmarshal(%s) error: %v", b, err)
+	}
+	return v.Elem().Field(0).Bool()
+}
+
+func TestFold(t *testing.T) {
+	for i, tt := range foldTests {
+		if got := foldMatch(t, tt.s, tt.t); got != tt.want {
+			t.Errorf("%d. %q, %q = %v; want %v", i, tt.s, tt.t, got, tt.want)
+		}
+		truth := strings.EqualFold(tt.s, tt.t)
+		if truth != tt.want {
+			t.Errorf("strings.EqualFold doesn't agree with case %d", i)
+		}
+	}
+}
+
+func TestFoldAgainstUnicode(t *testing.T) {
+	var runes []rune
+	for i := 0x20; i <= 0x7f; i++ {
+		if isASCIILetter(byte(i)) {
+			runes = append(runes, rune(i))
+		}
+	}
+	runes = append(runes, '\u212a', '\u017f') // Kelvin sign and small long s
+
+	var buf1, buf2 []byte
+	for _, r := range runes {
+		for _, r2 := range runes {
+			buf1 = append(utf8.AppendRune(append(buf1[:0], 'x'), r), 'x')
+			buf2 = append(utf8.AppendRune(append(buf2[:0], 'x'), r2), 'x')
+			want := strings.EqualFold(string(buf1), string(buf2))
+			if got := foldMatch(t, string(buf1), string(buf2)); got != want {
+				t.Errorf("match(%q, %q) = %v; want %v", buf1, buf2, got, want)
+			}
+		}
+	}
+}
+
+func isASCIILetter(b byte) bool {
+	return ('A' <= b && b <= 'Z') || ('a' <= b && b <= 'z')
+}
@@ -0,0 +1,263 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.jsonv2
+
+package json
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"encoding/json/internal"
+	"encoding/json/jsontext"
+	jsonv2 "encoding/json/v2"
+)
+
+// Inject functionality into v2 to properly handle v1 types.
+func init() {
+	internal.TransformMarshalError = transformMarshalError
+	internal.NewMarshalerError = func(val any, err error, funcName string) error {
+		return &MarshalerError{reflect.TypeOf(val), err, funcName}
+	}
+	internal.NewUnsupportedValueError = func(val any, str string) error {
+		return &UnsupportedValueError{val.(reflect.Value), str}
+	}
+	internal.TransformUnmarshalError = transformUnmarshalError
+	internal.IsUnmarshalTypeError = func(err error) bool {
+		_, ok := err.(*UnmarshalTypeError)
+		return ok
+	}
+
+	internal.NewRawNumber = func() any { return new(Number) }
+	internal.RawNumberOf = func(b []byte) any { return Number(b) }
+}
+
+// transformMarshalError converts an error reported by v2 marshaling
+// into the error type historically reported by v1.
+func transformMarshalError(root any, err error) error {
+	// Errors from Marshal methods and unsupported values are already
+	// reported by v2 using v1 types through the injected constructors.
+	serr, ok := err.(*jsonv2.SemanticError)
+	if !ok {
+		return transformSyntacticError(err)
+	}
+	if serr.Err == nil {
+		return &UnsupportedTypeError{Type: serr.GoType}
+	}
+	if errors.Is(serr.Err, internal.ErrCycle) {
+		return &UnsupportedValueError{reflect.ValueOf(root), fmt.Sprintf("encountered a cycle via %s", serr.GoType)}
+	}
+	return err
+}
+
+// transformUnmarshalError converts an error reported by v2 unmarshaling
+// into the error type historically reported by v1.
+func transformUnmarshalError(root any, err error) error {
+	// Errors from Unmarshal methods are reported verbatim by v2
+	// under legacy semantics, so only errors produced by v2 itself
+	// need to be converted here.
+	serr, ok := err.(*jsonv2.SemanticError)
+	if !ok {
+		return transformSyntacticError(err)
+	}
+	if uerr, ok := serr.Err.(*UnmarshalTypeError); ok {
+		// A type error from an Unmarshal method is reported as is,
+		// but with the context of the struct field that holds the value.
+		if structName, fieldPath, _ := legacyFieldContext(reflect.TypeOf(root), serr.JSONPointer); structName != "" || fieldPath != "" {
+			uerr.Struct, uerr.Field = structName, fieldPath
+		}
+		return uerr
+	}
+	switch serr.Err {
+	case internal.ErrNonNilReference:
+		return &InvalidUnmarshalError{Type: reflect.TypeOf(root)}
+	case jsonv2.ErrUnknownName:
+		return fmt.Errorf("json: unknown field %q", serr.JSONPointer.LastToken())
+	case internal.ErrNilField:
+		if t := legacyNilEmbedded(serr.GoType, serr.JSONPointer.LastToken()); t != nil {
+			return fmt.Errorf("json: cannot set embedded pointer to unexported struct: %v", t)
+		}
+		return err
+	}
+
+	var value string
+	switch serr.JSONKind {
+	case 'n':
+		value = "null"
+	case 'f', 't':
+		value = "bool"
+	case '"':
+		value = "string"
+	case '0':
+		value = "number"
+		if len(serr.JSONValue) > 0 {
+			value += " " + string(serr.JSONValue)
+		}
+	case '[':
+		value = "array"
+	case '{':
+		value = "object"
+	default:
+		// Without the JSON kind, this is not a type mismatch
+		// (e.g., a struct field that cannot be set), so report it as is.
+		return err
+	}
+	if serr.GoType == nil {
+		return err
+	}
+	offset := serr.ByteOffset
+	structName, fieldPath, field := legacyFieldContext(reflect.TypeOf(root), serr.JSONPointer)
+	quoted := serr.JSONKind == '"' && len(serr.JSONValue) > 0
+	s, _ := jsontext.AppendUnquote(nil, serr.JSONValue)
+	switch {
+	case field != nil && legacyQuoted(*field):
+		// Misuse of the ",string" tag option is reported as v1 did,
+		// except for quoted numbers, which are still type errors.
+		if !quoted {
+			return fmt.Errorf("json: invalid use of ,string struct tag, trying to unmarshal unquoted value into %v", serr.GoType)
+		}
+		if !legacyNumberKind(serr.GoType.Kind()) || len(s) == 0 || (s[0] != '-' && (s[0] < '0' || s[0] > '9')) {
+			return fmt.Errorf("json: invalid use of ,string struct tag, trying to unmarshal %q into %v", s, serr.GoType)
+		}
+		value = "number " + string(s)
+	case quoted && legacyNumberKind(serr.GoType.Kind()):
+		// Otherwise, only object names are parsed as numbers from
+		// JSON strings, which v1 reported as numbers located
+		// right after the opening quote.
+		value = "number " + string(s)
+		offset -= int64(len(serr.JSONValue) - len(`"`))
+	}
+	return &UnmarshalTypeError{
+		Value:  value,
+		Type:   serr.GoType,
+		Offset: offset,
+		Struct: structName,
+		Field:  fieldPath,
+	}
+}
+
+// legacyFieldContext follows the JSON pointer through the Go type t and
+// reports the name of the innermost Go struct type traversed together with
+// the dot-delimited path of struct field names leading to the value,
+// which is how v1 historically identified the location of a type error.
+// It also reports the struct field that holds the value, if any.
+func legacyFieldContext(t reflect.Type, p jsontext.Pointer) (structName, fieldPath string, field *reflect.StructField) {
+	var fields []string
+	for tok := range p.Tokens() {
+		for t != nil && t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t == nil {
+			break
+		}
+		switch t.Kind() {
+		case reflect.Struct:
+			f, ok := legacyFieldByName(t, tok)
+			if !ok {
+				return structName, strings.Join(fields, "."), nil
+			}
+			structName = t.Name()
+			fields = append(fields, tok)
+			field = &f
+			t = f.Type
+		case reflect.Map, reflect.Slice, reflect.Array:
+			field = nil
+			t = t.Elem()
+		default:
+			return structName, strings.Join(fields, "."), nil
+		}
+	}
+	return structName, strings.Join(fields, "."), field
+}
+
+// legacyNilEmbedded reports the struct type pointed to by the first
+// embedded pointer to an unexported struct type that must be traversed
+// within struct type t to reach the field with the given JSON object name,
+// or the inlined fallback if there is no such field.
+func legacyNilEmbedded(t reflect.Type, name string) reflect.Type {
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	f, ok := legacyFieldByName(t, name)
+	if !ok {
+		// Otherwise, the member was destined for the inlined fallback.
+		for _, sf := range reflect.VisibleFields(t) {
+			_, opts, _ := strings.Cut(sf.Tag.Get("json"), ",")
+			if sf.Type.Kind() == reflect.Map && slices.Contains(strings.Split(opts, ","), "inline") {
+				f, ok = sf, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return nil
+	}
+	for _, i := range f.Index[:len(f.Index)-1] {
+		sf := t.Field(i)
+		t = sf.Type
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+			if !sf.IsExported() {
+				return t
+			}
+		}
+	}
+	return nil
+}
+
+// legacyQuoted reports whether v1 historically decoded the value of f
+// from within a JSON string because of the ",string" tag option.
+func legacyQuoted(f reflect.StructField) bool {
+	_, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if !slices.Contains(strings.Split(opts, ","), "string") {
+		return false
+	}
+	t := f.Type
+	if t.Name() == "" && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Bool || t.Kind() == reflect.String || legacyNumberKind(t.Kind())
+}
+
+// legacyNumberKind reports whether v1 decoded a Go value of kind k
+// only from a JSON number.
+func legacyNumberKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// legacyFieldByName looks up the exported field of struct type t
+// that is serialized with the given JSON object name.
+func legacyFieldByName(t reflect.Type, name string) (reflect.StructField, bool) {
+	var folded reflect.StructField
+	var foundFolded bool
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous && f.Tag.Get("json") == "" {
+			continue
+		}
+		tagName, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if tagName == "-" {
+			continue
+		}
+		if tagName == "" {
+			tagName = f.Name
+		}
+		if tagName == name {
+			return f, true
+		}
+		if !foundFolded && strings.EqualFold(tagName, name) {
+			folded, foundFolded = f, true
+		}
+	}
+	return folded, foundFolded
+}
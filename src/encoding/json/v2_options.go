@@ -0,0 +1,298 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.jsonv2
+
+package json
+
+import (
+	"encoding/json/internal/jsonflags"
+	"encoding/json/internal/jsonopts"
+)
+
+// Options are a set of options to configure the v2 "json" package
+// to operate with v1 semantics for particular features.
+// Values of this type can be passed to v2 functions like
+// [jsonv2.Marshal] or [jsonv2.Unmarshal].
+// Instead of referencing this type, use [jsonv2.Options].
+//
+// See the "Migrating to v2" section for guidance on how to migrate usage
+// of "json" to using "json/v2" instead.
+//
+// # Migrating to v2
+//
+// This package (i.e., v1) is implemented entirely in terms of v2.
+// A v1 function such as [Marshal] is equivalent to calling
+// the v2 function [jsonv2.Marshal] with [DefaultOptionsV1].
+// Similarly, an encoder or decoder in this package operates like
+// a [jsontext.Encoder] or [jsontext.Decoder] with [DefaultOptionsV1].
+//
+// The following options control the behavior differences between v1 and v2.
+// Each option is true by default for v1 and false for v2.
+// It is recommended that options be migrated to v2 semantics one at a time
+// and only removed once the program is known to behave the same way.
+// For example, to start using v2 semantics for all options except for
+// the behavior of method calls:
+//
+//	jsonv2.Marshal(v,
+//		jsonv2.DefaultOptionsV2(),
+//		json.CallMethodsWithLegacySemantics(true))
+type Options = jsonopts.Options
+
+// DefaultOptionsV1 is the full set of all options that define v1 semantics.
+// It is equivalent to the following boolean options being set to true:
+//
+//   - [CallMethodsWithLegacySemantics]
+//   - [FormatByteArrayAsArray]
+//   - [FormatBytesWithLegacySemantics]
+//   - [FormatDurationAsNano]
+//   - [MergeWithLegacySemantics]
+//   - [OmitEmptyWithLegacyDefinition]
+//   - [ReportErrorsWithLegacySemantics]
+//   - [StringifyWithLegacySemantics]
+//   - [UnmarshalArrayFromAnyLength]
+//   - [jsonv2.Deterministic]
+//   - [jsonv2.FormatNilMapAsNull]
+//   - [jsonv2.FormatNilSliceAsNull]
+//   - [jsonv2.MatchCaseInsensitiveNames]
+//   - [jsontext.AllowDuplicateNames]
+//   - [jsontext.AllowInvalidUTF8]
+//   - [jsontext.EscapeForHTML]
+//   - [jsontext.EscapeForJS]
+//   - [jsontext.PreserveRawStrings]
+//
+// All other options are set to false.
+// All non-boolean options are set to the zero value.
+func DefaultOptionsV1() Options {
+	return &jsonopts.DefaultOptionsV1
+}
+
+// CallMethodsWithLegacySemantics specifies that calling of type-provided
+// marshal and unmarshal methods follow legacy semantics:
+//
+//   - When marshaling, a marshal method declared on a pointer receiver
+//     is only called if the Go value is addressable.
+//     Values obtained from an interface or map element are not addressable.
+//     Values obtained from a pointer or slice element are addressable.
+//     Values obtained from an array element or struct field inherit
+//     the addressability of the parent. In contrast, the v2 semantic
+//     is to always call marshal methods regardless of addressability.
+//
+//   - When marshaling or unmarshaling, the [Marshaler] or [Unmarshaler]
+//     methods are ignored for map keys. However, [encoding.TextMarshaler]
+//     or [encoding.TextUnmarshaler] are still callable.
+//     In contrast, the v2 semantic is to serialize map keys
+//     like any other value (with regard to calling methods),
+//     which may include calling [Marshaler] or [Unmarshaler] methods,
+//     where it is the implementation's responsibility to represent the
+//     Go value as a JSON string (as required for JSON object names).
+//
+//   - When marshaling, if a map key value implements a marshal method
+//     and is a nil pointer, then it is serialized as an empty JSON string.
+//     In contrast, the v2 semantic is to report an error.
+//
+//   - When marshaling, if an interface value holds a nil pointer and
+//     the interface type itself declares a marshal method,
+//     then that method is called on the nil pointer.
+//     In contrast, the v2 semantic is to serialize it as a JSON null.
+//
+// This affects either marshaling or unmarshaling.
+// The v1 default is true.
+func CallMethodsWithLegacySemantics(v bool) Options {
+	if v {
+		return jsonflags.CallMethodsWithLegacySemantics | 1
+	} else {
+		return jsonflags.CallMethodsWithLegacySemantics | 0
+	}
+}
+
+// FormatByteArrayAsArray specifies that a Go [N]byte is
+// formatted as a normal Go array in contrast to the v2 default of
+// formatting [N]byte as using binary data encoding (RFC 4648).
+// If a struct field has a `format` tag option,
+// then the specified formatting takes precedence.
+//
+// This affects either marshaling or unmarshaling.
+// The v1 default is true.
+func FormatByteArrayAsArray(v bool) Options {
+	if v {
+		return jsonflags.FormatByteArrayAsArray | 1
+	} else {
+		return jsonflags.FormatByteArrayAsArray | 0
+	}
+}
+
+// FormatBytesWithLegacySemantics specifies that handling of
+// []~byte and [N]~byte types follow legacy semantics:
+//
+//   - A Go []~byte is to be treated as binary data encoding (RFC 4648),
+//     even if the element type has a named type.
+//     In contrast, the v2 default is to only treat []byte as binary data,
+//     while []~byte with a named element type is treated like any other slice.
+//
+//   - A Go []~byte whose element type implements a marshal method
+//     is formatted as a JSON array of elements rather than binary data.
+//
+//   - When unmarshaling, a JSON array is permitted to decode into
+//     a Go []~byte as if it were any other slice.
+//
+//   - When unmarshaling, the binary data encoding is permitted to contain
+//     '\r' and '\n' characters as allowed by the base64 decoder.
+//     In contrast, the v2 default is to reject such characters.
+//
+// This affects either marshaling or unmarshaling.
+// The v1 default is true.
+func FormatBytesWithLegacySemantics(v bool) Options {
+	if v {
+		return jsonflags.FormatBytesWithLegacySemantics | 1
+	} else {
+		return jsonflags.FormatBytesWithLegacySemantics | 0
+	}
+}
+
+// FormatDurationAsNano specifies that a [time.Duration] is
+// formatted as a JSON number representing the number of nanoseconds
+// in contrast to the v2 default of using a JSON string with the duration
+// formatted with [time.Duration.String].
+// If a duration field has a `format` tag option,
+// then the specified formatting takes precedence.
+//
+// This affects either marshaling or unmarshaling.
+// The v1 default is true.
+func FormatDurationAsNano(v bool) Options {
+	if v {
+		return jsonflags.FormatDurationAsNano | 1
+	} else {
+		return jsonflags.FormatDurationAsNano | 0
+	}
+}
+
+// MergeWithLegacySemantics specifies that unmarshaling into a non-zero
+// Go value uses legacy semantics:
+//
+//   - When unmarshaling a JSON null, this preserves the original Go value
+//     if the kind is a bool, int, uint, float, string, array, or struct.
+//     Otherwise, it zeros the Go value.
+//     In contrast, the v2 default is to consistently zero the Go value
+//     when unmarshaling a JSON null into it.
+//
+//   - When unmarshaling a JSON value other than null, this merges into
+//     the original Go value for array elements, slice elements,
+//     struct fields (but not map values),
+//     pointer values, and interface values (only if a non-nil pointer).
+//     Otherwise, it replaces the original Go value.
+//     In contrast, the v2 default is to consistently merge into the Go value
+//     for struct fields, map values, pointer values, and interface values.
+//
+// This only affects unmarshaling and is ignored when marshaling.
+// The v1 default is true.
+func MergeWithLegacySemantics(v bool) Options {
+	if v {
+		return jsonflags.MergeWithLegacySemantics | 1
+	} else {
+		return jsonflags.MergeWithLegacySemantics | 0
+	}
+}
+
+// OmitEmptyWithLegacyDefinition specifies that the `omitempty` tag option
+// follows a definition of empty where a field is omitted if the Go value is
+// false, 0, a nil pointer, a nil interface value,
+// or any empty array, slice, map, or string.
+// This overrides the v2 semantic where a field is empty if the value
+// marshals as a JSON null or an empty JSON string, object, or array.
+//
+// The v1 and v2 definitions of `omitempty` are practically the same for
+// Go strings, slices, arrays, and maps. Usages of `omitempty` on
+// Go bools, ints, uints, and floats should migrate to `omitzero`.
+//
+// This only affects marshaling and is ignored when unmarshaling.
+// The v1 default is true.
+func OmitEmptyWithLegacyDefinition(v bool) Options {
+	if v {
+		return jsonflags.OmitEmptyWithLegacyDefinition | 1
+	} else {
+		return jsonflags.OmitEmptyWithLegacyDefinition | 0
+	}
+}
+
+// ReportErrorsWithLegacySemantics specifies that Marshal and Unmarshal
+// should report errors with legacy semantics:
+//
+//   - When marshaling or unmarshaling, the returned error values are
+//     usually of types such as [SyntaxError], [MarshalerError],
+//     [UnsupportedTypeError], [UnsupportedValueError],
+//     [InvalidUnmarshalError], or [UnmarshalTypeError].
+//     In contrast, the v2 semantic is to report errors with the
+//     [jsontext.SyntacticError] or [jsonv2.SemanticError] types.
+//
+//   - When marshaling, if a user-defined marshal method reports an error,
+//     it is always wrapped in a [MarshalerError], even if the error itself
+//     is already a [MarshalerError].
+//
+//   - When unmarshaling, if a user-defined unmarshal method reports an error,
+//     it is never wrapped.
+//
+//   - When unmarshaling, if a JSON value type mismatches the Go type,
+//     unmarshaling continues with the rest of the input and the first
+//     such error is reported once the entire value has been processed.
+//     In contrast, the v2 semantic is to report the first error immediately.
+//
+// This affects either marshaling or unmarshaling.
+// The v1 default is true.
+func ReportErrorsWithLegacySemantics(v bool) Options {
+	if v {
+		return jsonflags.ReportErrorsWithLegacySemantics | 1
+	} else {
+		return jsonflags.ReportErrorsWithLegacySemantics | 0
+	}
+}
+
+// StringifyWithLegacySemantics specifies that the `string` tag option
+// may stringify bools and string values. It only takes effect on fields
+// where the top-level type is a bool, string, numeric kind, or a pointer to
+// such a kind. Specifically, `string` will not stringify bool, string,
+// or numeric kinds within a composite data type
+// (e.g., array, slice, struct, map, or interface).
+//
+// When marshaling, such Go values are serialized as their usual
+// JSON representation, but quoted within a JSON string.
+// When unmarshaling, such Go values must be deserialized from
+// a JSON string containing their usual JSON representation.
+// A JSON null quoted in a JSON string is a valid substitute for JSON null
+// while unmarshaling into a Go value that `string` takes effect on.
+//
+// This affects either marshaling or unmarshaling.
+// The v1 default is true.
+func StringifyWithLegacySemantics(v bool) Options {
+	if v {
+		return jsonflags.StringifyWithLegacySemantics | 1
+	} else {
+		return jsonflags.StringifyWithLegacySemantics | 0
+	}
+}
+
+// UnmarshalArrayFromAnyLength specifies that Go arrays can be unmarshaled
+// from input JSON arrays of any length. If the JSON array is too short,
+// then the remaining Go array elements are zeroed. If the JSON array
+// is too long, then the excess JSON array elements are skipped over.
+//
+// This only affects unmarshaling and is ignored when marshaling.
+// The v1 default is true.
+func UnmarshalArrayFromAnyLength(v bool) Options {
+	if v {
+		return jsonflags.UnmarshalArrayFromAnyLength | 1
+	} else {
+		return jsonflags.UnmarshalArrayFromAnyLength | 0
+	}
+}
+
+// unmarshalAnyWithRawNumber specifies that unmarshaling a JSON number into
+// an empty Go interface should use the Number type instead of a float64.
+func unmarshalAnyWithRawNumber(v bool) Options {
+	if v {
+		return jsonflags.UnmarshalAnyWithRawNumber | 1
+	} else {
+		return jsonflags.UnmarshalAnyWithRawNumber | 0
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.jsonv2
+
+package json
+
+import (
+	"errors"
+	"io"
+
+	"encoding/json/jsontext"
+)
+
+var errUnexpectedEnd = errors.New("unexpected end of JSON input")
+
+// transformSyntacticError converts a syntactic error reported by
+// the jsontext package into a [SyntaxError].
+// All other errors are returned unchanged.
+func transformSyntacticError(err error) error {
+	switch serr, ok := err.(*jsontext.SyntacticError); {
+	case serr != nil:
+		if serr.Err == io.ErrUnexpectedEOF {
+			return &SyntaxError{errUnexpectedEnd.Error(), serr.ByteOffset}
+		}
+		return &SyntaxError{serr.Err.Error(), serr.ByteOffset}
+	case ok:
+		return (*SyntaxError)(nil)
+	case err == io.ErrUnexpectedEOF:
+		return &SyntaxError{msg: errUnexpectedEnd.Error()}
+	}
+	return err
+}
+
+// legacySyntaxError returns the SyntaxError that the scanner reports for
+// data, which jsontext rejected with err. The scanner describes the error
+// the same way as the original implementation did, so it is preferred;
+// if it accepts data, err is converted by transformSyntacticError instead.
+// Since data is scanned again, this is only called on the error path.
+func legacySyntaxError(data []byte, err error) error {
+	scan := newScanner()
+	defer freeScanner(scan)
+	if serr := checkValid(data, scan); serr != nil {
+		return serr
+	}
+	return transformSyntacticError(err)
+}
@@ -0,0 +1,27 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.jsonv2
+
+package json
+
+import (
+	"testing"
+)
+
+// The v2 implementation parses struct tags itself,
+// so this checks the parsed name and options through Marshal instead.
+func TestTagParsing(t *testing.T) {
+	type T struct {
+		Field  int `json:"field,foobar,foo"`
+		String int `json:"str,foo,string"`
+	}
+	got, err := Marshal(T{1, 2})
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	if want := `{"field":1,"str":"2"}`; string(got) != want {
+		t.Fatalf("Marshal = %s, want %s", got, want)
+	}
+}
@@ -39,13 +39,14 @@ import (
 var depsRules = `
 	# No dependencies allowed for any of these packages.
 	NONE
-	< constraints, container/list, container/ring,
+	< cmp, constraints, container/list, container/ring,
 	  internal/buildinternal,
 	  internal/cfg, internal/coverage, internal/coverage/rtcov,
 	  internal/coverage/uleb128, internal/coverage/calloc,
 	  internal/cpu, internal/goarch,
 	  internal/goexperiment, internal/goos,
 	  internal/goversion, internal/nettrace, internal/platform,
+	  log/internal,
 	  unicode/utf8, unicode/utf16, unicode,
 	  unsafe;
 
@@ -67,6 +68,7 @@ var depsRules = `
 	< runtime/internal/syscall
 	< runtime/internal/atomic
 	< runtime/internal/math
+	< runtime/internal/cgroup
 	< runtime
 	< sync/atomic
 	< internal/race
@@ -86,6 +88,19 @@ var depsRules = `
 	RUNTIME
 	< arena;
 
+	RUNTIME
+	< weak
+	< unique;
+
+	RUNTIME
+	< iter;
+
+	cmp, math/bits, unsafe
+	< slices;
+
+	iter, unsafe
+	< maps;
+
 	syscall !< io;
 	reflect !< sort;
 
@@ -214,7 +229,26 @@ var depsRules = `
 
 	FMT, encoding/base32, encoding/base64, internal/saferio
 	< encoding/ascii85, encoding/csv, encoding/gob, encoding/hex,
-	  encoding/json, encoding/pem, encoding/xml, mime;
+	  encoding/pem, encoding/xml, mime;
+
+	STR, errors, cmp, iter, slices
+	< encoding/json/internal
+	< encoding/json/internal/jsonflags
+	< encoding/json/internal/jsonopts
+	< encoding/json/internal/jsonwire
+	< encoding/json/jsontext;
+
+	FMT, cmp, slices,
+	encoding/hex,
+	encoding/base32,
+	encoding/base64,
+	encoding/json/jsontext,
+	encoding/json/internal,
+	encoding/json/internal/jsonflags,
+	encoding/json/internal/jsonopts,
+	encoding/json/internal/jsonwire
+	< encoding/json/v2
+	< encoding/json;
 
 	# hashes
 	io
@@ -340,7 +374,7 @@ var depsRules = `
 	internal/godebug
 	< internal/intern;
 
-	internal/bytealg, internal/intern, internal/itoa, math/bits, sort, strconv
+	internal/bytealg, internal/itoa, math/bits, sort, strconv, unique
 	< net/netip;
 
 	# net is unavoidable when doing any networking,
@@ -371,14 +405,24 @@ var depsRules = `
 	< NET;
 
 	# logging - most packages should not import; http and up is allowed
-	FMT
+	FMT, log/internal
 	< log;
 
-	log !< crypto/tls, database/sql, go/importer, testing;
+	log, log/slog !< crypto/tls, database/sql, go/importer, testing;
 
 	FMT, log, net
 	< log/syslog;
 
+	RUNTIME
+	< log/slog/internal/buffer;
+
+	FMT,
+	encoding, encoding/json,
+	log,
+	log/slog/internal/buffer,
+	slices
+	< log/slog;
+
 	NET, log
 	< net/mail;
 
@@ -523,6 +567,9 @@ var depsRules = `
 	< testing/iotest
 	< testing/fstest;
 
+	log/slog
+	< testing/slogtest;
+
 	FMT, flag, math/rand
 	< testing/quick;
 
@@ -552,7 +599,8 @@ var depsRules = `
 	< os/exec/internal/fdtest;
 
 	FMT, container/heap, math/rand
-	< internal/trace;
+	< internal/trace
+	< debug/trace;
 
 	FMT
 	< internal/diff, internal/txtar;
@@ -7,6 +7,7 @@ package gcimporter
 import (
 	"go/token"
 	"go/types"
+	"internal/godebug"
 	"internal/pkgbits"
 )
 
@@ -514,8 +515,12 @@ func (pr *pkgReader) objIdx(idx pkgbits.Index) (*types.Package, string) {
 
 		case pkgbits.ObjAlias:
 			pos := r.pos()
+			var tparams []*types.TypeParam
+			if r.p.Version().Has(pkgbits.AliasTypeParamNames) {
+				tparams = r.typeParamNames()
+			}
 			typ := r.typ()
-			declare(types.NewTypeName(pos, objPkg, objName, typ))
+			declare(newAliasTypeName(pos, objPkg, objName, typ, tparams))
 
 		case pkgbits.ObjConst:
 			pos := r.pos()
@@ -702,3 +707,21 @@ func pkgScope(pkg *types.Package) *types.Scope {
 	}
 	return types.Universe
 }
+
+// newAliasTypeName returns a new TypeName for an alias declaration
+// with the given type parameters and aliased type. Alias types are
+// only created if the GODEBUG setting gotypesalias=1 is in effect;
+// otherwise the type name denotes the aliased type directly, and
+// generic aliases, which cannot be represented, denote an invalid
+// type.
+func newAliasTypeName(pos token.Pos, pkg *types.Package, name string, rhs types.Type, tparams []*types.TypeParam) *types.TypeName {
+	if godebug.Get("gotypesalias") != "1" {
+		if len(tparams) > 0 {
+			rhs = types.Typ[types.Invalid]
+		}
+		return types.NewTypeName(pos, pkg, name, rhs)
+	}
+	tname := types.NewTypeName(pos, pkg, name, nil)
+	types.NewAlias(tname, rhs).SetTypeParams(tparams)
+	return tname
+}
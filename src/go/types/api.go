@@ -426,7 +426,7 @@ func (conf *Config) Check(path string, fset *token.FileSet, files []*ast.File, i
 func AssertableTo(V *Interface, T Type) bool {
 	// Checker.newAssertableTo suppresses errors for invalid types, so we need special
 	// handling here.
-	if T.Underlying() == Typ[Invalid] {
+	if !isValid(T.Underlying()) {
 		return false
 	}
 	return (*Checker)(nil).newAssertableTo(V, T)
@@ -464,7 +464,7 @@ func Implements(V Type, T *Interface) bool {
 	}
 	// Checker.implements suppresses errors for invalid types, so we need special
 	// handling here.
-	if V.Underlying() == Typ[Invalid] {
+	if !isValid(V.Underlying()) {
 		return false
 	}
 	return (*Checker)(nil).implements(V, T, false, nil)
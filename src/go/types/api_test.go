@@ -2568,3 +2568,40 @@ func (V4) M()
 	// V4 has no method m but has M. Should not report wrongType.
 	checkMissingMethod("V4", false)
 }
+
+func TestGenericAlias(t *testing.T) {
+	t.Setenv("GODEBUG", "gotypesalias=1")
+
+	const src = `
+package p
+
+type T[P any] struct{ f P }
+
+type A[P any] = T[P]
+
+var x A[int]
+`
+	pkg := mustTypecheck("p", src, nil)
+
+	A := pkg.Scope().Lookup("A").Type().(*Alias)
+	if got := A.TypeParams().Len(); got != 1 {
+		t.Fatalf("A has %d type parameters, want 1", got)
+	}
+
+	inst, ok := pkg.Scope().Lookup("x").Type().(*Alias)
+	if !ok {
+		t.Fatalf("type of x is %T, want *Alias", pkg.Scope().Lookup("x").Type())
+	}
+	if inst.Origin() != A {
+		t.Errorf("x.Origin() = %s, want %s", inst.Origin(), A)
+	}
+	if got := inst.TypeArgs().Len(); got != 1 || inst.TypeArgs().At(0) != Typ[Int] {
+		t.Errorf("x has type arguments %v, want [int]", inst.TypeArgs())
+	}
+	if got, want := inst.String(), "p.A[int]"; got != want {
+		t.Errorf("x has type %s, want %s", got, want)
+	}
+	if got, want := Unalias(inst).String(), "p.T[int]"; got != want {
+		t.Errorf("Unalias(x) = %s, want %s", got, want)
+	}
+}
@@ -218,9 +218,13 @@ func testFiles(t *testing.T, sizes Sizes, filenames []string, srcs [][]byte, man
 	flags.StringVar(&conf.GoVersion, "lang", "", "")
 	flags.BoolVar(&conf.FakeImportC, "fakeImportC", false, "")
 	flags.BoolVar(addrAltComparableSemantics(&conf), "altComparableSemantics", false, "")
+	gotypesalias := flags.String("gotypesalias", "", "")
 	if err := parseFlags(filenames[0], srcs[0], flags); err != nil {
 		t.Fatal(err)
 	}
+	if *gotypesalias != "" {
+		t.Setenv("GODEBUG", "gotypesalias="+*gotypesalias)
+	}
 
 	files, errlist := parseFiles(t, filenames, srcs, parser.AllErrors)
 
@@ -137,8 +137,8 @@ func (x *operand) convertibleTo(check *Checker, T Type, cause *string) bool {
 	// "V and T are unnamed pointer types and their pointer base types
 	// have identical underlying types if tags are ignored
 	// and their pointer base types are not type parameters"
-	if V, ok := V.(*Pointer); ok {
-		if T, ok := T.(*Pointer); ok {
+	if V, ok := Unalias(V).(*Pointer); ok {
+		if T, ok := Unalias(T).(*Pointer); ok {
 			if IdenticalIgnoreTags(under(V.base), under(T.base)) && !isTypeParam(V.base) && !isTypeParam(T.base) {
 				return true
 			}
@@ -460,7 +460,7 @@ func (check *Checker) constDecl(obj *Const, typ, init ast.Expr, inherited bool)
 		if !isConstType(t) {
 			// don't report an error if the type is an invalid C (defined) type
 			// (issue #22090)
-			if under(t) != Typ[Invalid] {
+			if isValid(under(t)) {
 				check.errorf(typ, InvalidConstType, "invalid constant type %s", t)
 			}
 			obj.typ = Typ[Invalid]
@@ -548,7 +548,7 @@ func (check *Checker) varDecl(obj *Var, lhs []*Var, typ, init ast.Expr) {
 
 // isImportedConstraint reports whether typ is an imported type constraint.
 func (check *Checker) isImportedConstraint(typ Type) bool {
-	named, _ := typ.(*Named)
+	named := asNamed(typ)
 	if named == nil || named.obj.pkg == check.pkg || named.obj.pkg == nil {
 		return false
 	}
@@ -572,10 +572,14 @@ func (check *Checker) typeDecl(obj *TypeName, tdecl *ast.TypeSpec, def *Named) {
 
 	alias := tdecl.Assign.IsValid()
 	if alias && tdecl.TypeParams.NumFields() != 0 {
-		// The parser will ensure this but we may still get an invalid AST.
-		// Complain and continue as regular type definition.
-		check.error(atPos(tdecl.Assign), BadDecl, "generic type cannot be alias")
-		alias = false
+		if !check.enableAlias {
+			// Without Alias types, generic aliases cannot be represented.
+			// Complain and continue as regular type definition.
+			check.error(atPos(tdecl.Assign), UnsupportedFeature, "generic type alias requires GODEBUG=gotypesalias=1")
+			alias = false
+		} else if !check.allowVersion(check.pkg, 1, 20) {
+			check.versionErrorf(atPos(tdecl.Assign), "go1.20", "generic type alias")
+		}
 	}
 
 	// alias declaration
@@ -584,9 +588,35 @@ func (check *Checker) typeDecl(obj *TypeName, tdecl *ast.TypeSpec, def *Named) {
 			check.error(atPos(tdecl.Assign), UnsupportedFeature, "type aliases requires go1.9 or later")
 		}
 
+		// The alias is broken (and references to it are reported as
+		// invalid) until its right-hand side has been type-checked.
 		check.brokenAlias(obj)
+
+		if !check.enableAlias {
+			rhs = check.typ(tdecl.Type)
+			check.validAlias(obj, rhs)
+			return
+		}
+
+		var tparams *TypeParamList
+		if tdecl.TypeParams != nil {
+			check.openScope(tdecl, "type parameters")
+			defer check.closeScope()
+			check.collectTypeParams(&tparams, tdecl.TypeParams)
+		}
+
 		rhs = check.typ(tdecl.Type)
-		check.validAlias(obj, rhs)
+
+		// spec: "In an alias declaration the given type cannot be a type
+		// parameter declared in the same declaration."
+		if isTypeParam(rhs) {
+			check.error(tdecl.Type, MisplacedTypeParam, "cannot use type parameter declared in alias declaration as RHS")
+			rhs = Typ[Invalid]
+		}
+
+		a := check.newAlias(obj, rhs)
+		a.tparams = tparams
+		check.validAlias(obj, a)
 		return
 	}
 
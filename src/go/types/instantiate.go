@@ -15,9 +15,9 @@ import (
 )
 
 // Instantiate instantiates the type orig with the given type arguments targs.
-// orig must be a *Named or a *Signature type. If there is no error, the
-// resulting Type is an instantiated type of the same kind (either a *Named or
-// a *Signature). Methods attached to a *Named type are also instantiated, and
+// orig must be an *Alias, *Named, or *Signature type. If there is no error,
+// the resulting Type is an instantiated type of the same kind (*Alias, *Named
+// or *Signature, respectively). Methods attached to a *Named type are also instantiated, and
 // associated with a new *Func that has the same position as the original
 // method, but nil function scope.
 //
@@ -47,6 +47,8 @@ func Instantiate(ctxt *Context, orig Type, targs []Type, validate bool) (Type, e
 	if validate {
 		var tparams []*TypeParam
 		switch t := orig.(type) {
+		case *Alias:
+			tparams = t.TypeParams().list()
 		case *Named:
 			tparams = t.TypeParams().list()
 		case *Signature:
@@ -118,6 +120,16 @@ func (check *Checker) instance(pos token.Pos, orig Type, targs []Type, expanding
 	case *Named:
 		res = check.newNamedInstance(pos, orig, targs, expanding) // substituted lazily
 
+	case *Alias:
+		tparams := orig.TypeParams()
+		if !check.validateTArgLen(pos, tparams.Len(), len(targs)) {
+			return Typ[Invalid]
+		}
+		if tparams.Len() == 0 {
+			return orig // nothing to do (minor optimization)
+		}
+		res = check.newAliasInstance(pos, orig, targs, expanding, ctxt)
+
 	case *Signature:
 		assert(expanding == nil) // function instances cannot be reached from Named types
 
@@ -192,10 +204,10 @@ func (check *Checker) verify(pos token.Pos, tparams []*TypeParam, targs []Type,
 func (check *Checker) implements(V, T Type, constraint bool, cause *string) bool {
 	Vu := under(V)
 	Tu := under(T)
-	if Vu == Typ[Invalid] || Tu == Typ[Invalid] {
+	if !isValid(Vu) || !isValid(Tu) {
 		return true // avoid follow-on errors
 	}
-	if p, _ := Vu.(*Pointer); p != nil && under(p.base) == Typ[Invalid] {
+	if p, _ := Vu.(*Pointer); p != nil && !isValid(under(p.base)) {
 		return true // avoid follow-on errors (see issue #49541 for an example)
 	}
 
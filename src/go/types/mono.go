@@ -210,6 +210,9 @@ func (w *monoGraph) assign(pkg *Package, pos token.Pos, tpar *TypeParam, targ Ty
 		default:
 			panic("unexpected type")
 
+		case *Alias:
+			do(Unalias(typ))
+
 		case *TypeParam:
 			assert(typ.Obj().Pkg() == pkg)
 			flow(w.typeParamVertex(typ), typ)
@@ -141,8 +141,14 @@ func (check *Checker) funcType(sig *Signature, recvPar *ast.FieldList, ftyp *ast
 				// Also: Don't report an error via genericType since it will be reported
 				//       again when we type-check the signature.
 				// TODO(gri) maybe the receiver should be marked as invalid instead?
-				if recv, _ := check.genericType(rname, nil).(*Named); recv != nil {
+				switch recv := check.genericType(rname, nil).(type) {
+				case *Named:
 					recvTParams = recv.TypeParams().list()
+				case *Alias:
+					if recv.TypeParams().Len() > 0 {
+						check.errorf(rname, InvalidRecv, "cannot define new methods on generic alias type %s", recv)
+						recvTParams = recv.TypeParams().list()
+					}
 				}
 			}
 			// provide type parameter bounds
@@ -212,13 +218,13 @@ func (check *Checker) funcType(sig *Signature, recvPar *ast.FieldList, ftyp *ast
 		check.later(func() {
 			// spec: "The receiver type must be of the form T or *T where T is a type name."
 			rtyp, _ := deref(recv.typ)
-			if rtyp == Typ[Invalid] {
+			if !isValid(rtyp) {
 				return // error was reported before
 			}
 			// spec: "The type denoted by T is called the receiver base type; it must not
 			// be a pointer or interface type and it must be declared in the same package
 			// as the method."
-			switch T := rtyp.(type) {
+			switch T := Unalias(rtyp).(type) {
 			case *Named:
 				// The receiver type may be an instantiated type referred to
 				// by an alias (which cannot have receiver parameters for now).
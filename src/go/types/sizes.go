@@ -105,8 +105,8 @@ func (s *StdSizes) Alignof(T Type) int64 {
 }
 
 func isSyncAtomicAlign64(T Type) bool {
-	named, ok := T.(*Named)
-	if !ok {
+	named := asNamed(T)
+	if named == nil {
 		return false
 	}
 	obj := named.Obj()
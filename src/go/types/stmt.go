@@ -291,7 +291,7 @@ L:
 			check.expr(&dummy, e) // run e through expr so we get the usual Info recordings
 		} else {
 			T = check.varType(e)
-			if T == Typ[Invalid] {
+			if !isValid(T) {
 				continue L
 			}
 		}
@@ -850,8 +850,25 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 					cause = "receive from send-only channel"
 				}
 			}
-			key, val = rangeKeyVal(u)
-			if key == nil || cause != "" {
+			var isFunc bool
+			key, val, isFunc = rangeKeyVal(u)
+			if isFunc && cause == "" {
+				cause = rangeFuncCause(u.(*Signature))
+				if cause == "" && !check.allowVersion(check.pkg, 1, 20) {
+					cause = "requires go1.20 or later"
+				}
+				if cause == "" {
+					switch {
+					case key == nil && s.Key != nil:
+						check.softErrorf(s.Key, InvalidIterVar, "range over %s permits no iteration variables", &x)
+						// ok to continue
+					case val == nil && s.Value != nil:
+						check.softErrorf(s.Value, InvalidIterVar, "range over %s permits only one iteration variable", &x)
+						// ok to continue
+					}
+				}
+			}
+			if (key == nil && !isFunc) || cause != "" {
 				if cause == "" {
 					check.softErrorf(&x, InvalidRangeExpr, "cannot range over %s", &x)
 				} else {
@@ -942,21 +959,64 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 
 // rangeKeyVal returns the key and value type produced by a range clause
 // over an expression of type typ. If the range clause is not permitted
-// the results are nil.
-func rangeKeyVal(typ Type) (key, val Type) {
+// the results are nil. If typ is a function, isFunc is set and key and
+// val are the types of the yield function's parameters, if any; the
+// function's shape is validated separately by rangeFuncCause.
+func rangeKeyVal(typ Type) (key, val Type, isFunc bool) {
 	switch typ := arrayPtrDeref(typ).(type) {
 	case *Basic:
 		if isString(typ) {
-			return Typ[Int], universeRune // use 'rune' name
+			return Typ[Int], universeRune, false // use 'rune' name
 		}
 	case *Array:
-		return Typ[Int], typ.elem
+		return Typ[Int], typ.elem, false
 	case *Slice:
-		return Typ[Int], typ.elem
+		return Typ[Int], typ.elem, false
 	case *Map:
-		return typ.key, typ.elem
+		return typ.key, typ.elem, false
 	case *Chan:
-		return typ.elem, Typ[Invalid]
+		return typ.elem, Typ[Invalid], false
+	case *Signature:
+		if typ.params.Len() == 1 {
+			if cb, _ := coreType(typ.params.vars[0].typ).(*Signature); cb != nil {
+				if n := cb.params.Len(); n >= 1 {
+					key = cb.params.vars[0].typ
+					if n >= 2 {
+						val = cb.params.vars[1].typ
+					}
+				}
+			}
+		}
+		return key, val, true
 	}
 	return
 }
+
+// rangeFuncCause returns the reason why a range clause over a function
+// of type sig is not permitted, or the empty string if it is permitted.
+// A range-over-func iterator must have the form
+//
+//	func(yield func() bool)
+//	func(yield func(K) bool)
+//	func(yield func(K, V) bool)
+func rangeFuncCause(sig *Signature) string {
+	const prefix = "func must be func(yield func(...) bool): "
+	if sig.params.Len() != 1 {
+		return prefix + "wrong argument count"
+	}
+	if sig.results.Len() != 0 {
+		return prefix + "unexpected results"
+	}
+	cb, _ := coreType(sig.params.vars[0].typ).(*Signature)
+	switch {
+	case cb == nil:
+		return prefix + "argument is not func"
+	case cb.params.Len() > 2:
+		return prefix + "yield func has too many parameters"
+	case cb.variadic:
+		return prefix + "yield func is variadic"
+	case cb.results.Len() != 1 || !isBoolean(cb.results.vars[0].typ):
+		return prefix + "yield func does not return bool"
+	}
+	return ""
+}
@@ -201,6 +201,26 @@ func (subst *subster) typ(typ Type) Type {
 			return &Chan{dir: t.dir, elem: elem}
 		}
 
+	case *Alias:
+		// This code follows the code for *Named types closely.
+		orig := t.Origin()
+		n := orig.TypeParams().Len()
+		if n == 0 {
+			return t // type is not parameterized
+		}
+
+		if t.TypeArgs().Len() != n {
+			return Typ[Invalid] // error reported elsewhere
+		}
+
+		// already instantiated
+		// For each (existing) type argument determine if it needs
+		// to be substituted; i.e., if it is or contains a type parameter
+		// that has a type argument for it.
+		if targs, updated := subst.typeList(t.TypeArgs().list()); updated {
+			return subst.check.instance(subst.pos, orig, targs, subst.expanding, subst.ctxt)
+		}
+
 	case *Named:
 		// dump is for debugging
 		dump := func(string, ...any) {}
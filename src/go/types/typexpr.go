@@ -80,7 +80,7 @@ func (check *Checker) ident(x *operand, e *ast.Ident, def *Named, wantType bool)
 
 	case *Const:
 		check.addDeclDep(obj)
-		if typ == Typ[Invalid] {
+		if !isValid(typ) {
 			return
 		}
 		if obj == universeIota {
@@ -110,7 +110,7 @@ func (check *Checker) ident(x *operand, e *ast.Ident, def *Named, wantType bool)
 			obj.used = true
 		}
 		check.addDeclDep(obj)
-		if typ == Typ[Invalid] {
+		if !isValid(typ) {
 			return
 		}
 		x.mode = variable
@@ -194,7 +194,7 @@ func (check *Checker) definedType(e ast.Expr, def *Named) Type {
 func (check *Checker) genericType(e ast.Expr, cause *string) Type {
 	typ := check.typInternal(e, nil)
 	assert(isTyped(typ))
-	if typ != Typ[Invalid] && !isGeneric(typ) {
+	if isValid(typ) && !isGeneric(typ) {
 		if cause != nil {
 			*cause = check.sprintf("%s is not a generic type", typ)
 		}
@@ -410,15 +410,10 @@ func (check *Checker) instantiatedType(ix *typeparams.IndexExpr, def *Named) (re
 	if cause != "" {
 		check.errorf(ix.Orig, NotAGenericType, invalidOp+"%s (%s)", ix.Orig, cause)
 	}
-	if gtyp == Typ[Invalid] {
+	if !isValid(gtyp) {
 		return gtyp // error already reported
 	}
 
-	orig, _ := gtyp.(*Named)
-	if orig == nil {
-		panic(fmt.Sprintf("%v: cannot instantiate %v", ix.Pos(), gtyp))
-	}
-
 	// evaluate arguments
 	targs := check.typeList(ix.Indices)
 	if targs == nil {
@@ -426,6 +421,15 @@ func (check *Checker) instantiatedType(ix *typeparams.IndexExpr, def *Named) (re
 		return Typ[Invalid]
 	}
 
+	if orig, _ := gtyp.(*Alias); orig != nil && orig.TypeParams().Len() > 0 {
+		return check.aliasInstance(ix, orig, targs, def)
+	}
+
+	orig := asNamed(gtyp)
+	if orig == nil {
+		panic(fmt.Sprintf("%v: cannot instantiate %v", ix.Pos(), gtyp))
+	}
+
 	// create the instance
 	inst := check.instance(ix.Pos(), orig, targs, nil, check.context()).(*Named)
 	def.setUnderlying(inst)
@@ -459,6 +463,38 @@ func (check *Checker) instantiatedType(ix *typeparams.IndexExpr, def *Named) (re
 	return inst
 }
 
+// aliasInstance instantiates the generic alias type orig with the type
+// arguments targs, given by the type expressions ix.Indices.
+func (check *Checker) aliasInstance(ix *typeparams.IndexExpr, orig *Alias, targs []Type, def *Named) Type {
+	// Unlike the type parameters of a Named type, the type parameters
+	// of an alias are set up before the alias can be referenced, so
+	// the type arguments can be validated right away.
+	tparams := orig.TypeParams().list()
+	if !check.validateTArgLen(ix.Pos(), len(tparams), len(targs)) {
+		def.setUnderlying(Typ[Invalid])
+		return Typ[Invalid]
+	}
+
+	inst := check.instance(ix.Pos(), orig, targs, nil, check.context())
+	def.setUnderlying(inst)
+
+	check.later(func() {
+		check.recordInstance(ix.Orig, targs, inst)
+		if i, err := check.verify(ix.Pos(), tparams, targs, check.context()); err != nil {
+			// best position for error reporting
+			pos := ix.Pos()
+			if i < len(ix.Indices) {
+				pos = ix.Indices[i].Pos()
+			}
+			check.softErrorf(atPos(pos), InvalidTypeArg, err.Error())
+		} else {
+			check.mono.recordInstance(check.pkg, ix.Pos(), tparams, targs, ix.Indices)
+		}
+	}).describef(ix, "verify instance %s", inst)
+
+	return inst
+}
+
 // arrayLength type-checks the array length expression e
 // and returns the constant length >= 0, or a value < 0
 // to indicate an error (and thus an unknown length).
@@ -510,7 +546,7 @@ func (check *Checker) typeList(list []ast.Expr) []Type {
 	res := make([]Type, len(list)) // res != nil even if len(list) == 0
 	for i, x := range list {
 		t := check.varType(x)
-		if t == Typ[Invalid] {
+		if !isValid(t) {
 			res = nil
 		}
 		if res != nil {
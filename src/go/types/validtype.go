@@ -31,6 +31,9 @@ func (check *Checker) validType0(typ Type, nest, path []*Named) bool {
 			panic("validType0(nil)")
 		}
 
+	case *Alias:
+		return check.validType0(Unalias(t), nest, path)
+
 	case *Array:
 		return check.validType0(t.elem, nest, path)
 
@@ -68,7 +71,7 @@ func (check *Checker) validType0(typ Type, nest, path []*Named) bool {
 		// Don't report a 2nd error if we already know the type is invalid
 		// (e.g., if a cycle was detected earlier, via under).
 		// Note: ensure that t.orig is fully resolved by calling Underlying().
-		if t.Underlying() == Typ[Invalid] {
+		if !isValid(t.Underlying()) {
 			return false
 		}
 
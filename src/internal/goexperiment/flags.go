@@ -94,4 +94,15 @@ type Flags struct {
 	// Arenas causes the "arena" standard library package to be visible
 	// to the outside world.
 	Arenas bool
+
+	// LoopVar changes loop semantics so that each iteration of a
+	// 3-clause or range "for" loop has its own copy of the variables
+	// declared by the loop, in files whose language version (the go
+	// version in go.mod) is go1.20 or later.
+	LoopVar bool
+
+	// JSONv2 makes the "encoding/json/jsontext" and "encoding/json/v2"
+	// standard library packages visible to the outside world and
+	// reimplements "encoding/json" in terms of "encoding/json/v2".
+	JSONv2 bool
 }
@@ -21,7 +21,7 @@ import (
 // export data.
 type PkgDecoder struct {
 	// version is the file format version.
-	version uint32
+	version Version
 
 	// sync indicates whether the file uses sync markers.
 	sync bool
@@ -62,6 +62,9 @@ type PkgDecoder struct {
 // TODO(mdempsky): Remove; unneeded since CL 391014.
 func (pr *PkgDecoder) PkgPath() string { return pr.pkgPath }
 
+// Version returns the file format version of the export data.
+func (pr *PkgDecoder) Version() Version { return pr.version }
+
 // SyncMarkers reports whether pr uses sync markers.
 func (pr *PkgDecoder) SyncMarkers() bool { return pr.sync }
 
@@ -82,12 +85,11 @@ func NewPkgDecoder(pkgPath, input string) PkgDecoder {
 
 	assert(binary.Read(r, binary.LittleEndian, &pr.version) == nil)
 
-	switch pr.version {
-	default:
+	if pr.version >= numVersions {
 		panic(fmt.Errorf("unsupported version: %v", pr.version))
-	case 0:
-		// no flags
-	case 1:
+	}
+
+	if pr.version.Has(Flags) {
 		var flags uint32
 		assert(binary.Read(r, binary.LittleEndian, &flags) == nil)
 		pr.sync = flags&flagSyncMarkers != 0
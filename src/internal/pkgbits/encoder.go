@@ -15,16 +15,13 @@ import (
 	"strings"
 )
 
-// currentVersion is the current version number.
-//
-//   - v0: initial prototype
-//
-//   - v1: adds the flags uint32 word
+// currentVersion is the current version number written by PkgEncoder.
+// See Version for the changes made in each version.
 //
 // TODO(mdempsky): For the next version bump:
 //   - remove the legacy "has init" bool from the public root
 //   - remove obj's "derived func instance" bool
-const currentVersion uint32 = 1
+const currentVersion = V2
 
 // A PkgEncoder provides methods for encoding a package's Unified IR
 // export data.
@@ -69,7 +66,7 @@ func (pw *PkgEncoder) DumpTo(out0 io.Writer) (fingerprint [8]byte) {
 		assert(binary.Write(out, binary.LittleEndian, x) == nil)
 	}
 
-	writeUint32(currentVersion)
+	writeUint32(uint32(currentVersion))
 
 	var flags uint32
 	if pw.SyncMarkers() {
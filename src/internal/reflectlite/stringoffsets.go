@@ -0,0 +1,41 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflectlite
+
+import "unsafe"
+
+// StringOffsets returns the offsets of all the strings stored directly
+// in a value of type t: t itself if it is a string, and the strings in
+// its struct fields and array elements, at any depth. Strings reached
+// through pointers or held in interface values are not included.
+func StringOffsets(t Type) []uintptr {
+	if t == nil {
+		return nil
+	}
+	return appendStringOffsets(nil, t.common(), 0)
+}
+
+func appendStringOffsets(offsets []uintptr, t *rtype, base uintptr) []uintptr {
+	if !t.pointers() {
+		// No strings in here, and no need to walk a large array.
+		return offsets
+	}
+	switch t.Kind() {
+	case String:
+		offsets = append(offsets, base)
+	case Struct:
+		tt := (*structType)(unsafe.Pointer(t))
+		for i := range tt.fields {
+			f := &tt.fields[i]
+			offsets = appendStringOffsets(offsets, f.typ, base+f.offset)
+		}
+	case Array:
+		tt := (*arrayType)(unsafe.Pointer(t))
+		for i := uintptr(0); i < tt.len; i++ {
+			offsets = appendStringOffsets(offsets, tt.elem, base+i*tt.elem.size)
+		}
+	}
+	return offsets
+}
@@ -124,6 +124,10 @@ func order1007(m map[int][]*Event) (events []*Event, err error) {
 		switch ev.Type {
 		case EvGoSysBlock, EvGoInSyscall:
 			lastSysBlock[ev.G] = ev.Ts
+		case EvGoStatus:
+			if ev.Args[1] == GoStatusSyscall {
+				lastSysBlock[ev.G] = ev.Ts
+			}
 		case EvGoSysExit:
 			ts := int64(ev.Args[2])
 			if ts == 0 {
@@ -156,6 +160,15 @@ func stateTransition(ev *Event) (g uint64, init, next gState) {
 		g = ev.G
 		init = gState{1, gRunnable}
 		next = gState{2, gWaiting}
+	case EvGoStatus:
+		// The goroutine's sequence number carries over from the
+		// previous generation, which is not available here.
+		g = ev.G
+		init = gState{0, gDead}
+		next = gState{ev.Args[2] + 1, gRunnable}
+		if ev.Args[1] != GoStatusRunnable {
+			next.status = gWaiting
+		}
 	case EvGoStart, EvGoStartLabel:
 		g = ev.G
 		init = gState{ev.Args[1], gRunnable}
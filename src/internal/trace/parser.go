@@ -101,64 +101,190 @@ func Parse(r io.Reader, bin string) (ParseResult, error) {
 // parse parses, post-processes and verifies the trace. It returns the
 // trace version and the list of events.
 func parse(r io.Reader, bin string) (int, ParseResult, error) {
-	ver, rawEvents, strings, err := readTrace(r)
+	rd, err := NewReader(r)
 	if err != nil {
 		return 0, ParseResult{}, err
 	}
-	events, stacks, err := parseEvents(ver, rawEvents, strings)
-	if err != nil {
-		return 0, ParseResult{}, err
+	var events []*Event
+	stacks := make(map[uint64][]*Frame)
+	for {
+		evs, stks, err := rd.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, ParseResult{}, err
+		}
+		events = append(events, evs...)
+		for id, stk := range stks {
+			stacks[id] = stk
+		}
 	}
-	events = removeFutile(events)
-	err = postProcessTrace(ver, events)
-	if err != nil {
-		return 0, ParseResult{}, err
+	events = expandGoStatus(events)
+	if rd.ver < 1007 && bin != "" {
+		if err := symbolize(events, bin); err != nil {
+			return 0, ParseResult{}, err
+		}
 	}
-	// Attach stack traces.
+	return rd.ver, ParseResult{Events: events, Stacks: stacks}, nil
+}
+
+// expandGoStatus rewrites the EvGoStatus events in events into the
+// EvGoCreate, EvGoWaiting and EvGoInSyscall events that describe the
+// goroutines at the start of traces written before Go 1.20, so that
+// users of Parse need not know about generations.
+func expandGoStatus(events []*Event) []*Event {
+	n := 0
 	for _, ev := range events {
-		if ev.StkID != 0 {
-			ev.Stk = stacks[ev.StkID]
+		if ev.Type == EvGoStatus {
+			n++
 		}
 	}
-	if ver < 1007 && bin != "" {
-		if err := symbolize(events, bin); err != nil {
-			return 0, ParseResult{}, err
+	if n == 0 {
+		return events
+	}
+	out := make([]*Event, 0, len(events)+n)
+	for _, ev := range events {
+		if ev.Type != EvGoStatus {
+			out = append(out, ev)
+			continue
+		}
+		g, status, stkID := ev.Args[0], ev.Args[1], ev.StkID
+		create := ev
+		if status != GoStatusRunnable {
+			// The status event becomes the EvGoWaiting or
+			// EvGoInSyscall event, so that it stays linked to the
+			// event that unblocks the goroutine.
+			create = &Event{Off: ev.Off, Ts: ev.Ts, P: ev.P}
+			out = append(out, create)
+			ev.Type = EvGoWaiting
+			if status == GoStatusSyscall {
+				ev.Type = EvGoInSyscall
+			}
+			ev.G = g
+			ev.Args = [3]uint64{g}
+			ev.StkID, ev.Stk = 0, nil
 		}
+		create.Type = EvGoCreate
+		create.G = 0
+		create.Args = [3]uint64{g, stkID}
+		create.StkID, create.Stk = 0, nil
+		out = append(out, ev)
 	}
-	return ver, ParseResult{Events: events, Stacks: stacks}, nil
+	return out
 }
 
-// rawEvent is a helper type used during parsing.
-type rawEvent struct {
-	off   int
-	typ   byte
-	args  []uint64
-	sargs []string
+// A Reader reads a trace incrementally.
+//
+// Since Go 1.20, traces are partitioned into generations that can each
+// be understood on their own, and a Reader parses one generation at a
+// time, so the memory it needs depends on the size of a generation
+// rather than that of the whole trace. Older traces are parsed all at
+// once.
+type Reader struct {
+	r    *bufio.Reader
+	off  int
+	ver  int
+	done bool
+
+	post *postProcessor
+
+	// State carried over from one generation to the next.
+	gen       uint64 // last generation read
+	stackBase uint64 // offset added to the stack IDs of the next generation
+	baseTicks int64  // timestamp, in ticks, that corresponds to baseTs
+	baseTs    int64  // timestamp of the last event of the previous generation, in nanoseconds
+	started   bool   // whether baseTicks is valid
 }
 
-// readTrace does wire-format parsing and verification.
-// It does not care about specific event types and argument meaning.
-func readTrace(r io.Reader) (ver int, events []rawEvent, strings map[uint64]string, err error) {
+// NewReader reads the trace header from r and returns a Reader for the
+// rest of the trace.
+func NewReader(r io.Reader) (*Reader, error) {
+	rd := &Reader{r: bufio.NewReader(r)}
 	// Read and validate trace header.
 	var buf [16]byte
-	off, err := io.ReadFull(r, buf[:])
+	off, err := io.ReadFull(rd.r, buf[:])
 	if err != nil {
-		err = fmt.Errorf("failed to read header: read %v, err %v", off, err)
-		return
+		return nil, fmt.Errorf("failed to read header: read %v, err %v", off, err)
 	}
-	ver, err = parseHeader(buf[:])
+	rd.off = off
+	ver, err := parseHeader(buf[:])
 	if err != nil {
-		return
+		return nil, err
 	}
 	switch ver {
-	case 1005, 1007, 1008, 1009, 1010, 1011, 1019:
+	case 1005, 1007, 1008, 1009, 1010, 1011, 1019, 1020:
 		// Note: When adding a new version, confirm that canned traces from the
 		// old version are part of the test suite. Add them using mkcanned.bash.
 		break
 	default:
-		err = fmt.Errorf("unsupported trace file version %v.%v (update Go toolchain) %v", ver/1000, ver%1000, ver)
-		return
+		return nil, fmt.Errorf("unsupported trace file version %v.%v (update Go toolchain) %v", ver/1000, ver%1000, ver)
 	}
+	rd.ver = ver
+	rd.post = newPostProcessor(ver)
+	return rd, nil
+}
+
+// Version returns the version of the trace format, such as 1020 for
+// traces written by Go 1.20.
+func (r *Reader) Version() int {
+	return r.ver
+}
+
+// Next returns the events of the next generation of the trace, in
+// order, with stacks attached, and the stack traces of the generation
+// keyed by stack ID. Goroutines seen for the first time at the start
+// of a generation are described by EvGoStatus events.
+// Traces written before Go 1.20 are returned whole by the first call.
+// Next returns io.EOF once the trace has been read completely.
+func (r *Reader) Next() ([]*Event, map[uint64][]*Frame, error) {
+	if r.done {
+		return nil, nil, io.EOF
+	}
+	rawEvents, strings, err := r.readGeneration()
+	if err != nil {
+		r.done = true
+		return nil, nil, err
+	}
+	if len(rawEvents) == 0 && r.done && r.started {
+		return nil, nil, io.EOF
+	}
+	events, stacks, err := r.parseEvents(rawEvents, strings)
+	if err != nil {
+		r.done = true
+		return nil, nil, err
+	}
+	events = removeFutile(events)
+	events, err = r.post.process(events, stacks)
+	if err != nil {
+		r.done = true
+		return nil, nil, err
+	}
+	// Attach stack traces.
+	for _, ev := range events {
+		if ev.StkID != 0 && ev.Stk == nil {
+			ev.Stk = stacks[ev.StkID]
+		}
+	}
+	return events, stacks, nil
+}
+
+// rawEvent is a helper type used during parsing.
+type rawEvent struct {
+	off   int
+	typ   byte
+	args  []uint64
+	sargs []string
+}
+
+// readGeneration does wire-format parsing and verification of the
+// next generation, or of the whole trace for versions before 1.20.
+// It does not care about specific event types and argument meaning.
+// It sets r.done when it reaches the end of the input.
+func (r *Reader) readGeneration() (events []rawEvent, strings map[uint64]string, err error) {
+	ver, off := r.ver, r.off
+	defer func() { r.off = off }()
+	var buf [16]byte
 
 	// Read events.
 	strings = make(map[uint64]string)
@@ -166,9 +292,10 @@ func readTrace(r io.Reader) (ver int, events []rawEvent, strings map[uint64]stri
 		// Read event type and number of arguments (1 byte).
 		off0 := off
 		var n int
-		n, err = r.Read(buf[:1])
+		n, err = r.r.Read(buf[:1])
 		if err == io.EOF {
 			err = nil
+			r.done = true
 			break
 		}
 		if err != nil || n != 1 {
@@ -190,7 +317,7 @@ func readTrace(r io.Reader) (ver int, events []rawEvent, strings map[uint64]stri
 		if typ == EvString {
 			// String dictionary entry [ID, length, string].
 			var id uint64
-			id, off, err = readVal(r, off)
+			id, off, err = readVal(r.r, off)
 			if err != nil {
 				return
 			}
@@ -203,7 +330,7 @@ func readTrace(r io.Reader) (ver int, events []rawEvent, strings map[uint64]stri
 				return
 			}
 			var ln uint64
-			ln, off, err = readVal(r, off)
+			ln, off, err = readVal(r.r, off)
 			if err != nil {
 				return
 			}
@@ -217,7 +344,7 @@ func readTrace(r io.Reader) (ver int, events []rawEvent, strings map[uint64]stri
 			}
 			buf := make([]byte, ln)
 			var n int
-			n, err = io.ReadFull(r, buf)
+			n, err = io.ReadFull(r.r, buf)
 			if err != nil {
 				err = fmt.Errorf("failed to read trace at offset %d: read %v, want %v, error %v", off, n, ln, err)
 				return
@@ -230,7 +357,7 @@ func readTrace(r io.Reader) (ver int, events []rawEvent, strings map[uint64]stri
 		if narg < inlineArgs {
 			for i := 0; i < int(narg); i++ {
 				var v uint64
-				v, off, err = readVal(r, off)
+				v, off, err = readVal(r.r, off)
 				if err != nil {
 					err = fmt.Errorf("failed to read event %v argument at offset %v (%v)", typ, off, err)
 					return
@@ -240,7 +367,7 @@ func readTrace(r io.Reader) (ver int, events []rawEvent, strings map[uint64]stri
 		} else {
 			// More than inlineArgs args, the first value is length of the event in bytes.
 			var v uint64
-			v, off, err = readVal(r, off)
+			v, off, err = readVal(r.r, off)
 			if err != nil {
 				err = fmt.Errorf("failed to read event %v argument at offset %v (%v)", typ, off, err)
 				return
@@ -248,7 +375,7 @@ func readTrace(r io.Reader) (ver int, events []rawEvent, strings map[uint64]stri
 			evLen := v
 			off1 := off
 			for evLen > uint64(off-off1) {
-				v, off, err = readVal(r, off)
+				v, off, err = readVal(r.r, off)
 				if err != nil {
 					err = fmt.Errorf("failed to read event %v argument at offset %v (%v)", typ, off, err)
 					return
@@ -263,8 +390,20 @@ func readTrace(r io.Reader) (ver int, events []rawEvent, strings map[uint64]stri
 		switch ev.typ {
 		case EvUserLog: // EvUserLog records are followed by a value string of length ev.args[len(ev.args)-1]
 			var s string
-			s, off, err = readStr(r, off)
+			s, off, err = readStr(r.r, off)
 			ev.sargs = append(ev.sargs, s)
+		case EvGeneration:
+			if len(ev.args) != 1 {
+				err = fmt.Errorf("EvGeneration has wrong number of arguments at offset 0x%x: want 1, got %v", off0, len(ev.args))
+				return
+			}
+			gen := ev.args[0]
+			if r.started && gen <= r.gen {
+				err = fmt.Errorf("generation %v at offset 0x%x does not follow generation %v", gen, off0, r.gen)
+				return
+			}
+			r.gen = gen
+			return
 		}
 		events = append(events, ev)
 	}
@@ -314,8 +453,12 @@ func parseHeader(buf []byte) (int, error) {
 
 // Parse events transforms raw events into events.
 // It does analyze and verify per-event-type arguments.
-func parseEvents(ver int, rawEvents []rawEvent, strings map[uint64]string) (events []*Event, stacks map[uint64][]*Frame, err error) {
+// Stack IDs are made unique across generations, and timestamps
+// continue from those of the previous generation.
+func (r *Reader) parseEvents(rawEvents []rawEvent, strings map[uint64]string) (events []*Event, stacks map[uint64][]*Frame, err error) {
+	ver := r.ver
 	var ticksPerSec, lastSeq, lastTs int64
+	var maxStackID uint64
 	var lastG uint64
 	var lastP int
 	timerGoids := make(map[uint64]bool)
@@ -378,6 +521,12 @@ func parseEvents(ver int, rawEvents []rawEvent, strings map[uint64]string) (even
 				return
 			}
 			id := raw.args[0]
+			if id > maxStackID {
+				maxStackID = id
+			}
+			if id != 0 {
+				id += r.stackBase
+			}
 			if id != 0 && size > 0 {
 				stk := make([]*Frame, size)
 				for i := 0; i < int(size); i++ {
@@ -409,6 +558,9 @@ func parseEvents(ver int, rawEvents []rawEvent, strings map[uint64]string) (even
 			for i := argOffset; i < narg; i++ {
 				if i == narg-1 && desc.Stack {
 					e.StkID = raw.args[i]
+					if e.StkID != 0 {
+						e.StkID += r.stackBase
+					}
 				} else {
 					e.Args[i-argOffset] = raw.args[i]
 				}
@@ -438,8 +590,12 @@ func parseEvents(ver int, rawEvents []rawEvent, strings map[uint64]string) (even
 				EvGoBlockSelect, EvGoBlockSync, EvGoBlockCond, EvGoBlockNet,
 				EvGoSysBlock, EvGoBlockGC:
 				lastG = 0
-			case EvGoSysExit, EvGoWaiting, EvGoInSyscall:
+			case EvGoSysExit, EvGoWaiting, EvGoInSyscall, EvGoStatus:
 				e.G = e.Args[0]
+			case EvGoCreate:
+				if ver >= 1007 && e.Args[1] != 0 {
+					e.Args[1] += r.stackBase
+				}
 			case EvUserTaskCreate:
 				// e.Args 0: taskID, 1:parentID, 2:nameID
 				e.SArgs = []string{strings[e.Args[2]]}
@@ -499,12 +655,20 @@ func parseEvents(ver int, rawEvents []rawEvent, strings map[uint64]string) (even
 		return
 	}
 
-	// Translate cpu ticks to real time.
-	minTs := events[0].Ts
+	// Translate cpu ticks to real time. The first generation starts
+	// at 0; later ones continue from the last event of the previous
+	// generation, using their own frequency.
+	if !r.started {
+		r.baseTicks = events[0].Ts
+		r.started = true
+	}
+	baseTicks, baseTs := r.baseTicks, r.baseTs
+	r.baseTicks = events[len(events)-1].Ts
+	r.stackBase += maxStackID
 	// Use floating point to avoid integer overflows.
 	freq := 1e9 / float64(ticksPerSec)
 	for _, ev := range events {
-		ev.Ts = int64(float64(ev.Ts-minTs) * freq)
+		ev.Ts = baseTs + int64(float64(ev.Ts-baseTicks)*freq)
 		// Move timers and syscalls to separate fake Ps.
 		if timerGoids[ev.G] && ev.Type == EvGoUnblock {
 			ev.P = TimerP
@@ -513,6 +677,7 @@ func parseEvents(ver int, rawEvents []rawEvent, strings map[uint64]string) (even
 			ev.P = SyscallP
 		}
 	}
+	r.baseTs = events[len(events)-1].Ts
 
 	return
 }
@@ -526,8 +691,9 @@ func removeFutile(events []*Event) []*Event {
 	// Two non-trivial aspects:
 	// 1. A goroutine can be preempted during a futile wakeup and migrate to another P.
 	//	We want to remove all of that.
-	// 2. Tracing can start in the middle of a futile wakeup.
-	//	That is, we can see a futile wakeup event w/o the actual wakeup before it.
+	// 2. Tracing can start in the middle of a futile wakeup, and so can a
+	//	generation. That is, we can see a futile wakeup event w/o the actual
+	//	wakeup before it. We leave such wakeups alone.
 	// postProcessTrace runs after us and ensures that we leave the trace in a consistent state.
 
 	// Phase 1: determine futile wakeup sequences.
@@ -545,6 +711,11 @@ func removeFutile(events []*Event) []*Event {
 			gs[ev.Args[0]] = g
 		case EvGoStart, EvGoPreempt, EvFutileWakeup:
 			g := gs[ev.G]
+			if g.wakeup == nil {
+				// The wakeup happened before the start of the trace
+				// or of this generation, so we can't remove all of it.
+				break
+			}
 			g.wakeup = append(g.wakeup, ev)
 			if ev.Type == EvFutileWakeup {
 				g.futile = true
@@ -576,39 +747,81 @@ func removeFutile(events []*Event) []*Event {
 // time stamps that do not respect actual event ordering.
 var ErrTimeOrder = fmt.Errorf("time stamps out of order")
 
-// postProcessTrace does inter-event verification and information restoration.
+// postProcessor does inter-event verification and information restoration.
 // The resulting trace is guaranteed to be consistent
 // (for example, a P does not run two Gs at the same time, or a G is indeed
 // blocked before an unblock event).
+// Its state carries over from one generation of the trace to the next.
+type postProcessor struct {
+	ver           int
+	gs            map[uint64]postG
+	ps            map[int]postP
+	tasks         map[uint64]*Event   // task id to task creation events
+	activeRegions map[uint64][]*Event // goroutine id to stack of regions
+	evGC, evSTW   *Event
+}
+
+type postG struct {
+	state        int
+	ev           *Event
+	evStart      *Event
+	evCreate     *Event
+	evMarkAssist *Event
+	startStk     []*Frame // stack of the goroutine's start function, if evCreate is an EvGoStatus
+}
+
+type postP struct {
+	running bool
+	g       uint64
+	evSTW   *Event
+	evSweep *Event
+}
+
+const (
+	postGDead = iota
+	postGRunnable
+	postGRunning
+	postGWaiting
+)
+
+func newPostProcessor(ver int) *postProcessor {
+	pp := &postProcessor{
+		ver:           ver,
+		gs:            make(map[uint64]postG),
+		ps:            make(map[int]postP),
+		tasks:         make(map[uint64]*Event),
+		activeRegions: make(map[uint64][]*Event),
+	}
+	pp.gs[0] = postG{state: postGRunning}
+	return pp
+}
+
+// postProcessTrace post-processes a complete trace in one go.
 func postProcessTrace(ver int, events []*Event) error {
+	_, err := newPostProcessor(ver).process(events, nil)
+	return err
+}
+
+// process post-processes the events of one generation. stacks holds
+// the generation's stack traces. It returns events without the
+// EvGoStatus events of goroutines that are already known.
+func (pp *postProcessor) process(events []*Event, stacks map[uint64][]*Frame) ([]*Event, error) {
 	const (
-		gDead = iota
-		gRunnable
-		gRunning
-		gWaiting
+		gDead     = postGDead
+		gRunnable = postGRunnable
+		gRunning  = postGRunning
+		gWaiting  = postGWaiting
 	)
-	type gdesc struct {
-		state        int
-		ev           *Event
-		evStart      *Event
-		evCreate     *Event
-		evMarkAssist *Event
-	}
-	type pdesc struct {
-		running bool
-		g       uint64
-		evSTW   *Event
-		evSweep *Event
-	}
+	ver := pp.ver
+	gs := pp.gs
+	ps := pp.ps
+	tasks := pp.tasks
+	activeRegions := pp.activeRegions
+	evGC, evSTW := pp.evGC, pp.evSTW
+	defer func() { pp.evGC, pp.evSTW = evGC, evSTW }()
+	out := events[:0]
 
-	gs := make(map[uint64]gdesc)
-	ps := make(map[int]pdesc)
-	tasks := make(map[uint64]*Event)           // task id to task creation events
-	activeRegions := make(map[uint64][]*Event) // goroutine id to stack of regions
-	gs[0] = gdesc{state: gRunning}
-	var evGC, evSTW *Event
-
-	checkRunning := func(p pdesc, g gdesc, ev *Event, allowG0 bool) error {
+	checkRunning := func(p postP, g postG, ev *Event, allowG0 bool) error {
 		name := EventDescriptions[ev.Type].Name
 		if g.state != gRunning {
 			return fmt.Errorf("g %v is not running while %v (offset %v, time %v)", ev.G, name, ev.Off, ev.Ts)
@@ -627,29 +840,48 @@ func postProcessTrace(ver int, events []*Event) error {
 		p := ps[ev.P]
 
 		switch ev.Type {
+		case EvGoStatus:
+			var state int
+			switch ev.Args[1] {
+			case GoStatusRunnable:
+				state = gRunnable
+			case GoStatusWaiting, GoStatusSyscall:
+				state = gWaiting
+			default:
+				return nil, fmt.Errorf("g %v has unknown status %v (offset %v, time %v)", ev.G, ev.Args[1], ev.Off, ev.Ts)
+			}
+			if _, ok := gs[ev.G]; ok {
+				// We already know this goroutine from an earlier
+				// generation. Its status carries no new information.
+				if g.state != state {
+					return nil, fmt.Errorf("g %v has status %v at the start of a generation, but should be in state %v (offset %v, time %v)", ev.G, ev.Args[1], g.state, ev.Off, ev.Ts)
+				}
+				continue
+			}
+			g = postG{state: state, ev: ev, evCreate: ev, startStk: stacks[ev.StkID]}
 		case EvProcStart:
 			if p.running {
-				return fmt.Errorf("p %v is running before start (offset %v, time %v)", ev.P, ev.Off, ev.Ts)
+				return nil, fmt.Errorf("p %v is running before start (offset %v, time %v)", ev.P, ev.Off, ev.Ts)
 			}
 			p.running = true
 		case EvProcStop:
 			if !p.running {
-				return fmt.Errorf("p %v is not running before stop (offset %v, time %v)", ev.P, ev.Off, ev.Ts)
+				return nil, fmt.Errorf("p %v is not running before stop (offset %v, time %v)", ev.P, ev.Off, ev.Ts)
 			}
 			if p.g != 0 {
-				return fmt.Errorf("p %v is running a goroutine %v during stop (offset %v, time %v)", ev.P, p.g, ev.Off, ev.Ts)
+				return nil, fmt.Errorf("p %v is running a goroutine %v during stop (offset %v, time %v)", ev.P, p.g, ev.Off, ev.Ts)
 			}
 			p.running = false
 		case EvGCStart:
 			if evGC != nil {
-				return fmt.Errorf("previous GC is not ended before a new one (offset %v, time %v)", ev.Off, ev.Ts)
+				return nil, fmt.Errorf("previous GC is not ended before a new one (offset %v, time %v)", ev.Off, ev.Ts)
 			}
 			evGC = ev
 			// Attribute this to the global GC state.
 			ev.P = GCP
 		case EvGCDone:
 			if evGC == nil {
-				return fmt.Errorf("bogus GC end (offset %v, time %v)", ev.Off, ev.Ts)
+				return nil, fmt.Errorf("bogus GC end (offset %v, time %v)", ev.Off, ev.Ts)
 			}
 			evGC.Link = ev
 			evGC = nil
@@ -660,7 +892,7 @@ func postProcessTrace(ver int, events []*Event) error {
 				evp = &p.evSTW
 			}
 			if *evp != nil {
-				return fmt.Errorf("previous STW is not ended before a new one (offset %v, time %v)", ev.Off, ev.Ts)
+				return nil, fmt.Errorf("previous STW is not ended before a new one (offset %v, time %v)", ev.Off, ev.Ts)
 			}
 			*evp = ev
 		case EvGCSTWDone:
@@ -670,18 +902,18 @@ func postProcessTrace(ver int, events []*Event) error {
 				evp = &p.evSTW
 			}
 			if *evp == nil {
-				return fmt.Errorf("bogus STW end (offset %v, time %v)", ev.Off, ev.Ts)
+				return nil, fmt.Errorf("bogus STW end (offset %v, time %v)", ev.Off, ev.Ts)
 			}
 			(*evp).Link = ev
 			*evp = nil
 		case EvGCSweepStart:
 			if p.evSweep != nil {
-				return fmt.Errorf("previous sweeping is not ended before a new one (offset %v, time %v)", ev.Off, ev.Ts)
+				return nil, fmt.Errorf("previous sweeping is not ended before a new one (offset %v, time %v)", ev.Off, ev.Ts)
 			}
 			p.evSweep = ev
 		case EvGCMarkAssistStart:
 			if g.evMarkAssist != nil {
-				return fmt.Errorf("previous mark assist is not ended before a new one (offset %v, time %v)", ev.Off, ev.Ts)
+				return nil, fmt.Errorf("previous mark assist is not ended before a new one (offset %v, time %v)", ev.Off, ev.Ts)
 			}
 			g.evMarkAssist = ev
 		case EvGCMarkAssistDone:
@@ -693,36 +925,36 @@ func postProcessTrace(ver int, events []*Event) error {
 			}
 		case EvGCSweepDone:
 			if p.evSweep == nil {
-				return fmt.Errorf("bogus sweeping end (offset %v, time %v)", ev.Off, ev.Ts)
+				return nil, fmt.Errorf("bogus sweeping end (offset %v, time %v)", ev.Off, ev.Ts)
 			}
 			p.evSweep.Link = ev
 			p.evSweep = nil
 		case EvGoWaiting:
 			if g.state != gRunnable {
-				return fmt.Errorf("g %v is not runnable before EvGoWaiting (offset %v, time %v)", ev.G, ev.Off, ev.Ts)
+				return nil, fmt.Errorf("g %v is not runnable before EvGoWaiting (offset %v, time %v)", ev.G, ev.Off, ev.Ts)
 			}
 			g.state = gWaiting
 			g.ev = ev
 		case EvGoInSyscall:
 			if g.state != gRunnable {
-				return fmt.Errorf("g %v is not runnable before EvGoInSyscall (offset %v, time %v)", ev.G, ev.Off, ev.Ts)
+				return nil, fmt.Errorf("g %v is not runnable before EvGoInSyscall (offset %v, time %v)", ev.G, ev.Off, ev.Ts)
 			}
 			g.state = gWaiting
 			g.ev = ev
 		case EvGoCreate:
 			if err := checkRunning(p, g, ev, true); err != nil {
-				return err
+				return nil, err
 			}
 			if _, ok := gs[ev.Args[0]]; ok {
-				return fmt.Errorf("g %v already exists (offset %v, time %v)", ev.Args[0], ev.Off, ev.Ts)
+				return nil, fmt.Errorf("g %v already exists (offset %v, time %v)", ev.Args[0], ev.Off, ev.Ts)
 			}
-			gs[ev.Args[0]] = gdesc{state: gRunnable, ev: ev, evCreate: ev}
+			gs[ev.Args[0]] = postG{state: gRunnable, ev: ev, evCreate: ev}
 		case EvGoStart, EvGoStartLabel:
 			if g.state != gRunnable {
-				return fmt.Errorf("g %v is not runnable before start (offset %v, time %v)", ev.G, ev.Off, ev.Ts)
+				return nil, fmt.Errorf("g %v is not runnable before start (offset %v, time %v)", ev.G, ev.Off, ev.Ts)
 			}
 			if p.g != 0 {
-				return fmt.Errorf("p %v is already running g %v while start g %v (offset %v, time %v)", ev.P, p.g, ev.G, ev.Off, ev.Ts)
+				return nil, fmt.Errorf("p %v is already running g %v while start g %v (offset %v, time %v)", ev.P, p.g, ev.G, ev.Off, ev.Ts)
 			}
 			g.state = gRunning
 			g.evStart = ev
@@ -731,10 +963,14 @@ func postProcessTrace(ver int, events []*Event) error {
 				if ver < 1007 {
 					// +1 because symbolizer expects return pc.
 					ev.Stk = []*Frame{{PC: g.evCreate.Args[1] + 1}}
+				} else if g.evCreate.Type == EvGoStatus {
+					ev.StkID = g.evCreate.StkID
+					ev.Stk = g.startStk
 				} else {
 					ev.StkID = g.evCreate.Args[1]
 				}
 				g.evCreate = nil
+				g.startStk = nil
 			}
 
 			if g.ev != nil {
@@ -743,7 +979,7 @@ func postProcessTrace(ver int, events []*Event) error {
 			}
 		case EvGoEnd, EvGoStop:
 			if err := checkRunning(p, g, ev, false); err != nil {
-				return err
+				return nil, err
 			}
 			g.evStart.Link = ev
 			g.evStart = nil
@@ -760,7 +996,7 @@ func postProcessTrace(ver int, events []*Event) error {
 
 		case EvGoSched, EvGoPreempt:
 			if err := checkRunning(p, g, ev, false); err != nil {
-				return err
+				return nil, err
 			}
 			g.state = gRunnable
 			g.evStart.Link = ev
@@ -769,14 +1005,14 @@ func postProcessTrace(ver int, events []*Event) error {
 			g.ev = ev
 		case EvGoUnblock:
 			if g.state != gRunning {
-				return fmt.Errorf("g %v is not running while unpark (offset %v, time %v)", ev.G, ev.Off, ev.Ts)
+				return nil, fmt.Errorf("g %v is not running while unpark (offset %v, time %v)", ev.G, ev.Off, ev.Ts)
 			}
 			if ev.P != TimerP && p.g != ev.G {
-				return fmt.Errorf("p %v is not running g %v while unpark (offset %v, time %v)", ev.P, ev.G, ev.Off, ev.Ts)
+				return nil, fmt.Errorf("p %v is not running g %v while unpark (offset %v, time %v)", ev.P, ev.G, ev.Off, ev.Ts)
 			}
 			g1 := gs[ev.Args[0]]
 			if g1.state != gWaiting {
-				return fmt.Errorf("g %v is not waiting before unpark (offset %v, time %v)", ev.Args[0], ev.Off, ev.Ts)
+				return nil, fmt.Errorf("g %v is not waiting before unpark (offset %v, time %v)", ev.Args[0], ev.Off, ev.Ts)
 			}
 			if g1.ev != nil && g1.ev.Type == EvGoBlockNet && ev.P != TimerP {
 				ev.P = NetpollP
@@ -789,12 +1025,12 @@ func postProcessTrace(ver int, events []*Event) error {
 			gs[ev.Args[0]] = g1
 		case EvGoSysCall:
 			if err := checkRunning(p, g, ev, false); err != nil {
-				return err
+				return nil, err
 			}
 			g.ev = ev
 		case EvGoSysBlock:
 			if err := checkRunning(p, g, ev, false); err != nil {
-				return err
+				return nil, err
 			}
 			g.state = gWaiting
 			g.evStart.Link = ev
@@ -802,7 +1038,7 @@ func postProcessTrace(ver int, events []*Event) error {
 			p.g = 0
 		case EvGoSysExit:
 			if g.state != gWaiting {
-				return fmt.Errorf("g %v is not waiting during syscall exit (offset %v, time %v)", ev.G, ev.Off, ev.Ts)
+				return nil, fmt.Errorf("g %v is not waiting during syscall exit (offset %v, time %v)", ev.G, ev.Off, ev.Ts)
 			}
 			if g.ev != nil && g.ev.Type == EvGoSysCall {
 				g.ev.Link = ev
@@ -812,7 +1048,7 @@ func postProcessTrace(ver int, events []*Event) error {
 		case EvGoSleep, EvGoBlock, EvGoBlockSend, EvGoBlockRecv,
 			EvGoBlockSelect, EvGoBlockSync, EvGoBlockCond, EvGoBlockNet, EvGoBlockGC:
 			if err := checkRunning(p, g, ev, false); err != nil {
-				return err
+				return nil, err
 			}
 			g.state = gWaiting
 			g.ev = ev
@@ -822,7 +1058,7 @@ func postProcessTrace(ver int, events []*Event) error {
 		case EvUserTaskCreate:
 			taskid := ev.Args[0]
 			if prevEv, ok := tasks[taskid]; ok {
-				return fmt.Errorf("task id conflicts (id:%d), %q vs %q", taskid, ev, prevEv)
+				return nil, fmt.Errorf("task id conflicts (id:%d), %q vs %q", taskid, ev, prevEv)
 			}
 			tasks[ev.Args[0]] = ev
 		case EvUserTaskEnd:
@@ -841,7 +1077,7 @@ func postProcessTrace(ver int, events []*Event) error {
 				if n > 0 { // matching region start event is in the trace.
 					s := regions[n-1]
 					if s.Args[0] != ev.Args[0] || s.SArgs[0] != ev.SArgs[0] { // task id, region name mismatch
-						return fmt.Errorf("misuse of region in goroutine %d: span end %q when the inner-most active span start event is %q", ev.G, ev, s)
+						return nil, fmt.Errorf("misuse of region in goroutine %d: span end %q when the inner-most active span start event is %q", ev.G, ev, s)
 					}
 					// Link region start event with span end event
 					s.Link = ev
@@ -853,18 +1089,19 @@ func postProcessTrace(ver int, events []*Event) error {
 					}
 				}
 			} else {
-				return fmt.Errorf("invalid user region mode: %q", ev)
+				return nil, fmt.Errorf("invalid user region mode: %q", ev)
 			}
 		}
 
 		gs[ev.G] = g
 		ps[ev.P] = p
+		out = append(out, ev)
 	}
 
 	// TODO(dvyukov): restore stacks for EvGoStart events.
 	// TODO(dvyukov): test that all EvGoStart events has non-nil Link.
 
-	return nil
+	return out, nil
 }
 
 // symbolize attaches func/file/line info to stack traces.
@@ -996,7 +1233,7 @@ func argNum(raw rawEvent, ver int) int {
 		narg++
 	}
 	switch raw.typ {
-	case EvBatch, EvFrequency, EvTimerGoroutine:
+	case EvBatch, EvFrequency, EvTimerGoroutine, EvGeneration:
 		if ver < 1007 {
 			narg++ // there was an unused arg before 1.7
 		}
@@ -1079,7 +1316,16 @@ const (
 	EvUserRegion        = 47 // trace.WithRegion [timestamp, internal task id, mode(0:start, 1:end), stack, name string]
 	EvUserLog           = 48 // trace.Log [timestamp, internal id, key string id, stack, value string]
 	EvCPUSample         = 49 // CPU profiling sample [timestamp, stack, real timestamp, real P id (-1 when absent), goroutine id]
-	EvCount             = 50
+	EvGoStatus          = 50 // goroutine status at the start of a generation [timestamp, goroutine id, status, seq, start stack id]
+	EvGeneration        = 51 // end of generation [generation number]
+	EvCount             = 52
+)
+
+// Goroutine statuses in EvGoStatus events.
+const (
+	GoStatusRunnable = 1
+	GoStatusWaiting  = 2
+	GoStatusSyscall  = 3
 )
 
 var EventDescriptions = [EvCount]struct {
@@ -1139,4 +1385,6 @@ var EventDescriptions = [EvCount]struct {
 	EvUserRegion:        {"UserRegion", 1011, true, []string{"taskid", "mode", "typeid"}, []string{"name"}},
 	EvUserLog:           {"UserLog", 1011, true, []string{"id", "keyid"}, []string{"category", "message"}},
 	EvCPUSample:         {"CPUSample", 1019, true, []string{"ts", "p", "g"}, nil},
+	EvGoStatus:          {"GoStatus", 1020, true, []string{"g", "status", "seq"}, nil},
+	EvGeneration:        {"Generation", 1020, false, []string{"gen"}, nil},
 }
@@ -990,7 +990,8 @@ const (
 	_ // InvalidChanRange was removed.
 
 	// InvalidIterVar occurs when two iteration variables are used while ranging
-	// over a channel.
+	// over a channel, or when more iteration variables are used than the yield
+	// function of a range-over-func iterator accepts.
 	//
 	// Example:
 	//  func f(c chan int) {
@@ -1001,7 +1002,8 @@ const (
 	InvalidIterVar
 
 	// InvalidRangeExpr occurs when the type of a range expression is not array,
-	// slice, string, map, or channel.
+	// slice, string, map, channel, or a function of the form
+	// func(yield func(...) bool).
 	//
 	// Example:
 	//  func f(i int) {
@@ -1428,4 +1430,25 @@ const (
 	// InvalidUnsafeStringData occurs if it is used in a package
 	// compiled for a language version before go1.20.
 	_ // not used anymore
+
+	// InvalidMinMaxOperand occurs if min or max is called
+	// with an operand that cannot be ordered because it
+	// does not support the < operator.
+	//
+	// Example:
+	//  const _ = min(true)
+	//
+	// Example:
+	//  var s, t []byte
+	//  var _ = max(s, t)
+	InvalidMinMaxOperand
+
+	// InvalidClear occurs when clear is called with an argument
+	// that is not of map or slice type.
+	//
+	// Example:
+	//  func _(x int) {
+	//  	clear(x)
+	//  }
+	InvalidClear
 )
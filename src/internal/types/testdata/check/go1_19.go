@@ -13,3 +13,9 @@ type Array [8]byte
 
 var s Slice
 var p = (Array)(s /* ERROR requires go1.20 or later */)
+
+// range over func requires go1.20
+func _(seq func(func(int) bool)) {
+	for range seq /* ERROR requires go1.20 or later */ {
+	}
+}
@@ -18,9 +18,8 @@ type T2[P any] struct {
 
 type List[P any] []P
 
-// Alias type declarations cannot have type parameters.
-// Issue #46477 proposses to change that.
-type A1[P any] = /* ERROR cannot be alias */ struct{}
+// Generic alias type declarations require Alias types (issue #46477).
+type A1[P any] = /* ERROR requires GODEBUG=gotypesalias=1 */ struct{}
 
 // Pending clarification of #46477 we disallow aliases
 // of generic types.
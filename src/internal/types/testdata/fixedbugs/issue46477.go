@@ -0,0 +1,42 @@
+// -gotypesalias=1
+
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package p
+
+type T[P any] struct{ f P }
+
+type Set[K comparable] = map[K]struct{}
+
+type A1[P any] = T[P]
+type A2[P any] = *T[P]
+type A3[P, Q any] = func(P) Q
+type A4[P any] = A1[P] // alias of a generic alias instance
+
+type _[P any] = P // ERROR "cannot use type parameter declared in alias declaration as RHS"
+
+var (
+	_ Set[int]        = map[int]struct{}{}
+	_ A1[int]         = T[int]{}
+	_ T[string]       = A1[string]{f: "foo"}
+	_ A2[int]         = new(T[int])
+	_ A3[int, string] = func(int) string { return "" }
+	_ A4[int]         = T[int]{}
+	_ A1[int]         = T /* ERROR "cannot use" */ [string]{}
+)
+
+var _ Set /* ERROR "cannot use generic type Set without instantiation" */
+var _ Set /* ERROR "got 2 arguments but 1 type parameters" */ [int, string]
+
+type F func()
+
+var _ Set[F /* ERROR "F does not implement comparable" */]
+
+func _[P comparable](s Set[P], x P) bool {
+	_, ok := s[x]
+	return ok
+}
+
+func (A1 /* ERROR "cannot define new methods on generic alias type A1" */ [P]) m() {}
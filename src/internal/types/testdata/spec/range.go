@@ -0,0 +1,103 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package p
+
+type MyInt int32
+type MyBool bool
+type MyString string
+type MyFunc1 func(func(int) bool)
+type MyFunc2 func(int) bool
+type MyFunc3 func(MyFunc2)
+
+type T struct{}
+
+func (*T) PM(func(int) bool) {}
+func (T) M(func(int) bool)   {}
+
+func f0()                              {}
+func f1(func())                        {}
+func f2(func(int))                     {}
+func f3(func(int) bool)                {}
+func f4(func(int, MyString) bool)      {}
+func f5(func(int, string, MyInt) bool) {}
+func f6(func(int) MyBool)              {}
+func f7(func(...int) bool)             {}
+func f8(func(int) bool) int            { return 0 }
+func f9(func(int) bool, int)           {}
+func f10(func() bool)                  {}
+
+func test() {
+	// check that all the functions can be range-over'd
+	for range f0 /* ERROR "wrong argument count" */ {
+	}
+	for range f1 /* ERROR "yield func does not return bool" */ {
+	}
+	for range f2 /* ERROR "yield func does not return bool" */ {
+	}
+	for range f4 {
+	}
+	for _ = range f4 {
+	}
+	for _, _ = range f4 {
+	}
+	for range f5 /* ERROR "yield func has too many parameters" */ {
+	}
+	for range f6 {
+	}
+	for range f7 /* ERROR "yield func is variadic" */ {
+	}
+	for range f8 /* ERROR "unexpected results" */ {
+	}
+	for range f9 /* ERROR "wrong argument count" */ {
+	}
+	for range f10 {
+	}
+	for _ /* ERROR "permits no iteration variables" */ = range f10 {
+	}
+	for _, _ /* ERROR "permits only one iteration variable" */ = range f3 {
+	}
+
+	var t T
+	for range t.M {
+	}
+	for range (&t).PM {
+	}
+	for range MyFunc1(nil) {
+	}
+	for range MyFunc3(nil) {
+	}
+
+	var i int
+	var s string
+	var mi MyInt
+	var ms MyString
+	for i := range f3 {
+		_ = i
+	}
+	for i = range f3 {
+	}
+	for i, s := range f4 {
+		_, _ = i, s
+	}
+	for i, ms = range f4 {
+	}
+	for i, s /* ERROR "cannot use .* as string value in assignment" */ = range f4 {
+	}
+	for mi /* ERROR "cannot use .* as MyInt value in assignment" */ = range f3 {
+	}
+	_, _, _, _ = i, s, mi, ms
+}
+
+func _[P func(func(int) bool) | func(func(string) bool)](p P) {
+	for range p /* ERROR "no core type" */ {
+	}
+}
+
+func _[P ~func(func(int, string) bool)](p P) {
+	for i, s := range p {
+		var _ int = i
+		var _ string = s
+	}
+}
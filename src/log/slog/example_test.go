@@ -0,0 +1,71 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog_test
+
+import (
+	"log/slog"
+	"os"
+	"time"
+)
+
+// removeTime removes the top-level time attribute, so that the
+// example output is deterministic.
+func removeTime(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.TimeKey && len(groups) == 0 {
+		return slog.Attr{}
+	}
+	return a
+}
+
+func ExampleGroup() {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{ReplaceAttr: removeTime}))
+	logger.Info("finished",
+		slog.Group("req",
+			slog.String("method", "GET"),
+			slog.String("url", "localhost")),
+		slog.Int("status", 200),
+		slog.Duration("duration", time.Second))
+
+	// Output:
+	// level=INFO msg=finished req.method=GET req.url=localhost status=200 duration=1s
+}
+
+func ExampleLogger_With() {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{ReplaceAttr: removeTime}))
+	logger = logger.With("service", "billing").WithGroup("request")
+	logger.Warn("slow", "path", "/invoice", "ms", 1250)
+
+	// Output:
+	// {"level":"WARN","msg":"slow","service":"billing","request":{"path":"/invoice","ms":1250}}
+}
+
+func ExampleHandlerOptions_level() {
+	var programLevel slog.LevelVar // Info by default
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level:       &programLevel,
+		ReplaceAttr: removeTime,
+	}))
+	logger.Debug("hidden")
+	programLevel.Set(slog.LevelDebug)
+	logger.Debug("shown", "n", 1)
+
+	// Output:
+	// level=DEBUG msg=shown n=1
+}
+
+// Password is a string that is redacted when logged.
+type Password string
+
+func (Password) LogValue() slog.Value {
+	return slog.StringValue("REDACTED")
+}
+
+func ExampleLogValuer_secret() {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{ReplaceAttr: removeTime}))
+	logger.Info("login", "user", "gopher", "password", Password("hunter2"))
+
+	// Output:
+	// level=INFO msg=login user=gopher password=REDACTED
+}
@@ -0,0 +1,269 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package maps
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+var m1 = map[int]int{1: 2, 2: 4, 4: 8, 8: 16}
+var m2 = map[int]string{1: "2", 2: "4", 4: "8", 8: "16"}
+
+func TestKeys(t *testing.T) {
+	var got []int
+	for k := range Keys(m1) {
+		got = append(got, k)
+	}
+	slices.Sort(got)
+	if want := []int{1, 2, 4, 8}; !slices.Equal(got, want) {
+		t.Errorf("Keys(%v) = %v, want %v", m1, got, want)
+	}
+
+	// Stop early.
+	n := 0
+	for range Keys(m1) {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("Keys(%v) yielded %d keys after break, want 1", m1, n)
+	}
+}
+
+func TestValues(t *testing.T) {
+	var got []string
+	for v := range Values(m2) {
+		got = append(got, v)
+	}
+	slices.Sort(got)
+	if want := []string{"16", "2", "4", "8"}; !slices.Equal(got, want) {
+		t.Errorf("Values(%v) = %v, want %v", m2, got, want)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	if !Equal(m1, m1) {
+		t.Errorf("Equal(%v, %v) = false, want true", m1, m1)
+	}
+	if Equal(m1, (map[int]int)(nil)) {
+		t.Errorf("Equal(%v, nil) = true, want false", m1)
+	}
+	if Equal((map[int]int)(nil), m1) {
+		t.Errorf("Equal(nil, %v) = true, want false", m1)
+	}
+	if !Equal[map[int]int, map[int]int](nil, nil) {
+		t.Error("Equal(nil, nil) = false, want true")
+	}
+	if ms := map[int]int{1: 2}; Equal(m1, ms) {
+		t.Errorf("Equal(%v, %v) = true, want false", m1, ms)
+	}
+
+	// Comparing NaN for equality is expected to fail.
+	mf := map[int]float64{1: 0, 2: math.NaN()}
+	if Equal(mf, mf) {
+		t.Errorf("Equal(%v, %v) = true, want false", mf, mf)
+	}
+}
+
+// equal is simply ==.
+func equal[T comparable](v1, v2 T) bool {
+	return v1 == v2
+}
+
+// equalNaN is like == except that all NaNs are equal.
+func equalNaN[T comparable](v1, v2 T) bool {
+	isNaN := func(f T) bool { return f != f }
+	return v1 == v2 || (isNaN(v1) && isNaN(v2))
+}
+
+// equalStr compares ints and strings.
+func equalIntStr(v1 int, v2 string) bool {
+	return strconv.Itoa(v1) == v2
+}
+
+func TestEqualFunc(t *testing.T) {
+	if !EqualFunc(m1, m1, equal[int]) {
+		t.Errorf("EqualFunc(%v, %v, equal) = false, want true", m1, m1)
+	}
+	if EqualFunc(m1, (map[int]int)(nil), equal[int]) {
+		t.Errorf("EqualFunc(%v, nil, equal) = true, want false", m1)
+	}
+	if EqualFunc((map[int]int)(nil), m1, equal[int]) {
+		t.Errorf("EqualFunc(nil, %v, equal) = true, want false", m1)
+	}
+	if !EqualFunc[map[int]int, map[int]int](nil, nil, equal[int]) {
+		t.Error("EqualFunc(nil, nil, equal) = false, want true")
+	}
+	if ms := map[int]int{1: 2}; EqualFunc(m1, ms, equal[int]) {
+		t.Errorf("EqualFunc(%v, %v, equal) = true, want false", m1, ms)
+	}
+
+	// Comparing NaN for equality is expected to fail.
+	mf := map[int]float64{1: 0, 2: math.NaN()}
+	if EqualFunc(mf, mf, equal[float64]) {
+		t.Errorf("EqualFunc(%v, %v, equal) = true, want false", mf, mf)
+	}
+	// But it should succeed using equalNaN.
+	if !EqualFunc(mf, mf, equalNaN[float64]) {
+		t.Errorf("EqualFunc(%v, %v, equalNaN) = false, want true", mf, mf)
+	}
+
+	if !EqualFunc(m1, m2, equalIntStr) {
+		t.Errorf("EqualFunc(%v, %v, equalIntStr) = false, want true", m1, m2)
+	}
+}
+
+func TestClone(t *testing.T) {
+	mc := Clone(m1)
+	if !Equal(mc, m1) {
+		t.Errorf("Clone(%v) = %v, want %v", m1, mc, m1)
+	}
+	mc[16] = 32
+	if Equal(mc, m1) {
+		t.Errorf("Equal(%v, %v) = true, want false", mc, m1)
+	}
+}
+
+func TestCloneNil(t *testing.T) {
+	var m1 map[string]int
+	mc := Clone(m1)
+	if mc != nil {
+		t.Errorf("Clone(%v) = %v, want %v", m1, mc, m1)
+	}
+}
+
+func TestCloneSizes(t *testing.T) {
+	// Clone maps of every size up to a few thousand entries, which
+	// covers maps that are in the middle of growing.
+	m := make(map[int]int)
+	for i := 0; i < 3000; i++ {
+		mc := Clone(m)
+		if !Equal(mc, m) {
+			t.Fatalf("Clone of map with %d entries differs", len(m))
+		}
+		// The clone must be usable on its own.
+		mc[-1] = -1
+		if _, ok := m[-1]; ok {
+			t.Fatalf("write to clone of map with %d entries is visible in the original", len(m))
+		}
+		delete(mc, -1)
+		for k := range mc {
+			mc[k]++
+		}
+		for k, v := range m {
+			if mc[k] != v+1 {
+				t.Fatalf("clone of map with %d entries: mc[%d] = %d, want %d", len(m), k, mc[k], v+1)
+			}
+		}
+		m[i] = i
+	}
+}
+
+func TestCloneSameSizeGrow(t *testing.T) {
+	// Repeated inserts and deletes grow the map without increasing
+	// its size.
+	m := make(map[int]int)
+	for i := 0; i < 1000; i++ {
+		m[i] = i
+	}
+	for i := 1000; i < 100000; i++ {
+		delete(m, i-1000)
+		m[i] = i
+		if i%997 == 0 {
+			if mc := Clone(m); !Equal(mc, m) {
+				t.Fatalf("Clone differs after %d deletions", i-999)
+			}
+		}
+	}
+}
+
+func TestCloneIndirect(t *testing.T) {
+	// Keys and values larger than 128 bytes are stored indirectly.
+	type big [200]byte
+	m := make(map[big]big)
+	for i := 0; i < 100; i++ {
+		var k, v big
+		k[0], k[199] = byte(i), byte(i+1)
+		v[0], v[199] = byte(i+2), byte(i+3)
+		m[k] = v
+	}
+	mc := Clone(m)
+	if !Equal(mc, m) {
+		t.Fatalf("Clone of map with indirect keys and values differs")
+	}
+	for k, v := range mc {
+		v[0]++
+		mc[k] = v
+	}
+	for k, v := range m {
+		if v[0] != k[0]+2 {
+			t.Fatalf("write to clone is visible in the original")
+		}
+	}
+}
+
+func TestCloneNaN(t *testing.T) {
+	m := map[float64]int{math.NaN(): 1, math.NaN(): 2, 1: 3}
+	mc := Clone(m)
+	if len(mc) != len(m) {
+		t.Fatalf("len(Clone(%v)) = %d, want %d", m, len(mc), len(m))
+	}
+	sum := 0
+	for k, v := range mc {
+		if k != k {
+			sum += v
+		}
+	}
+	if sum != 3 {
+		t.Errorf("Clone(%v) = %v, want NaN keys with values 1 and 2", m, mc)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	mc := Clone(m1)
+	Copy(mc, mc)
+	if !Equal(mc, m1) {
+		t.Errorf("Copy(%v, %v) = %v, want %v", m1, m1, mc, m1)
+	}
+	Copy(mc, map[int]int{16: 32})
+	want := map[int]int{1: 2, 2: 4, 4: 8, 8: 16, 16: 32}
+	if !Equal(mc, want) {
+		t.Errorf("Copy result = %v, want %v", mc, want)
+	}
+
+	type M1 map[int]bool
+	type M2 map[int]bool
+	Copy(make(M1), make(M2))
+}
+
+func TestDeleteFunc(t *testing.T) {
+	mc := Clone(m1)
+	DeleteFunc(mc, func(int, int) bool { return false })
+	if !Equal(mc, m1) {
+		t.Errorf("DeleteFunc(%v, true) = %v, want %v", m1, mc, m1)
+	}
+	DeleteFunc(mc, func(k, v int) bool { return k > 3 })
+	want := map[int]int{1: 2, 2: 4}
+	if !Equal(mc, want) {
+		t.Errorf("DeleteFunc result = %v, want %v", mc, want)
+	}
+}
+
+func BenchmarkClone(b *testing.B) {
+	for _, n := range []int{8, 1000, 100000} {
+		m := make(map[int]int, n)
+		for i := 0; i < n; i++ {
+			m[i] = i
+		}
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = Clone(m)
+			}
+		})
+	}
+}
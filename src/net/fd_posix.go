@@ -15,7 +15,10 @@ import (
 
 // Network file descriptor.
 type netFD struct {
-	pfd poll.FD
+	// pfd is allocated separately from the netFD, so that the
+	// cleanup registered by setAddr can close it without
+	// referring to the netFD itself.
+	pfd *poll.FD
 
 	// immutable until Close
 	family      int
@@ -24,16 +27,18 @@ type netFD struct {
 	net         string
 	laddr       Addr
 	raddr       Addr
+
+	cleanup runtime.Cleanup // cleanup closes pfd when fd is no longer referenced
 }
 
 func (fd *netFD) setAddr(laddr, raddr Addr) {
 	fd.laddr = laddr
 	fd.raddr = raddr
-	runtime.SetFinalizer(fd, (*netFD).Close)
+	fd.cleanup = runtime.AddCleanup(fd, func(pfd *poll.FD) { pfd.Close() }, fd.pfd)
 }
 
 func (fd *netFD) Close() error {
-	runtime.SetFinalizer(fd, nil)
+	fd.cleanup.Stop()
 	return fd.pfd.Close()
 }
 
@@ -35,7 +35,7 @@ func canUseConnectEx(net string) bool {
 
 func newFD(sysfd syscall.Handle, family, sotype int, net string) (*netFD, error) {
 	ret := &netFD{
-		pfd: poll.FD{
+		pfd: &poll.FD{
 			Sysfd:         sysfd,
 			IsStream:      sotype == syscall.SOCK_STREAM,
 			ZeroReadIsEOF: sotype != syscall.SOCK_DGRAM && sotype != syscall.SOCK_RAW,
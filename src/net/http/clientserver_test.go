@@ -7,12 +7,15 @@
 package http_test
 
 import (
+	"bufio"
 	"bytes"
 	"compress/gzip"
 	"context"
 	"crypto/rand"
 	"crypto/sha1"
 	"crypto/tls"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"hash"
 	"io"
@@ -1741,3 +1744,284 @@ func testEarlyHintsRequest(t *testing.T, mode testMode) {
 		t.Errorf("Read body %q; want Hello", body)
 	}
 }
+
+// newUnencryptedHTTP2Server returns a started test server accepting
+// HTTP/1 and unencrypted HTTP/2.
+func newUnencryptedHTTP2Server(t *testing.T, h Handler, conf *HTTP2Config) *httptest.Server {
+	CondSkipHTTP2(t)
+	ts := httptest.NewUnstartedServer(h)
+	ts.Config.Protocols = new(Protocols)
+	ts.Config.Protocols.SetHTTP1(true)
+	ts.Config.Protocols.SetUnencryptedHTTP2(true)
+	ts.Config.HTTP2 = conf
+	ts.Start()
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+const (
+	h2FrameData         = 0x0
+	h2FrameSettings     = 0x4
+	h2FramePing         = 0x6
+	h2FrameWindowUpdate = 0x8
+
+	h2FlagEndStream = 0x1
+	h2FlagAck       = 0x1
+)
+
+// h2Preface is the HTTP/2 client connection preface followed by an
+// empty SETTINGS frame.
+const h2Preface = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n" + "\x00\x00\x00\x04\x00\x00\x00\x00\x00"
+
+// readH2Frame reads an HTTP/2 frame from r.
+func readH2Frame(r io.Reader) (typ, flags byte, streamID uint32, payload []byte, err error) {
+	var hdr [9]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return 0, 0, 0, nil, err
+	}
+	n := uint32(hdr[0])<<16 | uint32(hdr[1])<<8 | uint32(hdr[2])
+	payload = make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, 0, 0, nil, err
+	}
+	streamID = binary.BigEndian.Uint32(hdr[5:]) & (1<<31 - 1)
+	return hdr[3], hdr[4], streamID, payload, nil
+}
+
+// readH2Settings reads an HTTP/2 SETTINGS frame from r.
+func readH2Settings(t *testing.T, r io.Reader) map[uint16]uint32 {
+	t.Helper()
+	typ, _, _, payload, err := readH2Frame(r)
+	if err != nil {
+		t.Fatalf("reading SETTINGS frame: %v", err)
+	}
+	if typ != h2FrameSettings {
+		t.Fatalf("read frame of type %v, want SETTINGS", typ)
+	}
+	settings := map[uint16]uint32{}
+	for ; len(payload) >= 6; payload = payload[6:] {
+		settings[binary.BigEndian.Uint16(payload)] = binary.BigEndian.Uint32(payload[2:])
+	}
+	return settings
+}
+
+func TestUnencryptedHTTP2PriorKnowledge(t *testing.T) {
+	ts := newUnencryptedHTTP2Server(t, HandlerFunc(func(w ResponseWriter, r *Request) {
+		fmt.Fprintf(w, "%v %v", r.Proto, r.TLS != nil)
+	}), nil)
+	for _, test := range []struct {
+		name  string
+		proto func(*Protocols)
+		want  string
+	}{
+		{"HTTP1", func(p *Protocols) { p.SetHTTP1(true) }, "HTTP/1.1 false"},
+		{"UnencryptedHTTP2", func(p *Protocols) { p.SetUnencryptedHTTP2(true) }, "HTTP/2.0 false"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			tr := &Transport{Protocols: new(Protocols)}
+			test.proto(tr.Protocols)
+			defer tr.CloseIdleConnections()
+			c := &Client{Transport: tr}
+			for i := 0; i < 2; i++ {
+				res, err := c.Get(ts.URL)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body, err := io.ReadAll(res.Body)
+				res.Body.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := string(body); got != test.want {
+					t.Errorf("request %v: got body %q, want %q", i, got, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestUnencryptedHTTP2Upgrade(t *testing.T) {
+	ts := newUnencryptedHTTP2Server(t, HandlerFunc(func(w ResponseWriter, r *Request) {
+		fmt.Fprintf(w, "%v %v %q", r.Proto, r.URL.Path, r.Header.Get("Upgrade"))
+	}), nil)
+
+	conn, err := net.Dial("tcp", ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	br := bufio.NewReader(conn)
+
+	// A request with a body is served using HTTP/1.1.
+	io.WriteString(conn, "POST /post HTTP/1.1\r\nHost: example.com\r\n"+
+		"Connection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: \r\n"+
+		"Content-Length: 3\r\n\r\nabc")
+	res, err := ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	if res.StatusCode != 200 || string(body) != `HTTP/1.1 /post "h2c"` {
+		t.Fatalf("request with body: got %v %q, want 200 OK without upgrade", res.Status, body)
+	}
+
+	io.WriteString(conn, "GET /get HTTP/1.1\r\nHost: example.com\r\n"+
+		"Connection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: \r\n\r\n")
+	res, err = ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != StatusSwitchingProtocols || res.Header.Get("Upgrade") != "h2c" {
+		t.Fatalf("upgrade request: got %v, Upgrade: %q; want 101 Switching Protocols to h2c", res.Status, res.Header.Get("Upgrade"))
+	}
+
+	io.WriteString(conn, h2Preface)
+	var got []byte
+	for {
+		typ, flags, streamID, payload, err := readH2Frame(br)
+		if err != nil {
+			t.Fatalf("reading response: %v", err)
+		}
+		if typ != h2FrameData || streamID != 1 {
+			continue
+		}
+		got = append(got, payload...)
+		if flags&h2FlagEndStream != 0 {
+			break
+		}
+	}
+	if want := `HTTP/2.0 /get ""`; string(got) != want {
+		t.Errorf("upgraded response body = %q, want %q", got, want)
+	}
+}
+
+func TestServerHTTP2Config(t *testing.T) {
+	ts := newUnencryptedHTTP2Server(t, HandlerFunc(func(w ResponseWriter, r *Request) {}), &HTTP2Config{
+		MaxConcurrentStreams:      10,
+		MaxReadFrameSize:          1 << 20,
+		MaxReceiveBufferPerStream: 1 << 10,
+		MaxDecoderHeaderTableSize: 1 << 13,
+	})
+	conn, err := net.Dial("tcp", ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	io.WriteString(conn, h2Preface)
+
+	settings := readH2Settings(t, conn)
+	for _, want := range []struct {
+		id  uint16
+		val uint32
+	}{
+		{0x1, 1 << 13}, // SETTINGS_HEADER_TABLE_SIZE
+		{0x3, 10},      // SETTINGS_MAX_CONCURRENT_STREAMS
+		{0x4, 1 << 10}, // SETTINGS_INITIAL_WINDOW_SIZE
+		{0x5, 1 << 20}, // SETTINGS_MAX_FRAME_SIZE
+	} {
+		if got, ok := settings[want.id]; !ok || got != want.val {
+			t.Errorf("setting %#x = %v, want %v", want.id, got, want.val)
+		}
+	}
+}
+
+func TestServerHTTP2SendPingTimeout(t *testing.T) {
+	ts := newUnencryptedHTTP2Server(t, HandlerFunc(func(w ResponseWriter, r *Request) {}), &HTTP2Config{
+		SendPingTimeout: 10 * time.Millisecond,
+		PingTimeout:     10 * time.Millisecond,
+	})
+	conn, err := net.Dial("tcp", ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	io.WriteString(conn, h2Preface)
+
+	// The server pings the idle connection, and closes it
+	// when we don't respond.
+	sawPing := false
+	for {
+		typ, flags, _, _, err := readH2Frame(conn)
+		if err != nil {
+			if !sawPing {
+				t.Fatalf("connection closed without a PING: %v", err)
+			}
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				t.Fatalf("connection not closed after unanswered PING: %v", err)
+			}
+			break
+		}
+		if typ == h2FramePing && flags&h2FlagAck == 0 {
+			sawPing = true
+		}
+	}
+}
+
+func TestTransportHTTP2Config(t *testing.T) {
+	CondSkipHTTP2(t)
+	ln := newLocalListener(t)
+	defer ln.Close()
+
+	tr := &Transport{
+		Protocols: new(Protocols),
+		HTTP2: &HTTP2Config{
+			MaxReadFrameSize:              1 << 20,
+			MaxReceiveBufferPerConnection: 1 << 20,
+			MaxReceiveBufferPerStream:     1 << 10,
+		},
+	}
+	tr.Protocols.SetUnencryptedHTTP2(true)
+	defer tr.CloseIdleConnections()
+	errc := make(chan error, 1)
+	go func() {
+		res, err := tr.RoundTrip(&Request{
+			Method: "GET",
+			URL:    &url.URL{Scheme: "http", Host: ln.Addr().String()},
+			Header: Header{},
+		})
+		if err == nil {
+			res.Body.Close()
+		}
+		errc <- err
+	}()
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	preface := make([]byte, len("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"))
+	if _, err := io.ReadFull(conn, preface); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(h2Preface, string(preface)) {
+		t.Fatalf("client sent %q, want HTTP/2 connection preface", preface)
+	}
+	settings := readH2Settings(t, conn)
+	if got, want := settings[0x4], uint32(1<<10); got != want {
+		t.Errorf("SETTINGS_INITIAL_WINDOW_SIZE = %v, want %v", got, want)
+	}
+	if got, want := settings[0x5], uint32(1<<20); got != want {
+		t.Errorf("SETTINGS_MAX_FRAME_SIZE = %v, want %v", got, want)
+	}
+	typ, _, streamID, payload, err := readH2Frame(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ != h2FrameWindowUpdate || streamID != 0 || len(payload) != 4 {
+		t.Fatalf("got frame of type %v on stream %v, want connection WINDOW_UPDATE", typ, streamID)
+	}
+	if got, want := binary.BigEndian.Uint32(payload), uint32(1<<20); got != want {
+		t.Errorf("connection WINDOW_UPDATE increment = %v, want %v", got, want)
+	}
+
+	conn.Close()
+	if err := <-errc; err == nil {
+		t.Errorf("RoundTrip succeeded after server closed connection, want error")
+	}
+}
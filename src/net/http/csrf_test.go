@@ -0,0 +1,289 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// httptest.NewRequest sets Req.Host to "example.com".
+
+func TestCrossOriginProtectionSecFetchSite(t *testing.T) {
+	protection := http.NewCrossOriginProtection()
+	handler := protection.Handler(okHandler)
+
+	tests := []struct {
+		name           string
+		method         string
+		secFetchSite   string
+		origin         string
+		expectedStatus int
+	}{
+		{"same-origin allowed", "POST", "same-origin", "", http.StatusOK},
+		{"none allowed", "POST", "none", "", http.StatusOK},
+		{"cross-site blocked", "POST", "cross-site", "", http.StatusForbidden},
+		{"same-site blocked", "POST", "same-site", "", http.StatusForbidden},
+
+		{"no header with no origin", "POST", "", "", http.StatusOK},
+		{"no header with matching origin", "POST", "", "https://example.com", http.StatusOK},
+		{"no header with mismatched origin", "POST", "", "https://attacker.example", http.StatusForbidden},
+		{"no header with null origin", "POST", "", "null", http.StatusForbidden},
+
+		{"GET allowed", "GET", "cross-site", "", http.StatusOK},
+		{"HEAD allowed", "HEAD", "cross-site", "", http.StatusOK},
+		{"OPTIONS allowed", "OPTIONS", "cross-site", "", http.StatusOK},
+		{"PUT blocked", "PUT", "cross-site", "", http.StatusForbidden},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "https://example.com/", nil)
+			if tc.secFetchSite != "" {
+				req.Header.Set("Sec-Fetch-Site", tc.secFetchSite)
+			}
+			if tc.origin != "" {
+				req.Header.Set("Origin", tc.origin)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("got status %d, want %d", w.Code, tc.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestCrossOriginProtectionTrustedOriginBypass(t *testing.T) {
+	protection := http.NewCrossOriginProtection()
+	err := protection.AddTrustedOrigin("https://trusted.example")
+	if err != nil {
+		t.Fatalf("AddTrustedOrigin: %v", err)
+	}
+	handler := protection.Handler(okHandler)
+
+	tests := []struct {
+		name           string
+		origin         string
+		secFetchSite   string
+		expectedStatus int
+	}{
+		{"trusted origin without sec-fetch-site", "https://trusted.example", "", http.StatusOK},
+		{"trusted origin with cross-site", "https://trusted.example", "cross-site", http.StatusOK},
+		{"untrusted origin without sec-fetch-site", "https://attacker.example", "", http.StatusForbidden},
+		{"untrusted origin with cross-site", "https://attacker.example", "cross-site", http.StatusForbidden},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "https://example.com/", nil)
+			req.Header.Set("Origin", tc.origin)
+			if tc.secFetchSite != "" {
+				req.Header.Set("Sec-Fetch-Site", tc.secFetchSite)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("got status %d, want %d", w.Code, tc.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestCrossOriginProtectionPatternBypass(t *testing.T) {
+	protection := http.NewCrossOriginProtection()
+	protection.AddInsecureBypassPattern("/bypass/")
+	protection.AddInsecureBypassPattern("/only/{foo}")
+	protection.AddInsecureBypassPattern("/no-trailing")
+	protection.AddInsecureBypassPattern("/yes-trailing/")
+	protection.AddInsecureBypassPattern("PUT /put-only/")
+	handler := protection.Handler(okHandler)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		secFetchSite   string
+		expectedStatus int
+	}{
+		{"bypass path without sec-fetch-site", "POST", "/bypass/", "", http.StatusOK},
+		{"bypass path with cross-site", "POST", "/bypass/", "cross-site", http.StatusOK},
+		{"non-bypass path without sec-fetch-site", "POST", "/api/", "", http.StatusForbidden},
+		{"non-bypass path with cross-site", "POST", "/api/", "cross-site", http.StatusForbidden},
+
+		// Paths which ServeMux would redirect to a bypass pattern are not
+		// themselves exempt.
+		{"redirect to bypass path with ..", "POST", "/foo/../bypass/bar", "", http.StatusForbidden},
+		{"redirect to bypass path with trailing slash", "POST", "/bypass", "", http.StatusForbidden},
+		{"redirect to bypass path with .. and cross-site", "POST", "/foo/../bypass/bar", "cross-site", http.StatusForbidden},
+		{"redirect to bypass path with trailing slash and cross-site", "POST", "/bypass", "cross-site", http.StatusForbidden},
+
+		{"wildcard bypass", "POST", "/only/123", "cross-site", http.StatusOK},
+		{"non-wildcard", "POST", "/only/123/foo", "cross-site", http.StatusForbidden},
+
+		{"no trailing slash exact match", "POST", "/no-trailing", "cross-site", http.StatusOK},
+		{"no trailing slash with slash", "POST", "/no-trailing/", "cross-site", http.StatusForbidden},
+		{"yes trailing slash exact match", "POST", "/yes-trailing/", "cross-site", http.StatusOK},
+		{"yes trailing slash without slash", "POST", "/yes-trailing", "cross-site", http.StatusForbidden},
+
+		{"method-specific hit", "PUT", "/put-only/", "cross-site", http.StatusOK},
+		{"method-specific miss", "DELETE", "/put-only/", "cross-site", http.StatusForbidden},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "https://example.com"+tc.path, nil)
+			req.Header.Set("Origin", "https://attacker.example")
+			if tc.secFetchSite != "" {
+				req.Header.Set("Sec-Fetch-Site", tc.secFetchSite)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("got status %d, want %d", w.Code, tc.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestCrossOriginProtectionSetDenyHandler(t *testing.T) {
+	protection := http.NewCrossOriginProtection()
+
+	handler := protection.Handler(okHandler)
+
+	req := httptest.NewRequest("POST", "https://example.com/", nil)
+	req.Header.Set("Sec-Fetch-Site", "cross-site")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	protection.SetDenyHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		io.WriteString(w, "custom error")
+	}))
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusTeapot {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusTeapot)
+	}
+	if !strings.Contains(w.Body.String(), "custom error") {
+		t.Errorf("expected custom error message, got: %q", w.Body.String())
+	}
+
+	req = httptest.NewRequest("POST", "https://example.com/", nil)
+	req.Header.Set("Sec-Fetch-Site", "same-origin")
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+
+	protection.SetDenyHandler(nil)
+
+	req = httptest.NewRequest("POST", "https://example.com/", nil)
+	req.Header.Set("Sec-Fetch-Site", "cross-site")
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestCrossOriginProtectionAddTrustedOriginErrors(t *testing.T) {
+	protection := http.NewCrossOriginProtection()
+
+	tests := []struct {
+		name    string
+		origin  string
+		wantErr bool
+	}{
+		{"valid origin", "https://example.com", false},
+		{"valid origin with port", "https://example.com:8080", false},
+		{"http origin", "http://example.com", false},
+		{"missing scheme", "example.com", true},
+		{"missing host", "https://", true},
+		{"trailing slash", "https://example.com/", true},
+		{"with path", "https://example.com/path", true},
+		{"with query", "https://example.com?query=value", true},
+		{"with fragment", "https://example.com#fragment", true},
+		{"invalid url", "https://ex ample.com", true},
+		{"empty string", "", true},
+		{"null", "null", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := protection.AddTrustedOrigin(tc.origin)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("AddTrustedOrigin(%q) error = %v, wantErr %v", tc.origin, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestCrossOriginProtectionAddingBypassesConcurrently(t *testing.T) {
+	protection := http.NewCrossOriginProtection()
+	handler := protection.Handler(okHandler)
+
+	req := httptest.NewRequest("POST", "https://example.com/", nil)
+	req.Header.Set("Sec-Fetch-Site", "cross-site")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			protection.AddInsecureBypassPattern(fmt.Sprintf("/bypass/%d/", i))
+			protection.AddTrustedOrigin(fmt.Sprintf("https://trusted%d.example", i))
+		}
+	}()
+
+	for i := 0; i < 1000; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+	}
+	<-done
+}
+
+func TestCrossOriginProtectionCheck(t *testing.T) {
+	protection := http.NewCrossOriginProtection()
+	protection.SetDenyHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Check called the deny handler")
+	}))
+
+	req := httptest.NewRequest("POST", "https://example.com/", nil)
+	req.Header.Set("Sec-Fetch-Site", "cross-site")
+	if err := protection.Check(req); err == nil {
+		t.Errorf("Check of cross-site request succeeded, want error")
+	}
+
+	req.Header.Set("Sec-Fetch-Site", "same-origin")
+	if err := protection.Check(req); err != nil {
+		t.Errorf("Check of same-origin request: %v", err)
+	}
+}
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
@@ -218,3 +218,58 @@ func TestNoUnicodeStrings(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestProtocols(t *testing.T) {
+	var p Protocols
+	if p.HTTP1() {
+		t.Errorf("zero-value protocols: p.HTTP1() = true, want false")
+	}
+	p.SetHTTP1(true)
+	p.SetHTTP2(true)
+	if !p.HTTP1() {
+		t.Errorf("initialized protocols: p.HTTP1() = false, want true")
+	}
+	if !p.HTTP2() {
+		t.Errorf("initialized protocols: p.HTTP2() = false, want true")
+	}
+	p.SetHTTP1(false)
+	if p.HTTP1() {
+		t.Errorf("after unsetting HTTP1: p.HTTP1() = true, want false")
+	}
+	if !p.HTTP2() {
+		t.Errorf("after unsetting HTTP1: p.HTTP2() = false, want true")
+	}
+	p.SetUnencryptedHTTP2(true)
+	if got, want := p.String(), "{HTTP2,UnencryptedHTTP2}"; got != want {
+		t.Errorf("p.String() = %q, want %q", got, want)
+	}
+}
+
+func TestAdjustNextProtos(t *testing.T) {
+	var h1, h2, both Protocols
+	h1.SetHTTP1(true)
+	h2.SetHTTP2(true)
+	both.SetHTTP1(true)
+	both.SetHTTP2(true)
+	for _, test := range []struct {
+		in     []string
+		protos Protocols
+		want   []string
+	}{
+		{nil, h1, []string{"http/1.1"}},
+		{nil, h2, nil},
+		{[]string{"h2", "http/1.1"}, both, []string{"h2", "http/1.1"}},
+		{[]string{"h2", "http/1.1"}, h1, []string{"http/1.1"}},
+		{[]string{"h2", "http/1.1"}, h2, []string{"h2"}},
+		{[]string{"foo", "h2"}, h1, []string{"foo", "http/1.1"}},
+	} {
+		in := append([]string(nil), test.in...)
+		got := adjustNextProtos(in, test.protos)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("adjustNextProtos(%q, %v) = %q, want %q", test.in, test.protos, got, test.want)
+		}
+		if !reflect.DeepEqual(in, test.in) {
+			t.Errorf("adjustNextProtos(%q, %v) modified its input to %q", test.in, test.protos, in)
+		}
+	}
+}
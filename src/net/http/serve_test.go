@@ -398,6 +398,113 @@ func TestServeMuxHandleFuncWithNilHandler(t *testing.T) {
 	mux.HandleFunc("/", nil)
 }
 
+func TestMethodNotAllowed(t *testing.T) {
+	mux := NewServeMux()
+	mux.Handle("GET /", serve(200))
+	mux.Handle("PUT /api/{id}", serve(200))
+	mux.Handle("DELETE /api/{id}", serve(200))
+
+	for _, test := range []struct {
+		method, path string
+		wantCode     int
+		wantAllow    string
+	}{
+		{"GET", "/x", 200, ""},
+		{"HEAD", "/x", 200, ""},
+		{"POST", "/x", 405, "GET, HEAD"},
+		{"POST", "/api/7", 405, "DELETE, GET, HEAD, PUT"},
+		{"PUT", "/api/7", 200, ""},
+	} {
+		rw := httptest.NewRecorder()
+		req := httptest.NewRequest(test.method, test.path, nil)
+		mux.ServeHTTP(rw, req)
+		if rw.Code != test.wantCode {
+			t.Errorf("%s %s: got code %d, want %d", test.method, test.path, rw.Code, test.wantCode)
+		}
+		if got := rw.Header().Get("Allow"); got != test.wantAllow {
+			t.Errorf("%s %s: got Allow %q, want %q", test.method, test.path, got, test.wantAllow)
+		}
+	}
+}
+
+func TestPathValue(t *testing.T) {
+	for _, test := range []struct {
+		pattern string
+		url     string
+		want    map[string]string
+	}{
+		{
+			"/{a}/is/{b}/{c...}",
+			"/now/is/the/time/for/all",
+			map[string]string{
+				"a": "now",
+				"b": "the",
+				"c": "time/for/all",
+				"d": "",
+			},
+		},
+		{
+			"/names/{name}/{other...}",
+			"/names/%2fjohn/address",
+			map[string]string{
+				"name":  "/john",
+				"other": "address",
+			},
+		},
+		{
+			"/names/{name}/{other...}",
+			"/names/john%2Fdoe/there/is%2F/more",
+			map[string]string{
+				"name":  "john/doe",
+				"other": "there/is//more",
+			},
+		},
+	} {
+		mux := NewServeMux()
+		mux.HandleFunc(test.pattern, func(w ResponseWriter, r *Request) {
+			for name, want := range test.want {
+				got := r.PathValue(name)
+				if got != want {
+					t.Errorf("%q, %q: got %q, want %q", test.pattern, name, got, want)
+				}
+			}
+		})
+		server := httptest.NewServer(mux)
+		defer server.Close()
+		res, err := Get(server.URL + test.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+}
+
+func TestSetPathValue(t *testing.T) {
+	mux := NewServeMux()
+	mux.HandleFunc("/a/{b}/c/{d...}", func(_ ResponseWriter, r *Request) {
+		kvs := map[string]string{
+			"b": "X",
+			"d": "Y",
+			"a": "Z",
+		}
+		for k, v := range kvs {
+			r.SetPathValue(k, v)
+		}
+		for k, w := range kvs {
+			if g := r.PathValue(k); g != w {
+				t.Errorf("got %q, want %q", g, w)
+			}
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	res, err := Get(server.URL + "/a/b/c/d/e")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+}
+
 var serveMuxTests2 = []struct {
 	method  string
 	host    string
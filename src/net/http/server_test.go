@@ -8,6 +8,8 @@ package http
 
 import (
 	"fmt"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 )
@@ -90,9 +92,114 @@ func BenchmarkServerMatch(b *testing.B) {
 		"/products/", "/products/3/image.jpg"}
 	b.StartTimer()
 	for i := 0; i < b.N; i++ {
-		if h, p := mux.match(paths[i%len(paths)]); h != nil && p == "" {
+		if n, _ := mux.tree.match("", "GET", paths[i%len(paths)]); n != nil && n.pattern == nil {
 			b.Error("impossible")
 		}
 	}
 	b.StopTimer()
 }
+
+func TestFindHandler(t *testing.T) {
+	mux := NewServeMux()
+	for _, ph := range []struct {
+		pat string
+		h   Handler
+	}{
+		{"/", &handler{1}},
+		{"/foo/", &handler{2}},
+		{"/foo", &handler{3}},
+		{"/bar/", &handler{4}},
+		{"//foo", &handler{5}},
+	} {
+		mux.Handle(ph.pat, ph.h)
+	}
+
+	for _, test := range []struct {
+		method      string
+		path        string
+		wantHandler string
+	}{
+		{"GET", "/", "&http.handler{i:1}"},
+		{"GET", "//", `&http.redirectHandler{url:"/", code:301}`},
+		{"GET", "/foo/../bar/./..//baz", `&http.redirectHandler{url:"/baz", code:301}`},
+		{"GET", "/foo", "&http.handler{i:3}"},
+		{"GET", "/foo/x", "&http.handler{i:2}"},
+		{"GET", "/bar/x", "&http.handler{i:4}"},
+		{"GET", "/bar", `&http.redirectHandler{url:"/bar/", code:301}`},
+		{"CONNECT", "/", "&http.handler{i:1}"},
+		{"CONNECT", "//", "&http.handler{i:1}"},
+		{"CONNECT", "//foo", "&http.handler{i:5}"},
+		{"CONNECT", "/foo/../bar/./..//baz", "&http.handler{i:2}"},
+		{"CONNECT", "/foo", "&http.handler{i:3}"},
+		{"CONNECT", "/foo/x", "&http.handler{i:2}"},
+		{"CONNECT", "/bar/x", "&http.handler{i:4}"},
+		{"CONNECT", "/bar", `&http.redirectHandler{url:"/bar/", code:301}`},
+	} {
+		var r Request
+		r.Method = test.method
+		r.Host = "example.com"
+		r.URL = &url.URL{Path: test.path}
+		gotH, _, _, _ := mux.findHandler(&r)
+		got := fmt.Sprintf("%#v", gotH)
+		if got != test.wantHandler {
+			t.Errorf("%s %q: got %q, want %q", test.method, test.path, got, test.wantHandler)
+		}
+	}
+}
+
+func TestEmptyPattern(t *testing.T) {
+	mux := NewServeMux()
+	defer func() {
+		if recover() == nil {
+			t.Error("Handle with empty pattern did not panic")
+		}
+	}()
+	mux.Handle("", &handler{1})
+}
+
+func TestConflictPanic(t *testing.T) {
+	mux := NewServeMux()
+	mux.Handle("GET /a/{x}", &handler{1})
+	for _, pat := range []string{"GET /a/{y}", "/{x}/b", "HEAD /{y}/c"} {
+		t.Run(pat, func(t *testing.T) {
+			defer func() {
+				r := recover()
+				err, ok := r.(error)
+				if !ok {
+					t.Fatalf("Handle(%q) did not panic with an error; got %v", pat, r)
+				}
+				if got, want := err.Error(), "conflicts with pattern"; !strings.Contains(got, want) {
+					t.Errorf("got %q, want substring %q", got, want)
+				}
+			}()
+			mux.Handle(pat, &handler{2})
+		})
+	}
+	// More specific and disjoint patterns are fine.
+	mux.Handle("GET /a/b", &handler{3})
+	mux.Handle("POST /a/{x}", &handler{4})
+	mux.Handle("example.com/a/{x}", &handler{5})
+}
+
+type handler struct{ i int }
+
+func (handler) ServeHTTP(ResponseWriter, *Request) {}
+
+func BenchmarkServerMatchPatterns(b *testing.B) {
+	fn := func(w ResponseWriter, r *Request) {}
+	mux := NewServeMux()
+	mux.HandleFunc("GET /", fn)
+	mux.HandleFunc("GET /items/{id}", fn)
+	mux.HandleFunc("POST /items/{id}", fn)
+	mux.HandleFunc("GET /items/{id}/parts/{rest...}", fn)
+	mux.HandleFunc("/static/", fn)
+
+	paths := []string{"/", "/items/17", "/items/17/parts/a/b", "/static/x.css", "/notfound"}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if n, _ := mux.tree.match("", "GET", paths[i%len(paths)]); n != nil && n.pattern == nil {
+			b.Error("impossible")
+		}
+	}
+}
@@ -5930,7 +5930,10 @@ func TestTransportClone(t *testing.T) {
 		},
 		ReadBufferSize:  1,
 		WriteBufferSize: 1,
+		HTTP2:           &HTTP2Config{MaxConcurrentStreams: 1},
+		Protocols:       &Protocols{},
 	}
+	tr.Protocols.SetHTTP1(true)
 	tr2 := tr.Clone()
 	rv := reflect.ValueOf(tr2).Elem()
 	rt := rv.Type()
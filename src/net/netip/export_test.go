@@ -4,7 +4,7 @@
 
 package netip
 
-import "internal/intern"
+import "unique"
 
 var (
 	Z0    = z0
@@ -18,7 +18,13 @@ func Mk128(hi, lo uint64) Uint128 {
 	return uint128{hi, lo}
 }
 
-func MkAddr(u Uint128, z *intern.Value) Addr {
+type AddrDetail = addrDetail
+
+func MakeAddrDetail(isV6 bool, zoneV6 string) AddrDetail {
+	return AddrDetail{isV6: isV6, zoneV6: zoneV6}
+}
+
+func MkAddr(u Uint128, z unique.Handle[AddrDetail]) Addr {
 	return Addr{u, z}
 }
 
@@ -65,7 +65,7 @@ func sendFile(c *netFD, r io.Reader) (written int64, err error, handled bool) {
 
 	var werr error
 	err = sc.Read(func(fd uintptr) bool {
-		written, werr = poll.SendFile(&c.pfd, int(fd), pos, remain)
+		written, werr = poll.SendFile(c.pfd, int(fd), pos, remain)
 		return true
 	})
 	if err == nil {
@@ -36,7 +36,7 @@ func splice(c *netFD, r io.Reader) (written int64, err error, handled bool) {
 		return 0, nil, false
 	}
 
-	written, handled, sc, err := poll.Splice(&c.pfd, &s.pfd, remain)
+	written, handled, sc, err := poll.Splice(c.pfd, s.pfd, remain)
 	if lr != nil {
 		lr.N -= written
 	}
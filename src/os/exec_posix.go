@@ -53,7 +53,7 @@ func startProcess(name string, argv []string, attr *ProcAttr) (p *Process, err e
 
 	pid, h, e := syscall.StartProcess(name, argv, sysattr)
 
-	// Make sure we don't run the finalizers of attr.Files.
+	// Make sure we don't run the cleanups of attr.Files.
 	runtime.KeepAlive(attr)
 
 	if e != nil {
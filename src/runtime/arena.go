@@ -333,6 +333,9 @@ func (a *userArena) free() {
 	// Note that active's reference is always the last reference in refs.
 	s = a.active
 	if s != nil {
+		// The chunk's memory may be handed out again right away,
+		// so clear any weak pointers into it now.
+		s.clearUserArenaWeakHandles()
 		if raceenabled || msanenabled || asanenabled {
 			// Don't reuse arenas with sanitizers enabled. We want to catch
 			// any use-after-free errors aggressively.
@@ -828,6 +831,44 @@ func inUserArenaChunk(p uintptr) bool {
 	return s.isUserArenaChunk
 }
 
+// clearUserArenaWeakHandles removes all weak handle specials from the
+// user arena chunk s and clears the handles, so that weak pointers into
+// the chunk observe nil once the chunk is freed.
+func (s *mspan) clearUserArenaWeakHandles() {
+	// Ensure that the span is swept, since sweeping
+	// accesses the specials list w/o locks.
+	mp := acquirem()
+	s.ensureSwept()
+
+	// Unlink the weak handles under the span's lock, but free
+	// them after releasing it, since freeing acquires the heap's
+	// special lock.
+	var list *special
+	lock(&s.speciallock)
+	hadSpecials := s.specials != nil
+	siter := newSpecialsIter(s)
+	for siter.valid() {
+		if siter.s.kind != _KindSpecialWeakHandle {
+			siter.next()
+			continue
+		}
+		sp := siter.unlinkAndNext()
+		sp.next = list
+		list = sp
+	}
+	if hadSpecials && s.specials == nil {
+		spanHasNoSpecials(s)
+	}
+	unlock(&s.speciallock)
+
+	for list != nil {
+		sp := list
+		list = sp.next
+		freeSpecial(sp, unsafe.Pointer(s.base()+uintptr(sp.offset)), s.elemsize)
+	}
+	releasem(mp)
+}
+
 // freeUserArenaChunk releases the user arena represented by s back to the runtime.
 //
 // x must be a live pointer within s.
@@ -843,6 +884,10 @@ func freeUserArenaChunk(s *mspan, x unsafe.Pointer) {
 		throw("invalid user arena span size")
 	}
 
+	// Clear any weak pointers into the chunk before the memory is
+	// released, so they can never observe the freed memory.
+	s.clearUserArenaWeakHandles()
+
 	// Mark the region as free to various santizers immediately instead
 	// of handling them at sweep time.
 	if raceenabled {
@@ -0,0 +1,66 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime
+
+import (
+	"runtime/internal/cgroup"
+	"unsafe"
+)
+
+var (
+	// containerCgroup holds the cgroup directories of the process.
+	// It is set during bootstrap and then only used by
+	// containerLimitsUpdater.
+	containerCgroup cgroup.Cgroup
+	cgroupScratch   [4096]byte
+
+	cgroupFS = cgroup.FS{
+		Open:  cgroupOpen,
+		Read:  cgroupRead,
+		Close: cgroupClose,
+	}
+
+	// cgroupRoot is prepended to the path of every file read to find
+	// the container limits. It is only set by tests, with the linker's
+	// -X flag, to point the runtime at a fake cgroup file system.
+	cgroupRoot    string
+	cgroupRootBuf [2 * cgroup.PathMax]byte
+)
+
+func cgroupOpen(path []byte) int {
+	if cgroupRoot != "" {
+		n := copy(cgroupRootBuf[:], cgroupRoot)
+		if n+len(path) > len(cgroupRootBuf) {
+			return -1
+		}
+		path = cgroupRootBuf[:n+copy(cgroupRootBuf[n:], path)]
+	}
+	return int(open(&path[0], _O_RDONLY|_O_CLOEXEC, 0))
+}
+
+func cgroupRead(fd int, b []byte) int {
+	if len(b) == 0 {
+		return 0
+	}
+	return int(read(int32(fd), unsafe.Pointer(&b[0]), int32(len(b))))
+}
+
+func cgroupClose(fd int) {
+	closefd(int32(fd))
+}
+
+// osInitContainerLimits finds the cgroups of the process, and reports
+// whether it found any.
+func osInitContainerLimits() bool {
+	return cgroup.Find(&cgroupFS, &containerCgroup, cgroupScratch[:])
+}
+
+// osReadContainerLimits returns the CPU limit in CPUs and the memory
+// limit in bytes of the process's cgroups, or 0 for no limit.
+func osReadContainerLimits() (cpu float64, memory uint64) {
+	cpu, _ = containerCgroup.CPULimit(&cgroupFS, cgroupScratch[:])
+	memory, _ = containerCgroup.MemoryLimit(&cgroupFS, cgroupScratch[:])
+	return cpu, memory
+}
@@ -0,0 +1,115 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime_test
+
+import (
+	"fmt"
+	"internal/testenv"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// buildCgroupTestProg builds testprog to read its cgroups from a fake
+// cgroup v2 file system under a new temporary directory, and returns
+// the executable and the directory of the process's cgroup.
+func buildCgroupTestProg(t *testing.T) (exe, dir string) {
+	testenv.MustHaveGoBuild(t)
+
+	root := t.TempDir()
+	files := map[string]string{
+		"proc/self/cgroup":    "0::/app\n",
+		"proc/self/mountinfo": "30 22 0:26 / /sys/fs/cgroup rw - cgroup2 cgroup2 rw\n",
+	}
+	for name, data := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dir = filepath.Join(root, "sys/fs/cgroup/app")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	exe = filepath.Join(t.TempDir(), "testprog.exe")
+	cmd := testenv.Command(t, testenv.GoToolPath(t), "build", "-o", exe, "-ldflags=-X=runtime.cgroupRoot="+root)
+	cmd.Dir = "testdata/testprog"
+	if out, err := testenv.CleanCmdEnv(cmd).CombinedOutput(); err != nil {
+		t.Fatalf("building testprog: %v\n%s", err, out)
+	}
+	return exe, dir
+}
+
+// setCgroupLimits writes the CPU and memory limits of the cgroup in dir.
+func setCgroupLimits(t *testing.T, dir, cpu, memory string) {
+	if err := os.WriteFile(filepath.Join(dir, "cpu.max"), []byte(cpu+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "memory.max"), []byte(memory+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func containerLimitsOutput(procs int, memlimit int64) string {
+	return fmt.Sprintf("GOMAXPROCS=%d memlimit=%d\n", procs, memlimit)
+}
+
+func TestContainerLimits(t *testing.T) {
+	exe, dir := buildCgroupTestProg(t)
+
+	ncpu := runtime.NumCPU()
+	procs := func(n int) int {
+		return min(n, ncpu)
+	}
+	const limit = 1 << 30 / 100 * 90
+
+	tests := []struct {
+		name     string
+		cpu      string
+		memory   string
+		env      []string
+		procs    int
+		memlimit int64
+	}{
+		{"default", "300000 100000", "1073741824", nil, procs(3), limit},
+		{"minimum", "50000 100000", "1073741824", nil, procs(2), limit},
+		{"unlimited", "max 100000", "max", nil, ncpu, math.MaxInt64},
+		{"containermaxprocs=0", "300000 100000", "1073741824", []string{"GODEBUG=containermaxprocs=0"}, ncpu, limit},
+		{"containermemlimit=0", "300000 100000", "1073741824", []string{"GODEBUG=containermemlimit=0"}, procs(3), math.MaxInt64},
+		{"explicit", "300000 100000", "1073741824", []string{"GOMAXPROCS=5", "GOMEMLIMIT=100MiB"}, 5, 100 << 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setCgroupLimits(t, dir, tt.cpu, tt.memory)
+			// Don't inherit GOMAXPROCS or GOMEMLIMIT from the environment.
+			env := append([]string{"GOMAXPROCS=", "GOMEMLIMIT="}, tt.env...)
+			got := runBuiltTestProg(t, exe, "ContainerLimits", env...)
+			if want := containerLimitsOutput(tt.procs, tt.memlimit); got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestContainerLimitsChange(t *testing.T) {
+	exe, dir := buildCgroupTestProg(t)
+
+	ncpu := runtime.NumCPU()
+	if ncpu < 3 {
+		t.Logf("only %d CPUs; GOMAXPROCS changes are not observable", ncpu)
+	}
+	setCgroupLimits(t, dir, "300000 100000", "1073741824")
+	got := runBuiltTestProg(t, exe, "ContainerLimitsChange", "GOMAXPROCS=", "GOMEMLIMIT=", "CGROUP_DIR="+dir)
+	want := containerLimitsOutput(min(3, ncpu), 1<<30/100*90) + containerLimitsOutput(min(2, ncpu), 1<<29/100*90)
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
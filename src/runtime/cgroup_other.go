@@ -0,0 +1,17 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+
+package runtime
+
+// Container limits are only supported on Linux.
+
+func osInitContainerLimits() bool {
+	return false
+}
+
+func osReadContainerLimits() (cpu float64, memory uint64) {
+	return 0, 0
+}
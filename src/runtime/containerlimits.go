@@ -0,0 +1,161 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Container limits
+//
+// Containers usually restrict the CPU time and memory available to a
+// process through Linux cgroups, without changing the number of CPUs
+// the process can see. Left alone, GOMAXPROCS then defaults to the
+// number of CPUs on the machine, so the process runs more threads than
+// its CPU quota allows and gets throttled, and the GC doesn't know
+// that the process will be killed if it exceeds its memory limit.
+//
+// So unless GOMAXPROCS or the memory limit is set explicitly, through
+// the environment or at run time, the runtime derives its default from
+// the cgroup's limits: GOMAXPROCS is the CPU limit rounded up, but at
+// least 2 and at most the number of CPUs, and the memory limit is 90%
+// of the cgroup's memory limit. Limits can change while the process
+// runs, so sysmon periodically wakes up a goroutine that rereads them
+// and updates the defaults.
+
+package runtime
+
+import (
+	"runtime/internal/atomic"
+	"unsafe"
+)
+
+var containerLimits struct {
+	// cpu is the CPU limit of the process's cgroup in CPUs, as
+	// float64 bits, or 0 if there is none.
+	cpu atomic.Uint64
+	// memory is the memory limit of the process's cgroup in bytes,
+	// or 0 if there is none.
+	memory atomic.Uint64
+
+	// autoProcs and autoMemLimit are set while GOMAXPROCS and the
+	// memory limit follow the cgroup's limits.
+	//
+	// autoProcs is only cleared with the world stopped, and
+	// autoMemLimit is only cleared with mheap_.lock held.
+	autoProcs    atomic.Bool
+	autoMemLimit atomic.Bool
+
+	// g is the goroutine that rereads the limits. idle is set while
+	// it waits for sysmon to wake it up.
+	g          *g
+	idle       atomic.Bool
+	lastUpdate int64 // last time sysmon woke g; owned by sysmon
+}
+
+// containerLimitsPeriod is how often the container limits are reread.
+const containerLimitsPeriod = 1e9 // 1 second
+
+// containerMemLimitPercent is the percentage of the cgroup's memory
+// limit that is used as the default memory limit. The remainder leaves
+// room for memory that the runtime doesn't manage, such as memory
+// allocated by C code, which also counts towards the cgroup's limit.
+const containerMemLimitPercent = 90
+
+// initContainerLimits finds the container limits and decides whether
+// GOMAXPROCS and the memory limit follow them. It is called during
+// bootstrap, after the environment and GODEBUG have been read.
+func initContainerLimits() {
+	if !osInitContainerLimits() {
+		return
+	}
+	readContainerLimits()
+	containerLimits.autoProcs.Store(debug.containermaxprocs > 0 && gogetenv("GOMAXPROCS") == "")
+	containerLimits.autoMemLimit.Store(debug.containermemlimit > 0 && gogetenv("GOMEMLIMIT") == "")
+}
+
+func init() {
+	if containerLimits.autoProcs.Load() || containerLimits.autoMemLimit.Load() {
+		go containerLimitsUpdater()
+	}
+}
+
+// readContainerLimits rereads the container limits.
+func readContainerLimits() {
+	cpu, memory := osReadContainerLimits()
+	containerLimits.cpu.Store(float64bits(cpu))
+	containerLimits.memory.Store(memory)
+}
+
+// containerGOMAXPROCS returns the default GOMAXPROCS for the
+// container's CPU limit.
+func containerGOMAXPROCS() int32 {
+	procs := ncpu
+	if cpu := float64frombits(containerLimits.cpu.Load()); cpu > 0 && cpu < float64(procs) {
+		procs = int32(cpu)
+		if float64(procs) < cpu {
+			procs++
+		}
+		// Leave room for the GC to run alongside the application.
+		if procs < 2 {
+			procs = 2
+		}
+		if procs > ncpu {
+			procs = ncpu
+		}
+	}
+	return procs
+}
+
+// containerMemoryLimit returns the default memory limit for the
+// container's memory limit.
+func containerMemoryLimit() int64 {
+	memory := containerLimits.memory.Load()
+	if memory == 0 || memory > uint64(maxInt64) {
+		return maxInt64
+	}
+	return int64(memory / 100 * containerMemLimitPercent)
+}
+
+// containerLimitsUpdater rereads the container limits each time sysmon
+// wakes it up, and updates GOMAXPROCS and the memory limit to follow
+// them for as long as they haven't been set explicitly.
+func containerLimitsUpdater() {
+	containerLimits.g = getg()
+	for containerLimits.autoProcs.Load() || containerLimits.autoMemLimit.Load() {
+		gopark(containerLimitsParkCommit, nil, waitReasonContainerLimitsIdle, traceEvGoBlock, 1)
+		// this goroutine is explicitly resumed by sysmon
+		readContainerLimits()
+
+		if containerLimits.autoProcs.Load() {
+			procs := containerGOMAXPROCS()
+			lock(&sched.lock)
+			changed := procs != gomaxprocs
+			unlock(&sched.lock)
+			if changed {
+				stopTheWorldGC("GOMAXPROCS (container limit)")
+				// GOMAXPROCS may have been called in the meantime.
+				if containerLimits.autoProcs.Load() {
+					// newprocs will be processed by startTheWorld
+					newprocs = procs
+				}
+				startTheWorldGC()
+			}
+		}
+
+		if containerLimits.autoMemLimit.Load() {
+			limit := containerMemoryLimit()
+			// Run on the system stack since we grab the heap lock.
+			systemstack(func() {
+				lock(&mheap_.lock)
+				if containerLimits.autoMemLimit.Load() && gcController.memoryLimit.Load() != limit {
+					gcController.setMemoryLimit(limit)
+					gcControllerCommit()
+				}
+				unlock(&mheap_.lock)
+			})
+		}
+	}
+	containerLimits.g = nil
+}
+
+func containerLimitsParkCommit(gp *g, _ unsafe.Pointer) bool {
+	containerLimits.idle.Store(true)
+	return true
+}
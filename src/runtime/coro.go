@@ -0,0 +1,156 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime
+
+import "unsafe"
+
+// A coro represents extra concurrency without extra parallelism,
+// as would be needed for a coroutine.
+//
+// A coro holds a goroutine that is blocked waiting for the coroutine
+// switch. The goroutine running a coroswitch with that coro blocks,
+// and the goroutine held by the coro starts running in its place,
+// on the same M and P, without going through the scheduler.
+// At any time only one of the goroutines sharing a coro is running.
+//
+// Coroutines are used by iter.Pull.
+type coro struct {
+	gp guintptr
+	f  func(*coro)
+}
+
+// newcoro creates a new coro containing a goroutine blocked waiting
+// to run f. The goroutine starts when coroswitch is first called on
+// the coro, and it exits when f returns, switching back to the
+// goroutine that last called coroswitch.
+func newcoro(f func(*coro)) *coro {
+	c := new(coro)
+	c.f = f
+	pc := getcallerpc()
+	gp := getg()
+	systemstack(func() {
+		start := corostart
+		startfv := *(**funcval)(unsafe.Pointer(&start))
+		gp = newproc1(startfv, gp, pc)
+	})
+	gp.coroarg = c
+	gp.waitreason = waitReasonCoroutine
+	casgstatus(gp, _Grunnable, _Gwaiting)
+	c.gp.set(gp)
+	return c
+}
+
+// corostart is the entry func for a new coroutine.
+// It runs the coroutine user function f passed to newcoro
+// and then calls coroexit to remove the extra concurrency.
+func corostart() {
+	gp := getg()
+	c := gp.coroarg
+	gp.coroarg = nil
+	if raceenabled {
+		raceacquire(unsafe.Pointer(c))
+	}
+
+	// Exit through a deferred call, so that a runtime.Goexit
+	// in f also switches back to the coroutine's caller.
+	defer coroexit(c)
+	c.f(c)
+}
+
+// coroexit is like coroswitch but closes the coro
+// and exits the current goroutine.
+func coroexit(c *coro) {
+	gp := getg()
+	gp.coroarg = c
+	gp.coroexit = true
+	if raceenabled {
+		racereleasemerge(unsafe.Pointer(c))
+		racegoend()
+	}
+	if trace.enabled {
+		traceGoEnd()
+	}
+	mcall(coroswitch_m)
+}
+
+// coroswitch switches to the goroutine blocked on c
+// and then blocks the current goroutine on c.
+func coroswitch(c *coro) {
+	gp := getg()
+	gp.coroarg = c
+	if raceenabled {
+		racereleasemerge(unsafe.Pointer(c))
+	}
+	mcall(coroswitch_m)
+	if raceenabled {
+		raceacquire(unsafe.Pointer(c))
+	}
+}
+
+// coroswitch_m is the implementation of coroswitch
+// that runs on the m stack.
+//
+// Note: Coroutine switches are not guaranteed to run on the same M as
+// the goroutine that started the coroutine, so a goroutine locked to
+// its thread cannot use them.
+func coroswitch_m(gp *g) {
+	c := gp.coroarg
+	gp.coroarg = nil
+	exit := gp.coroexit
+	gp.coroexit = false
+	mp := gp.m
+
+	if mp.lockedInt != 0 || mp.lockedExt != 0 {
+		throw("coroswitch on locked thread")
+	}
+
+	if exit {
+		gdestroy(gp)
+		gp = nil
+	} else {
+		if trace.enabled {
+			traceGoPark(traceEvGoBlock, 2)
+		}
+		gp.waitreason = waitReasonCoroutine
+		casgstatus(gp, _Grunning, _Gwaiting)
+		dropg()
+	}
+
+	// The goroutine stored in c is the one to run next.
+	// Swap it with ourselves.
+	var gnext *g
+	for {
+		next := c.gp
+		if next.ptr() == nil {
+			throw("coroswitch on exited coro")
+		}
+		var self guintptr
+		self.set(gp)
+		if c.gp.cas(next, self) {
+			gnext = next.ptr()
+			break
+		}
+	}
+
+	if goroutineProfile.active {
+		tryRecordGoroutineProfile(gnext, osyield)
+	}
+
+	// Start running next, without heavy scheduling machinery.
+	mp.curg = gnext
+	gnext.m = mp
+	if trace.enabled {
+		traceGoUnpark(gnext, 0)
+	}
+	casgstatus(gnext, _Gwaiting, _Grunnable)
+	casgstatus(gnext, _Grunnable, _Grunning)
+	gnext.waitsince = 0
+	if trace.enabled {
+		traceGoStart()
+	}
+
+	// Switch to gnext. Does not return.
+	gogo(&gnext.sched)
+}
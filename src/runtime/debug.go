@@ -11,7 +11,11 @@ import (
 
 // GOMAXPROCS sets the maximum number of CPUs that can be executing
 // simultaneously and returns the previous setting. It defaults to
-// the value of runtime.NumCPU. If n < 1, it does not change the current setting.
+// the value of runtime.NumCPU. On Linux, if the process's cgroup has a
+// lower CPU limit, it defaults to that limit rounded up, but not less
+// than 2, and follows changes to the limit; see the GOMAXPROCS
+// environment variable. If n < 1, it does not change the current setting.
+// Otherwise, the setting no longer follows the cgroup's CPU limit.
 // This call will go away when the scheduler improves.
 func GOMAXPROCS(n int) int {
 	if GOARCH == "wasm" && n > 1 {
@@ -21,12 +25,15 @@ func GOMAXPROCS(n int) int {
 	lock(&sched.lock)
 	ret := int(gomaxprocs)
 	unlock(&sched.lock)
-	if n <= 0 || n == ret {
+	if n <= 0 || n == ret && !containerLimits.autoProcs.Load() {
 		return ret
 	}
 
 	stopTheWorldGC("GOMAXPROCS")
 
+	// An explicit setting overrides the container's CPU limit from now on.
+	containerLimits.autoProcs.Store(false)
+
 	// newprocs will be processed by startTheWorld
 	newprocs = int32(n)
 
@@ -29,8 +29,11 @@ The supported suffixes include B, KiB, MiB, GiB, and TiB. These suffixes
 represent quantities of bytes as defined by the IEC 80000-13 standard. That is,
 they are based on powers of two: KiB means 2^10 bytes, MiB means 2^20 bytes,
 and so on. The default setting is math.MaxInt64, which effectively disables the
-memory limit. [runtime/debug.SetMemoryLimit] allows changing this limit at run
-time.
+memory limit. On Linux, if the process's cgroup has a memory limit, the default
+is instead 90% of that limit, leaving room for memory the Go runtime doesn't
+manage, and it follows changes to the cgroup's limit.
+[runtime/debug.SetMemoryLimit] allows changing this limit at run time, after
+which it no longer follows the cgroup's limit.
 
 The GODEBUG variable controls debugging variables within the runtime.
 It is a comma-separated list of name=val pairs setting these named variables:
@@ -55,6 +58,12 @@ It is a comma-separated list of name=val pairs setting these named variables:
 	expensive checks that should not miss any errors, but will
 	cause your program to run slower.
 
+	containermaxprocs: setting containermaxprocs=0 makes the default
+	GOMAXPROCS ignore the CPU limit of the process's cgroup.
+
+	containermemlimit: setting containermemlimit=0 makes the default
+	memory limit ignore the memory limit of the process's cgroup.
+
 	efence: setting efence=1 causes the allocator to run in a mode
 	where each object is allocated on a unique page and addresses are
 	never recycled.
@@ -163,6 +172,11 @@ It is a comma-separated list of name=val pairs setting these named variables:
 	IDs will refer to the ID of the goroutine at the time of creation; it's possible for this
 	ID to be reused for another goroutine. Setting N to 0 will report no ancestry information.
 
+	tracefpunwindoff: setting tracefpunwindoff=1 forces the execution tracer to
+	use the runtime's default stack unwinder instead of frame pointer unwinding.
+	This increases tracer overhead, but could be helpful as a workaround or for
+	debugging unexpected regressions caused by frame pointer unwinding.
+
 	asyncpreemptoff: asyncpreemptoff=1 disables signal-based
 	asynchronous goroutine preemption. This makes some loops
 	non-preemptible for long periods, which may delay GC and
@@ -177,7 +191,12 @@ The GOMAXPROCS variable limits the number of operating system threads that
 can execute user-level Go code simultaneously. There is no limit to the number of threads
 that can be blocked in system calls on behalf of Go code; those do not count against
 the GOMAXPROCS limit. This package's GOMAXPROCS function queries and changes
-the limit.
+the limit. The default is the number of logical CPUs. On Linux, if the process's
+cgroup limits its CPU bandwidth to fewer CPUs, the default is that limit rounded up,
+but not less than 2. The runtime rereads the cgroup's limits about once a second
+and adjusts the default GOMAXPROCS and memory limit to follow them, unless they
+were set by the environment or at run time. The runtime/metrics package reports
+the cgroup's limits under /cgroup/.
 
 The GORACE variable configures the race detector, for programs built using -race.
 See https://golang.org/doc/articles/race_detector.html for details.
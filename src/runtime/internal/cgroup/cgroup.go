@@ -0,0 +1,502 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package cgroup finds the CPU and memory limits that Linux control
+// groups (cgroups) place on the current process.
+//
+// It supports both cgroup v1 and the unified cgroup v2 hierarchy, as
+// well as hybrid systems that mount both. The cgroup of the process is
+// found through /proc/self/cgroup, and the file system it lives on
+// through /proc/self/mountinfo, so cgroup namespaces and cgroup file
+// systems mounted in unusual places are handled.
+//
+// This package is used by the runtime, so it must not allocate. All
+// buffers are provided by the caller, and file access goes through an
+// FS.
+package cgroup
+
+// Version is a cgroup version.
+type Version int
+
+const (
+	None Version = iota // No cgroup found.
+	V1                  // cgroup v1
+	V2                  // cgroup v2, the unified hierarchy
+)
+
+// PathMax is the maximum length of a cgroup directory path.
+const PathMax = 1024
+
+// An FS provides access to the files read by this package.
+type FS struct {
+	// Open opens the file with the given NUL-terminated path for
+	// reading and returns its file descriptor, or a negative value
+	// on error.
+	Open func(path []byte) int
+	// Read reads from fd into b. It returns the number of bytes
+	// read, 0 at end of file, or a negative value on error.
+	Read func(fd int, b []byte) int
+	// Close closes fd.
+	Close func(fd int)
+}
+
+// A Dir is a cgroup directory in a mounted cgroup file system.
+type Dir struct {
+	Version Version
+
+	path  [PathMax]byte
+	n     int // len of path
+	mount int // len of the mount point prefix of path
+}
+
+// Path returns the path of d.
+func (d *Dir) Path() []byte {
+	return d.path[:d.n]
+}
+
+// A Cgroup holds the cgroup directories that control the CPU and
+// memory usage of the process.
+type Cgroup struct {
+	CPU    Dir
+	Memory Dir
+
+	file [PathMax + 32]byte // path of the file being read
+}
+
+// Find finds the cgroups of the current process. It reports whether
+// it found any. scratch is used to read files; lines that don't fit
+// in it are ignored.
+func Find(fs *FS, cg *Cgroup, scratch []byte) bool {
+	cg.CPU = Dir{}
+	cg.Memory = Dir{}
+
+	// Each line of /proc/self/cgroup has the form
+	//
+	//	hierarchy-ID:controller-list:cgroup-path
+	//
+	// The v2 hierarchy has ID 0 and no controllers. Controllers
+	// bound to a v1 hierarchy take precedence over v2.
+	var lr lineReader
+	if !lr.open(fs, cg.setFile("/proc/self/cgroup"), scratch) {
+		return false
+	}
+	for {
+		line, ok := lr.next()
+		if !ok {
+			break
+		}
+		id, rest := cut(line, ':')
+		controllers, path := cut(rest, ':')
+		if len(path) == 0 || path[0] != '/' {
+			continue
+		}
+		if string(id) == "0" && len(controllers) == 0 {
+			if cg.CPU.Version != V1 {
+				cg.CPU.set(V2, path)
+			}
+			if cg.Memory.Version != V1 {
+				cg.Memory.set(V2, path)
+			}
+			continue
+		}
+		if hasElem(controllers, "cpu") {
+			cg.CPU.set(V1, path)
+		}
+		if hasElem(controllers, "memory") {
+			cg.Memory.set(V1, path)
+		}
+	}
+	lr.close()
+	if cg.CPU.Version == None && cg.Memory.Version == None {
+		return false
+	}
+
+	// Translate the cgroup paths into paths in the file system.
+	// Each line of /proc/self/mountinfo has the form
+	//
+	//	36 35 98:0 /root /mount/point rw,noatime master:1 - fstype source super-options
+	//
+	// where the number of optional fields before "-" varies.
+	cpuDone, memDone := cg.CPU.Version == None, cg.Memory.Version == None
+	if !lr.open(fs, cg.setFile("/proc/self/mountinfo"), scratch) {
+		cg.CPU.Version, cg.Memory.Version = None, None
+		return false
+	}
+	for !cpuDone || !memDone {
+		line, ok := lr.next()
+		if !ok {
+			break
+		}
+		var f [5][]byte
+		for i := range f {
+			f[i], line = cutField(line)
+		}
+		root, mountPoint := f[3], f[4]
+		var sep []byte
+		for len(line) > 0 {
+			if sep, line = cutField(line); string(sep) == "-" {
+				break
+			}
+		}
+		if string(sep) != "-" {
+			continue
+		}
+		fstype, line := cutField(line)
+		_, line = cutField(line) // source
+		superOptions, _ := cutField(line)
+
+		switch string(fstype) {
+		case "cgroup2":
+			if !cpuDone && cg.CPU.Version == V2 {
+				cpuDone = cg.CPU.resolve(root, mountPoint)
+			}
+			if !memDone && cg.Memory.Version == V2 {
+				memDone = cg.Memory.resolve(root, mountPoint)
+			}
+		case "cgroup":
+			if !cpuDone && cg.CPU.Version == V1 && hasElem(superOptions, "cpu") {
+				cpuDone = cg.CPU.resolve(root, mountPoint)
+			}
+			if !memDone && cg.Memory.Version == V1 && hasElem(superOptions, "memory") {
+				memDone = cg.Memory.resolve(root, mountPoint)
+			}
+		}
+	}
+	lr.close()
+	if !cpuDone {
+		cg.CPU = Dir{}
+	}
+	if !memDone {
+		cg.Memory = Dir{}
+	}
+	return cg.CPU.Version != None || cg.Memory.Version != None
+}
+
+// set records the cgroup path of d, relative to the root of its
+// hierarchy, until resolve translates it.
+func (d *Dir) set(v Version, path []byte) {
+	if len(path) > len(d.path) {
+		*d = Dir{}
+		return
+	}
+	d.Version = v
+	d.n = copy(d.path[:], path)
+}
+
+// resolve translates the cgroup path of d into a path in the cgroup
+// file system mounted at the escaped mountPoint, whose root is the
+// escaped cgroup path root. It reports whether the cgroup is in that
+// file system.
+func (d *Dir) resolve(root, mountPoint []byte) bool {
+	var rootBuf, mountBuf [PathMax]byte
+	r, ok := unescape(rootBuf[:], root)
+	if !ok {
+		return false
+	}
+	m, ok := unescape(mountBuf[:], mountPoint)
+	if !ok {
+		return false
+	}
+
+	// Find the cgroup path relative to the mount root.
+	path := d.path[:d.n]
+	var rel []byte
+	switch {
+	case string(r) == "/":
+		rel = path
+	case string(path) == string(r):
+		rel = nil
+	case hasPrefix(path, r) && path[len(r)] == '/':
+		rel = path[len(r):]
+	default:
+		return false
+	}
+	if string(rel) == "/" {
+		rel = nil
+	}
+	if len(m)+len(rel) > len(d.path) {
+		return false
+	}
+	copy(d.path[len(m):], rel)
+	copy(d.path[:], m)
+	d.n = len(m) + len(rel)
+	d.mount = len(m)
+	return true
+}
+
+// CPULimit returns the CPU limit of cg in CPUs, which is the lowest
+// limit set on the process's cgroup and its ancestors. It reports false
+// if there is no limit.
+func (cg *Cgroup) CPULimit(fs *FS, scratch []byte) (limit float64, ok bool) {
+	d := &cg.CPU
+	for n := d.n; d.Version != None; n = d.parent(n) {
+		dir := d.path[:n]
+		var quota, period uint64
+		var found bool
+		if d.Version == V2 {
+			// cpu.max holds "$MAX $PERIOD", where $MAX is
+			// "max" if there is no limit.
+			if b, ok := cg.readFile(fs, dir, "cpu.max", scratch); ok {
+				q, p := cut(b, ' ')
+				quota, found = parseUint(q)
+				period, ok = parseUint(p)
+				found = found && ok
+			}
+		} else {
+			// A quota of -1 means there is no limit.
+			if b, ok := cg.readFile(fs, dir, "cpu.cfs_quota_us", scratch); ok {
+				quota, found = parseUint(b)
+			}
+			if found {
+				var b []byte
+				if b, found = cg.readFile(fs, dir, "cpu.cfs_period_us", scratch); found {
+					period, found = parseUint(b)
+				}
+			}
+		}
+		if found && quota > 0 && period > 0 {
+			l := float64(quota) / float64(period)
+			if !ok || l < limit {
+				limit, ok = l, true
+			}
+		}
+		if n == d.mount {
+			break
+		}
+	}
+	return limit, ok
+}
+
+// MemoryLimit returns the memory limit of cg in bytes, which is the
+// lowest limit set on the process's cgroup and its ancestors. It
+// reports false if there is no limit.
+func (cg *Cgroup) MemoryLimit(fs *FS, scratch []byte) (limit uint64, ok bool) {
+	d := &cg.Memory
+	for n := d.n; d.Version != None; n = d.parent(n) {
+		dir := d.path[:n]
+		var l uint64
+		var found bool
+		if d.Version == V2 {
+			// memory.max is "max" if there is no limit.
+			if b, ok := cg.readFile(fs, dir, "memory.max", scratch); ok {
+				l, found = parseUint(b)
+			}
+		} else {
+			// There is no limit if memory.limit_in_bytes is
+			// close to the largest int64, rounded down to a
+			// multiple of the page size.
+			if b, ok := cg.readFile(fs, dir, "memory.limit_in_bytes", scratch); ok {
+				l, found = parseUint(b)
+				found = found && l < 1<<62
+			}
+		}
+		if found && (!ok || l < limit) {
+			limit, ok = l, true
+		}
+		if n == d.mount {
+			break
+		}
+	}
+	return limit, ok
+}
+
+// parent returns the length of the path of the parent of the cgroup
+// directory d.path[:n], which must not be the mount point.
+func (d *Dir) parent(n int) int {
+	for n > d.mount && d.path[n-1] != '/' {
+		n--
+	}
+	if n > d.mount {
+		n-- // trailing slash
+	}
+	if n < d.mount {
+		n = d.mount
+	}
+	return n
+}
+
+// setFile stores the NUL-terminated name in cg.file and returns it.
+func (cg *Cgroup) setFile(name string) []byte {
+	n := copy(cg.file[:], name)
+	cg.file[n] = 0
+	return cg.file[:n+1]
+}
+
+// readFile reads the file name in dir into scratch and returns its
+// contents, without surrounding white space.
+func (cg *Cgroup) readFile(fs *FS, dir []byte, name string, scratch []byte) ([]byte, bool) {
+	if len(dir)+1+len(name)+1 > len(cg.file) {
+		return nil, false
+	}
+	n := copy(cg.file[:], dir)
+	cg.file[n] = '/'
+	n++
+	n += copy(cg.file[n:], name)
+	cg.file[n] = 0
+	fd := fs.Open(cg.file[:n+1])
+	if fd < 0 {
+		return nil, false
+	}
+	m := fs.Read(fd, scratch)
+	fs.Close(fd)
+	if m < 0 {
+		return nil, false
+	}
+	return trimSpace(scratch[:m]), true
+}
+
+// A lineReader reads a file line by line into a fixed buffer.
+type lineReader struct {
+	fs   *FS
+	fd   int
+	buf  []byte
+	r, w int // unread data is buf[r:w]
+	eof  bool
+}
+
+func (lr *lineReader) open(fs *FS, path, buf []byte) bool {
+	*lr = lineReader{fs: fs, fd: fs.Open(path), buf: buf}
+	return lr.fd >= 0 && len(buf) > 0
+}
+
+func (lr *lineReader) close() {
+	if lr.fd >= 0 {
+		lr.fs.Close(lr.fd)
+	}
+	lr.fd = -1
+}
+
+// next returns the next line, without its newline. The line is only
+// valid until the next call to next. Lines that don't fit in the
+// buffer are skipped.
+func (lr *lineReader) next() (line []byte, ok bool) {
+	skip := false
+	for {
+		if i := indexByte(lr.buf[lr.r:lr.w], '\n'); i >= 0 {
+			line = lr.buf[lr.r : lr.r+i]
+			lr.r += i + 1
+			if skip {
+				skip = false
+				continue
+			}
+			return line, true
+		}
+		if lr.eof {
+			if lr.r < lr.w && !skip {
+				line = lr.buf[lr.r:lr.w]
+				lr.r = lr.w
+				return line, true
+			}
+			return nil, false
+		}
+		if lr.r == 0 && lr.w == len(lr.buf) {
+			// The line doesn't fit. Drop what we have of it.
+			skip = true
+			lr.w = 0
+		} else {
+			lr.w = copy(lr.buf, lr.buf[lr.r:lr.w])
+			lr.r = 0
+		}
+		n := lr.fs.Read(lr.fd, lr.buf[lr.w:])
+		if n <= 0 {
+			lr.eof = true
+			continue
+		}
+		lr.w += n
+	}
+}
+
+// cut slices s around the first instance of sep.
+func cut(s []byte, sep byte) (before, after []byte) {
+	if i := indexByte(s, sep); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+	return s, nil
+}
+
+func indexByte(s []byte, c byte) int {
+	for i, b := range s {
+		if b == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// cutField returns the first space-separated field of s and the rest
+// of s after it.
+func cutField(s []byte) (field, rest []byte) {
+	for len(s) > 0 && s[0] == ' ' {
+		s = s[1:]
+	}
+	return cut(s, ' ')
+}
+
+// hasElem reports whether the comma-separated list s contains elem.
+func hasElem(s []byte, elem string) bool {
+	for len(s) > 0 {
+		var e []byte
+		e, s = cut(s, ',')
+		if string(e) == elem {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPrefix(s, prefix []byte) bool {
+	return len(s) >= len(prefix) && string(s[:len(prefix)]) == string(prefix)
+}
+
+func trimSpace(s []byte) []byte {
+	for len(s) > 0 && (s[0] == ' ' || s[0] == '\n' || s[0] == '\t') {
+		s = s[1:]
+	}
+	for len(s) > 0 && (s[len(s)-1] == ' ' || s[len(s)-1] == '\n' || s[len(s)-1] == '\t') {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// parseUint parses s as a decimal number.
+func parseUint(s []byte) (uint64, bool) {
+	if len(s) == 0 {
+		return 0, false
+	}
+	var n uint64
+	for _, c := range s {
+		if c < '0' || c > '9' || n > (1<<64-1)/10 {
+			return 0, false
+		}
+		d := uint64(c - '0')
+		if n*10 > 1<<64-1-d {
+			return 0, false
+		}
+		n = n*10 + d
+	}
+	return n, true
+}
+
+// unescape copies s to buf, replacing the octal escapes that
+// /proc/self/mountinfo uses for white space and backslashes.
+func unescape(buf, s []byte) ([]byte, bool) {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if n == len(buf) {
+			return nil, false
+		}
+		c := s[i]
+		if c == '\\' && i+3 < len(s) && isOctal(s[i+1]) && isOctal(s[i+2]) && isOctal(s[i+3]) {
+			c = (s[i+1]-'0')<<6 | (s[i+2]-'0')<<3 | (s[i+3] - '0')
+			i += 3
+		}
+		buf[n] = c
+		n++
+	}
+	return buf[:n], true
+}
+
+func isOctal(c byte) bool {
+	return '0' <= c && c <= '7'
+}
@@ -0,0 +1,275 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cgroup_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime/internal/cgroup"
+	"strings"
+	"testing"
+)
+
+// fakeFS returns an FS that serves the files in the tree under root as
+// if root were the root directory.
+func fakeFS(t *testing.T, root string) *cgroup.FS {
+	files := map[int]*os.File{}
+	next := 3
+	return &cgroup.FS{
+		Open: func(path []byte) int {
+			if len(path) == 0 || path[len(path)-1] != 0 {
+				t.Fatalf("Open(%q): path is not NUL-terminated", path)
+			}
+			f, err := os.Open(filepath.Join(root, string(path[:len(path)-1])))
+			if err != nil {
+				return -1
+			}
+			fd := next
+			next++
+			files[fd] = f
+			return fd
+		},
+		Read: func(fd int, b []byte) int {
+			n, err := files[fd].Read(b)
+			if n == 0 && err != nil {
+				return 0
+			}
+			return n
+		},
+		Close: func(fd int) {
+			files[fd].Close()
+			delete(files, fd)
+		},
+	}
+}
+
+// writeTree creates the files in tree under a new temporary directory
+// and returns it.
+func writeTree(t *testing.T, tree map[string]string) string {
+	root := t.TempDir()
+	for name, data := range tree {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+const (
+	v2Mountinfo = `22 1 0:20 / /sys rw,nosuid - sysfs sysfs rw
+30 22 0:26 / /sys/fs/cgroup rw,nosuid,nodev,noexec shared:4 - cgroup2 cgroup2 rw,nsdelegate
+`
+	v1Mountinfo = `22 1 0:20 / /sys rw,nosuid - sysfs sysfs rw
+31 22 0:27 / /sys/fs/cgroup/unified rw shared:5 - cgroup2 cgroup2 rw
+32 22 0:28 / /sys/fs/cgroup/cpu,cpuacct rw shared:6 - cgroup cgroup rw,cpu,cpuacct
+33 22 0:29 / /sys/fs/cgroup/cpuset rw shared:7 - cgroup cgroup rw,cpuset
+34 22 0:30 / /sys/fs/cgroup/memory rw shared:8 - cgroup cgroup rw,memory
+`
+)
+
+var limitTests = []struct {
+	name    string
+	tree    map[string]string
+	cpu     float64 // 0 means no limit
+	memory  uint64  // 0 means no limit
+	cpuPath string
+	memPath string
+}{
+	{
+		name: "v2",
+		tree: map[string]string{
+			"proc/self/cgroup":                               "0::/app.slice/app.service\n",
+			"proc/self/mountinfo":                            v2Mountinfo,
+			"sys/fs/cgroup/app.slice/app.service/cpu.max":    "250000 100000\n",
+			"sys/fs/cgroup/app.slice/app.service/memory.max": "1073741824\n",
+		},
+		cpu:     2.5,
+		memory:  1 << 30,
+		cpuPath: "/sys/fs/cgroup/app.slice/app.service",
+		memPath: "/sys/fs/cgroup/app.slice/app.service",
+	},
+	{
+		name: "v2-unlimited",
+		tree: map[string]string{
+			"proc/self/cgroup":             "0::/app\n",
+			"proc/self/mountinfo":          v2Mountinfo,
+			"sys/fs/cgroup/app/cpu.max":    "max 100000\n",
+			"sys/fs/cgroup/app/memory.max": "max\n",
+		},
+		cpuPath: "/sys/fs/cgroup/app",
+		memPath: "/sys/fs/cgroup/app",
+	},
+	{
+		name: "v2-ancestor-limits",
+		tree: map[string]string{
+			"proc/self/cgroup":                       "0::/pod/container\n",
+			"proc/self/mountinfo":                    v2Mountinfo,
+			"sys/fs/cgroup/cpu.max":                  "400000 100000\n", // mount root, still applies
+			"sys/fs/cgroup/pod/cpu.max":              "150000 100000\n",
+			"sys/fs/cgroup/pod/memory.max":           "536870912\n",
+			"sys/fs/cgroup/pod/container/cpu.max":    "max 100000\n",
+			"sys/fs/cgroup/pod/container/memory.max": "1073741824\n",
+		},
+		cpu:     1.5,
+		memory:  1 << 29,
+		cpuPath: "/sys/fs/cgroup/pod/container",
+		memPath: "/sys/fs/cgroup/pod/container",
+	},
+	{
+		name: "v2-namespace",
+		tree: map[string]string{
+			// Inside a cgroup namespace, the process's cgroup
+			// is the root of the mounted hierarchy.
+			"proc/self/cgroup":         "0::/\n",
+			"proc/self/mountinfo":      "1 0 0:26 / /sys/fs/cgroup ro - cgroup2 cgroup rw\n",
+			"sys/fs/cgroup/cpu.max":    "50000 100000\n",
+			"sys/fs/cgroup/memory.max": "268435456\n",
+		},
+		cpu:     0.5,
+		memory:  1 << 28,
+		cpuPath: "/sys/fs/cgroup",
+		memPath: "/sys/fs/cgroup",
+	},
+	{
+		name: "v2-bind-mount",
+		tree: map[string]string{
+			// Only the container's part of the hierarchy is
+			// mounted, at an escaped path.
+			"proc/self/cgroup":      "0::/kubepods/pod1/ctr\n",
+			"proc/self/mountinfo":   "1 0 0:26 /kubepods/pod1 /cgroup\\040fs rw - cgroup2 cgroup rw\n",
+			"cgroup fs/cpu.max":     "200000 100000\n",
+			"cgroup fs/ctr/cpu.max": "300000 100000\n",
+		},
+		cpu:     2,
+		cpuPath: "/cgroup fs/ctr",
+		memPath: "/cgroup fs/ctr",
+	},
+	{
+		name: "v1",
+		tree: map[string]string{
+			"proc/self/cgroup": `12:memory:/docker/abc
+11:cpuset:/docker/abc
+4:cpu,cpuacct:/docker/abc
+1:name=systemd:/docker/abc
+0::/docker/abc
+`,
+			"proc/self/mountinfo": v1Mountinfo,
+			"sys/fs/cgroup/cpu,cpuacct/docker/abc/cpu.cfs_quota_us":  "300000\n",
+			"sys/fs/cgroup/cpu,cpuacct/docker/abc/cpu.cfs_period_us": "100000\n",
+			"sys/fs/cgroup/memory/docker/abc/memory.limit_in_bytes":  "2147483648\n",
+			"sys/fs/cgroup/unified/docker/abc/cpu.max":               "100000 100000\n", // ignored
+		},
+		cpu:     3,
+		memory:  2 << 30,
+		cpuPath: "/sys/fs/cgroup/cpu,cpuacct/docker/abc",
+		memPath: "/sys/fs/cgroup/memory/docker/abc",
+	},
+	{
+		name: "v1-unlimited",
+		tree: map[string]string{
+			"proc/self/cgroup":                            "4:cpu,cpuacct:/\n12:memory:/\n",
+			"proc/self/mountinfo":                         v1Mountinfo,
+			"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_quota_us":  "-1\n",
+			"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_period_us": "100000\n",
+			"sys/fs/cgroup/memory/memory.limit_in_bytes":  "9223372036854771712\n",
+		},
+		cpuPath: "/sys/fs/cgroup/cpu,cpuacct",
+		memPath: "/sys/fs/cgroup/memory",
+	},
+	{
+		name: "not-mounted",
+		tree: map[string]string{
+			"proc/self/cgroup":    "0::/app\n",
+			"proc/self/mountinfo": "22 1 0:20 / /sys rw,nosuid - sysfs sysfs rw\n",
+		},
+	},
+	{
+		name: "no-cgroups",
+		tree: map[string]string{},
+	},
+}
+
+func TestLimits(t *testing.T) {
+	for _, tt := range limitTests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := fakeFS(t, writeTree(t, tt.tree))
+			var cg cgroup.Cgroup
+			scratch := make([]byte, 512)
+
+			found := cgroup.Find(fs, &cg, scratch)
+			if want := tt.cpuPath != "" || tt.memPath != ""; found != want {
+				t.Fatalf("Find = %v, want %v", found, want)
+			}
+			if got := string(cg.CPU.Path()); got != tt.cpuPath {
+				t.Errorf("CPU cgroup = %q, want %q", got, tt.cpuPath)
+			}
+			if got := string(cg.Memory.Path()); got != tt.memPath {
+				t.Errorf("memory cgroup = %q, want %q", got, tt.memPath)
+			}
+
+			cpu, ok := cg.CPULimit(fs, scratch)
+			if ok != (tt.cpu != 0) || cpu != tt.cpu {
+				t.Errorf("CPULimit = %v, %v, want %v", cpu, ok, tt.cpu)
+			}
+			mem, ok := cg.MemoryLimit(fs, scratch)
+			if ok != (tt.memory != 0) || mem != tt.memory {
+				t.Errorf("MemoryLimit = %v, %v, want %v", mem, ok, tt.memory)
+			}
+		})
+	}
+}
+
+func TestLongMountinfoLines(t *testing.T) {
+	// Lines that don't fit in the scratch buffer are skipped.
+	long := "40 22 0:40 / /" + strings.Repeat("x", 300) + " rw - tmpfs tmpfs rw\n"
+	root := writeTree(t, map[string]string{
+		"proc/self/cgroup":          "0::/app\n",
+		"proc/self/mountinfo":       long + v2Mountinfo + long,
+		"sys/fs/cgroup/app/cpu.max": "100000 100000\n",
+	})
+	fs := fakeFS(t, root)
+	var cg cgroup.Cgroup
+	scratch := make([]byte, 128)
+	if !cgroup.Find(fs, &cg, scratch) {
+		t.Fatal("Find = false, want true")
+	}
+	if cpu, ok := cg.CPULimit(fs, scratch); !ok || cpu != 1 {
+		t.Errorf("CPULimit = %v, %v, want 1, true", cpu, ok)
+	}
+}
+
+func TestLimitsChange(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"proc/self/cgroup":             "0::/app\n",
+		"proc/self/mountinfo":          v2Mountinfo,
+		"sys/fs/cgroup/app/cpu.max":    "100000 100000\n",
+		"sys/fs/cgroup/app/memory.max": "max\n",
+	})
+	fs := fakeFS(t, root)
+	var cg cgroup.Cgroup
+	scratch := make([]byte, 512)
+	if !cgroup.Find(fs, &cg, scratch) {
+		t.Fatal("Find = false, want true")
+	}
+
+	// Limits are reread each time.
+	write := func(name, data string) {
+		if err := os.WriteFile(filepath.Join(root, "sys/fs/cgroup/app", name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("cpu.max", "400000 100000\n")
+	write("memory.max", "4096\n")
+	if cpu, ok := cg.CPULimit(fs, scratch); !ok || cpu != 4 {
+		t.Errorf("CPULimit = %v, %v, want 4, true", cpu, ok)
+	}
+	if mem, ok := cg.MemoryLimit(fs, scratch); !ok || mem != 4096 {
+		t.Errorf("MemoryLimit = %v, %v, want 4096, true", mem, ok)
+	}
+}
@@ -1414,5 +1414,163 @@ func reflectlite_maplen(h *hmap) int {
 	return h.count
 }
 
+//go:linkname maps_clone maps.clone
+func maps_clone(m any) any {
+	e := efaceOf(&m)
+	src := (*hmap)(e.data)
+	if raceenabled && src != nil {
+		callerpc := getcallerpc()
+		racereadpc(unsafe.Pointer(src), callerpc, abi.FuncPCABIInternal(maps_clone))
+	}
+	e.data = unsafe.Pointer(mapclone((*maptype)(unsafe.Pointer(e._type)), src))
+	return m
+}
+
+// mapclone returns a copy of src. Rather than inserting every key
+// into a new map, it copies src's buckets directly: the clone uses the
+// same hash seed and number of buckets as src, so every entry belongs
+// in the bucket with the same index. Only entries still in the old
+// buckets of a map that is growing to twice its size are reinserted.
+func mapclone(t *maptype, src *hmap) *hmap {
+	dst := makemap(t, 0, nil)
+	if src == nil || src.count == 0 {
+		return dst
+	}
+	if src.flags&hashWriting != 0 {
+		fatal("concurrent map clone and map write")
+	}
+	dst.hash0 = src.hash0
+	dst.B = src.B
+
+	if src.B == 0 && src.oldbuckets == nil && !(t.indirectkey() && t.needkeyupdate()) && !t.indirectelem() {
+		// Quick copy for small maps. A map with a single bucket
+		// has no overflow buckets, as it grows before it needs one.
+		dst.buckets = newobject(t.bucket)
+		dst.count = src.count
+		typedmemmove(t.bucket, dst.buckets, src.buckets)
+		return dst
+	}
+
+	var nextOverflow *bmap
+	dst.buckets, nextOverflow = makeBucketArray(t, dst.B, nil)
+	if nextOverflow != nil {
+		dst.extra = new(mapextra)
+		dst.extra.nextOverflow = nextOverflow
+	}
+
+	nbuckets := bucketShift(dst.B)
+	for i := uintptr(0); i < nbuckets; i++ {
+		dstb := (*bmap)(add(dst.buckets, i*uintptr(t.bucketsize)))
+		pos := 0
+		for srcb := (*bmap)(add(src.buckets, i*uintptr(t.bucketsize))); srcb != nil; srcb = srcb.overflow(t) {
+			dstb, pos = moveToBmap(t, dst, dstb, pos, srcb)
+		}
+	}
+
+	if src.oldbuckets == nil {
+		return dst
+	}
+
+	// Copy the entries that have not been evacuated yet.
+	sameSize := src.sameSizeGrow()
+	noldbuckets := src.noldbuckets()
+	for i := uintptr(0); i < noldbuckets; i++ {
+		srcb := (*bmap)(add(src.oldbuckets, i*uintptr(t.bucketsize)))
+		if evacuated(srcb) {
+			continue
+		}
+		if sameSize {
+			// Old bucket i maps to bucket i of the clone.
+			// Append its entries to that bucket's chain.
+			dstb := (*bmap)(add(dst.buckets, i*uintptr(t.bucketsize)))
+			for dstb.overflow(t) != nil {
+				dstb = dstb.overflow(t)
+			}
+			pos := 0
+			for ; srcb != nil; srcb = srcb.overflow(t) {
+				dstb, pos = moveToBmap(t, dst, dstb, pos, srcb)
+			}
+			continue
+		}
+		// The entries of old bucket i belong to either bucket i or
+		// bucket i+noldbuckets of the clone, so insert them one by one.
+		for ; srcb != nil; srcb = srcb.overflow(t) {
+			for j := uintptr(0); j < bucketCnt; j++ {
+				if isEmpty(srcb.tophash[j]) {
+					continue
+				}
+				if src.flags&hashWriting != 0 {
+					fatal("concurrent map clone and map write")
+				}
+				k := add(unsafe.Pointer(srcb), dataOffset+j*uintptr(t.keysize))
+				if t.indirectkey() {
+					k = *((*unsafe.Pointer)(k))
+				}
+				e := add(unsafe.Pointer(srcb), dataOffset+bucketCnt*uintptr(t.keysize)+j*uintptr(t.elemsize))
+				if t.indirectelem() {
+					e = *((*unsafe.Pointer)(e))
+				}
+				typedmemmove(t.elem, mapassign(t, dst, k), e)
+			}
+		}
+	}
+	return dst
+}
+
+// moveToBmap copies the entries of the bucket src, but not those of its
+// overflow buckets, to dst, starting at slot pos. It returns the bucket
+// and slot at which to copy further entries, which are in a new overflow
+// bucket of dst if dst filled up.
+func moveToBmap(t *maptype, h *hmap, dst *bmap, pos int, src *bmap) (*bmap, int) {
+	for i := 0; i < bucketCnt; i++ {
+		if isEmpty(src.tophash[i]) {
+			continue
+		}
+
+		for ; pos < bucketCnt; pos++ {
+			if isEmpty(dst.tophash[pos]) {
+				break
+			}
+		}
+
+		if pos == bucketCnt {
+			dst = h.newoverflow(t, dst)
+			pos = 0
+		}
+
+		srcK := add(unsafe.Pointer(src), dataOffset+uintptr(i)*uintptr(t.keysize))
+		srcEle := add(unsafe.Pointer(src), dataOffset+bucketCnt*uintptr(t.keysize)+uintptr(i)*uintptr(t.elemsize))
+		dstK := add(unsafe.Pointer(dst), dataOffset+uintptr(pos)*uintptr(t.keysize))
+		dstEle := add(unsafe.Pointer(dst), dataOffset+bucketCnt*uintptr(t.keysize)+uintptr(pos)*uintptr(t.elemsize))
+
+		dst.tophash[pos] = src.tophash[i]
+		if t.indirectkey() {
+			srcK = *(*unsafe.Pointer)(srcK)
+			if t.needkeyupdate() {
+				kStore := newobject(t.key)
+				typedmemmove(t.key, kStore, srcK)
+				srcK = kStore
+			}
+			// Note: if needkeyupdate is false, then the memory
+			// used to store the key is immutable, so we can share
+			// it between the original map and its clone.
+			*(*unsafe.Pointer)(dstK) = srcK
+		} else {
+			typedmemmove(t.key, dstK, srcK)
+		}
+		if t.indirectelem() {
+			srcEle = *(*unsafe.Pointer)(srcEle)
+			eStore := newobject(t.elem)
+			typedmemmove(t.elem, eStore, srcEle)
+			*(*unsafe.Pointer)(dstEle) = eStore
+		} else {
+			typedmemmove(t.elem, dstEle, srcEle)
+		}
+		pos++
+		h.count++
+	}
+	return dst, pos
+}
+
 const maxZero = 1024 // must match value in reflect/value.go:maxZero cmd/compile/internal/gc/walk.go:zeroValSize
 var zeroVal [maxZero]byte
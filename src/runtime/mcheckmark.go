@@ -88,12 +88,7 @@ func setCheckmark(obj, base, off uintptr, mbits markBits) bool {
 		throw("checkmark found unmarked object")
 	}
 
-	ai := arenaIndex(obj)
-	arena := mheap_.arenas[ai.l1()][ai.l2()]
-	arenaWord := (obj / heapArenaBytes / 8) % uintptr(len(arena.checkmarks.b))
-	mask := byte(1 << ((obj / heapArenaBytes) % 8))
-	bytep := &arena.checkmarks.b[arenaWord]
-
+	bytep, mask := checkmarkBit(obj)
 	if atomic.Load8(bytep)&mask != 0 {
 		// Already checkmarked.
 		return true
@@ -102,3 +97,18 @@ func setCheckmark(obj, base, off uintptr, mbits markBits) bool {
 	atomic.Or8(bytep, mask)
 	return false
 }
+
+// isCheckmarked reports whether obj has been checkmarked.
+func isCheckmarked(obj uintptr) bool {
+	bytep, mask := checkmarkBit(obj)
+	return atomic.Load8(bytep)&mask != 0
+}
+
+// checkmarkBit returns the byte and mask of obj's checkmark bit.
+func checkmarkBit(obj uintptr) (bytep *uint8, mask uint8) {
+	ai := arenaIndex(obj)
+	arena := mheap_.arenas[ai.l1()][ai.l2()]
+	arenaWord := (obj / goarch.PtrSize / 8) % uintptr(len(arena.checkmarks.b))
+	mask = byte(1 << ((obj / goarch.PtrSize) % 8))
+	return &arena.checkmarks.b[arenaWord], mask
+}
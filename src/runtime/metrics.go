@@ -90,6 +90,36 @@ func initMetrics() {
 				out.scalar = uint64(NumCgoCall())
 			},
 		},
+		"/cgroup/cpu-limit:cpus": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				out.kind = metricKindFloat64
+				out.scalar = containerLimits.cpu.Load()
+			},
+		},
+		"/cgroup/gomaxprocs:threads": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = 0
+				if containerLimits.autoProcs.Load() {
+					out.scalar = uint64(containerGOMAXPROCS())
+				}
+			},
+		},
+		"/cgroup/gomemlimit:bytes": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = 0
+				if containerLimits.autoMemLimit.Load() {
+					out.scalar = uint64(containerMemoryLimit())
+				}
+			},
+		},
+		"/cgroup/memory-limit:bytes": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = containerLimits.memory.Load()
+			},
+		},
 		"/cpu/classes/gc/mark/assist:cpu-seconds": {
 			deps: makeStatDepSet(cpuStatsDep),
 			compute: func(in *statAggregate, out *metricValue) {
@@ -188,6 +218,12 @@ func initMetrics() {
 				out.scalar = in.sysStats.gcCyclesDone
 			},
 		},
+		"/gc/gomemlimit:bytes": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = uint64(gcController.memoryLimit.Load())
+			},
+		},
 		"/gc/heap/allocs-by-size:bytes": {
 			deps: makeStatDepSet(heapStatsDep),
 			compute: func(in *statAggregate, out *metricValue) {
@@ -57,6 +57,32 @@ var allDesc = []Description{
 		Kind:        KindUint64,
 		Cumulative:  true,
 	},
+	{
+		Name: "/cgroup/cpu-limit:cpus",
+		Description: "CPU bandwidth limit of the process's cgroup, in CPUs, as read by the runtime. " +
+			"Zero if there is no limit or cgroups are not supported on this platform.",
+		Kind: KindFloat64,
+	},
+	{
+		Name: "/cgroup/gomaxprocs:threads",
+		Description: "GOMAXPROCS setting derived from /cgroup/cpu-limit:cpus, which the runtime " +
+			"uses as long as GOMAXPROCS is not set by the environment or runtime.GOMAXPROCS. " +
+			"Zero if GOMAXPROCS does not follow the cgroup's CPU limit.",
+		Kind: KindUint64,
+	},
+	{
+		Name: "/cgroup/gomemlimit:bytes",
+		Description: "Memory limit derived from /cgroup/memory-limit:bytes, which the runtime " +
+			"uses as long as it is not set by GOMEMLIMIT or runtime/debug.SetMemoryLimit. " +
+			"Zero if the memory limit does not follow the cgroup's memory limit.",
+		Kind: KindUint64,
+	},
+	{
+		Name: "/cgroup/memory-limit:bytes",
+		Description: "Memory limit of the process's cgroup, as read by the runtime. " +
+			"Zero if there is no limit or cgroups are not supported on this platform.",
+		Kind: KindUint64,
+	},
 	{
 		Name: "/cpu/classes/gc/mark/assist:cpu-seconds",
 		Description: "Estimated total CPU time goroutines spent performing GC tasks " +
@@ -191,6 +217,12 @@ var allDesc = []Description{
 		Kind:        KindUint64,
 		Cumulative:  true,
 	},
+	{
+		Name: "/gc/gomemlimit:bytes",
+		Description: "Go runtime memory limit, as set by GOMEMLIMIT, runtime/debug.SetMemoryLimit, " +
+			"or derived from the cgroup's memory limit, otherwise math.MaxInt64.",
+		Kind: KindUint64,
+	},
 	{
 		Name: "/gc/heap/allocs-by-size:bytes",
 		Description: "Distribution of heap allocations by approximate size. " +
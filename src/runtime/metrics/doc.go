@@ -54,6 +54,24 @@ Below is the full list of supported metrics, ordered lexicographically.
 	/cgo/go-to-c-calls:calls
 		Count of calls made from Go to C by the current process.
 
+	/cgroup/cpu-limit:cpus
+		CPU bandwidth limit of the process's cgroup, in CPUs, as read by the runtime.
+		Zero if there is no limit or cgroups are not supported on this platform.
+
+	/cgroup/gomaxprocs:threads
+		GOMAXPROCS setting derived from /cgroup/cpu-limit:cpus, which the runtime
+		uses as long as GOMAXPROCS is not set by the environment or runtime.GOMAXPROCS.
+		Zero if GOMAXPROCS does not follow the cgroup's CPU limit.
+
+	/cgroup/gomemlimit:bytes
+		Memory limit derived from /cgroup/memory-limit:bytes, which the runtime
+		uses as long as it is not set by GOMEMLIMIT or runtime/debug.SetMemoryLimit.
+		Zero if the memory limit does not follow the cgroup's memory limit.
+
+	/cgroup/memory-limit:bytes
+		Memory limit of the process's cgroup, as read by the runtime.
+		Zero if there is no limit or cgroups are not supported on this platform.
+
 	/cpu/classes/gc/mark/assist:cpu-seconds
 		Estimated total CPU time goroutines spent performing GC tasks
 		to assist the GC and prevent it from falling behind the application.
@@ -147,6 +165,10 @@ Below is the full list of supported metrics, ordered lexicographically.
 	/gc/cycles/total:gc-cycles
 		Count of all completed GC cycles.
 
+	/gc/gomemlimit:bytes
+		Go runtime memory limit, as set by GOMEMLIMIT, runtime/debug.SetMemoryLimit,
+		or derived from the cgroup's memory limit, otherwise math.MaxInt64.
+
 	/gc/heap/allocs-by-size:bytes
 		Distribution of heap allocations by approximate size.
 		Note that this does not include tiny objects as defined by /gc/heap/tiny/allocs:objects,
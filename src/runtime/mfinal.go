@@ -172,7 +172,30 @@ func finalizercommit(gp *g, lock unsafe.Pointer) bool {
 	return true
 }
 
-// This is the goroutine that runs all of the finalizers
+// isGoPointerWithoutSpan reports whether p points into memory that
+// belongs to the Go program but has no span: the zero-sized allocation
+// base, or the data and bss segments of a module.
+func isGoPointerWithoutSpan(p unsafe.Pointer) bool {
+	// 0-length objects are okay.
+	if p == unsafe.Pointer(&zerobase) {
+		return true
+	}
+
+	// The relevant segments are: noptrdata, data, bss, noptrbss.
+	// We cannot assume they are in any order or even contiguous,
+	// due to external linking.
+	for datap := &firstmoduledata; datap != nil; datap = datap.next {
+		if datap.noptrdata <= uintptr(p) && uintptr(p) < datap.enoptrdata ||
+			datap.data <= uintptr(p) && uintptr(p) < datap.edata ||
+			datap.bss <= uintptr(p) && uintptr(p) < datap.ebss ||
+			datap.noptrbss <= uintptr(p) && uintptr(p) < datap.enoptrbss {
+			return true
+		}
+	}
+	return false
+}
+
+// This is the goroutine that runs all of the finalizers and cleanups.
 func runfinq() {
 	var (
 		frame    unsafe.Pointer
@@ -202,58 +225,65 @@ func runfinq() {
 			for i := fb.cnt; i > 0; i-- {
 				f := &fb.fin[i-1]
 
-				var regs abi.RegArgs
-				// The args may be passed in registers or on stack. Even for
-				// the register case, we still need the spill slots.
-				// TODO: revisit if we remove spill slots.
-				//
-				// Unfortunately because we can have an arbitrary
-				// amount of returns and it would be complex to try and
-				// figure out how many of those can get passed in registers,
-				// just conservatively assume none of them do.
-				framesz := unsafe.Sizeof((any)(nil)) + f.nret
-				if framecap < framesz {
-					// The frame does not contain pointers interesting for GC,
-					// all not yet finalized objects are stored in finq.
-					// If we do not mark it as FlagNoScan,
-					// the last finalized object is not collected.
-					frame = mallocgc(framesz, nil, true)
-					framecap = framesz
-				}
-
 				if f.fint == nil {
-					throw("missing type in runfinq")
-				}
-				r := frame
-				if argRegs > 0 {
-					r = unsafe.Pointer(&regs.Ints)
+					// No type information means this is a cleanup,
+					// which takes no arguments. (Finalizers always
+					// have a type.)
+					cleanup := *(*func())(unsafe.Pointer(&f.fn))
+					fingStatus.Or(fingRunningFinalizer)
+					cleanup()
+					fingStatus.And(^fingRunningFinalizer)
 				} else {
-					// frame is effectively uninitialized
-					// memory. That means we have to clear
-					// it before writing to it to avoid
-					// confusing the write barrier.
-					*(*[2]uintptr)(frame) = [2]uintptr{}
-				}
-				switch f.fint.kind & kindMask {
-				case kindPtr:
-					// direct use of pointer
-					*(*unsafe.Pointer)(r) = f.arg
-				case kindInterface:
-					ityp := (*interfacetype)(unsafe.Pointer(f.fint))
-					// set up with empty interface
-					(*eface)(r)._type = &f.ot.typ
-					(*eface)(r).data = f.arg
-					if len(ityp.mhdr) != 0 {
-						// convert to interface with methods
-						// this conversion is guaranteed to succeed - we checked in SetFinalizer
-						(*iface)(r).tab = assertE2I(ityp, (*eface)(r)._type)
+					var regs abi.RegArgs
+					// The args may be passed in registers or on stack. Even for
+					// the register case, we still need the spill slots.
+					// TODO: revisit if we remove spill slots.
+					//
+					// Unfortunately because we can have an arbitrary
+					// amount of returns and it would be complex to try and
+					// figure out how many of those can get passed in registers,
+					// just conservatively assume none of them do.
+					framesz := unsafe.Sizeof((any)(nil)) + f.nret
+					if framecap < framesz {
+						// The frame does not contain pointers interesting for GC,
+						// all not yet finalized objects are stored in finq.
+						// If we do not mark it as FlagNoScan,
+						// the last finalized object is not collected.
+						frame = mallocgc(framesz, nil, true)
+						framecap = framesz
 					}
-				default:
-					throw("bad kind in runfinq")
+
+					r := frame
+					if argRegs > 0 {
+						r = unsafe.Pointer(&regs.Ints)
+					} else {
+						// frame is effectively uninitialized
+						// memory. That means we have to clear
+						// it before writing to it to avoid
+						// confusing the write barrier.
+						*(*[2]uintptr)(frame) = [2]uintptr{}
+					}
+					switch f.fint.kind & kindMask {
+					case kindPtr:
+						// direct use of pointer
+						*(*unsafe.Pointer)(r) = f.arg
+					case kindInterface:
+						ityp := (*interfacetype)(unsafe.Pointer(f.fint))
+						// set up with empty interface
+						(*eface)(r)._type = &f.ot.typ
+						(*eface)(r).data = f.arg
+						if len(ityp.mhdr) != 0 {
+							// convert to interface with methods
+							// this conversion is guaranteed to succeed - we checked in SetFinalizer
+							(*iface)(r).tab = assertE2I(ityp, (*eface)(r)._type)
+						}
+					default:
+						throw("bad kind in runfinq")
+					}
+					fingStatus.Or(fingRunningFinalizer)
+					reflectcall(nil, unsafe.Pointer(f.fn), frame, uint32(framesz), uint32(framesz), uint32(framesz), &regs)
+					fingStatus.And(^fingRunningFinalizer)
 				}
-				fingStatus.Or(fingRunningFinalizer)
-				reflectcall(nil, unsafe.Pointer(f.fn), frame, uint32(framesz), uint32(framesz), uint32(framesz), &regs)
-				fingStatus.And(^fingRunningFinalizer)
 
 				// Drop finalizer queue heap references
 				// before hiding them from markroot.
@@ -284,6 +314,9 @@ func runfinq() {
 //
 // SetFinalizer(obj, nil) clears any finalizer associated with obj.
 //
+// New Go code should consider using [AddCleanup] instead, which is much
+// less error-prone than SetFinalizer.
+//
 // The argument obj must be a pointer to an object allocated by calling
 // new, by taking the address of a composite literal, or by taking the
 // address of a local variable.
@@ -380,25 +413,13 @@ func SetFinalizer(obj any, finalizer any) {
 
 	if base == 0 {
 		// 0-length objects are okay.
-		if e.data == unsafe.Pointer(&zerobase) {
-			return
-		}
-
 		// Global initializers might be linker-allocated.
 		//	var Foo = &Object{}
 		//	func main() {
 		//		runtime.SetFinalizer(Foo, nil)
 		//	}
-		// The relevant segments are: noptrdata, data, bss, noptrbss.
-		// We cannot assume they are in any order or even contiguous,
-		// due to external linking.
-		for datap := &firstmoduledata; datap != nil; datap = datap.next {
-			if datap.noptrdata <= uintptr(e.data) && uintptr(e.data) < datap.enoptrdata ||
-				datap.data <= uintptr(e.data) && uintptr(e.data) < datap.edata ||
-				datap.bss <= uintptr(e.data) && uintptr(e.data) < datap.ebss ||
-				datap.noptrbss <= uintptr(e.data) && uintptr(e.data) < datap.enoptrbss {
-				return
-			}
+		if isGoPointerWithoutSpan(e.data) {
+			return
 		}
 		throw("runtime.SetFinalizer: pointer not in allocated block")
 	}
@@ -0,0 +1,307 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Goroutine leak detection
+//
+// A goroutine blocked on a channel, a semaphore or a sync.Cond can
+// only be woken up by another goroutine that operates on the same
+// object. If no goroutine that could ever run again can reach that
+// object, the blocked goroutine is leaked: it will never run again,
+// and neither its stack nor anything it references will ever be freed.
+//
+// Leak detection uses the garbage collector to find such goroutines.
+// When requested, mark termination runs an extra stop-the-world
+// traversal of the heap using the checkmark bits, after the real mark
+// is complete. This traversal does not affect what the GC frees. It
+// differs from a normal mark in that the stacks of goroutines blocked
+// on synchronization objects (the "candidates") are not roots, and the
+// runtime's own references to them (their g, sudogs queued on
+// semaphores) are not followed. Once the traversal reaches an object
+// that a candidate is blocked on, that candidate can be woken up by
+// some reachable goroutine, so its stack is scanned in turn and the
+// traversal continues. When no more candidates become reachable, the
+// remaining ones are leaked.
+//
+// Goroutines blocked on a nil channel or in an empty select can never
+// be woken up, so they are always reported. Objects that are not in
+// the heap, such as sync.Mutexes in global variables, are treated as
+// reachable.
+
+package runtime
+
+import (
+	"runtime/internal/atomic"
+	"unsafe"
+)
+
+var goroutineLeak struct {
+	// pending requests that the next mark termination run
+	// leak detection.
+	pending atomic.Bool
+
+	// detecting is set while leak detection is running. It tells
+	// markroot not to scan the stacks of candidates.
+	detecting bool
+
+	// cycle counts the leak detection passes that have run.
+	// Goroutines found leaked by the most recent pass have a
+	// leakCycle equal to cycle.
+	//
+	// Protected by the world being stopped.
+	cycle uint32
+}
+
+// isLeakCandidate reports whether gp is blocked on a synchronization
+// object and could therefore be leaked.
+//
+// The world must be stopped.
+func isLeakCandidate(gp *g) bool {
+	if readgstatus(gp)&^_Gscan != _Gwaiting || isSystemGoroutine(gp, false) {
+		return false
+	}
+	switch gp.waitreason {
+	case waitReasonChanReceive, waitReasonChanSend, waitReasonSelect,
+		waitReasonChanReceiveNilChan, waitReasonChanSendNilChan, waitReasonSelectNoCases,
+		waitReasonSemacquire, waitReasonSyncCondWait,
+		waitReasonSyncMutexLock, waitReasonSyncRWMutexRLock, waitReasonSyncRWMutexLock:
+		return true
+	}
+	return false
+}
+
+// leakBlockerReachable reports whether any of the objects that the
+// candidate gp is blocked on has been reached by leak detection.
+func leakBlockerReachable(gp *g) bool {
+	switch gp.waitreason {
+	case waitReasonChanReceive, waitReasonChanSend, waitReasonSelect:
+		for sg := gp.waiting; sg != nil; sg = sg.waitlink {
+			if leakObjectReachable(unsafe.Pointer(sg.c)) {
+				return true
+			}
+		}
+		return false
+	case waitReasonSemacquire, waitReasonSyncCondWait,
+		waitReasonSyncMutexLock, waitReasonSyncRWMutexRLock, waitReasonSyncRWMutexLock:
+		// Be conservative if we don't know the object.
+		return gp.blockedOn == nil || leakObjectReachable(gp.blockedOn)
+	}
+	// Nil channels and empty selects can never proceed.
+	return false
+}
+
+// leakObjectReachable reports whether p points to an object that leak
+// detection has reached, or outside the heap.
+func leakObjectReachable(p unsafe.Pointer) bool {
+	base, _, _ := findObject(uintptr(p), 0, 0)
+	return base == 0 || isCheckmarked(base)
+}
+
+// checkmarkObject sets the checkmark of the heap object at obj
+// without queuing it for scanning.
+func checkmarkObject(obj uintptr) {
+	base, span, objIndex := findObject(obj, 0, 0)
+	if base == 0 {
+		return
+	}
+	setCheckmark(base, 0, 0, span.markBitsForIndex(objIndex))
+}
+
+// checkmarkSemaSudogs checkmarks all the sudogs in the semaphore treap
+// rooted at s, so that the traversal doesn't reach the semaphores
+// through their waiters.
+func checkmarkSemaSudogs(s *sudog) {
+	if s == nil {
+		return
+	}
+	for t := s; t != nil; t = t.waitlink {
+		checkmarkObject(uintptr(unsafe.Pointer(t)))
+	}
+	checkmarkSemaSudogs(s.prev)
+	checkmarkSemaSudogs(s.next)
+}
+
+// findGoroutineLeaks runs leak detection and records its result in
+// the leakCycle of each leaked goroutine.
+//
+// It must be called during mark termination, after marking is
+// complete, with the world stopped and on the system stack.
+func findGoroutineLeaks() {
+	assertWorldStopped()
+
+	// This pass must not count towards the pacer's scan work.
+	heapScanWork := gcController.heapScanWork.Load()
+	stackScanWork := gcController.stackScanWork.Load()
+	globalsScanWork := gcController.globalsScanWork.Load()
+
+	startCheckmarks()
+	forEachG(func(gp *g) {
+		gp.gcscandone = false
+	})
+	forEachGRace(func(gp *g) {
+		if isLeakCandidate(gp) {
+			checkmarkObject(uintptr(unsafe.Pointer(gp)))
+		}
+	})
+	for i := range semtable {
+		checkmarkSemaSudogs(semtable[i].root.treap)
+	}
+
+	goroutineLeak.detecting = true
+	gcMarkRootPrepare()
+	gcw := &getg().m.p.ptr().gcw
+	gcDrain(gcw, 0)
+	for {
+		progress := false
+		forEachGRace(func(gp *g) {
+			if gp.gcscandone || !isLeakCandidate(gp) || !leakBlockerReachable(gp) {
+				return
+			}
+			stopped := suspendG(gp)
+			scanstack(gp, gcw)
+			gp.gcscandone = true
+			resumeG(stopped)
+			scanobject(uintptr(unsafe.Pointer(gp)), gcw)
+			progress = true
+		})
+		if !progress {
+			break
+		}
+		gcDrain(gcw, 0)
+	}
+	goroutineLeak.detecting = false
+	work.stackRoots = nil
+
+	goroutineLeak.cycle++
+	forEachGRace(func(gp *g) {
+		if !gp.gcscandone && isLeakCandidate(gp) {
+			gp.leakCycle = goroutineLeak.cycle
+		}
+	})
+
+	wbBufFlush1(getg().m.p.ptr())
+	gcw.heapScanWork = 0
+	gcw.dispose()
+	endCheckmarks()
+
+	gcController.heapScanWork.Store(heapScanWork)
+	gcController.stackScanWork.Store(stackScanWork)
+	gcController.globalsScanWork.Store(globalsScanWork)
+}
+
+// isLeaked reports whether gp was found leaked by the most recent
+// leak detection pass.
+//
+// The world must be stopped.
+func isLeaked(gp *g) bool {
+	return goroutineLeak.cycle != 0 && gp.leakCycle == goroutineLeak.cycle && readgstatus(gp) == _Gwaiting
+}
+
+//go:linkname runtime_goroutineLeakGC runtime/pprof.runtime_goroutineLeakGC
+func runtime_goroutineLeakGC() {
+	goroutineLeak.pending.Store(true)
+	GC()
+}
+
+//go:linkname runtime_goroutineLeakProfileWithLabels runtime/pprof.runtime_goroutineLeakProfileWithLabels
+func runtime_goroutineLeakProfileWithLabels(p []StackRecord, labels []unsafe.Pointer) (n int, ok bool) {
+	return goroutineLeakProfileWithLabels(p, labels)
+}
+
+// goroutineLeakProfileWithLabels is like goroutineProfileWithLabels,
+// but only reports the goroutines found leaked by the most recent leak
+// detection pass. Each stack ends with the goroutine's creation site.
+//
+// labels may be nil. If labels is non-nil, it must have the same length as p.
+func goroutineLeakProfileWithLabels(p []StackRecord, labels []unsafe.Pointer) (n int, ok bool) {
+	if labels != nil && len(labels) != len(p) {
+		labels = nil
+	}
+
+	stopTheWorld("profile")
+
+	// World is stopped, no locking required.
+	forEachGRace(func(gp1 *g) {
+		if isLeaked(gp1) {
+			n++
+		}
+	})
+
+	if n <= len(p) {
+		ok = true
+		r, lbl := p, labels
+		forEachGRace(func(gp1 *g) {
+			if !isLeaked(gp1) {
+				return
+			}
+			// See goroutineProfileWithLabelsSync for why this
+			// runs on the system stack.
+			systemstack(func() { saveLeakedg(gp1, &r[0]) })
+			if labels != nil {
+				lbl[0] = gp1.labels
+				lbl = lbl[1:]
+			}
+			r = r[1:]
+		})
+	}
+
+	if raceenabled {
+		raceacquire(unsafe.Pointer(&labelSync))
+	}
+
+	startTheWorld()
+	return n, ok
+}
+
+// saveLeakedg is like saveg for a goroutine that is not running, but
+// also records the PC of the go statement that created gp as the
+// outermost frame.
+func saveLeakedg(gp *g, r *StackRecord) {
+	n := gentraceback(^uintptr(0), ^uintptr(0), 0, gp, 0, &r.Stack0[0], len(r.Stack0), nil, nil, 0)
+	if n < len(r.Stack0) && gp.gopc != 0 {
+		r.Stack0[n] = gp.gopc
+		n++
+	}
+	if n < len(r.Stack0) {
+		r.Stack0[n] = 0
+	}
+}
+
+//go:linkname runtime_goroutineLeakStacks runtime/pprof.runtime_goroutineLeakStacks
+func runtime_goroutineLeakStacks(buf []byte) int {
+	return goroutineLeakStacks(buf)
+}
+
+// goroutineLeakStacks is like Stack(buf, true), but only formats the
+// stack traces of the goroutines found leaked by the most recent leak
+// detection pass.
+func goroutineLeakStacks(buf []byte) int {
+	stopTheWorld("stack trace")
+
+	n := 0
+	if len(buf) > 0 {
+		systemstack(func() {
+			g0 := getg()
+			g0.m.traceback = 1
+			g0.writebuf = buf[0:0:len(buf)]
+			first := true
+			forEachGRace(func(gp *g) {
+				if !isLeaked(gp) {
+					return
+				}
+				if !first {
+					print("\n")
+				}
+				first = false
+				goroutineheader(gp)
+				traceback(^uintptr(0), ^uintptr(0), 0, gp)
+			})
+			g0.m.traceback = 0
+			n = len(g0.writebuf)
+			g0.writebuf = nil
+		})
+	}
+
+	startTheWorld()
+	return n
+}
@@ -1353,6 +1353,11 @@ func setMemoryLimit(in int64) (out int64) {
 	// Run on the system stack since we grab the heap lock.
 	systemstack(func() {
 		lock(&mheap_.lock)
+		if in >= 0 {
+			// An explicit setting overrides the container's
+			// memory limit from now on.
+			containerLimits.autoMemLimit.Store(false)
+		}
 		out = gcController.setMemoryLimit(in)
 		if in < 0 || out == in {
 			// If we're just checking the value or not changing
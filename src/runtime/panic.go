@@ -5,6 +5,7 @@
 package runtime
 
 import (
+	"internal/abi"
 	"internal/goarch"
 	"runtime/internal/atomic"
 	"runtime/internal/sys"
@@ -253,6 +254,16 @@ func panicfloat() {
 	panic(floatError)
 }
 
+var rangeExitError = error(errorString("range function continued iteration after exit"))
+
+// panicrangeexit is called by the code the compiler generates for a
+// range-over-func loop when the iterator function calls the loop body's
+// yield function again after the loop has exited or after the yield
+// function returned false.
+func panicrangeexit() {
+	panic(rangeExitError)
+}
+
 var memoryError = error(errorString("invalid memory address or nil pointer dereference"))
 
 func panicmem() {
@@ -298,6 +309,100 @@ func deferproc(fn func()) {
 	// been set and must not be clobbered.
 }
 
+// deferrangefunc is called by the code the compiler generates for a
+// range-over-func loop whose body contains defer statements. The
+// compiler emits a defer of an empty function just before the call;
+// deferrangefunc turns the defer record pushed by it into a placeholder
+// for the defers executed by the loop body, which run as part of the
+// function containing the loop, and returns a token that the loop body
+// passes to deferprocat to queue them.
+func deferrangefunc() any {
+	gp := getg()
+	if gp.m.curg != gp {
+		// go code on the system stack can't defer
+		throw("defer on system stack")
+	}
+
+	d := gp._defer
+	if d == nil || d.sp != getcallersp() || d.openDefer || d.rangefunc {
+		throw("deferrangefunc: missing defer record")
+	}
+	d.fn = nil
+	d.rangefunc = true
+	d.head = new(atomic.Pointer[_defer])
+	return d.head
+}
+
+// badDefer returns a fixed bad defer pointer for poisoning an
+// atomic.Pointer[_defer] once its defers have been claimed.
+func badDefer() *_defer {
+	return (*_defer)(unsafe.Pointer(uintptr(1)))
+}
+
+// deferprocat is like deferproc but queues fn on the list of defers
+// identified by frame, which was returned by deferrangefunc.
+// The compiler turns a defer statement in the body of a range-over-func
+// loop into a call to this.
+func deferprocat(fn func(), frame any) {
+	head := frame.(*atomic.Pointer[_defer])
+	if raceenabled {
+		racewritepc(unsafe.Pointer(head), getcallerpc(), abi.FuncPCABIInternal(deferprocat))
+	}
+	d1 := newdefer()
+	d1.fn = fn
+	for {
+		d1.link = head.Load()
+		if d1.link == badDefer() {
+			throw("defer after range func returned")
+		}
+		if head.CompareAndSwap(d1.link, d1) {
+			break
+		}
+	}
+
+	// Must be last - see deferproc above.
+	return0()
+}
+
+// deferconvert replaces the range-over-func placeholder d0 at the top
+// of gp's defer chain with the defers queued for it by deferprocat,
+// which from then on are treated as ordinary defers of d0's frame.
+func deferconvert(gp *g, d0 *_defer) {
+	if gp._defer != d0 || !d0.rangefunc {
+		throw("deferconvert: bad defer record")
+	}
+	head := d0.head
+	if raceenabled {
+		racereadpc(unsafe.Pointer(head), getcallerpc(), abi.FuncPCABIInternal(deferconvert))
+	}
+	var d *_defer
+	for {
+		d = head.Load()
+		if head.CompareAndSwap(d, badDefer()) {
+			break
+		}
+	}
+
+	tail := d0.link
+	d0.rangefunc = false
+	d0.head = nil
+	gp._defer = tail
+	freedefer(d0)
+
+	if d == nil {
+		return
+	}
+	for d1 := d; ; d1 = d1.link {
+		d1.sp = d0.sp
+		d1.pc = d0.pc
+		if d1.link == nil {
+			d1.link = tail
+			break
+		}
+	}
+	gp._defer = d
+}
+
 // deferprocStack queues a new deferred function with a defer record on the stack.
 // The defer record must have its fn field initialized.
 // All other fields can contain junk.
@@ -316,6 +421,7 @@ func deferprocStack(d *_defer) {
 	d.started = false
 	d.heap = false
 	d.openDefer = false
+	d.rangefunc = false
 	d.sp = getcallersp()
 	d.pc = getcallerpc()
 	d.framepc = 0
@@ -333,6 +439,7 @@ func deferprocStack(d *_defer) {
 	// keep track of pointers to them with a write barrier.
 	*(*uintptr)(unsafe.Pointer(&d._panic)) = 0
 	*(*uintptr)(unsafe.Pointer(&d.fd)) = 0
+	*(*uintptr)(unsafe.Pointer(&d.head)) = 0
 	*(*uintptr)(unsafe.Pointer(&d.link)) = uintptr(unsafe.Pointer(gp._defer))
 	*(*uintptr)(unsafe.Pointer(&gp._defer)) = uintptr(unsafe.Pointer(d))
 
@@ -456,6 +563,10 @@ func deferreturn() {
 		if d.sp != sp {
 			return
 		}
+		if d.rangefunc {
+			deferconvert(gp, d)
+			continue
+		}
 		if d.openDefer {
 			done := runOpenDeferFrame(d)
 			if !done {
@@ -504,6 +615,10 @@ func Goexit() {
 		if d == nil {
 			break
 		}
+		if d.rangefunc {
+			deferconvert(gp, d)
+			continue
+		}
 		if d.started {
 			if d._panic != nil {
 				d._panic.aborted = true
@@ -848,6 +963,10 @@ func gopanic(e any) {
 		if d == nil {
 			break
 		}
+		if d.rangefunc {
+			deferconvert(gp, d)
+			continue
+		}
 
 		// If defer was started by earlier panic or Goexit (and, since we're back here, that triggered a new panic),
 		// take defer off list. An earlier panic will not continue running, but we will make sure below that an
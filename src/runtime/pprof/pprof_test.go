@@ -1335,6 +1335,91 @@ func TestGoroutineCounts(t *testing.T) {
 	time.Sleep(10 * time.Millisecond) // let goroutines exit
 }
 
+func leakedChanSend(c chan int)             { c <- 1 }
+func leakedMutexLock(mu *sync.Mutex)        { mu.Lock() }
+func leakedCondWait(c *sync.Cond)           { c.L.Lock(); c.Wait() }
+func reachableChanRecv(c chan int)          { <-c }
+func reachableWaitGroup(wg *sync.WaitGroup) { wg.Wait() }
+
+var reachableWG sync.WaitGroup
+
+func TestGoroutineLeakProfile(t *testing.T) {
+	// The leaked goroutines can never be released, so run the test in
+	// a child process to keep them out of the other tests.
+	if os.Getenv("GO_TEST_GOROUTINE_LEAK_PROFILE") != "1" {
+		testenv.MustHaveExec(t)
+		cmd := testenv.CleanCmdEnv(exec.Command(os.Args[0], "-test.run=^TestGoroutineLeakProfile$", "-test.v"))
+		cmd.Env = append(cmd.Env, "GO_TEST_GOROUTINE_LEAK_PROFILE=1")
+		out, err := cmd.CombinedOutput()
+		if err != nil || !bytes.Contains(out, []byte("--- PASS: TestGoroutineLeakProfile")) {
+			t.Fatalf("child process failed: %v\n%s", err, out)
+		}
+		return
+	}
+
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+
+	// Goroutines blocked on objects nothing else references.
+	go leakedChanSend(make(chan int))
+	mu := &struct {
+		sync.Mutex
+		p *int // keeps the mutex out of the tiny allocator
+	}{}
+	mu.Lock()
+	go leakedMutexLock(&mu.Mutex)
+	go leakedCondWait(sync.NewCond(new(sync.Mutex)))
+
+	// Goroutines blocked on objects that are still reachable.
+	c := make(chan int)
+	go reachableChanRecv(c)
+	reachableWG.Add(1)
+	go reachableWaitGroup(&reachableWG)
+
+	for i := 0; i < 5; i++ {
+		runtime.Gosched()
+	}
+
+	leakProf := Lookup("goroutineleak")
+	var w bytes.Buffer
+	if err := leakProf.WriteTo(&w, 1); err != nil {
+		t.Fatalf("writing goroutineleak profile: %v", err)
+	}
+	prof := w.String()
+	for _, fn := range []string{"leakedChanSend", "leakedMutexLock", "leakedCondWait"} {
+		if !strings.Contains(prof, "runtime/pprof."+fn+"+") {
+			t.Errorf("goroutineleak profile does not report %s:\n%s", fn, prof)
+		}
+	}
+	for _, fn := range []string{"reachableChanRecv", "reachableWaitGroup"} {
+		if strings.Contains(prof, "runtime/pprof."+fn+"+") {
+			t.Errorf("goroutineleak profile reports %s:\n%s", fn, prof)
+		}
+	}
+	if n := leakProf.Count(); n < 3 {
+		t.Errorf("goroutineleak profile count = %d, want at least 3", n)
+	}
+
+	// Full stack traces include the creation site.
+	w.Reset()
+	leakProf.WriteTo(&w, 2)
+	if !strings.Contains(w.String(), "created by runtime/pprof.TestGoroutineLeakProfile") {
+		t.Errorf("goroutineleak profile does not report creation sites:\n%s", w.String())
+	}
+
+	w.Reset()
+	leakProf.WriteTo(&w, 0)
+	p, err := profile.Parse(&w)
+	if err != nil {
+		t.Fatalf("error parsing protobuf profile: %v", err)
+	}
+	if err := p.CheckValid(); err != nil {
+		t.Errorf("protobuf profile is invalid: %v", err)
+	}
+
+	close(c)
+	reachableWG.Done()
+}
+
 func containsInOrder(s string, all ...string) bool {
 	for _, t := range all {
 		var ok bool
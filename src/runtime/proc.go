@@ -685,7 +685,9 @@ func schedinit() {
 	lockInit(&trace.stringsLock, lockRankTraceStrings)
 	lockInit(&trace.lock, lockRankTrace)
 	lockInit(&cpuprof.lock, lockRankCpuprof)
-	lockInit(&trace.stackTab.lock, lockRankTraceStackTab)
+	for i := range trace.stackTab {
+		lockInit(&trace.stackTab[i].lock, lockRankTraceStackTab)
+	}
 	// Enforce that this lock is always a leaf lock.
 	// All of this lock's critical sections should be
 	// extremely short.
@@ -721,6 +723,7 @@ func schedinit() {
 	goargs()
 	goenvs()
 	parsedebugvars()
+	initContainerLimits() // must run before gcinit
 	gcinit()
 
 	// if disableMemoryProfiling is set, update MemProfileRate to 0 to turn off memprofile.
@@ -736,6 +739,8 @@ func schedinit() {
 	procs := ncpu
 	if n, ok := atoi32(gogetenv("GOMAXPROCS")); ok && n > 0 {
 		procs = n
+	} else if containerLimits.autoProcs.Load() {
+		procs = containerGOMAXPROCS()
 	}
 	if procresize(procs) != nil {
 		throw("unknown runnable goroutine during bootstrap")
@@ -3614,6 +3619,15 @@ func goexit1() {
 
 // goexit continuation on g0.
 func goexit0(gp *g) {
+	gdestroy(gp)
+	schedule()
+}
+
+// gdestroy frees the resources of the exiting goroutine gp, which is
+// running on the current M, and puts it on the free list. If gp was
+// locked to the thread, gdestroy exits the thread instead of
+// returning.
+func gdestroy(gp *g) {
 	mp := getg().m
 	pp := mp.p.ptr()
 
@@ -3650,7 +3664,7 @@ func goexit0(gp *g) {
 
 	if GOARCH == "wasm" { // no threads yet on wasm
 		gfput(pp, gp)
-		schedule() // never returns
+		return
 	}
 
 	if mp.lockedInt != 0 {
@@ -3673,7 +3687,6 @@ func goexit0(gp *g) {
 			mp.lockedExt = 0
 		}
 	}
-	schedule()
 }
 
 // save updates getg().sched to refer to pc and sp so that a following
@@ -4307,6 +4320,9 @@ func newproc1(fn *funcval, callergp *g, callerpc uintptr) *g {
 	if newg.trackingSeq%gTrackingPeriod == 0 {
 		newg.tracking = true
 	}
+	// newg may be reused from a goroutine found leaked by the most
+	// recent leak detection pass; it must not be reported again.
+	newg.leakCycle = 0
 	casgstatus(newg, _Gdead, _Grunnable)
 	gcController.addScannableStack(pp, int64(newg.stack.hi-newg.stack.lo))
 
@@ -5406,6 +5422,14 @@ func sysmon() {
 			injectglist(&list)
 			unlock(&forcegc.lock)
 		}
+		// check if the container limits need to be reread
+		if containerLimits.idle.Load() && now-containerLimits.lastUpdate >= containerLimitsPeriod {
+			containerLimits.lastUpdate = now
+			containerLimits.idle.Store(false)
+			var list gList
+			list.push(containerLimits.g)
+			injectglist(&list)
+		}
 		if debug.schedtrace > 0 && lasttrace+int64(debug.schedtrace)*1000000 <= now {
 			lasttrace = now
 			schedtrace(debug.scheddetail > 0)
@@ -307,6 +307,8 @@ type dbgVar struct {
 var debug struct {
 	cgocheck           int32
 	clobberfree        int32
+	containermaxprocs  int32
+	containermemlimit  int32
 	efence             int32
 	gccheckmark        int32
 	gcpacertrace       int32
@@ -322,6 +324,7 @@ var debug struct {
 	asyncpreemptoff    int32
 	harddecommit       int32
 	adaptivestackstart int32
+	tracefpunwindoff   int32
 
 	// debug.malloc is used as a combined debug check
 	// in the malloc function and should be set
@@ -336,6 +339,8 @@ var dbgvars = []dbgVar{
 	{"allocfreetrace", &debug.allocfreetrace},
 	{"clobberfree", &debug.clobberfree},
 	{"cgocheck", &debug.cgocheck},
+	{"containermaxprocs", &debug.containermaxprocs},
+	{"containermemlimit", &debug.containermemlimit},
 	{"efence", &debug.efence},
 	{"gccheckmark", &debug.gccheckmark},
 	{"gcpacertrace", &debug.gcpacertrace},
@@ -353,6 +358,7 @@ var dbgvars = []dbgVar{
 	{"inittrace", &debug.inittrace},
 	{"harddecommit", &debug.harddecommit},
 	{"adaptivestackstart", &debug.adaptivestackstart},
+	{"tracefpunwindoff", &debug.tracefpunwindoff},
 }
 
 var globalGODEBUG string
@@ -362,6 +368,8 @@ func parsedebugvars() {
 	debug.cgocheck = 1
 	debug.invalidptr = 1
 	debug.adaptivestackstart = 1 // go119 - set this to 0 to turn larger initial goroutine stacks off
+	debug.containermaxprocs = 1
+	debug.containermemlimit = 1
 	if GOOS == "linux" {
 		// On Linux, MADV_FREE is faster than MADV_DONTNEED,
 		// but doesn't affect many of the statistics that
@@ -488,11 +488,19 @@ type g struct {
 	labels         unsafe.Pointer // profiler labels
 	timer          *timer         // cached timer for time.Sleep
 	selectDone     atomic.Uint32  // are we participating in a select and did someone win the race?
+	blockedOn      unsafe.Pointer // semaphore or sync.Cond notify list this g is parked on, for leak detection
+
+	coroarg  *coro // argument during coroutine transfers
+	coroexit bool  // exit after coroutine transfer
 
 	// goroutineProfiled indicates the status of this goroutine's stack for the
 	// current in-progress goroutine profile
 	goroutineProfiled goroutineProfileStateHolder
 
+	// leakCycle is the goroutine leak detection pass that last
+	// found this goroutine leaked, or 0 if none has.
+	leakCycle uint32
+
 	// Per-G GC state
 
 	// gcAssistBytes is this G's GC assist credit in terms of
@@ -988,6 +996,10 @@ type _defer struct {
 	// defers. We have only one defer record for the entire frame (which may
 	// currently have 0, 1, or more defers active).
 	openDefer bool
+	// rangefunc indicates that this _defer is a placeholder for the
+	// defers executed by the body of a range-over-func loop, which are
+	// queued on head until the placeholder is reached (see deferconvert).
+	rangefunc bool
 	sp        uintptr // sp at time of defer
 	pc        uintptr // pc at time of defer
 	fn        func()  // can be nil for open-coded defers
@@ -1005,6 +1017,10 @@ type _defer struct {
 	// framepc/sp can be used as pc/sp pair to continue a stack trace via
 	// gentraceback().
 	framepc uintptr
+
+	// If rangefunc is true, head is the list of defers queued by the
+	// loop body with deferprocat.
+	head *atomic.Pointer[_defer]
 }
 
 // A _panic holds information about an active panic.
@@ -1079,6 +1095,9 @@ const (
 	waitReasonDebugCall                               // "debug call"
 	waitReasonGCMarkTermination                       // "GC mark termination"
 	waitReasonStoppingTheWorld                        // "stopping the world"
+	waitReasonCoroutine                               // "coroutine"
+	waitReasonGCWeakToStrongWait                      // "GC weak to strong wait"
+	waitReasonContainerLimitsIdle                     // "container limits (idle)"
 )
 
 var waitReasonStrings = [...]string{
@@ -1114,6 +1133,9 @@ var waitReasonStrings = [...]string{
 	waitReasonDebugCall:             "debug call",
 	waitReasonGCMarkTermination:     "GC mark termination",
 	waitReasonStoppingTheWorld:      "stopping the world",
+	waitReasonCoroutine:             "coroutine",
+	waitReasonGCWeakToStrongWait:    "GC weak to strong wait",
+	waitReasonContainerLimitsIdle:   "container limits (idle)",
 }
 
 func (w waitReason) String() string {
@@ -157,11 +157,13 @@ func semacquire1(addr *uint32, lifo bool, profile semaProfileFlags, skipframes i
 		// Any semrelease after the cansemacquire knows we're waiting
 		// (we set nwait above), so go to sleep.
 		root.queue(addr, s, lifo)
+		gp.blockedOn = unsafe.Pointer(addr)
 		goparkunlock(&root.lock, reason, traceEvGoBlockSync, 4+skipframes)
 		if s.ticket != 0 || cansemacquire(addr) {
 			break
 		}
 	}
+	gp.blockedOn = nil
 	if s.releasetime > 0 {
 		blockevent(s.releasetime-t0, 3+skipframes)
 	}
@@ -509,8 +511,9 @@ func notifyListWait(l *notifyList, t uint32) {
 	}
 
 	// Enqueue itself.
+	gp := getg()
 	s := acquireSudog()
-	s.g = getg()
+	s.g = gp
 	s.ticket = t
 	s.releasetime = 0
 	t0 := int64(0)
@@ -524,7 +527,9 @@ func notifyListWait(l *notifyList, t uint32) {
 		l.tail.next = s
 	}
 	l.tail = s
+	gp.blockedOn = unsafe.Pointer(l)
 	goparkunlock(&l.lock, waitReasonSyncCondWait, traceEvGoBlockCond, 3)
+	gp.blockedOn = nil
 	if t0 != 0 {
 		blockevent(s.releasetime-t0, 2)
 	}
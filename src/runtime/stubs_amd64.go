@@ -47,3 +47,6 @@ func asmcgocall_no_g(fn, arg unsafe.Pointer)
 // respectively. Does not follow the Go ABI.
 func spillArgs()
 func unspillArgs()
+
+// getfp returns the frame pointer register of its caller.
+func getfp() uintptr
@@ -0,0 +1,12 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !amd64
+
+package runtime
+
+// getfp returns the frame pointer register of its caller or 0 if not
+// implemented.
+// TODO: Make this a compiler intrinsic
+func getfp() uintptr { return 0 }
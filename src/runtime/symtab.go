@@ -365,6 +365,7 @@ const (
 	funcID_asmcgocall
 	funcID_asyncPreempt
 	funcID_cgocallback
+	funcID_corostart
 	funcID_debugCallV2
 	funcID_gcBgMarkWorker
 	funcID_goexit
@@ -0,0 +1,59 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// These programs are run by TestContainerLimits against a fake cgroup
+// file system.
+
+func init() {
+	register("ContainerLimits", ContainerLimits)
+	register("ContainerLimitsChange", ContainerLimitsChange)
+}
+
+func printContainerLimits() {
+	fmt.Printf("GOMAXPROCS=%d memlimit=%d\n", runtime.GOMAXPROCS(0), debug.SetMemoryLimit(-1))
+}
+
+func ContainerLimits() {
+	printContainerLimits()
+}
+
+// ContainerLimitsChange lowers the limits of the cgroup in CGROUP_DIR
+// and waits for the runtime to pick up the new memory limit. The new
+// GOMAXPROCS is applied before the memory limit.
+func ContainerLimitsChange() {
+	printContainerLimits()
+
+	dir := os.Getenv("CGROUP_DIR")
+	limit := debug.SetMemoryLimit(-1)
+	for name, data := range map[string]string{
+		"cpu.max":    "150000 100000\n",
+		"memory.max": "536870912\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	deadline := time.Now().Add(time.Minute)
+	for debug.SetMemoryLimit(-1) == limit {
+		if time.Now().After(deadline) {
+			fmt.Println("memory limit not updated")
+			os.Exit(1)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	printContainerLimits()
+}
@@ -9,6 +9,13 @@
 // in a compact form. A precise nanosecond-precision timestamp and a stack
 // trace is captured for most events.
 // See https://golang.org/s/go15trace for more info.
+//
+// The trace is partitioned into generations. Each generation is
+// self-contained: it has its own string and stack tables and its own
+// timer frequency, and it begins with the status of every goroutine.
+// A reader can therefore parse a trace one generation at a time, and
+// can start reading at any generation boundary. The tracer starts a
+// new generation about once a second; see traceAdvance.
 
 package runtime
 
@@ -71,7 +78,9 @@ const (
 	traceEvUserRegion        = 47 // trace.WithRegion [timestamp, internal task id, mode(0:start, 1:end), stack, name string]
 	traceEvUserLog           = 48 // trace.Log [timestamp, internal task id, key string id, stack, value string]
 	traceEvCPUSample         = 49 // CPU profiling sample [timestamp, stack, real timestamp, real P id (-1 when absent), goroutine id]
-	traceEvCount             = 50
+	traceEvGoStatus          = 50 // goroutine status at the start of a generation [timestamp, goroutine id, status, seq, start stack id]
+	traceEvGeneration        = 51 // end of generation [generation number]
+	traceEvCount             = 52
 	// Byte is used but only 6 bits are available for event type.
 	// The remaining 2 bits are used to specify the number of arguments.
 	// That means, the max event type value is 63.
@@ -105,6 +114,22 @@ const (
 	// Such wakeups happen on buffered channels and sync.Mutex,
 	// but are generally not interesting for end user.
 	traceFutileWakeup byte = 128
+	// Generation period. The tracer starts a new generation at least
+	// this often, so that readers can process the trace in pieces.
+	traceAdvancePeriod = 1e9 // 1 second
+	// logicalStackSentinel is the first word of a stack in the stack
+	// table if the rest of the stack holds logical PCs, as returned by
+	// callers. Otherwise the first word is the number of logical frames
+	// to skip and the rest are return PCs found by frame pointer
+	// unwinding, which must be expanded with fpunwindExpand.
+	logicalStackSentinel = ^uintptr(0)
+)
+
+// Goroutine states in traceEvGoStatus events.
+const (
+	traceGoRunnable = 1
+	traceGoWaiting  = 2
+	traceGoSyscall  = 3
 )
 
 // trace is global tracing context.
@@ -119,16 +144,30 @@ var trace struct {
 	footerWritten bool        // whether ReadTrace has emitted trace footer
 	shutdownSema  uint32      // used to wait for ReadTrace completion
 	seqStart      uint64      // sequence number when tracing was started
-	ticksStart    int64       // cputicks when tracing was started
-	ticksEnd      int64       // cputicks when tracing was stopped
-	timeStart     int64       // nanotime when tracing was started
-	timeEnd       int64       // nanotime when tracing was stopped
+	ticksStart    int64       // cputicks when the current generation was started
+	ticksEnd      int64       // cputicks when the current generation was ended
+	timeStart     int64       // nanotime when the current generation was started
+	timeEnd       int64       // nanotime when the current generation was ended
 	seqGC         uint64      // GC start/done sequencer
 	reading       traceBufPtr // buffer currently handed off to user
 	empty         traceBufPtr // stack of empty buffers
 	fullHead      traceBufPtr // queue of full buffers
 	fullTail      traceBufPtr
-	stackTab      traceStackTable // maps stack traces to unique ids
+	pendingHead   traceBufPtr // queue of full buffers held back until the footer of dumpGen is written
+	pendingTail   traceBufPtr
+
+	// gen is the current generation. It changes only while the world
+	// is stopped and trace.bufLock is held, so an event writer that has
+	// acquired a trace buffer sees a stable value.
+	gen uint64
+
+	// dumpGen is the generation whose footer traceAdvance is writing,
+	// or 0. Buffers from later generations are held in the pending
+	// queue until the footer has been queued, to keep the generations
+	// in order in the output.
+	dumpGen uint64
+
+	stackTab [2]traceStackTable // maps stack traces to unique ids, indexed by gen%2
 	// cpuLogRead accepts CPU profile samples from the signal handler where
 	// they're generated. It uses a two-word header to hold the IDs of the P and
 	// G (respectively) that were active at the time of the sample. Because
@@ -156,22 +195,30 @@ var trace struct {
 	//   option: pre-assign ids to all user annotation region names and tags
 	//   option: per-P cache
 	//   option: sync.Map like data structure
+	//
+	// Like the stack table, the dictionary is per generation and
+	// indexed by gen%2.
 	stringsLock mutex
-	strings     map[string]uint64
-	stringSeq   uint64
+	strings     [2]map[string]uint64
+	stringSeq   [2]uint64
 
-	// markWorkerLabels maps gcMarkWorkerMode to string ID.
-	markWorkerLabels [len(gcMarkWorkerModeStrings)]uint64
+	// markWorkerLabels maps gcMarkWorkerMode to string ID, indexed by gen%2.
+	markWorkerLabels [2][len(gcMarkWorkerModeStrings)]uint64
 
 	bufLock mutex       // protects buf
 	buf     traceBufPtr // global trace buffer, used when running without a p
 }
 
+// traceAdvanceSema serializes traceAdvance and StopTrace.
+var traceAdvanceSema uint32 = 1
+
 // traceBufHeader is per-P tracing buffer.
 type traceBufHeader struct {
 	link      traceBufPtr             // in trace.empty/full
 	lastTicks uint64                  // when we wrote the last event
 	pos       int                     // next write offset in arr
+	gen       uint64                  // generation the events in this buffer belong to
+	genEnd    bool                    // buffer ends with the generation's traceEvGeneration
 	stk       [traceStackSize]uintptr // scratch buffer for traceback
 }
 
@@ -230,17 +277,13 @@ func StartTrace() error {
 	// Can't set trace.enabled yet. While the world is stopped, exitsyscall could
 	// already emit a delayed event (see exitTicks in exitsyscall) if we set trace.enabled here.
 	// That would lead to an inconsistent trace:
-	// - either GoSysExit appears before EvGoInSyscall,
-	// - or GoSysExit appears for a goroutine for which we don't emit EvGoInSyscall below.
+	// - either GoSysExit appears before EvGoStatus,
+	// - or GoSysExit appears for a goroutine for which we don't emit EvGoStatus below.
 	// To instruct traceEvent that it must not ignore events below, we set startingtrace.
 	// trace.enabled is set afterwards once we have emitted all preliminary events.
 	mp := getg().m
 	mp.startingtrace = true
 
-	// Obtain current stack ID to use in all traceEvGoCreate events below.
-	stkBuf := make([]uintptr, traceStackSize)
-	stackID := traceStackID(mp, stkBuf, 2)
-
 	profBuf := newProfBuf(2, profBufWordCount, profBufTagCount) // after the timestamp, header is [pp.id, gp.goid]
 	trace.cpuLogRead = profBuf
 
@@ -253,78 +296,96 @@ func StartTrace() error {
 	// here.)
 	atomicstorep(unsafe.Pointer(&trace.cpuLogWrite), unsafe.Pointer(profBuf))
 
+	trace.gen++
+	traceGenStart()
+	trace.headerWritten = false
+	trace.footerWritten = false
+
+	mp.startingtrace = false
+	trace.enabled = true
+
+	unlock(&trace.bufLock)
+
+	unlock(&sched.sysmonlock)
+
+	startTheWorldGC()
+	return nil
+}
+
+// traceGenStart begins generation trace.gen. It resets the
+// per-generation string table and writes the status of every
+// goroutine, so that the generation can be read without the ones
+// before it.
+//
+// The world must be stopped and trace.bufLock must be held.
+func traceGenStart() {
+	gen := trace.gen
+
+	// string to id mapping
+	//  0 : reserved for an empty string
+	//  remaining: other strings registered by traceString
+	trace.stringSeq[gen%2] = 0
+	trace.strings[gen%2] = make(map[string]uint64)
+	trace.seqGC = 0
+
 	// World is stopped, no need to lock.
 	forEachGRace(func(gp *g) {
-		status := readgstatus(gp)
-		if status != _Gdead {
-			gp.traceseq = 0
-			gp.tracelastp = getg().m.p
-			// +PCQuantum because traceFrameForPC expects return PCs and subtracts PCQuantum.
-			id := trace.stackTab.put([]uintptr{startPCforTrace(gp.startpc) + sys.PCQuantum})
-			traceEvent(traceEvGoCreate, -1, gp.goid, uint64(id), stackID)
-		}
-		if status == _Gwaiting {
-			// traceEvGoWaiting is implied to have seq=1.
-			gp.traceseq++
-			traceEvent(traceEvGoWaiting, -1, gp.goid)
+		status := readgstatus(gp) &^ _Gscan
+		startpc := gp.startpc
+		var st uint64
+		switch {
+		case status == _Gdead && gp.m != nil && gp.m.isextra:
+			// The dead g in the extra m is in a syscall as far as
+			// the trace is concerned, since its next event will be
+			// traceEvGoSysExit in exitsyscall, while calling from C
+			// thread to Go.
+			st = traceGoSyscall
+			startpc = 0 // no start pc
+		case status == _Gdead:
+			gp.sysblocktraced = false
+			return
+		case status == _Gsyscall:
+			st = traceGoSyscall
+		case status == _Gwaiting, status == _Gpreempted:
+			st = traceGoWaiting
+		default:
+			st = traceGoRunnable
 		}
-		if status == _Gsyscall {
-			gp.traceseq++
-			traceEvent(traceEvGoInSyscall, -1, gp.goid)
-		} else if status == _Gdead && gp.m != nil && gp.m.isextra {
-			// Trigger two trace events for the dead g in the extra m,
-			// since the next event of the g will be traceEvGoSysExit in exitsyscall,
-			// while calling from C thread to Go.
-			gp.traceseq = 0
-			gp.tracelastp = getg().m.p
-			// +PCQuantum because traceFrameForPC expects return PCs and subtracts PCQuantum.
-			id := trace.stackTab.put([]uintptr{startPCforTrace(0) + sys.PCQuantum}) // no start pc
-			traceEvent(traceEvGoCreate, -1, gp.goid, uint64(id), stackID)
-			gp.traceseq++
-			traceEvent(traceEvGoInSyscall, -1, gp.goid)
-		} else {
+		if st != traceGoSyscall {
 			gp.sysblocktraced = false
 		}
+		gp.tracelastp = getg().m.p
+		// +PCQuantum because traceFrameForPC expects return PCs and subtracts PCQuantum.
+		id := trace.stackTab[gen%2].put([]uintptr{logicalStackSentinel, startPCforTrace(startpc) + sys.PCQuantum})
+		mp, pid, bufp := traceAcquireBuffer()
+		traceEventLocked(0, mp, pid, bufp, traceEvGoStatus, id, 0, gp.goid, st, gp.traceseq)
+		traceReleaseBuffer(pid)
 	})
 	traceProcStart()
 	traceGoStart()
-	// Note: ticksStart needs to be set after we emit traceEvGoInSyscall events.
+	// Note: ticksStart needs to be set after we emit traceEvGoStatus events.
 	// If we do it the other way around, it is possible that exitsyscall will
-	// query sysexitticks after ticksStart but before traceEvGoInSyscall timestamp.
+	// query sysexitticks after ticksStart but before traceEvGoStatus timestamp.
 	// It will lead to a false conclusion that cputicks is broken.
 	trace.ticksStart = cputicks()
 	trace.timeStart = nanotime()
-	trace.headerWritten = false
-	trace.footerWritten = false
-
-	// string to id mapping
-	//  0 : reserved for an empty string
-	//  remaining: other strings registered by traceString
-	trace.stringSeq = 0
-	trace.strings = make(map[string]uint64)
-
-	trace.seqGC = 0
-	mp.startingtrace = false
-	trace.enabled = true
 
 	// Register runtime goroutine labels.
 	_, pid, bufp := traceAcquireBuffer()
 	for i, label := range gcMarkWorkerModeStrings[:] {
-		trace.markWorkerLabels[i], bufp = traceString(bufp, pid, label)
+		trace.markWorkerLabels[gen%2][i], bufp = traceString(bufp, pid, label, gen)
 	}
 	traceReleaseBuffer(pid)
-
-	unlock(&trace.bufLock)
-
-	unlock(&sched.sysmonlock)
-
-	startTheWorldGC()
-	return nil
 }
 
 // StopTrace stops tracing, if it was previously enabled.
 // StopTrace only returns after all the reads for the trace have completed.
 func StopTrace() {
+	// Wait for any generation change in progress, and keep new ones
+	// from starting until the trace is fully shut down.
+	semacquire(&traceAdvanceSema)
+	defer semrelease(&traceAdvanceSema)
+
 	// Stop the world so that we can collect the trace buffers from all p's below,
 	// and also to avoid races with traceEvent.
 	stopTheWorldGC("stop tracing")
@@ -348,6 +409,58 @@ func StopTrace() {
 	trace.cpuLogRead.close()
 	traceReadCPU()
 
+	traceFlushAll()
+	traceGenEnd()
+
+	trace.enabled = false
+	trace.shutdown = true
+	unlock(&trace.bufLock)
+
+	unlock(&sched.sysmonlock)
+
+	startTheWorldGC()
+
+	// The world is started but we've set trace.shutdown, so new tracing can't start.
+	// Wait for the trace reader to flush pending buffers and stop.
+	semacquire(&trace.shutdownSema)
+	if raceenabled {
+		raceacquire(unsafe.Pointer(&trace.shutdownSema))
+	}
+
+	systemstack(func() {
+		// The lock protects us from races with StartTrace/StopTrace because they do stop-the-world.
+		lock(&trace.lock)
+		for _, p := range allp[:cap(allp)] {
+			if p.tracebuf != 0 {
+				throw("trace: non-empty trace buffer in proc")
+			}
+		}
+		if trace.buf != 0 {
+			throw("trace: non-empty global trace buffer")
+		}
+		if trace.fullHead != 0 || trace.fullTail != 0 || trace.pendingHead != 0 {
+			throw("trace: non-empty full trace buffer")
+		}
+		if trace.reading != 0 || trace.reader.Load() != nil {
+			throw("trace: reading after shutdown")
+		}
+		for trace.empty != 0 {
+			buf := trace.empty
+			trace.empty = buf.ptr().link
+			sysFree(unsafe.Pointer(buf), unsafe.Sizeof(*buf.ptr()), &memstats.other_sys)
+		}
+		trace.strings = [2]map[string]uint64{}
+		trace.shutdown = false
+		trace.cpuLogRead = nil
+		unlock(&trace.lock)
+	})
+}
+
+// traceFlushAll queues the trace buffers of all Ps, the global buffer
+// and the CPU sample buffer for the reader.
+//
+// The world must be stopped and trace.bufLock must be held.
+func traceFlushAll() {
 	// Loop over all allocated Ps because dead Ps may still have
 	// trace buffers.
 	for _, p := range allp[:cap(allp)] {
@@ -371,7 +484,10 @@ func StopTrace() {
 			traceFullQueue(buf)
 		}
 	}
+}
 
+// traceGenEnd records the end time of the current generation.
+func traceGenEnd() {
 	for {
 		trace.ticksEnd = cputicks()
 		trace.timeEnd = nanotime()
@@ -381,49 +497,131 @@ func StopTrace() {
 		}
 		osyield()
 	}
+}
 
-	trace.enabled = false
-	trace.shutdown = true
-	unlock(&trace.bufLock)
+// traceFrequency returns the timer frequency of the current
+// generation, in ticks per second. It is only valid once traceGenEnd
+// has been called.
+func traceFrequency() uint64 {
+	// Use float64 because (trace.ticksEnd - trace.ticksStart) * 1e9 can overflow int64.
+	freq := float64(trace.ticksEnd-trace.ticksStart) * 1e9 / float64(trace.timeEnd-trace.timeStart) / traceTickDiv
+	if freq <= 0 {
+		throw("trace: ReadTrace got invalid frequency")
+	}
+	return uint64(freq)
+}
 
-	unlock(&sched.sysmonlock)
+// traceAdvance ends the current generation and starts a new one. It
+// returns the number of the generation that ended, or 0 if tracing is
+// not enabled. If block is false, traceAdvance does nothing and returns
+// 0 if another traceAdvance or StopTrace is in progress.
+//
+// Once traceAdvance returns, all of the ended generation's data,
+// including its footer, is queued for the reader.
+func traceAdvance(block bool) uint64 {
+	if block {
+		semacquire(&traceAdvanceSema)
+	} else if !cansemacquire(&traceAdvanceSema) {
+		return 0
+	}
 
-	startTheWorldGC()
+	// See the comments in StartTrace.
+	stopTheWorldGC("trace advance")
+	lock(&sched.sysmonlock)
+	lock(&trace.bufLock)
 
-	// The world is started but we've set trace.shutdown, so new tracing can't start.
-	// Wait for the trace reader to flush pending buffers and stop.
-	semacquire(&trace.shutdownSema)
-	if raceenabled {
-		raceacquire(unsafe.Pointer(&trace.shutdownSema))
+	if !trace.enabled {
+		unlock(&trace.bufLock)
+		unlock(&sched.sysmonlock)
+		startTheWorldGC()
+		semrelease(&traceAdvanceSema)
+		return 0
 	}
 
+	// End the generation as if this goroutine were descheduled and
+	// its P stopped. Every other P is already stopped, so the next
+	// generation starts from the same state as a new trace would.
+	traceGoSched()
+	traceProcStop(getg().m.p.ptr())
+	traceReadCPU()
+	traceFlushAll()
+	traceGenEnd()
+	gen := trace.gen
+	freq := traceFrequency()
+
+	// Buffers of the new generation must not reach the reader before
+	// the footer of the old one, which we write below.
+	trace.dumpGen = gen
+	trace.gen++
+	traceGenStart()
+
+	unlock(&trace.bufLock)
+	unlock(&sched.sysmonlock)
+	startTheWorldGC()
+
+	// Symbolizing the stacks may take a while, so do it with the
+	// world running.
 	systemstack(func() {
-		// The lock protects us from races with StartTrace/StopTrace because they do stop-the-world.
+		if raceenabled {
+			// g0 doesn't have a race context. Borrow the user G's,
+			// as readTrace0 does.
+			getg().racectx = getg().m.curg.racectx
+			defer func() { getg().racectx = 0 }()
+		}
+		bufp := traceFooter(gen, freq)
 		lock(&trace.lock)
-		for _, p := range allp[:cap(allp)] {
-			if p.tracebuf != 0 {
-				throw("trace: non-empty trace buffer in proc")
+		traceFullQueue(bufp)
+		trace.dumpGen = 0
+		if trace.pendingHead != 0 {
+			if trace.fullHead == 0 {
+				trace.fullHead = trace.pendingHead
+			} else {
+				trace.fullTail.ptr().link = trace.pendingHead
 			}
+			trace.fullTail = trace.pendingTail
+			trace.pendingHead, trace.pendingTail = 0, 0
 		}
-		if trace.buf != 0 {
-			throw("trace: non-empty global trace buffer")
-		}
-		if trace.fullHead != 0 || trace.fullTail != 0 {
-			throw("trace: non-empty full trace buffer")
-		}
-		if trace.reading != 0 || trace.reader.Load() != nil {
-			throw("trace: reading after shutdown")
-		}
-		for trace.empty != 0 {
-			buf := trace.empty
-			trace.empty = buf.ptr().link
-			sysFree(unsafe.Pointer(buf), unsafe.Sizeof(*buf.ptr()), &memstats.other_sys)
-		}
-		trace.strings = nil
-		trace.shutdown = false
-		trace.cpuLogRead = nil
 		unlock(&trace.lock)
 	})
+
+	semrelease(&traceAdvanceSema)
+	return gen
+}
+
+// traceFooter writes the footer of generation gen: the timer frequency,
+// the stack table and the traceEvGeneration event that ends the
+// generation. It returns the last buffer of the footer, which the
+// caller must queue; earlier buffers are queued as they fill up.
+//
+// This must run on the system stack because it calls traceFlush.
+//
+//go:systemstack
+func traceFooter(gen, freq uint64) traceBufPtr {
+	// Write frequency event.
+	bufp := traceFlush(0, 0, gen)
+	buf := bufp.ptr()
+	buf.byte(traceEvFrequency | 0<<traceArgCountShift)
+	buf.varint(freq)
+
+	// Dump stack table.
+	// This will emit a bunch of full buffers, we will pick them up
+	// on the next iteration.
+	bufp = trace.stackTab[gen%2].dump(bufp, gen)
+
+	if buf := bufp.ptr(); len(buf.arr)-buf.pos < 1+traceBytesPerNumber {
+		bufp = traceFlush(bufp, 0, gen)
+	}
+	buf = bufp.ptr()
+	buf.byte(traceEvGeneration | 0<<traceArgCountShift)
+	buf.varint(gen)
+	buf.genEnd = true
+
+	// The string table is no longer needed. It is recreated when the
+	// same slot is used again two generations from now.
+	lock(&trace.stringsLock)
+	trace.strings[gen%2] = nil
+	unlock(&trace.stringsLock)
+	return bufp
 }
 
 // ReadTrace returns the next chunk of binary tracing data, blocking until data
@@ -432,11 +630,25 @@ func StopTrace() {
 // returned data before calling ReadTrace again.
 // ReadTrace must be called from one goroutine at a time.
 func ReadTrace() []byte {
+	buf, _, _ := readTrace()
+	return buf
+}
+
+// readTrace is like ReadTrace, but also returns the generation the
+// data belongs to, and whether the data ends that generation. The
+// trace header belongs to generation 0.
+//
+// The reader is also what moves the trace from one generation to the
+// next: if the current generation is older than traceAdvancePeriod,
+// readTrace starts a new one before reading.
+func readTrace() (buf []byte, gen uint64, end bool) {
 top:
-	var buf []byte
+	if trace.enabled && nanotime()-trace.timeStart >= traceAdvancePeriod {
+		traceAdvance(false)
+	}
 	var park bool
 	systemstack(func() {
-		buf, park = readTrace0()
+		buf, gen, end, park = readTrace0()
 	})
 	if park {
 		gopark(func(gp *g, _ unsafe.Pointer) bool {
@@ -462,14 +674,14 @@ top:
 		goto top
 	}
 
-	return buf
+	return buf, gen, end
 }
 
 // readTrace0 is ReadTrace's continuation on g0. This must run on the
 // system stack because it acquires trace.lock.
 //
 //go:systemstack
-func readTrace0() (buf []byte, park bool) {
+func readTrace0() (buf []byte, gen uint64, end bool, park bool) {
 	if raceenabled {
 		// g0 doesn't have a race context. Borrow the user G's.
 		if getg().racectx != 0 {
@@ -497,7 +709,7 @@ func readTrace0() (buf []byte, park bool) {
 		trace.lockOwner = nil
 		unlock(&trace.lock)
 		println("runtime: ReadTrace called from multiple goroutines simultaneously")
-		return nil, false
+		return nil, 0, false, false
 	}
 	// Recycle the old buffer.
 	if buf := trace.reading; buf != 0 {
@@ -510,7 +722,7 @@ func readTrace0() (buf []byte, park bool) {
 		trace.headerWritten = true
 		trace.lockOwner = nil
 		unlock(&trace.lock)
-		return []byte("go 1.19 trace\x00\x00\x00"), false
+		return []byte("go 1.20 trace\x00\x00\x00"), 0, false, false
 	}
 	// Optimistically look for CPU profile samples. This may write new stack
 	// records, and may write new tracing buffers.
@@ -524,7 +736,7 @@ func readTrace0() (buf []byte, park bool) {
 		// (also a note would consume an M).
 		trace.lockOwner = nil
 		unlock(&trace.lock)
-		return nil, true
+		return nil, 0, false, true
 	}
 newFull:
 	assertLockHeld(&trace.lock)
@@ -534,30 +746,17 @@ newFull:
 		trace.reading = buf
 		trace.lockOwner = nil
 		unlock(&trace.lock)
-		return buf.ptr().arr[:buf.ptr().pos], false
+		return buf.ptr().arr[:buf.ptr().pos], buf.ptr().gen, buf.ptr().genEnd, false
 	}
 
-	// Write footer with timer frequency.
+	// Write footer of the last generation.
 	if !trace.footerWritten {
 		trace.footerWritten = true
-		// Use float64 because (trace.ticksEnd - trace.ticksStart) * 1e9 can overflow int64.
-		freq := float64(trace.ticksEnd-trace.ticksStart) * 1e9 / float64(trace.timeEnd-trace.timeStart) / traceTickDiv
-		if freq <= 0 {
-			throw("trace: ReadTrace got invalid frequency")
-		}
+		freq := traceFrequency()
 		trace.lockOwner = nil
 		unlock(&trace.lock)
 
-		// Write frequency event.
-		bufp := traceFlush(0, 0)
-		buf := bufp.ptr()
-		buf.byte(traceEvFrequency | 0<<traceArgCountShift)
-		buf.varint(uint64(freq))
-
-		// Dump stack table.
-		// This will emit a bunch of full buffers, we will pick them up
-		// on the next iteration.
-		bufp = trace.stackTab.dump(bufp)
+		bufp := traceFooter(trace.gen, freq)
 
 		// Flush final buffer.
 		lock(&trace.lock)
@@ -576,13 +775,13 @@ newFull:
 		}
 		// trace.enabled is already reset, so can call traceable functions.
 		semrelease(&trace.shutdownSema)
-		return nil, false
+		return nil, 0, false, false
 	}
 	// Also bad, but see the comment above.
 	trace.lockOwner = nil
 	unlock(&trace.lock)
 	println("runtime: spurious wakeup of trace reader")
-	return nil, false
+	return nil, 0, false, false
 }
 
 // traceReader returns the trace reader that should be woken up, if any.
@@ -633,8 +832,19 @@ func traceProcFree(pp *p) {
 }
 
 // traceFullQueue queues buf into queue of full buffers.
+// While the footer of trace.dumpGen is being written, buffers of
+// later generations go into the pending queue instead.
 func traceFullQueue(buf traceBufPtr) {
 	buf.ptr().link = 0
+	if trace.dumpGen != 0 && buf.ptr().gen != trace.dumpGen {
+		if trace.pendingHead == 0 {
+			trace.pendingHead = buf
+		} else {
+			trace.pendingTail.ptr().link = buf
+		}
+		trace.pendingTail = buf
+		return
+	}
 	if trace.fullHead == 0 {
 		trace.fullHead = buf
 	} else {
@@ -712,7 +922,7 @@ func traceEventLocked(extraBytes int, mp *m, pid int32, bufp *traceBufPtr, ev by
 	maxSize := 2 + 5*traceBytesPerNumber + extraBytes // event type, length, sequence, timestamp, stack id and two add params
 	if buf == nil || len(buf.arr)-buf.pos < maxSize {
 		systemstack(func() {
-			buf = traceFlush(traceBufPtrOf(buf), pid).ptr()
+			buf = traceFlush(traceBufPtrOf(buf), pid, trace.gen).ptr()
 		})
 		bufp.set(buf)
 	}
@@ -855,42 +1065,152 @@ func traceReadCPU() {
 			buf := bufp.ptr()
 			if buf == nil {
 				systemstack(func() {
-					*bufp = traceFlush(*bufp, 0)
+					*bufp = traceFlush(*bufp, 0, trace.gen)
 				})
 				buf = bufp.ptr()
 			}
+			buf.stk[0] = logicalStackSentinel
 			for i := range stk {
-				if i >= len(buf.stk) {
+				if i+1 >= len(buf.stk) {
 					break
 				}
-				buf.stk[i] = uintptr(stk[i])
+				buf.stk[i+1] = uintptr(stk[i])
 			}
-			stackID := trace.stackTab.put(buf.stk[:len(stk)])
+			stackID := trace.stackTab[trace.gen%2].put(buf.stk[:len(stk)+1])
 
 			traceEventLocked(0, nil, 0, bufp, traceEvCPUSample, stackID, 1, timestamp/traceTickDiv, ppid, goid)
 		}
 	}
 }
 
-func traceStackID(mp *m, buf []uintptr, skip int) uint64 {
+// traceStackID captures the stack of the goroutine running on mp,
+// skipping skip top frames, and returns its id in the current
+// generation's stack table.
+//
+// Where possible the stack is found by following frame pointers,
+// which is much cheaper than a full traceback. Such stacks hold only
+// physical frames; dump expands them into logical frames later, with
+// fpunwindExpand.
+//
+// Avoid calling this function directly. traceEventLocked is the only
+// caller that should use it: the frame pointer unwinding assumes a
+// fixed number of frames between the tracepoint and traceStackID.
+//
+//go:noinline
+func traceStackID(mp *m, pcBuf []uintptr, skip int) uint64 {
 	gp := getg()
 	curgp := mp.curg
-	var nstk int
-	if curgp == gp {
-		nstk = callers(skip+1, buf)
-	} else if curgp != nil {
-		nstk = gcallers(curgp, skip, buf)
+	nstk := 1
+	if tracefpunwindoff() || mp.ncgo > 0 || mp.isextra || curgp != gp {
+		// Slow path: Unwind using default unwinder. Used when frame
+		// pointer unwinding is unavailable, cgo frames may be on the
+		// stack, or the stack belongs to a different goroutine.
+		pcBuf[0] = logicalStackSentinel
+		if curgp == gp {
+			nstk += callers(skip+1, pcBuf[1:])
+		} else if curgp != nil {
+			nstk += gcallers(curgp, skip, pcBuf[1:])
+		}
+	} else {
+		// Fast path: Unwind using frame pointers.
+		pcBuf[0] = uintptr(skip)
+		nstk += fpTracebackPCs(unsafe.Pointer(getfp()), pcBuf[1:])
 	}
-	if nstk > 0 {
+	if nstk > 1 {
 		nstk-- // skip runtime.goexit
 	}
-	if nstk > 0 && curgp.goid == 1 {
+	if nstk > 1 && curgp.goid == 1 {
 		nstk-- // skip runtime.main
 	}
-	id := trace.stackTab.put(buf[:nstk])
+	id := trace.stackTab[trace.gen%2].put(pcBuf[:nstk])
 	return uint64(id)
 }
 
+// tracefpunwindoff reports whether frame pointer unwinding for the
+// tracer is disabled, either with GODEBUG=tracefpunwindoff=1 or
+// because it is not implemented for this architecture.
+func tracefpunwindoff() bool {
+	return debug.tracefpunwindoff != 0 || goarch.ArchFamily != goarch.AMD64
+}
+
+// fpTracebackPCs populates pcBuf with the return addresses for each
+// frame, starting at the frame pointer fp, and returns the number of
+// PCs written to pcBuf. The returned PCs correspond to physical
+// frames rather than logical frames; that is, if A is inlined into B,
+// only a PC for B is returned.
+func fpTracebackPCs(fp unsafe.Pointer, pcBuf []uintptr) (i int) {
+	for i = 0; i < len(pcBuf) && fp != nil; i++ {
+		// The return address sits one word above the frame pointer.
+		pcBuf[i] = *(*uintptr)(unsafe.Pointer(uintptr(fp) + goarch.PtrSize))
+		// Follow the frame pointer to the caller's frame.
+		fp = unsafe.Pointer(*(*uintptr)(fp))
+	}
+	return i
+}
+
+// fpunwindExpand turns a stack from the stack table into logical PCs,
+// in the same form callers returns. Stacks found by frame pointer
+// unwinding are expanded using the inlining tables, and wrapper and
+// skipped frames are dropped, the way gentraceback would have done.
+func fpunwindExpand(pcBuf []uintptr) []uintptr {
+	if len(pcBuf) > 0 && pcBuf[0] == logicalStackSentinel {
+		// The stack trace has already been expanded.
+		return pcBuf[1:]
+	}
+
+	var (
+		skip       = pcBuf[0]
+		newPCBuf   = make([]uintptr, 0, traceStackSize)
+		lastFuncID = funcID_normal
+	)
+	add := func(pc uintptr, funcID funcID) bool {
+		if funcID == funcID_wrapper && elideWrapperCalling(lastFuncID) {
+			// Ignore wrapper functions, as gentraceback does.
+			lastFuncID = funcID
+			return true
+		}
+		lastFuncID = funcID
+		if skip > 0 {
+			skip--
+			return true
+		}
+		newPCBuf = append(newPCBuf, pc)
+		return len(newPCBuf) < cap(newPCBuf)
+	}
+outer:
+	for _, pc := range pcBuf[1:] {
+		// pc is a return address; look up the call instruction.
+		tracepc := pc - 1
+		f := findfunc(tracepc)
+		if !f.valid() {
+			// Not a Go function; keep the PC as is.
+			if !add(pc, funcID_normal) {
+				break
+			}
+			continue
+		}
+		if inldata := funcdata(f, _FUNCDATA_InlTree); inldata != nil {
+			inltree := (*[1 << 20]inlinedCall)(inldata)
+			for {
+				ix := pcdatavalue(f, _PCDATA_InlTreeIndex, tracepc, nil)
+				if ix < 0 {
+					break
+				}
+				if !add(pc, inltree[ix].funcID) {
+					break outer
+				}
+				// Back up to an instruction in the "caller".
+				tracepc = f.entry() + uintptr(inltree[ix].parentPc)
+				pc = tracepc + 1
+			}
+		}
+		if !add(pc, f.funcID) {
+			break
+		}
+	}
+	return newPCBuf
+}
+
 // traceAcquireBuffer returns trace buffer to use and, if necessary, locks it.
 func traceAcquireBuffer() (mp *m, pid int32, bufp *traceBufPtr) {
 	// Any time we acquire a buffer, we may end up flushing it,
@@ -929,7 +1249,7 @@ func lockRankMayTraceFlush() {
 // This must run on the system stack because it acquires trace.lock.
 //
 //go:systemstack
-func traceFlush(buf traceBufPtr, pid int32) traceBufPtr {
+func traceFlush(buf traceBufPtr, pid int32, gen uint64) traceBufPtr {
 	owner := trace.lockOwner
 	dolock := owner == nil || owner != getg().m.curg
 	if dolock {
@@ -950,6 +1270,8 @@ func traceFlush(buf traceBufPtr, pid int32) traceBufPtr {
 	bufp := buf.ptr()
 	bufp.link.set(nil)
 	bufp.pos = 0
+	bufp.gen = gen
+	bufp.genEnd = false
 
 	// initialize the buffer for a new batch
 	ticks := uint64(cputicks()) / traceTickDiv
@@ -967,8 +1289,9 @@ func traceFlush(buf traceBufPtr, pid int32) traceBufPtr {
 	return buf
 }
 
-// traceString adds a string to the trace.strings and returns the id.
-func traceString(bufp *traceBufPtr, pid int32, s string) (uint64, *traceBufPtr) {
+// traceString adds a string to the string table of generation gen and
+// returns the id.
+func traceString(bufp *traceBufPtr, pid int32, s string, gen uint64) (uint64, *traceBufPtr) {
 	if s == "" {
 		return 0, bufp
 	}
@@ -980,7 +1303,7 @@ func traceString(bufp *traceBufPtr, pid int32, s string) (uint64, *traceBufPtr)
 		raceacquire(unsafe.Pointer(&trace.stringsLock))
 	}
 
-	if id, ok := trace.strings[s]; ok {
+	if id, ok := trace.strings[gen%2][s]; ok {
 		if raceenabled {
 			racerelease(unsafe.Pointer(&trace.stringsLock))
 		}
@@ -989,9 +1312,9 @@ func traceString(bufp *traceBufPtr, pid int32, s string) (uint64, *traceBufPtr)
 		return id, bufp
 	}
 
-	trace.stringSeq++
-	id := trace.stringSeq
-	trace.strings[s] = id
+	trace.stringSeq[gen%2]++
+	id := trace.stringSeq[gen%2]
+	trace.strings[gen%2][s] = id
 
 	if raceenabled {
 		racerelease(unsafe.Pointer(&trace.stringsLock))
@@ -1007,7 +1330,7 @@ func traceString(bufp *traceBufPtr, pid int32, s string) (uint64, *traceBufPtr)
 	size := 1 + 2*traceBytesPerNumber + len(s)
 	if buf == nil || len(buf.arr)-buf.pos < size {
 		systemstack(func() {
-			buf = traceFlush(traceBufPtrOf(buf), pid).ptr()
+			buf = traceFlush(traceBufPtrOf(buf), pid, gen).ptr()
 			bufp.set(buf)
 		})
 	}
@@ -1152,13 +1475,13 @@ func (tab *traceStackTable) newStack(n int) *traceStack {
 
 // traceFrames returns the frames corresponding to pcs. It may
 // allocate and may emit trace events.
-func traceFrames(bufp traceBufPtr, pcs []uintptr) ([]traceFrame, traceBufPtr) {
+func traceFrames(bufp traceBufPtr, pcs []uintptr, gen uint64) ([]traceFrame, traceBufPtr) {
 	frames := make([]traceFrame, 0, len(pcs))
 	ci := CallersFrames(pcs)
 	for {
 		var frame traceFrame
 		f, more := ci.Next()
-		frame, bufp = traceFrameForPC(bufp, 0, f)
+		frame, bufp = traceFrameForPC(bufp, 0, f, gen)
 		frames = append(frames, frame)
 		if !more {
 			return frames, bufp
@@ -1172,12 +1495,12 @@ func traceFrames(bufp traceBufPtr, pcs []uintptr) ([]traceFrame, traceBufPtr) {
 // This must run on the system stack because it calls traceFlush.
 //
 //go:systemstack
-func (tab *traceStackTable) dump(bufp traceBufPtr) traceBufPtr {
+func (tab *traceStackTable) dump(bufp traceBufPtr, gen uint64) traceBufPtr {
 	for i := range tab.tab {
 		stk := tab.tab[i].ptr()
 		for ; stk != nil; stk = stk.link.ptr() {
 			var frames []traceFrame
-			frames, bufp = traceFrames(bufp, stk.stack())
+			frames, bufp = traceFrames(bufp, fpunwindExpand(stk.stack()), gen)
 
 			// Estimate the size of this record. This
 			// bound is pretty loose, but avoids counting
@@ -1185,7 +1508,7 @@ func (tab *traceStackTable) dump(bufp traceBufPtr) traceBufPtr {
 			maxSize := 1 + traceBytesPerNumber + (2+4*len(frames))*traceBytesPerNumber
 			// Make sure we have enough buffer space.
 			if buf := bufp.ptr(); len(buf.arr)-buf.pos < maxSize {
-				bufp = traceFlush(bufp, 0)
+				bufp = traceFlush(bufp, 0, gen)
 			}
 
 			// Emit header, with space reserved for length.
@@ -1226,7 +1549,7 @@ type traceFrame struct {
 
 // traceFrameForPC records the frame information.
 // It may allocate memory.
-func traceFrameForPC(buf traceBufPtr, pid int32, f Frame) (traceFrame, traceBufPtr) {
+func traceFrameForPC(buf traceBufPtr, pid int32, f Frame, gen uint64) (traceFrame, traceBufPtr) {
 	bufp := &buf
 	var frame traceFrame
 	frame.PC = f.PC
@@ -1236,13 +1559,13 @@ func traceFrameForPC(buf traceBufPtr, pid int32, f Frame) (traceFrame, traceBufP
 	if len(fn) > maxLen {
 		fn = fn[len(fn)-maxLen:]
 	}
-	frame.funcID, bufp = traceString(bufp, pid, fn)
+	frame.funcID, bufp = traceString(bufp, pid, fn, gen)
 	frame.line = uint64(f.Line)
 	file := f.File
 	if len(file) > maxLen {
 		file = file[len(file)-maxLen:]
 	}
-	frame.fileID, bufp = traceString(bufp, pid, file)
+	frame.fileID, bufp = traceString(bufp, pid, file, gen)
 	return frame, (*bufp)
 }
 
@@ -1389,7 +1712,7 @@ func traceGoCreate(newg *g, pc uintptr) {
 	newg.traceseq = 0
 	newg.tracelastp = getg().m.p
 	// +PCQuantum because traceFrameForPC expects return PCs and subtracts PCQuantum.
-	id := trace.stackTab.put([]uintptr{startPCforTrace(pc) + sys.PCQuantum})
+	id := trace.stackTab[trace.gen%2].put([]uintptr{logicalStackSentinel, startPCforTrace(pc) + sys.PCQuantum})
 	traceEvent(traceEvGoCreate, 2, newg.goid, uint64(id))
 }
 
@@ -1398,7 +1721,7 @@ func traceGoStart() {
 	pp := gp.m.p
 	gp.traceseq++
 	if pp.ptr().gcMarkWorkerMode != gcMarkWorkerNotWorker {
-		traceEvent(traceEvGoStartLabel, -1, gp.goid, gp.traceseq, trace.markWorkerLabels[pp.ptr().gcMarkWorkerMode])
+		traceEvent(traceEvGoStartLabel, -1, gp.goid, gp.traceseq, trace.markWorkerLabels[trace.gen%2][pp.ptr().gcMarkWorkerMode])
 	} else if gp.tracelastp == pp {
 		traceEvent(traceEvGoStartLocal, -1, gp.goid)
 	} else {
@@ -1505,7 +1828,7 @@ func trace_userTaskCreate(id, parentID uint64, taskType string) {
 		return
 	}
 
-	typeStringID, bufp := traceString(bufp, pid, taskType)
+	typeStringID, bufp := traceString(bufp, pid, taskType, trace.gen)
 	traceEventLocked(0, mp, pid, bufp, traceEvUserTaskCreate, 0, 3, id, parentID, typeStringID)
 	traceReleaseBuffer(pid)
 }
@@ -1527,7 +1850,7 @@ func trace_userRegion(id, mode uint64, name string) {
 		return
 	}
 
-	nameStringID, bufp := traceString(bufp, pid, name)
+	nameStringID, bufp := traceString(bufp, pid, name, trace.gen)
 	traceEventLocked(0, mp, pid, bufp, traceEvUserRegion, 0, 3, id, mode, nameStringID)
 	traceReleaseBuffer(pid)
 }
@@ -1544,7 +1867,7 @@ func trace_userLog(id uint64, category, message string) {
 		return
 	}
 
-	categoryID, bufp := traceString(bufp, pid, category)
+	categoryID, bufp := traceString(bufp, pid, category, trace.gen)
 
 	extraSpace := traceBytesPerNumber + len(message) // extraSpace for the value string
 	traceEventLocked(extraSpace, mp, pid, bufp, traceEvUserLog, 0, 3, id, categoryID)
@@ -1564,6 +1887,16 @@ func trace_userLog(id uint64, category, message string) {
 	traceReleaseBuffer(pid)
 }
 
+//go:linkname trace_readTrace runtime/trace.readTrace
+func trace_readTrace() (data []byte, gen uint64, end bool) {
+	return readTrace()
+}
+
+//go:linkname trace_advance runtime/trace.advance
+func trace_advance() uint64 {
+	return traceAdvance(true)
+}
+
 // the start PC of a goroutine for tracing purposes. If pc is a wrapper,
 // it returns the PC of the wrapped function. Otherwise it returns pc.
 func startPCforTrace(pc uintptr) uintptr {
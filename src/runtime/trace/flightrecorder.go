@@ -0,0 +1,255 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trace
+
+import (
+	"errors"
+	"io"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// FlightRecorderConfig is used to configure a FlightRecorder.
+type FlightRecorderConfig struct {
+	// MinAge is a lower bound on the age of the oldest event the
+	// flight recorder keeps. The flight recorder discards trace data
+	// only once the rest of its data covers at least MinAge.
+	//
+	// If zero, a default of 10 seconds is used.
+	MinAge time.Duration
+
+	// MaxBytes is an upper bound on the amount of trace data the
+	// flight recorder keeps. MaxBytes takes precedence over MinAge:
+	// if keeping MinAge worth of trace data would take more than
+	// MaxBytes, older data is discarded anyway.
+	//
+	// If zero, a default of 10 MiB is used.
+	MaxBytes uint64
+}
+
+// A FlightRecorder keeps the execution trace of the recent past in
+// memory, so that it can be written out on demand, for example when a
+// request turns out to be slow or a watchdog fires.
+//
+// The execution trace is made of generations, each of which can be
+// read without the ones before it. The flight recorder keeps the most
+// recent complete generations within the limits of its
+// FlightRecorderConfig, and discards older ones.
+//
+// Only one of a FlightRecorder and Start may be tracing at a time.
+type FlightRecorder struct {
+	minAge   time.Duration
+	maxBytes uint64
+
+	mu      sync.Mutex // serializes Start, Stop and WriteTo
+	enabled bool
+	done    chan struct{} // closed when the reader goroutine has exited
+
+	ringMu  sync.Mutex
+	ringCV  sync.Cond     // signaled when a generation is complete
+	header  []byte        // trace header
+	gens    []*generation // complete generations, oldest first
+	cur     *generation   // generation being read
+	lastGen uint64        // last complete generation
+	size    uint64        // total size of gens
+}
+
+// A generation holds the trace data of one generation.
+type generation struct {
+	gen    uint64
+	end    time.Time // when the last of the data arrived
+	chunks [][]byte
+	size   uint64
+}
+
+// NewFlightRecorder creates a new flight recorder with the given
+// configuration. The flight recorder does not record anything until
+// Start is called.
+func NewFlightRecorder(cfg FlightRecorderConfig) *FlightRecorder {
+	fr := &FlightRecorder{
+		minAge:   cfg.MinAge,
+		maxBytes: cfg.MaxBytes,
+	}
+	if fr.minAge == 0 {
+		fr.minAge = 10 * time.Second
+	}
+	if fr.maxBytes == 0 {
+		fr.maxBytes = 10 << 20
+	}
+	fr.ringCV.L = &fr.ringMu
+	return fr
+}
+
+// Start begins recording the execution trace into memory.
+// Start returns an error if the flight recorder is already running
+// or if tracing is already enabled by some other means.
+func (fr *FlightRecorder) Start() error {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+
+	if fr.enabled {
+		return errors.New("flight recorder already started")
+	}
+	tracing.Lock()
+	defer tracing.Unlock()
+	if err := runtime.StartTrace(); err != nil {
+		return err
+	}
+	fr.header = nil
+	fr.gens = nil
+	fr.cur = nil
+	fr.lastGen = 0
+	fr.size = 0
+	fr.done = make(chan struct{})
+	go fr.readLoop(fr.done)
+	fr.enabled = true
+	tracing.enabled.Store(true)
+	tracing.flightRecorder = true
+	return nil
+}
+
+// Stop ends recording and discards the recorded trace.
+// Stop does nothing if the flight recorder is not running.
+func (fr *FlightRecorder) Stop() {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+
+	if !fr.enabled {
+		return
+	}
+	tracing.Lock()
+	tracing.enabled.Store(false)
+	tracing.flightRecorder = false
+	runtime.StopTrace()
+	tracing.Unlock()
+	<-fr.done
+
+	fr.enabled = false
+	fr.ringMu.Lock()
+	fr.header = nil
+	fr.gens = nil
+	fr.size = 0
+	fr.ringMu.Unlock()
+}
+
+// Enabled reports whether the flight recorder is running.
+func (fr *FlightRecorder) Enabled() bool {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	return fr.enabled
+}
+
+// WriteTo writes a snapshot of the recorded trace to w.
+// The snapshot is a complete trace that can be read by cmd/trace.
+// To include the most recent events, WriteTo first ends the current
+// generation, so the snapshot covers the time up to the call.
+//
+// WriteTo returns an error if the flight recorder is not running.
+// Concurrent calls to WriteTo are serialized.
+func (fr *FlightRecorder) WriteTo(w io.Writer) (n int64, err error) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+
+	if !fr.enabled {
+		return 0, errors.New("flight recorder is not running")
+	}
+	gen := advance()
+	if gen == 0 {
+		return 0, errors.New("tracing is not enabled")
+	}
+
+	// Wait for the reader goroutine to collect the generation.
+	fr.ringMu.Lock()
+	for fr.lastGen < gen {
+		fr.ringCV.Wait()
+	}
+	header := fr.header
+	gens := fr.gens
+	fr.ringMu.Unlock()
+
+	// Complete generations are never modified, so they can be
+	// written out without holding ringMu.
+	m, err := w.Write(header)
+	n += int64(m)
+	if err != nil {
+		return n, err
+	}
+	for _, g := range gens {
+		for _, chunk := range g.chunks {
+			m, err := w.Write(chunk)
+			n += int64(m)
+			if err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// readLoop reads trace data into the flight recorder until tracing
+// stops, and then closes done.
+func (fr *FlightRecorder) readLoop(done chan struct{}) {
+	defer close(done)
+	for {
+		data, gen, end := readTrace()
+		if data == nil {
+			return
+		}
+		data = append([]byte(nil), data...)
+
+		fr.ringMu.Lock()
+		if gen == 0 {
+			fr.header = data
+			fr.ringMu.Unlock()
+			continue
+		}
+		if fr.cur == nil || fr.cur.gen != gen {
+			fr.cur = &generation{gen: gen}
+		}
+		fr.cur.chunks = append(fr.cur.chunks, data)
+		fr.cur.size += uint64(len(data))
+		if end {
+			fr.cur.end = time.Now()
+			fr.gens = append(fr.gens, fr.cur)
+			fr.size += fr.cur.size
+			fr.lastGen = gen
+			fr.cur = nil
+			fr.trim()
+			fr.ringCV.Broadcast()
+		}
+		fr.ringMu.Unlock()
+	}
+}
+
+// trim discards the oldest generations while they exceed the limits
+// of the flight recorder, always keeping the most recent one.
+// fr.ringMu must be held.
+func (fr *FlightRecorder) trim() {
+	now := time.Now()
+	for len(fr.gens) > 1 {
+		oldest := fr.gens[0]
+		// The generations after the oldest one cover the time
+		// since it ended.
+		if fr.size <= fr.maxBytes && now.Sub(oldest.end) < fr.minAge {
+			break
+		}
+		fr.gens[0] = nil
+		fr.gens = fr.gens[1:]
+		fr.size -= oldest.size
+	}
+}
+
+//
+// Function bodies are defined in runtime/trace.go
+//
+
+// readTrace is like runtime.ReadTrace, but also returns the
+// generation of the data, and whether the data ends that generation.
+func readTrace() (data []byte, gen uint64, end bool)
+
+// advance ends the current generation and starts a new one. It returns
+// the generation that ended, or 0 if tracing is not enabled.
+func advance() uint64
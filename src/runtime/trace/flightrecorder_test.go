@@ -0,0 +1,136 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trace_test
+
+import (
+	"bytes"
+	"context"
+	"internal/trace"
+	. "runtime/trace"
+	"testing"
+	"time"
+)
+
+func TestFlightRecorder(t *testing.T) {
+	if IsEnabled() {
+		t.Skip("skipping because -test.trace is set")
+	}
+	fr := NewFlightRecorder(FlightRecorderConfig{})
+	if err := fr.Start(); err != nil {
+		t.Fatalf("failed to start flight recorder: %v", err)
+	}
+	defer fr.Stop()
+	if !fr.Enabled() || !IsEnabled() {
+		t.Fatalf("tracing not enabled after starting flight recorder")
+	}
+
+	// Each snapshot ends the current generation, but the earlier
+	// generations stay in the flight recorder, so later snapshots
+	// still contain the regions of earlier ones.
+	for i := 0; i < 3; i++ {
+		WithRegion(context.Background(), "flight", func() {
+			time.Sleep(time.Millisecond)
+		})
+
+		buf := new(bytes.Buffer)
+		if _, err := fr.WriteTo(buf); err != nil {
+			t.Fatalf("failed to write snapshot: %v", err)
+		}
+		saveTrace(t, buf, "TestFlightRecorder")
+		events, _ := parseTrace(t, buf)
+		if n := countRegions(events, "flight"); n != i+1 {
+			t.Errorf("snapshot %d contains %d regions, want %d", i, n, i+1)
+		}
+	}
+}
+
+func TestFlightRecorderMaxBytes(t *testing.T) {
+	if IsEnabled() {
+		t.Skip("skipping because -test.trace is set")
+	}
+	fr := NewFlightRecorder(FlightRecorderConfig{MaxBytes: 1})
+	if err := fr.Start(); err != nil {
+		t.Fatalf("failed to start flight recorder: %v", err)
+	}
+	defer fr.Stop()
+
+	// With a tiny MaxBytes, only the most recent generation is kept,
+	// so each snapshot has only what happened since the previous one.
+	WithRegion(context.Background(), "old", func() {})
+	if _, err := fr.WriteTo(new(bytes.Buffer)); err != nil {
+		t.Fatalf("failed to write snapshot: %v", err)
+	}
+	WithRegion(context.Background(), "new", func() {})
+	buf := new(bytes.Buffer)
+	if _, err := fr.WriteTo(buf); err != nil {
+		t.Fatalf("failed to write snapshot: %v", err)
+	}
+	saveTrace(t, buf, "TestFlightRecorderMaxBytes")
+	events, _ := parseTrace(t, buf)
+	if !hasRegion(events, "new") {
+		t.Errorf("snapshot does not contain the latest region")
+	}
+	if hasRegion(events, "old") {
+		t.Errorf("snapshot contains a region older than MaxBytes allows")
+	}
+}
+
+func TestFlightRecorderExclusive(t *testing.T) {
+	if IsEnabled() {
+		t.Skip("skipping because -test.trace is set")
+	}
+	fr := NewFlightRecorder(FlightRecorderConfig{})
+	if err := fr.Start(); err != nil {
+		t.Fatalf("failed to start flight recorder: %v", err)
+	}
+	if err := fr.Start(); err == nil {
+		t.Errorf("started flight recorder twice")
+	}
+	if err := Start(new(bytes.Buffer)); err == nil {
+		t.Errorf("started tracing while flight recorder is running")
+	}
+	Stop()
+	if !fr.Enabled() || !IsEnabled() {
+		t.Errorf("Stop stopped the flight recorder")
+	}
+	fr.Stop()
+	fr.Stop()
+	if fr.Enabled() || IsEnabled() {
+		t.Errorf("tracing still enabled after stopping flight recorder")
+	}
+	if _, err := fr.WriteTo(new(bytes.Buffer)); err == nil {
+		t.Errorf("wrote snapshot from stopped flight recorder")
+	}
+
+	buf := new(bytes.Buffer)
+	if err := Start(buf); err != nil {
+		t.Fatalf("failed to start tracing: %v", err)
+	}
+	if err := fr.Start(); err == nil {
+		t.Errorf("started flight recorder while tracing")
+	}
+	Stop()
+}
+
+// countRegions returns the number of times a region called name
+// starts in events.
+func countRegions(events []*trace.Event, name string) int {
+	n := 0
+	for _, ev := range events {
+		if ev.Type == trace.EvUserRegion && ev.Args[1] == 0 && ev.SArgs[0] == name {
+			n++
+		}
+	}
+	return n
+}
+
+func hasRegion(events []*trace.Event, name string) bool {
+	for _, ev := range events {
+		if ev.Type == trace.EvUserRegion && ev.SArgs[0] == name {
+			return true
+		}
+	}
+	return false
+}
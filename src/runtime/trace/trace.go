@@ -140,15 +140,20 @@ func Start(w io.Writer) error {
 
 // Stop stops the current tracing, if any.
 // Stop only returns after all the writes for the trace have completed.
+// Stop does not affect tracing by a FlightRecorder.
 func Stop() {
 	tracing.Lock()
 	defer tracing.Unlock()
+	if tracing.flightRecorder {
+		return
+	}
 	tracing.enabled.Store(false)
 
 	runtime.StopTrace()
 }
 
 var tracing struct {
-	sync.Mutex // gate mutators (Start, Stop)
-	enabled    atomic.Bool
+	sync.Mutex     // gate mutators (Start, Stop, FlightRecorder)
+	enabled        atomic.Bool
+	flightRecorder bool // tracing was started by a FlightRecorder
 }
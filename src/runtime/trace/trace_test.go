@@ -142,6 +142,79 @@ func TestTrace(t *testing.T) {
 	}
 }
 
+// TestTraceGenerations checks that a trace split into several
+// generations can be read one generation at a time, and as a whole.
+func TestTraceGenerations(t *testing.T) {
+	if IsEnabled() {
+		t.Skip("skipping because -test.trace is set")
+	}
+	buf := new(bytes.Buffer)
+	if err := Start(buf); err != nil {
+		t.Fatalf("failed to start tracing: %v", err)
+	}
+
+	// A goroutine that stays blocked across generations, and one
+	// that is in the middle of a region.
+	c := make(chan bool)
+	done := make(chan bool)
+	go func() {
+		<-c
+		done <- true
+	}()
+	ctx, task := NewTask(context.Background(), "generations")
+	region := StartRegion(ctx, "across")
+	const n = 3
+	for i := 0; i < n; i++ {
+		Log(ctx, "generation", strconv.Itoa(i))
+		runtime.GC()
+		if gen := Advance(); gen == 0 {
+			t.Fatalf("Advance failed while tracing")
+		}
+	}
+	region.End()
+	task.End()
+	c <- true
+	<-done
+	Stop()
+	saveTrace(t, buf, "TestTraceGenerations")
+
+	r, err := trace.NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to read trace header: %v", err)
+	}
+	gens := 0
+	for {
+		_, _, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err == trace.ErrTimeOrder {
+			t.Skipf("skipping trace: %v", err)
+		}
+		if err != nil {
+			t.Fatalf("failed to parse generation %d: %v", gens+1, err)
+		}
+		gens++
+	}
+	if gens < n+1 {
+		t.Errorf("got %d generations, want at least %d", gens, n+1)
+	}
+
+	events, _ := parseTrace(t, buf)
+	logs := 0
+	for _, ev := range events {
+		if ev.Type == trace.EvUserLog && ev.SArgs[0] == "generation" {
+			logs++
+		}
+	}
+	if logs != n {
+		t.Errorf("got %d logs, want %d", logs, n)
+	}
+	if !hasRegion(events, "across") {
+		t.Errorf("trace does not contain region spanning generations")
+	}
+}
+
 func parseTrace(t *testing.T, r io.Reader) ([]*trace.Event, map[uint64]*trace.GDesc) {
 	res, err := trace.Parse(r, "")
 	if err == trace.ErrTimeOrder {
@@ -1055,7 +1055,7 @@ func isSystemGoroutine(gp *g, fixed bool) bool {
 	if !f.valid() {
 		return false
 	}
-	if f.funcID == funcID_runtime_main || f.funcID == funcID_handleAsyncEvent {
+	if f.funcID == funcID_runtime_main || f.funcID == funcID_corostart || f.funcID == funcID_handleAsyncEvent {
 		return false
 	}
 	if f.funcID == funcID_runfinq {
@@ -0,0 +1,81 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// dropGroupsHandler wraps a JSONHandler but ignores WithGroup,
+// violating the Handler contract.
+type dropGroupsHandler struct {
+	*slog.JSONHandler
+}
+
+func (h dropGroupsHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.JSONHandler.Handle(ctx, r)
+}
+
+func (h dropGroupsHandler) WithAttrs(as []slog.Attr) slog.Handler {
+	return dropGroupsHandler{h.JSONHandler.WithAttrs(as).(*slog.JSONHandler)}
+}
+
+func (h dropGroupsHandler) WithGroup(string) slog.Handler { return h }
+
+func parseLines(buf *bytes.Buffer) func() []map[string]any {
+	return func() []map[string]any {
+		var ms []map[string]any
+		for _, line := range bytes.Split(buf.Bytes(), []byte{'\n'}) {
+			if len(line) == 0 {
+				continue
+			}
+			var m map[string]any
+			if err := json.Unmarshal(line, &m); err != nil {
+				panic(err)
+			}
+			ms = append(ms, m)
+		}
+		return ms
+	}
+}
+
+func TestJSONHandler(t *testing.T) {
+	var buf bytes.Buffer
+	if err := TestHandler(slog.NewJSONHandler(&buf, nil), parseLines(&buf)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBadHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := dropGroupsHandler{slog.NewJSONHandler(&buf, nil)}
+	err := TestHandler(h, parseLines(&buf))
+	if err == nil {
+		t.Fatal("got nil error, want failures")
+	}
+	const want = "missing group \"G\": a Handler should handle the WithGroup method"
+	if !strings.Contains(err.Error(), want) {
+		t.Errorf("error does not contain %q:\n%v", want, err)
+	}
+	// Each failure is reported separately.
+	var u interface{ Unwrap() []error }
+	if !errors.As(err, &u) || len(u.Unwrap()) < 2 {
+		t.Errorf("want multiple joined errors, got %v", err)
+	}
+}
+
+func TestWrongResultCount(t *testing.T) {
+	var buf bytes.Buffer
+	err := TestHandler(slog.NewJSONHandler(&buf, nil), func() []map[string]any { return nil })
+	if err == nil || !strings.Contains(err.Error(), "got 0 results") {
+		t.Errorf("got %v, want result count error", err)
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package unique
+
+import (
+	"internal/reflectlite"
+	"unsafe"
+)
+
+// clone makes a copy of value in which every string found in value
+// is replaced by a copy of that string, as described by seq. This
+// keeps the canonical copy from giving a long lifetime to the memory
+// of a larger string that a caller's string is a substring of.
+//
+// Strings in struct fields and array elements are copied, as is value
+// itself if it is a string. Strings held in interface values are not.
+func clone[T comparable](value T, seq *cloneSeq) T {
+	for _, offset := range seq.stringOffsets {
+		ps := (*string)(unsafe.Add(unsafe.Pointer(&value), offset))
+		*ps = cloneString(*ps)
+	}
+	return value
+}
+
+// cloneSeq describes how to clone a value of a particular type.
+type cloneSeq struct {
+	stringOffsets []uintptr
+}
+
+// makeCloneSeq returns the cloneSeq for values of type T.
+func makeCloneSeq[T comparable]() cloneSeq {
+	typ := reflectlite.TypeOf((*T)(nil)).Elem()
+	return cloneSeq{stringOffsets: reflectlite.StringOffsets(typ)}
+}
+
+// cloneString returns a copy of s in newly allocated memory.
+func cloneString(s string) string {
+	if len(s) == 0 {
+		return ""
+	}
+	b := make([]byte, len(s))
+	copy(b, s)
+	return unsafe.String(&b[0], len(b))
+}
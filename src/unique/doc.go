@@ -0,0 +1,19 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+The unique package provides facilities for canonicalizing ("interning")
+comparable values.
+
+[Make] returns a [Handle] for a value. Handles for equal values are
+equal, so comparing two handles is as good as comparing the values used
+to create them, but is typically much cheaper: it is a single pointer
+comparison. A handle also stores its value only once, no matter how
+many times the same value is passed to Make.
+
+The values are held weakly: once no Handle for a value remains, the
+garbage collector reclaims the canonical copy, and the package drops
+its entry for the value after the next GC cycle.
+*/
+package unique
@@ -0,0 +1,145 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package unique
+
+import (
+	"sync"
+	_ "unsafe" // for go:linkname
+	"weak"
+)
+
+// Handle is a globally unique identity for some value of type T.
+//
+// Two handles compare equal exactly if the two values used to create the handles
+// would have also compared equal. The comparison of two handles is trivial and
+// typically much more efficient than comparing the values used to create them.
+type Handle[T comparable] struct {
+	value *T
+}
+
+// Value returns a shallow copy of the T value that produced the Handle.
+func (h Handle[T]) Value() T {
+	return *h.value
+}
+
+// Make returns a globally unique handle for a value of type T. Handles
+// are equal if and only if the values used to produce them are equal.
+func Make[T comparable](value T) Handle[T] {
+	m := getUniqueMap[T]()
+
+	// Fast path: the value is already present and still referenced.
+	if wp, ok := m.Load(value); ok {
+		if ptr := wp.(weak.Pointer[T]).Value(); ptr != nil {
+			return Handle[T]{ptr}
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if wp, ok := m.Load(value); ok {
+		if ptr := wp.(weak.Pointer[T]).Value(); ptr != nil {
+			return Handle[T]{ptr}
+		}
+	}
+	// Either the value was never present, or its previous canonical
+	// copy was reclaimed and cleanup hasn't removed the entry yet.
+	ptr := new(T)
+	*ptr = clone(value, &m.cloneSeq)
+	m.Store(*ptr, weak.Make(ptr))
+	return Handle[T]{ptr}
+}
+
+// uniqueMap is the canonicalization map for values of a single type.
+// It maps each value to a weak pointer to its canonical copy.
+//
+// Lookups go through the sync.Map without locking. Stores, and the
+// removal of entries whose canonical copy has been reclaimed, are
+// serialized by mu.
+type uniqueMap[T comparable] struct {
+	sync.Map
+	mu       sync.Mutex
+	cloneSeq cloneSeq
+}
+
+// cleanup removes all the entries in m whose canonical copy has been
+// reclaimed by the garbage collector.
+func (m *uniqueMap[T]) cleanup() {
+	m.Range(func(key, wp any) bool {
+		if wp.(weak.Pointer[T]).Value() != nil {
+			return true
+		}
+		m.mu.Lock()
+		// Re-check under the lock: Make may have replaced the entry.
+		if cur, ok := m.Load(key); ok && cur == wp {
+			m.Delete(key)
+		}
+		m.mu.Unlock()
+		return true
+	})
+}
+
+var (
+	// uniqueMaps is an index of type-specific maps,
+	// keyed by the zero *T for each type T.
+	uniqueMaps sync.Map
+
+	// cleanupMu is held for the duration of each cleanup.
+	cleanupMu sync.Mutex
+
+	cleanupFuncsMu sync.Mutex
+	cleanupFuncs   []func()
+
+	cleanupNotifyMu sync.Mutex
+	cleanupNotify   []func() // One-time notifications when cleanups finish.
+
+	setupMake sync.Once
+)
+
+func getUniqueMap[T comparable]() *uniqueMap[T] {
+	// The typed nil pointer is a cheap, comparable stand-in for T's type.
+	key := any((*T)(nil))
+	if m, ok := uniqueMaps.Load(key); ok {
+		return m.(*uniqueMap[T])
+	}
+	setupMake.Do(registerCleanup)
+
+	m := &uniqueMap[T]{cloneSeq: makeCloneSeq[T]()}
+	actual, loaded := uniqueMaps.LoadOrStore(key, m)
+	if !loaded {
+		cleanupFuncsMu.Lock()
+		cleanupFuncs = append(cleanupFuncs, m.cleanup)
+		cleanupFuncsMu.Unlock()
+	}
+	return actual.(*uniqueMap[T])
+}
+
+// registerCleanup registers a cleanup function with the runtime,
+// which is called once at the end of each GC cycle.
+func registerCleanup() {
+	runtime_registerUniqueMapCleanup(func() {
+		cleanupMu.Lock()
+		defer cleanupMu.Unlock()
+
+		cleanupFuncsMu.Lock()
+		fns := cleanupFuncs
+		cleanupFuncsMu.Unlock()
+		for _, fn := range fns {
+			fn()
+		}
+
+		// Run cleanup notifications.
+		cleanupNotifyMu.Lock()
+		for _, f := range cleanupNotify {
+			f()
+		}
+		cleanupNotify = nil
+		cleanupNotifyMu.Unlock()
+	})
+}
+
+// Implemented in runtime.
+
+//go:linkname runtime_registerUniqueMapCleanup
+func runtime_registerUniqueMapCleanup(cleanup func())
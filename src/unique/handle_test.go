@@ -0,0 +1,155 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package unique
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+// Set up special types. Because the internal maps are sharded by type,
+// this will ensure that we're not overlapping with other tests.
+type testString string
+type testIntArray [4]int
+type testStringArray [3]string
+type testStringStruct struct {
+	a string
+}
+type testStruct struct {
+	z float64
+	b string
+}
+
+func TestHandle(t *testing.T) {
+	testHandle[testString](t, "foo")
+	testHandle[testString](t, "bar")
+	testHandle[testString](t, "")
+	testHandle[testIntArray](t, [4]int{7, 77, 777, 7777})
+	testHandle[testStringArray](t, [3]string{"a", "b", "c"})
+	testHandle[testStringStruct](t, testStringStruct{"x"})
+	testHandle[testStruct](t, testStruct{0.5, "184"})
+	testHandle[string](t, "a string that doesn't fit in a tiny allocation")
+}
+
+func testHandle[T comparable](t *testing.T, value T) {
+	name := fmt.Sprintf("%T", value)
+	t.Run(fmt.Sprintf("%s/%#v", name, value), func(t *testing.T) {
+		t.Parallel()
+
+		v0 := Make(value)
+		v1 := Make(value)
+
+		if v0.Value() != v1.Value() {
+			t.Error("v0.Value != v1.Value")
+		}
+		if v0.Value() != value {
+			t.Errorf("v0.Value not %#v", value)
+		}
+		if v0 != v1 {
+			t.Error("v0 != v1")
+		}
+
+		drainMaps(t)
+		checkMapsFor(t, value)
+	})
+}
+
+// drainMaps ensures that the internal maps are drained.
+func drainMaps(t *testing.T) {
+	t.Helper()
+
+	wait := make(chan struct{}, 1)
+
+	// Set up a one-time notification for the next time the cleanup runs.
+	// Holding cleanupMu ensures there's no active cleanup, so the next
+	// cleanup to finish is one that starts after the notification is set up.
+	cleanupMu.Lock()
+	cleanupNotifyMu.Lock()
+	cleanupNotify = append(cleanupNotify, func() {
+		select {
+		case wait <- struct{}{}:
+		default:
+		}
+	})
+	cleanupNotifyMu.Unlock()
+	cleanupMu.Unlock()
+
+	runtime.GC()
+
+	// Wait until the cleanup runs.
+	select {
+	case <-wait:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the unique map cleanup")
+	}
+}
+
+func checkMapsFor[T comparable](t *testing.T, value T) {
+	// Manually load the value out of the map.
+	ma, ok := uniqueMaps.Load(any((*T)(nil)))
+	if !ok {
+		return
+	}
+	m := ma.(*uniqueMap[T])
+	if _, ok := m.Load(value); ok {
+		t.Errorf("value %v still referenced by the map", value)
+	}
+}
+
+func TestHandleUnique(t *testing.T) {
+	type S struct{ s string }
+	b := []byte("substring of a much longer string")
+	s := Make(S{string(b[:9])})
+	if got := Make(S{"substring"}); got != s {
+		t.Errorf("Make returned different handles for equal values")
+	}
+	if got := Make(S{"sub"}); got == s {
+		t.Errorf("Make returned the same handle for different values")
+	}
+
+	long := strings.Repeat("x", 1<<10)
+	h := Make(long[:16])
+	if unsafe.StringData(h.Value()) == unsafe.StringData(long) {
+		t.Errorf("Make(string) did not make a copy of the value")
+	}
+	runtime.KeepAlive(s)
+	runtime.KeepAlive(h)
+}
+
+func TestMakeClonesStrings(t *testing.T) {
+	type inner struct {
+		n int
+		s string
+	}
+	type S struct {
+		a [2]inner
+		s string
+	}
+	// The substrings below must not keep buf alive through the
+	// canonical copy. buf must be large enough not to be tiny-allocated.
+	buf := []byte(strings.Repeat("abcdefghijklmnopqrstuvwxyz", 4))
+	ran := make(chan bool, 1)
+	runtime.SetFinalizer(&buf[0], func(*byte) {
+		ran <- true
+	})
+	s := unsafe.String(&buf[0], len(buf))
+	h := Make(S{a: [2]inner{{1, s[0:3]}, {2, s[3:6]}}, s: s[6:9]})
+	buf, s = nil, ""
+
+	runtime.GC()
+	select {
+	case <-ran:
+	case <-time.After(10 * time.Second):
+		t.Fatal("substrings in the value passed to Make kept the original buffer alive")
+	}
+	if v := h.Value(); v.a[0].s != "abc" || v.a[1].s != "def" || v.s != "ghi" {
+		t.Errorf("h.Value() = %+v, want strings abc, def and ghi", v)
+	}
+	runtime.KeepAlive(h)
+}
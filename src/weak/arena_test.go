@@ -0,0 +1,26 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build goexperiment.arenas
+
+package weak_test
+
+import (
+	"arena"
+	"testing"
+	"weak"
+)
+
+func TestPointerArena(t *testing.T) {
+	a := arena.NewArena()
+	bt := arena.New[T](a)
+	wt := weak.Make(bt)
+	if st := wt.Value(); st != bt {
+		t.Fatalf("weak pointer is not the same as strong pointer: %p vs. %p", st, bt)
+	}
+	a.Free()
+	if st := wt.Value(); st != nil {
+		t.Fatalf("expected weak pointer to be nil after the arena was freed, got %p", st)
+	}
+}
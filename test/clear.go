@@ -0,0 +1,67 @@
+// run
+
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test the clear predeclared function.
+
+package main
+
+import (
+	"math"
+)
+
+func checkClearSlice() {
+	s := []int{1, 2, 3}
+	clear(s)
+	for i := range s {
+		if s[i] != 0 {
+			panic("clear not zeroing slice elem")
+		}
+	}
+
+	clear([]int{})
+}
+
+func checkClearSlicePointers() {
+	p1, p2 := new(int), new(int)
+	s := []*int{p1, p2, p1}
+	clear(s[1:])
+	if s[0] != p1 || s[1] != nil || s[2] != nil {
+		panic("clear of subslice did not zero the right elements")
+	}
+}
+
+func checkClearMap() {
+	m1 := make(map[int]int)
+	m1[0] = 0
+	m1[1] = 1
+	clear(m1)
+	if len(m1) != 0 {
+		panic("m1 is not cleared")
+	}
+
+	// map contains NaN keys is also cleared.
+	m2 := make(map[float64]int)
+	m2[math.NaN()] = 1
+	m2[math.NaN()] = 1
+	clear(m2)
+	if len(m2) != 0 {
+		panic("m2 is not cleared")
+	}
+
+	clear(map[int]int{})
+
+	// a cleared map is still usable.
+	m2[1] = 2
+	if m2[1] != 2 || len(m2) != 1 {
+		panic("m2 is not usable after clear")
+	}
+}
+
+func main() {
+	checkClearSlice()
+	checkClearSlicePointers()
+	checkClearMap()
+}
@@ -67,3 +67,14 @@ func toSliceData(s *[]int, p unsafe.Pointer) { // ERROR "s does not escape" "lea
 func toStringData(s *string, p unsafe.Pointer) { // ERROR "s does not escape" "leaking param: p$"
 	(*reflect.StringHeader)(unsafe.Pointer(s)).Data = uintptr(p)
 }
+
+// (7) unsafe.SliceData and unsafe.StringData return a pointer
+// into their argument's backing store.
+
+func sliceData(s []int) *int { // ERROR "leaking param: s to result ~r0 level=0$"
+	return unsafe.SliceData(s)
+}
+
+func stringData(s string) *byte { // ERROR "leaking param: s to result ~r0 level=0$"
+	return unsafe.StringData(s)
+}
@@ -0,0 +1,120 @@
+// run -gcflags=-d=loopvar=1
+
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test per-iteration loop variables.
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+)
+
+func check(name string, got, want any) {
+	if !reflect.DeepEqual(got, want) {
+		panic(fmt.Sprintf("%s: got %v, want %v", name, got, want))
+	}
+}
+
+func call(fs []func() int) []int {
+	var out []int
+	for _, f := range fs {
+		out = append(out, f())
+	}
+	return out
+}
+
+func deref(ps []*int) []int {
+	var out []int
+	for _, p := range ps {
+		out = append(out, *p)
+	}
+	return out
+}
+
+func threeClause() {
+	var fs []func() int
+	for i := 0; i < 3; i++ {
+		fs = append(fs, func() int { return i })
+	}
+	check("closure", call(fs), []int{0, 1, 2})
+
+	// The post statement applies to the next iteration's variable.
+	var ps []*int
+	for i, j := 0, 10; i < 6; i, j = i+2, j+1 {
+		if i == 2 {
+			continue
+		}
+		ps = append(ps, &j)
+	}
+	check("continue", deref(ps), []int{10, 12})
+
+	// Changes made in the body carry over to the next iteration.
+	fs = nil
+	for i := 0; i < 10; i++ {
+		fs = append(fs, func() int { return i })
+		i += 2
+	}
+	check("modified in body", call(fs), []int{2, 5, 8, 11})
+
+	// Closures in the condition and post statement see the current iteration.
+	fs = nil
+	var cond, post []int
+	for i := 0; func() bool { cond = append(cond, i); return i < 2 }(); func() { i++; post = append(post, i) }() {
+		fs = append(fs, func() int { return i })
+	}
+	check("cond closure", cond, []int{0, 1, 2})
+	check("post closure", post, []int{1, 2})
+	check("body closure", call(fs), []int{0, 1})
+}
+
+func rangeLoops() {
+	var ps []*int
+	for i := range []string{"a", "b", "c"} {
+		ps = append(ps, &i)
+	}
+	check("range key", deref(ps), []int{0, 1, 2})
+
+	var fs []func() int
+	for _, v := range []int{4, 5, 6} {
+		fs = append(fs, func() int { return v })
+	}
+	check("range value", call(fs), []int{4, 5, 6})
+
+	fs = nil
+	m := map[int]int{1: 1, 2: 4, 3: 9}
+	for k, v := range m {
+		fs = append(fs, func() int { return k*100 + v })
+	}
+	sum := 0
+	for _, x := range call(fs) {
+		sum += x
+	}
+	check("range map", sum, 614)
+}
+
+func nested() {
+	var fs []func() int
+outer:
+	for i := 0; i < 3; i++ {
+		for j := range []int{0, 1, 2} {
+			if j > i {
+				continue outer
+			}
+			fs = append(fs, func() int {
+				g := func() int { return i*10 + j }
+				return g()
+			})
+		}
+	}
+	check("nested", call(fs), []int{0, 10, 11, 20, 21, 22})
+}
+
+func main() {
+	threeClause()
+	rangeLoops()
+	nested()
+}
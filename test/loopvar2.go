@@ -0,0 +1,39 @@
+// errorcheck -0 -l -goexperiment loopvar -d=loopvar=2
+
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test reporting of loop variables that become per-iteration.
+
+package p
+
+var sink []*int
+
+func heap() {
+	for i := 0; i < 3; i++ { // ERROR "loop variable i now per-iteration, heap-allocated"
+		sink = append(sink, &i)
+	}
+	for _, v := range []int{1, 2} { // ERROR "loop variable v now per-iteration, heap-allocated"
+		sink = append(sink, &v)
+	}
+}
+
+func stack() int {
+	sum := 0
+	for i := 0; i < 3; i++ { // ERROR "loop variable i now per-iteration, stack-allocated"
+		func() { sum += i }()
+	}
+	return sum
+}
+
+func unchanged() int {
+	sum := 0
+	for i := 0; i < 3; i++ {
+		sum += i
+	}
+	for _, v := range []int{1, 2} {
+		sum += v
+	}
+	return sum
+}
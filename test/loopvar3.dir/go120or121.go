@@ -0,0 +1,20 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build (gc && go1.20) || go1.21
+
+package main
+
+// The minimum go version required by the constraint is go1.20.
+
+func go120Or121() ([]int, []int) {
+	var fs, rs []func() int
+	for i := 0; i < 3; i++ {
+		fs = append(fs, func() int { return i })
+	}
+	for _, v := range []int{4, 5, 6} {
+		rs = append(rs, func() int { return v })
+	}
+	return call(fs), call(rs)
+}
@@ -0,0 +1,57 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+)
+
+func call(fs []func() int) []int {
+	var out []int
+	for _, f := range fs {
+		out = append(out, f())
+	}
+	return out
+}
+
+func check(name string, got, want []int) {
+	if !reflect.DeepEqual(got, want) {
+		panic(fmt.Sprintf("%s: got %v, want %v", name, got, want))
+	}
+}
+
+// lang uses the module's go version, go1.14.
+func lang() ([]int, []int) {
+	var fs, rs []func() int
+	for i := 0; i < 3; i++ {
+		fs = append(fs, func() int { return i })
+	}
+	for _, v := range []int{4, 5, 6} {
+		rs = append(rs, func() int { return v })
+	}
+	return call(fs), call(rs)
+}
+
+func main() {
+	shared, perIteration := []int{3, 3, 3}, []int{0, 1, 2}
+	sharedRange, perIterationRange := []int{6, 6, 6}, []int{4, 5, 6}
+
+	fs, rs := lang()
+	check("lang", fs, shared)
+	check("lang range", rs, sharedRange)
+
+	fs, rs = go120()
+	check("go1.20", fs, perIteration)
+	check("go1.20 range", rs, perIterationRange)
+
+	fs, rs = go120Or121()
+	check("go1.20 || go1.21", fs, perIteration)
+	check("go1.20 || go1.21 range", rs, perIterationRange)
+
+	fs, rs = noVersion()
+	check("no version", fs, shared)
+	check("no version range", rs, sharedRange)
+}
@@ -0,0 +1,12 @@
+// runindir -goexperiment loopvar
+
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test that with GOEXPERIMENT=loopvar, loop variables are per-iteration
+// only in files whose go version is at least go1.20. The test module
+// declares go 1.14, and some files raise their go version with a
+// //go:build constraint.
+
+package ignored
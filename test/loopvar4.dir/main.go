@@ -0,0 +1,38 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+)
+
+func call(fs []func() int) []int {
+	var out []int
+	for _, f := range fs {
+		out = append(out, f())
+	}
+	return out
+}
+
+func check(name string, got, want []int) {
+	if !reflect.DeepEqual(got, want) {
+		panic(fmt.Sprintf("%s: got %v, want %v", name, got, want))
+	}
+}
+
+// lang uses the -lang version, go1.20.
+func lang() []int {
+	var fs []func() int
+	for i := 0; i < 3; i++ {
+		fs = append(fs, func() int { return i })
+	}
+	return call(fs)
+}
+
+func main() {
+	check("lang", lang(), []int{0, 1, 2})
+	check("go1.19", go119(), []int{3, 3, 3})
+}
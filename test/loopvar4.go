@@ -0,0 +1,10 @@
+// runindir -goexperiment loopvar -gcflags=-lang=go1.20
+
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test that a //go:build constraint requiring an earlier go version
+// than -lang keeps shared loop variables in its file.
+
+package ignored
@@ -0,0 +1,24 @@
+// errorcheck -0 -l -lang=go1.20 -d=loopvar=2
+
+//go:build !goexperiment.loopvar
+
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test that without GOEXPERIMENT=loopvar, loop variables stay shared
+// even with go1.20, and that reporting with -d=loopvar=2 doesn't
+// change that.
+
+package p
+
+var sink []*int
+
+func f() {
+	for i := 0; i < 3; i++ {
+		sink = append(sink, &i)
+	}
+	for _, v := range []int{1, 2} {
+		sink = append(sink, &v)
+	}
+}
@@ -0,0 +1,106 @@
+// run
+
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test the min and max predeclared functions.
+
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+var (
+	zero    = math.Copysign(0, +1)
+	negZero = math.Copysign(0, -1)
+	inf     = math.Inf(+1)
+	negInf  = math.Inf(-1)
+	nan     = math.NaN()
+)
+
+var tests = []struct{ min, max float64 }{
+	{1, 2},
+	{-2, 1},
+	{negZero, zero},
+	{zero, inf},
+	{negInf, zero},
+	{negInf, inf},
+	{1, inf},
+	{negInf, 1},
+}
+
+var all = []float64{1, 2, -1, -2, zero, negZero, inf, negInf, nan}
+
+func eq(x, y float64) bool {
+	return x == y && math.Signbit(x) == math.Signbit(y)
+}
+
+func main() {
+	for _, tt := range tests {
+		if x, y := tt.min, tt.max; !eq(min(x, y), x) || !eq(min(y, x), x) || !eq(max(x, y), y) || !eq(max(y, x), y) {
+			panic(fmt.Sprintf("min/max(%v, %v) = %v, %v", x, y, min(x, y), max(x, y)))
+		}
+		if x, y := float32(tt.min), float32(tt.max); !eq(float64(min(x, y)), float64(x)) || !eq(float64(max(x, y)), float64(y)) {
+			panic(fmt.Sprintf("min/max(float32(%v), float32(%v)) = %v, %v", x, y, min(x, y), max(x, y)))
+		}
+	}
+
+	for _, x := range all {
+		if m := min(x, nan); !math.IsNaN(m) {
+			panic(fmt.Sprintf("min(%v, NaN) = %v", x, m))
+		}
+		if m := min(nan, x); !math.IsNaN(m) {
+			panic(fmt.Sprintf("min(NaN, %v) = %v", x, m))
+		}
+		if m := max(x, nan); !math.IsNaN(m) {
+			panic(fmt.Sprintf("max(%v, NaN) = %v", x, m))
+		}
+		if m := max(nan, x); !math.IsNaN(m) {
+			panic(fmt.Sprintf("max(NaN, %v) = %v", x, m))
+		}
+	}
+
+	if m := max(negZero, zero, negZero); !eq(m, zero) {
+		panic(fmt.Sprintf("max(-0, 0, -0) = %v", m))
+	}
+	if m := min(zero, negZero, zero); !eq(m, negZero) {
+		panic(fmt.Sprintf("min(0, -0, 0) = %v", m))
+	}
+
+	if m := min(3, 1, 2); m != 1 {
+		panic(fmt.Sprintf("min(3, 1, 2) = %v", m))
+	}
+	if m := max(int8(-128), -1, -2); m != -1 {
+		panic(fmt.Sprintf("max(-128, -1, -2) = %v", m))
+	}
+	var u1, u2 uint = 1, math.MaxUint
+	if m := min(u1, u2); m != 1 {
+		panic(fmt.Sprintf("min(1, MaxUint) = %v", m))
+	}
+	if m := max(u1, u2); m != math.MaxUint {
+		panic(fmt.Sprintf("max(1, MaxUint) = %v", m))
+	}
+
+	s1, s2, s3 := "b", "a", "c"
+	if m := min(s1, s2, s3); m != "a" {
+		panic(fmt.Sprintf("min(b, a, c) = %q", m))
+	}
+	if m := max(s1, s2, s3); m != "c" {
+		panic(fmt.Sprintf("max(b, a, c) = %q", m))
+	}
+	if m := min("", s1); m != "" {
+		panic(fmt.Sprintf(`min("", b) = %q`, m))
+	}
+
+	const c = max(1, 2.5, 'a')
+	if c != 'a' {
+		panic("max(1, 2.5, 'a') != 'a'")
+	}
+	const d = min(1<<70, 1<<100) >> 69
+	if d != 2 {
+		panic("min(1<<70, 1<<100)>>69 != 2")
+	}
+}
@@ -0,0 +1,233 @@
+// run
+
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test range over functions.
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+)
+
+func count(n int) func(func(int) bool) {
+	return func(yield func(int) bool) {
+		for i := 0; i < n; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+}
+
+func pairs(yield func(string, int) bool) {
+	_ = yield("a", 1) && yield("b", 2) && yield("c", 3)
+}
+
+type List[T any] []T
+
+func (l List[T]) All(yield func(int, T) bool) {
+	for i, v := range l {
+		if !yield(i, v) {
+			return
+		}
+	}
+}
+
+func check(name string, got, want any) {
+	if !reflect.DeepEqual(got, want) {
+		panic(fmt.Sprintf("%s: got %v, want %v", name, got, want))
+	}
+}
+
+func checkForms() {
+	var keys []string
+	var vals []int
+	for k, v := range pairs {
+		keys = append(keys, k)
+		vals = append(vals, v)
+	}
+	check("keys", keys, []string{"a", "b", "c"})
+	check("vals", vals, []int{1, 2, 3})
+
+	var k string
+	for k = range pairs {
+		if k == "b" {
+			break
+		}
+	}
+	check("assigned key", k, "b")
+
+	n := 0
+	for range count(4) {
+		n++
+	}
+	check("no variables", n, 4)
+}
+
+func checkBreakContinue() {
+	var got []int
+	for i := range count(10) {
+		if i%2 == 0 {
+			continue
+		}
+		if i > 6 {
+			break
+		}
+		got = append(got, i)
+	}
+	check("break/continue", got, []int{1, 3, 5})
+
+	got = nil
+L:
+	for i := range count(5) {
+		for j := range count(5) {
+			if j > i {
+				continue L
+			}
+			if i == 3 {
+				break L
+			}
+			got = append(got, i*10+j)
+		}
+	}
+	check("labeled break/continue", got, []int{0, 10, 11, 20, 21, 22})
+
+	got = nil
+	for i := range count(3) {
+		for j := 0; j < 3; j++ {
+			switch {
+			case j == 1:
+				continue
+			case j == 2:
+				break
+			}
+			got = append(got, i*10+j)
+		}
+	}
+	check("inner loops", got, []int{0, 2, 10, 12, 20, 22})
+}
+
+func find(n, x int) (int, bool) {
+	for i := range count(n) {
+		for j := range count(n) {
+			if i*j == x {
+				return i*10 + j, true
+			}
+		}
+	}
+	return -1, false
+}
+
+func named() (r int) {
+	r = 5
+	for i := range count(10) {
+		if i == 3 {
+			r = i
+			return
+		}
+	}
+	return 0
+}
+
+func gotoOut() int {
+	n := 0
+	for i := range count(10) {
+		n += i
+		if i == 3 {
+			goto done
+		}
+	}
+	return -1
+done:
+	return n
+}
+
+func checkReturns() {
+	v, ok := find(5, 6)
+	check("find", v, 23)
+	check("find ok", ok, true)
+	v, ok = find(3, 6)
+	check("not found", v, -1)
+	check("not found ok", ok, false)
+	check("bare return", named(), 3)
+	check("goto", gotoOut(), 6)
+}
+
+func defers() (out []int) {
+	defer func() { out = append(out, -1) }()
+	for i := range count(3) {
+		for j := range count(2) {
+			defer func() { out = append(out, i*10+j) }()
+		}
+	}
+	out = append(out, 99)
+	return
+}
+
+func recovers() (r any) {
+	defer func() { r = recover() }()
+	for i := range count(3) {
+		defer func() {
+			if i != 0 {
+				panic(i)
+			}
+		}()
+		if i == 2 {
+			panic("boom")
+		}
+	}
+	return nil
+}
+
+func checkDefers() {
+	check("defers", defers(), []int{99, 21, 20, 11, 10, 1, 0, -1})
+	check("recover", recovers(), 1)
+}
+
+func checkGeneric() {
+	l := List[string]{"x", "yy", "zzz"}
+	var s string
+	for i, v := range l.All {
+		if i == 2 {
+			break
+		}
+		s += v
+	}
+	check("generic", s, "xyy")
+
+	var fs []func() int
+	for i := range count(3) {
+		fs = append(fs, func() int { return i })
+	}
+	var got []int
+	for _, f := range fs {
+		got = append(got, f())
+	}
+	check("per-iteration variables", got, []int{0, 1, 2})
+}
+
+func checkExit() {
+	defer func() {
+		if e, ok := recover().(error); !ok || e.Error() != "runtime error: range function continued iteration after exit" {
+			panic(fmt.Sprintf("unexpected recover: %v", e))
+		}
+	}()
+	var save func(int) bool
+	for range func(yield func(int) bool) { save = yield; yield(1) } {
+	}
+	save(2)
+	panic("yield after exit did not panic")
+}
+
+func main() {
+	checkForms()
+	checkBreakContinue()
+	checkReturns()
+	checkDefers()
+	checkGeneric()
+	checkExit()
+}
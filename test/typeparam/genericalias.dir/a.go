@@ -0,0 +1,22 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package a
+
+type Set[T comparable] = map[T]struct{}
+
+func Add[T comparable](s Set[T], x T) {
+	s[x] = struct{}{}
+}
+
+type Pair[K comparable, V any] struct {
+	Key K
+	Val V
+}
+
+func (p Pair[K, V]) String() string { return "pair" }
+
+type Entry[V any] = Pair[string, V]
+
+type List[T any] = []T
@@ -0,0 +1,49 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import "./a"
+
+type Ptr[T any] = *T
+
+type Entries[V any] = a.List[a.Entry[V]]
+
+func keys[V any](es Entries[V]) a.Set[string] {
+	s := make(a.Set[string])
+	for _, e := range es {
+		a.Add(s, e.Key)
+	}
+	return s
+}
+
+func main() {
+	s := a.Set[int]{}
+	a.Add(s, 1)
+	a.Add(s, 2)
+	a.Add(s, 1)
+	if len(s) != 2 {
+		panic(len(s))
+	}
+
+	var m map[int]struct{} = s
+	if _, ok := m[2]; !ok {
+		panic("missing 2")
+	}
+
+	es := Entries[int]{{Key: "x", Val: 1}, {Key: "y", Val: 2}, {Key: "x", Val: 3}}
+	if k := keys(es); len(k) != 2 {
+		panic(len(k))
+	}
+	if got := es[0].String(); got != "pair" {
+		panic(got)
+	}
+
+	var p Ptr[a.Entry[bool]] = &es2[0]
+	if p.Key != "z" || !p.Val {
+		panic(*p)
+	}
+}
+
+var es2 = []a.Pair[string, bool]{{"z", true}}
@@ -0,0 +1,9 @@
+// rundir
+
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test generic type aliases, declared in one package and used in another.
+
+package ignored